@@ -2,18 +2,22 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/faeln1/go-whatsapp-api/internal/app/controllers"
 	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
 	"github.com/faeln1/go-whatsapp-api/internal/app/services"
 	"github.com/faeln1/go-whatsapp-api/internal/config"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/cluster"
 	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/usage"
 	"github.com/faeln1/go-whatsapp-api/internal/platform/database"
 	httpPlatform "github.com/faeln1/go-whatsapp-api/internal/platform/http"
 	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
@@ -22,6 +26,7 @@ import (
 	storagepkg "github.com/faeln1/go-whatsapp-api/pkg/storage"
 	minioStorage "github.com/faeln1/go-whatsapp-api/pkg/storage/minio"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 	"go.mau.fi/whatsmeow"
 	waLog "go.mau.fi/whatsmeow/util/log"
 )
@@ -58,6 +63,16 @@ func main() {
 		repo           repositories.InstanceRepository
 		membershipRepo repositories.CommunityMembershipRepository
 		analyticsRepo  repositories.AnalyticsRepository
+		historyRepo    repositories.HistoryRepository
+		apiKeyRepo     repositories.APIKeyRepository
+		usageRepo      repositories.UsageRepository
+		outboxRepo     repositories.WebhookOutboxRepository
+		subsRepo       repositories.WebhookSubscriptionRepository
+		deliveryLog    repositories.WebhookDeliveryLogRepository
+		sinkRepo       repositories.EventSinkRepository
+		journalRepo    repositories.EventJournalRepository
+		leaseRepo      repositories.LeaseRepository
+		appDB          *sql.DB
 		dbClose        func() error
 	)
 
@@ -69,6 +84,7 @@ func main() {
 			log.Fatalf("database connection error: %v", err)
 		}
 		dbClose = db.Close
+		appDB = db
 		pgRepo, err := repositories.NewPostgresInstanceRepo(db)
 		if err != nil {
 			log.Fatalf("repository initialization error: %v", err)
@@ -79,10 +95,107 @@ func main() {
 			log.Fatalf("membership repository initialization error: %v", err)
 		}
 		analyticsRepo = repositories.NewAnalyticsRepository(db)
+		historyRepo, err = repositories.NewPostgresHistoryRepo(db)
+		if err != nil {
+			log.Fatalf("history repository initialization error: %v", err)
+		}
+		apiKeyRepo, err = repositories.NewPostgresAPIKeyRepo(db)
+		if err != nil {
+			log.Fatalf("api key repository initialization error: %v", err)
+		}
+		usageRepo, err = repositories.NewPostgresUsageRepo(db)
+		if err != nil {
+			log.Fatalf("usage repository initialization error: %v", err)
+		}
+		outboxRepo, err = repositories.NewPostgresWebhookOutboxRepo(db)
+		if err != nil {
+			log.Fatalf("webhook outbox repository initialization error: %v", err)
+		}
+		subsRepo, err = repositories.NewPostgresWebhookSubscriptionRepo(db)
+		if err != nil {
+			log.Fatalf("webhook subscription repository initialization error: %v", err)
+		}
+		deliveryLog, err = repositories.NewPostgresWebhookDeliveryLogRepo(db)
+		if err != nil {
+			log.Fatalf("webhook delivery log initialization error: %v", err)
+		}
+		sinkRepo, err = repositories.NewPostgresEventSinkRepo(db)
+		if err != nil {
+			log.Fatalf("event sink repository initialization error: %v", err)
+		}
+		journalRepo, err = repositories.NewPostgresEventJournalRepo(db)
+		if err != nil {
+			log.Fatalf("event journal initialization error: %v", err)
+		}
+		if cfg.Cluster.Enabled {
+			leaseRepo, err = repositories.NewPostgresLeaseRepo(db)
+			if err != nil {
+				log.Fatalf("lease repository initialization error: %v", err)
+			}
+		}
+	case "sqlite":
+		log.Printf("initializing sqlite repository")
+		db, err := database.Open("sqlite", cfg.DatabaseDSN)
+		if err != nil {
+			log.Fatalf("database connection error: %v", err)
+		}
+		dbClose = db.Close
+		appDB = db
+		repo, err = repositories.NewSQLiteInstanceRepo(db)
+		if err != nil {
+			log.Fatalf("repository initialization error: %v", err)
+		}
+		membershipRepo, err = repositories.NewSQLiteCommunityMembershipRepo(db)
+		if err != nil {
+			log.Fatalf("membership repository initialization error: %v", err)
+		}
+		analyticsRepo, err = repositories.NewSQLiteAnalyticsRepository(db)
+		if err != nil {
+			log.Fatalf("analytics repository initialization error: %v", err)
+		}
+		historyRepo, err = repositories.NewSQLiteHistoryRepo(db)
+		if err != nil {
+			log.Fatalf("history repository initialization error: %v", err)
+		}
+		apiKeyRepo, err = repositories.NewSQLiteAPIKeyRepo(db)
+		if err != nil {
+			log.Fatalf("api key repository initialization error: %v", err)
+		}
+		usageRepo, err = repositories.NewSQLiteUsageRepo(db)
+		if err != nil {
+			log.Fatalf("usage repository initialization error: %v", err)
+		}
+		outboxRepo, err = repositories.NewSQLiteWebhookOutboxRepo(db)
+		if err != nil {
+			log.Fatalf("webhook outbox repository initialization error: %v", err)
+		}
+		subsRepo, err = repositories.NewSQLiteWebhookSubscriptionRepo(db)
+		if err != nil {
+			log.Fatalf("webhook subscription repository initialization error: %v", err)
+		}
+		deliveryLog, err = repositories.NewSQLiteWebhookDeliveryLogRepo(db)
+		if err != nil {
+			log.Fatalf("webhook delivery log initialization error: %v", err)
+		}
+		sinkRepo, err = repositories.NewSQLiteEventSinkRepo(db)
+		if err != nil {
+			log.Fatalf("event sink repository initialization error: %v", err)
+		}
+		journalRepo, err = repositories.NewSQLiteEventJournalRepo(db)
+		if err != nil {
+			log.Fatalf("event journal initialization error: %v", err)
+		}
 	default:
 		log.Printf("initializing in-memory repository")
 		repo = repositories.NewInMemoryInstanceRepo()
 		membershipRepo = repositories.NewInMemoryCommunityMembershipRepo()
+		apiKeyRepo = repositories.NewInMemoryAPIKeyRepo()
+		usageRepo = repositories.NewInMemoryUsageRepo()
+		outboxRepo = repositories.NewInMemoryWebhookOutboxRepo()
+		subsRepo = repositories.NewInMemoryWebhookSubscriptionRepo()
+		deliveryLog = repositories.NewInMemoryWebhookDeliveryLogRepo()
+		sinkRepo = repositories.NewInMemoryEventSinkRepo()
+		journalRepo = repositories.NewInMemoryEventJournalRepo()
 	}
 	if membershipRepo == nil {
 		membershipRepo = repositories.NewInMemoryCommunityMembershipRepo()
@@ -98,8 +211,109 @@ func main() {
 
 	waMgr := whatsapp.NewManager(loggers.App.Sub("WA"))
 	storeFactory := whatsapp.NewStoreFactory(cfg.DataDir, loggers.App.Sub("Store"))
-	webhookDispatcher := services.NewWebhookDispatcher(nil, loggers.App.Sub("Webhook"))
-	communityEventsDispatcher := services.NewCommunityEventsDispatcher(cfg.CommunityEventsWebhookURL, cfg.CommunityEventsToken, nil, loggers.App.Sub("CommunityWebhook"))
+	if cfg.DeviceStore == "postgres" && cfg.DBDriver == "postgres" && appDB != nil {
+		pgStores, err := whatsapp.NewPostgresStoreFactory(context.Background(), appDB, cfg.DataDir, loggers.App.Sub("Store"))
+		if err != nil {
+			log.Fatalf("device store initialization error: %v", err)
+		}
+		storeFactory = pgStores
+		// Só os arquivos das instâncias cadastradas: o glob de DATA_DIR pegaria app.db e outros bancos.
+		var instanceNames []string
+		if insts, err := repo.List(context.Background()); err != nil {
+			log.Printf("sqlite device store migration: list instances: %v", err)
+		} else {
+			for _, inst := range insts {
+				instanceNames = append(instanceNames, inst.Name)
+			}
+		}
+		if migrated, err := storeFactory.MigrateSQLiteDevices(context.Background(), instanceNames); err != nil {
+			log.Printf("sqlite device store migration error: %v", err)
+		} else if migrated > 0 {
+			log.Printf("migrated %d sqlite device store(s) to postgres", migrated)
+		}
+		log.Printf("whatsmeow device store backed by postgres")
+	}
+	// lifecycle acompanha handlers de eventos e webhooks para o shutdown aguardar o término
+	lifecycle := services.NewLifecycle()
+	// Webhooks passam pelo outbox: gravados antes do envio e repetidos com backoff pelos workers
+	webhookQueue := services.NewWebhookQueue(outboxRepo, &http.Client{Timeout: cfg.Webhook.Timeout}, services.WebhookQueueOptions{
+		Retry: services.WebhookRetryPolicy{
+			MaxAttempts:    cfg.Webhook.MaxAttempts,
+			InitialBackoff: cfg.Webhook.RetryBackoff,
+			MaxBackoff:     cfg.Webhook.MaxBackoff,
+		},
+		Workers:      cfg.Webhook.Workers,
+		Ordered:      cfg.Webhook.Ordered,
+		LogRetention: cfg.Webhook.LogRetention,
+	}, loggers.App.Sub("Webhook"))
+	webhookQueue.SetInstances(repo)
+	webhookQueue.SetSubscriptions(subsRepo)
+	webhookQueue.SetDeliveryLog(deliveryLog)
+	// Webhook global do servidor: recebe os eventos de todas as instâncias além dos de cada uma
+	webhookQueue.SetGlobal(services.GlobalWebhook{
+		URL:     cfg.Webhook.Global.URL,
+		Token:   cfg.Webhook.Global.Token,
+		Events:  cfg.Webhook.Global.Events,
+		Secrets: cfg.Webhook.Global.Secrets,
+	})
+	if cfg.Webhook.Global.URL != "" {
+		log.Printf("global webhook enabled url=%s", cfg.Webhook.Global.URL)
+	}
+	webhookCtx, stopWebhooks := context.WithCancel(context.Background())
+	defer stopWebhooks()
+	webhookWorkers := make(chan struct{})
+	go func() {
+		defer close(webhookWorkers)
+		webhookQueue.Run(webhookCtx)
+	}()
+	// Destinos de eventos (Redis Streams) recebem o mesmo corpo dos webhooks; habilitados por instância
+	eventSinks := services.NewEventSinks(webhookQueue, sinkRepo, loggers.App.Sub("EventSink"))
+	sinkWorkers := make(chan struct{})
+	go func() {
+		defer close(sinkWorkers)
+		eventSinks.Run(webhookCtx)
+	}()
+	if cfg.Redis.Events.Enabled {
+		redisClient := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr, Password: cfg.Redis.Password, DB: cfg.Redis.DB})
+		defer redisClient.Close()
+		pingCtx, cancelPing := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := redisClient.Ping(pingCtx).Err(); err != nil {
+			log.Printf("redis event sink: %v (publishing will keep retrying the connection)", err)
+		}
+		cancelPing()
+		eventSinks.Register(services.NewRedisStreamSink(redisClient, services.RedisStreamOptions{
+			Stream:      cfg.Redis.Events.Stream,
+			PerInstance: cfg.Redis.Events.PerInstance,
+			MaxLen:      cfg.Redis.Events.MaxLen,
+		}))
+		log.Printf("redis streams event sink enabled (%s)", cfg.Redis.Addr)
+	}
+	var rabbit *services.RabbitMQ
+	if cfg.RabbitMQ.URL != "" && (cfg.RabbitMQ.EventsEnabled || cfg.RabbitMQ.CommandQueue != "") {
+		rabbit = services.NewRabbitMQ(services.RabbitMQOptions{
+			URL:            cfg.RabbitMQ.URL,
+			Exchange:       cfg.RabbitMQ.Exchange,
+			ConfirmTimeout: cfg.RabbitMQ.ConfirmTimeout,
+			CommandQueue:   cfg.RabbitMQ.CommandQueue,
+			Prefetch:       cfg.RabbitMQ.Prefetch,
+		}, loggers.App.Sub("RabbitMQ"))
+		defer rabbit.Close()
+		if cfg.RabbitMQ.EventsEnabled {
+			eventSinks.Register(rabbit)
+			rabbit.Connect()
+			log.Printf("rabbitmq event sink enabled (exchange %s)", cfg.RabbitMQ.Exchange)
+		}
+	}
+	// Streams de eventos (SSE/WebSocket) recebem os mesmos eventos dos webhooks
+	eventStreams := services.NewEventStreams(eventSinks, loggers.App.Sub("EventStream"))
+	eventStreams.SetJournal(journalRepo, cfg.EventStreamRetention)
+	if leaseRepo != nil {
+		// Em cluster o journal no Postgres é compartilhado: o stream inclui as instâncias dos outros nós
+		eventStreams.TailJournal(500 * time.Millisecond)
+	}
+	go eventStreams.Run(webhookCtx)
+	webhookDispatcher := lifecycle.TrackWebhooks(eventStreams)
+	communityEventsDispatcher := services.NewCommunityEventsDispatcher(cfg.CommunityEventsWebhookURL, cfg.CommunityEventsToken, cfg.CommunityEventsSecrets, nil, loggers.App.Sub("CommunityWebhook"))
 
 	var analyticsSvc services.AnalyticsService
 	if analyticsRepo != nil {
@@ -107,20 +321,78 @@ func main() {
 	}
 
 	messageEvents := services.NewMessageEventHandler(repo, waMgr, objectStorage, webhookDispatcher, analyticsSvc, loggers.App.Sub("Events"))
+	messageEvents.MaxBase64Bytes = int64(cfg.Webhook.Base64MaxBytes)
 	communityEvents := services.NewCommunityEventService(waMgr, membershipRepo, communityEventsDispatcher, loggers.App.Sub("CommunityEvents"))
 	eventLogger := eventlog.NewWriter(cfg.EventLogDir, loggers.App.Sub("EventLog"))
 	bootstrap := services.NewSessionBootstrap(storeFactory, waMgr, loggers.App.Sub("Bootstrap"), messageEvents, eventLogger)
 	bootstrap.ReceiptEvents = messageEvents
+	bootstrap.Lifecycle = lifecycle
+	bootstrap.Supervisor = whatsapp.NewSupervisor(waMgr, whatsapp.ReconnectPolicy{
+		InitialBackoff: cfg.Reconnect.InitialBackoff,
+		MaxBackoff:     cfg.Reconnect.MaxBackoff,
+		MaxRetries:     cfg.Reconnect.MaxRetries,
+	}, loggers.App.Sub("Supervisor"))
 	bootstrap.GroupEvents = communityEvents
+	bootstrap.GroupWebhooks = services.NewGroupEventHandler(repo, waMgr, webhookDispatcher, loggers.App.Sub("GroupEvents"))
+	presenceKeeper := services.NewPresenceKeeper(repo, waMgr, 0, loggers.App.Sub("Presence"))
+	bootstrap.PresenceEvents = presenceKeeper
+	bootstrap.HistoryEvents = services.NewHistorySyncHandler(repo, historyRepo, waMgr, webhookDispatcher, loggers.App.Sub("History"))
+	bootstrap.Instances = repo
+	connectionLifecycle := services.NewConnectionLifecycle(repo, waMgr, webhookDispatcher, loggers.App.Sub("Connection"))
+	// Os streams de login recebem os eventos antes e repassam ao ciclo de vida da conexão
+	loginStreams := services.NewLoginStreams(waMgr, connectionLifecycle, connectionLifecycle, loggers.App.Sub("LoginStream"))
+	bootstrap.ConnectionEvents = loginStreams
+	bootstrap.QREvents = loginStreams
 
 	instanceSvc := services.NewInstanceService(repo, waMgr, objectStorage)
+	instanceSvc.SetDeviceStore(storeFactory)
+	instanceSvc.SetWebhookSubscriptions(subsRepo)
+	instanceSvc.SetEventSinks(sinkRepo)
+	instanceSvc.AddRenameListener(presenceKeeper)
 	messageSvc := services.NewMessageService(waMgr, objectStorage)
+	quota := usage.Quota{Daily: cfg.Quota.Daily, Monthly: cfg.Quota.Monthly}
+	usageSvc := services.NewUsageService(usageRepo, repo, quota, cfg.Quota.Location, loggers.App.Sub("Usage"))
+	messageSvc.SetUsage(usageSvc)
 	communitySvc := services.NewCommunityService(waMgr, messageSvc, analyticsSvc, membershipRepo)
 	groupSvc := services.NewGroupService(waMgr)
+	groupSvc.SetUsage(usageSvc)
 	profileSvc := services.NewProfileService(waMgr)
 
-	if cfg.DBDriver == "postgres" {
-		restoreInstances(context.Background(), repo, instanceSvc, bootstrap, waMgr, loggers.App.Sub("Restore"))
+	// Em modo cluster o coordenador decide quais instâncias este nó conecta.
+	var coordinator *services.ClusterCoordinator
+	clusterCtx, stopCluster := context.WithCancel(context.Background())
+	defer stopCluster()
+	if leaseRepo != nil {
+		node := cluster.Node{ID: cfg.Cluster.NodeID, Address: cfg.Cluster.Address}
+		coordinator = services.NewClusterCoordinator(leaseRepo, repo, waMgr, node, cfg.Cluster.LeaseTTL, cfg.Cluster.Heartbeat, loggers.App.Sub("Cluster"))
+		restoreLog := loggers.App.Sub("Restore")
+		coordinator.Start = func(ctx context.Context, inst *instance.Instance) {
+			restoreInstance(ctx, inst, instanceSvc, bootstrap, waMgr, restoreLog.Sub(inst.Name))
+		}
+		bootstrap.Guard = coordinator
+		instanceSvc.SetOwners(coordinator)
+		instanceSvc.AddRenameListener(coordinator)
+		if rabbit != nil {
+			rabbit.SetLocalInstances(coordinator)
+		}
+		log.Printf("cluster mode enabled node=%s address=%s", node.ID, node.Address)
+	}
+
+	// Comandos de envio pela fila do RabbitMQ; o consumo para antes do drain, que espera o
+	// comando em andamento terminar
+	commandsCtx, stopCommands := context.WithCancel(context.Background())
+	defer stopCommands()
+	if rabbit != nil {
+		lifecycle.Go(func() { rabbit.RunCommands(commandsCtx, messageSvc) })
+	}
+
+	if appDB != nil {
+		if coordinator != nil {
+			hashLegacyTokens(context.Background(), repo, loggers.App.Sub("Restore"))
+			go coordinator.Run(clusterCtx)
+		} else {
+			restoreInstances(context.Background(), repo, instanceSvc, bootstrap, waMgr, loggers.App.Sub("Restore"))
+		}
 	}
 
 	instanceCtrl := controllers.NewInstanceController(instanceSvc, bootstrap, webhookDispatcher)
@@ -128,30 +400,67 @@ func main() {
 	communityCtrl := controllers.NewCommunityController(communitySvc)
 	groupCtrl := controllers.NewGroupController(groupSvc)
 	webhookCtrl := controllers.NewWebhookController(instanceSvc)
-	settingsCtrl := controllers.NewSettingsController(instanceSvc)
+	webhookSubsCtrl := controllers.NewWebhookSubscriptionController(services.NewWebhookSubscriptionService(repo, subsRepo))
+	webhookLogCtrl := controllers.NewWebhookDeliveryController(services.NewWebhookDeliveryService(repo, deliveryLog, webhookQueue))
+	settingsCtrl := controllers.NewSettingsController(instanceSvc, presenceKeeper)
 	profileCtrl := controllers.NewProfileController(profileSvc)
+	transferSvc := services.NewInstanceTransferService(repo, instanceSvc, waMgr, bootstrap, loggers.App.Sub("Transfer"))
+	transferSvc.SetWebhookSubscriptions(subsRepo)
+	transferSvc.SetEventSinks(sinkRepo)
+	transferSvc.SetUsage(usageRepo)
+	transferCtrl := controllers.NewTransferController(transferSvc)
+
+	apiKeySvc := services.NewAPIKeyService(apiKeyRepo, loggers.App.Sub("APIKeys"))
+	if err := apiKeySvc.Load(context.Background()); err != nil {
+		log.Fatalf("api key load error: %v", err)
+	}
+	apiKeyCtrl := controllers.NewAPIKeyController(apiKeySvc)
+	loginStreamCtrl := controllers.NewLoginStreamController(loginStreams)
+	eventStreamCtrl := controllers.NewEventStreamController(eventStreams, repo)
+	eventSinkCtrl := controllers.NewEventSinkController(services.NewEventSinkService(repo, sinkRepo, eventSinks), eventSinks.Names())
+	usageCtrl := controllers.NewUsageController(usageSvc)
 
 	var analyticsCtrl *controllers.AnalyticsController
 	if analyticsSvc != nil {
 		analyticsCtrl = controllers.NewAnalyticsController(analyticsSvc)
 	}
 
-	router := httpPlatform.NewRouter(httpPlatform.RouterConfig{
-		InstanceCtrl:  instanceCtrl,
-		MessageCtrl:   messageCtrl,
-		CommunityCtrl: communityCtrl,
-		GroupCtrl:     groupCtrl,
-		WebhookCtrl:   webhookCtrl,
-		SettingsCtrl:  settingsCtrl,
-		ProfileCtrl:   profileCtrl,
-		AnalyticsCtrl: analyticsCtrl,
-		Logger:        loggers.HTTP,
-		WAManager:     waMgr,
-		SwaggerEnable: cfg.SwaggerEnable,
-		MasterToken:   cfg.MasterToken,
-	})
+	routerCfg := httpPlatform.RouterConfig{
+		InstanceCtrl:    instanceCtrl,
+		MessageCtrl:     messageCtrl,
+		CommunityCtrl:   communityCtrl,
+		GroupCtrl:       groupCtrl,
+		WebhookCtrl:     webhookCtrl,
+		SettingsCtrl:    settingsCtrl,
+		ProfileCtrl:     profileCtrl,
+		AnalyticsCtrl:   analyticsCtrl,
+		TransferCtrl:    transferCtrl,
+		APIKeyCtrl:      apiKeyCtrl,
+		LoginStreamCtrl: loginStreamCtrl,
+		UsageCtrl:       usageCtrl,
+		WebhookSubsCtrl: webhookSubsCtrl,
+		WebhookLogCtrl:  webhookLogCtrl,
+		EventStreamCtrl: eventStreamCtrl,
+		EventSinkCtrl:   eventSinkCtrl,
+		APIKeys:         apiKeySvc,
+		Logger:          loggers.HTTP,
+		WAManager:       waMgr,
+		SwaggerEnable:   cfg.SwaggerEnable,
+		MasterToken:     cfg.MasterToken,
+		Lifecycle:       lifecycle,
+	}
+	if coordinator != nil {
+		routerCfg.ClusterOwners = coordinator
+		routerCfg.ClusterNodeID = coordinator.NodeID()
+		routerCfg.ClusterForward = cfg.Cluster.Forward
+		routerCfg.ClusterSecret = cfg.Cluster.Secret
+	}
+	router := httpPlatform.NewRouter(routerCfg)
 
 	srv := &http.Server{Addr: ":" + cfg.HTTPPort, Handler: router}
+	// Streams de login ficam abertos indefinidamente; encerrá-los para o Shutdown não esperar por eles
+	srv.RegisterOnShutdown(loginStreams.CloseAll)
+	srv.RegisterOnShutdown(eventStreams.CloseAll)
 	go func() {
 		log.Printf("HTTP server listening on %s", srv.Addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -164,10 +473,59 @@ func main() {
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 	<-stop
 	log.Println("shutting down...")
-	_ = srv.Shutdown(context.Background())
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelShutdown()
+
+	// 1. Recusar novos envios e concluir as requisições em andamento
+	stopCommands()
+	lifecycle.BeginDrain()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("http shutdown: %v", err)
+	}
+	// 2. Desconectar as sessões antes do drain: com os clients conectados novos eventos continuam
+	// chegando. O connection.update de cada sessão entra no outbox antes de os workers pararem;
+	// no cluster os leases são liberados para outro nó assumir
+	disconnected := waMgr.DisconnectAll("shutdown")
+	for _, name := range disconnected {
+		connectionLifecycle.HandleShutdown(shutdownCtx, name)
+	}
+	log.Printf("disconnected %d session(s)", len(disconnected))
+	stopCluster()
+	if coordinator != nil {
+		coordinator.Shutdown(context.Background())
+	}
+	// 3. Drenar handlers de eventos (inclui upload de mídia) e entregas de webhook
+	if err := lifecycle.Wait(shutdownCtx); err != nil {
+		log.Printf("drain incomplete: %v", err)
+	}
+	// Entregas iniciadas terminam; as pendentes continuam no outbox para o próximo start
+	stopWebhooks()
+	select {
+	case <-webhookWorkers:
+	case <-shutdownCtx.Done():
+		log.Printf("webhook workers did not stop before shutdown timeout")
+	}
+	select {
+	case <-sinkWorkers:
+	case <-shutdownCtx.Done():
+		log.Printf("event sink publisher did not stop before shutdown timeout")
+	}
+	// 4. Fechar os device stores; o banco da aplicação é fechado por último (defer)
+	if err := storeFactory.Close(); err != nil {
+		log.Printf("error closing device stores: %v", err)
+	}
+}
+
+func hashLegacyTokens(ctx context.Context, repo repositories.InstanceRepository, log waLog.Logger) {
+	if converted, err := services.HashLegacyTokens(ctx, repo); err != nil {
+		log.Errorf("failed to hash legacy instance tokens: %v", err)
+	} else if converted > 0 {
+		log.Infof("hashed %d legacy plaintext instance token(s)", converted)
+	}
 }
 
 func restoreInstances(ctx context.Context, repo repositories.InstanceRepository, instanceSvc services.InstanceService, bootstrap *services.SessionBootstrap, waMgr *whatsapp.Manager, log waLog.Logger) {
+	hashLegacyTokens(ctx, repo, log)
 	instances, err := repo.List(ctx)
 	if err != nil {
 		log.Errorf("failed to restore instances: %v", err)
@@ -185,7 +543,7 @@ func restoreInstances(ctx context.Context, repo repositories.InstanceRepository,
 }
 
 func restoreInstance(ctx context.Context, inst *instance.Instance, instanceSvc services.InstanceService, bootstrap *services.SessionBootstrap, waMgr *whatsapp.Manager, log waLog.Logger) {
-	sess, err := waMgr.Create(ctx, inst.Name, inst.Token)
+	sess, err := waMgr.Create(ctx, inst.Name, services.InstanceCredentials(inst)...)
 	if err != nil {
 		if errors.Is(err, whatsapp.ErrAlreadyExists) {
 			log.Warnf("session already registered in manager")
@@ -196,7 +554,6 @@ func restoreInstance(ctx context.Context, inst *instance.Instance, instanceSvc s
 	} else {
 		sess.ID = string(inst.ID)
 		sess.CreatedAt = inst.CreatedAt
-		sess.Token = inst.Token
 	}
 
 	qrChan, alreadyLogged, err := bootstrap.InitNewSession(context.Background(), inst.Name)
@@ -214,10 +571,10 @@ func restoreInstance(ctx context.Context, inst *instance.Instance, instanceSvc s
 	}
 
 	log.Infof("session requires QR scan; waiting for events")
-	watchQRChannel(ctx, inst.Name, qrChan, instanceSvc, log)
+	watchQRChannel(ctx, inst.Name, qrChan, instanceSvc, bootstrap.QREvents, log)
 }
 
-func watchQRChannel(ctx context.Context, instanceName string, qrChan <-chan whatsmeow.QRChannelItem, instanceSvc services.InstanceService, log waLog.Logger) {
+func watchQRChannel(ctx context.Context, instanceName string, qrChan <-chan whatsmeow.QRChannelItem, instanceSvc services.InstanceService, qrEvents services.QRCodeListener, log waLog.Logger) {
 	for {
 		select {
 		case item, ok := <-qrChan:
@@ -240,6 +597,9 @@ func watchQRChannel(ctx context.Context, instanceName string, qrChan <-chan what
 					}
 					whatsapp.PrintQRASCII(item.Code)
 				}
+				if qrEvents != nil {
+					go qrEvents.HandleQRCode(context.Background(), instanceName, item.Code, "")
+				}
 			case "success":
 				log.Infof("device paired successfully")
 				return
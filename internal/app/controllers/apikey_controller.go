@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/apikey"
+)
+
+// APIKeyController expõe o CRUD de API keys com escopo (restrito ao master token).
+type APIKeyController struct {
+	service services.APIKeyService
+}
+
+func NewAPIKeyController(s services.APIKeyService) *APIKeyController {
+	return &APIKeyController{service: s}
+}
+
+// POST /apikeys
+func (c *APIKeyController) Create(w http.ResponseWriter, r *http.Request) {
+	var in apikey.CreateAPIKeyInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := c.service.Create(r.Context(), in)
+	if err != nil {
+		writeError(w, apiKeyErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// GET /apikeys
+func (c *APIKeyController) List(w http.ResponseWriter, r *http.Request) {
+	keys, err := c.service.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, keys)
+}
+
+// GET /apikeys/{id}
+func (c *APIKeyController) Get(w http.ResponseWriter, r *http.Request, id string) {
+	key, err := c.service.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, apiKeyErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, key)
+}
+
+// PATCH /apikeys/{id}
+func (c *APIKeyController) Update(w http.ResponseWriter, r *http.Request, id string) {
+	var in apikey.UpdateAPIKeyInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	key, err := c.service.Update(r.Context(), id, in)
+	if err != nil {
+		writeError(w, apiKeyErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, key)
+}
+
+// DELETE /apikeys/{id}
+func (c *APIKeyController) Delete(w http.ResponseWriter, r *http.Request, id string) {
+	if err := c.service.Delete(r.Context(), id); err != nil {
+		writeError(w, apiKeyErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrAPIKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrAPIKeyNameRequired), errors.Is(err, services.ErrAPIKeyScopes), errors.Is(err, services.ErrAPIKeyExpiry):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+)
+
+// EventSinkController habilita por instância os destinos de eventos do servidor (Redis, ...).
+type EventSinkController struct {
+	service services.EventSinkService
+	sinks   []string
+}
+
+func NewEventSinkController(s services.EventSinkService, sinks []string) *EventSinkController {
+	return &EventSinkController{service: s, sinks: sinks}
+}
+
+// Sinks retorna os destinos disponíveis, cada um com as rotas /{sink}/set e /{sink}/find.
+func (c *EventSinkController) Sinks() []string {
+	return c.sinks
+}
+
+// POST /{sink}/set/{instance}
+func (c *EventSinkController) Set(w http.ResponseWriter, r *http.Request, sink, instanceName string) {
+	var in webhook.SinkConfigInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	cfg, err := c.service.Set(r.Context(), instanceName, sink, in)
+	if err != nil {
+		writeError(w, sinkErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, cfg)
+}
+
+// GET /{sink}/find/{instance}
+func (c *EventSinkController) Find(w http.ResponseWriter, r *http.Request, sink, instanceName string) {
+	cfg, err := c.service.Find(r.Context(), instanceName, sink)
+	if err != nil {
+		writeError(w, sinkErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, cfg)
+}
+
+func sinkErrorStatus(err error) int {
+	if errors.Is(err, services.ErrUnknownEventSink) {
+		return http.StatusNotFound
+	}
+	return webhookErrorStatus(err)
+}
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/gorilla/websocket"
+)
+
+// EventStreamController expõe os eventos das instâncias via SSE e WebSocket, para clientes que
+// não conseguem receber webhooks (ex.: atrás de NAT).
+type EventStreamController struct {
+	streams   *services.EventStreams
+	instances repositories.InstanceRepository
+	upgrader  websocket.Upgrader
+}
+
+func NewEventStreamController(streams *services.EventStreams, instances repositories.InstanceRepository) *EventStreamController {
+	return &EventStreamController{
+		streams:   streams,
+		instances: instances,
+		// A API já libera CORS para qualquer origem; a autenticação é feita pelo token.
+		upgrader: websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }},
+	}
+}
+
+// GET /instances/{name}/events/stream?events=&cursor= (Server-Sent Events)
+func (c *EventStreamController) InstanceSSE(w http.ResponseWriter, r *http.Request, name string) {
+	if _, err := c.instances.GetByName(r.Context(), name); err != nil {
+		writeError(w, webhookErrorStatus(err), err)
+		return
+	}
+	c.sse(w, r, services.EventStreamFilter{Instances: []string{name}, Events: listParam(r, "events")})
+}
+
+// GET /instances/{name}/events/ws?events=&cursor= (WebSocket, um quadro JSON por evento)
+func (c *EventStreamController) InstanceWebSocket(w http.ResponseWriter, r *http.Request, name string) {
+	if _, err := c.instances.GetByName(r.Context(), name); err != nil {
+		writeError(w, webhookErrorStatus(err), err)
+		return
+	}
+	c.webSocket(w, r, services.EventStreamFilter{Instances: []string{name}, Events: listParam(r, "events")})
+}
+
+// GET /events/stream?instances=&events=&cursor= (todas as instâncias, apenas master token)
+func (c *EventStreamController) SSE(w http.ResponseWriter, r *http.Request) {
+	c.sse(w, r, services.EventStreamFilter{Instances: listParam(r, "instances"), Events: listParam(r, "events")})
+}
+
+// GET /events/ws?instances=&events=&cursor= (todas as instâncias, apenas master token)
+func (c *EventStreamController) WebSocket(w http.ResponseWriter, r *http.Request) {
+	c.webSocket(w, r, services.EventStreamFilter{Instances: listParam(r, "instances"), Events: listParam(r, "events")})
+}
+
+// open assina o stream antes de ler o backlog, para não perder eventos entre os dois; um evento
+// na fronteira pode chegar duas vezes (entrega ao menos uma vez).
+func (c *EventStreamController) open(r *http.Request, filter services.EventStreamFilter) (<-chan services.StreamEvent, func(), []services.StreamEvent, error) {
+	cursor := strings.TrimSpace(r.URL.Query().Get("cursor"))
+	if cursor == "" {
+		cursor = strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	}
+	frames, cancel := c.streams.Subscribe(filter)
+	if cursor == "" {
+		return frames, cancel, nil, nil
+	}
+	backlog, err := c.streams.Backlog(r.Context(), filter, cursor)
+	if err != nil {
+		cancel()
+		return nil, nil, nil, err
+	}
+	return frames, cancel, backlog, nil
+}
+
+func (c *EventStreamController) sse(w http.ResponseWriter, r *http.Request, filter services.EventStreamFilter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming unsupported"))
+		return
+	}
+	frames, cancel, backlog, err := c.open(r, filter)
+	if err != nil {
+		writeError(w, streamErrorStatus(err), err)
+		return
+	}
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(evt services.StreamEvent) bool {
+		data, err := json.Marshal(evt)
+		if err != nil {
+			return true
+		}
+		// Sem cursor (falha ao gravar no journal) o quadro sai sem id, mantendo o último do cliente.
+		if evt.ID != "" {
+			if _, err := fmt.Fprintf(w, "id: %s\n", evt.ID); err != nil {
+				return false
+			}
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evt.Event, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+	for _, evt := range backlog {
+		if !send(evt) {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(loginStreamPing)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case evt, ok := <-frames:
+			if !ok || !send(evt) {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (c *EventStreamController) webSocket(w http.ResponseWriter, r *http.Request, filter services.EventStreamFilter) {
+	frames, cancel, backlog, err := c.open(r, filter)
+	if err != nil {
+		writeError(w, streamErrorStatus(err), err)
+		return
+	}
+	defer cancel()
+	conn, err := c.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade já respondeu com o erro ao cliente
+		return
+	}
+	defer conn.Close()
+
+	// Leitura apenas para processar close/pong do cliente.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(evt services.StreamEvent) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return conn.WriteJSON(evt) == nil
+	}
+	for _, evt := range backlog {
+		if !send(evt) {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(loginStreamPing)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case evt, ok := <-frames:
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "subscriber too slow"), time.Now().Add(time.Second))
+				return
+			}
+			if !send(evt) {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// listParam lê um parâmetro de query separado por vírgulas (ou repetido).
+func listParam(r *http.Request, key string) []string {
+	var out []string
+	for _, raw := range r.URL.Query()[key] {
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+func streamErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrStreamResumeUnavailable), errors.Is(err, services.ErrInvalidStreamCursor):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -126,7 +126,9 @@ func (c *GroupController) SendInvite(w http.ResponseWriter, r *http.Request, ins
 
 	out, err := c.service.SendInvite(r.Context(), in)
 	if err != nil {
-		writeError(w, mapGroupStatus(err), err)
+		if !writeQuotaError(w, err) {
+			writeError(w, mapGroupStatus(err), err)
+		}
 		return
 	}
 	writeJSON(w, http.StatusOK, out)
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
 	"net/http"
 	"strings"
@@ -59,12 +60,16 @@ type settingsResponseData struct {
 
 type qrPayload struct {
 	Event          string `json:"event"`
+	PairingCode    string `json:"pairingCode,omitempty"`
 	Code           string `json:"code,omitempty"`
 	Link           string `json:"link,omitempty"`
 	Image          string `json:"image,omitempty"`
 	TimeoutSeconds int    `json:"timeoutSeconds,omitempty"`
 }
 
+// maxPairingRefreshes limita quantas vezes o código de pareamento automático é renovado.
+const maxPairingRefreshes = 3
+
 type connectResponse struct {
 	Status         string `json:"status,omitempty"`
 	PairingCode    string `json:"pairingCode,omitempty"`
@@ -116,16 +121,21 @@ func (c *InstanceController) Create(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	if in.QRCode {
-		qrData, qrErr := c.generateQRCode(r.Context(), inst.Name)
+	// Com número informado o servidor já conecta e devolve o código de pareamento.
+	autoPair := strings.TrimSpace(inst.Number) != ""
+	if in.QRCode || autoPair {
+		qrData, qrErr := c.generateQRCode(r.Context(), inst.Name, autoPair)
 		if qrErr != nil {
 			_ = c.service.Delete(r.Context(), inst.Name)
-			writeError(w, http.StatusInternalServerError, qrErr)
+			writeError(w, pairingErrorStatus(qrErr), qrErr)
 			return
 		}
 		resp.QRCode = qrData
-		if qrData != nil && qrData.Event == "code" {
+		if qrData != nil && qrData.Event == "code" && c.qrListener() == nil {
 			payload := map[string]any{}
+			if qrData.PairingCode != "" {
+				payload["pairingCode"] = qrData.PairingCode
+			}
 			if qrData.Link != "" {
 				payload["link"] = qrData.Link
 			} else if qrData.Code != "" {
@@ -164,7 +174,7 @@ func (c *InstanceController) List(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return all instances
-	items, err := c.service.List(r.Context())
+	items, err := c.service.List(r.Context(), listFilter(r))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
@@ -172,6 +182,63 @@ func (c *InstanceController) List(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, items)
 }
 
+// listFilter lê os filtros do fetchInstances: tag (repetível ou separada por vírgula),
+// status e ownerJid.
+func listFilter(r *http.Request) instance.ListFilter {
+	q := r.URL.Query()
+	var tags []string
+	for _, raw := range q["tag"] {
+		for _, tag := range strings.Split(raw, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return instance.ListFilter{
+		Tags:     tags,
+		Status:   strings.TrimSpace(q.Get("status")),
+		OwnerJID: strings.TrimSpace(q.Get("ownerJid")),
+	}
+}
+
+// PATCH /instances/{name}: altera número, integração, tags, metadata e o nome da instância.
+// Uma sessão conectada é reconectada com o novo nome após a renomeação.
+func (c *InstanceController) Update(w http.ResponseWriter, r *http.Request, name string) {
+	var in instance.UpdateInstanceInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	renaming := in.InstanceName != nil && strings.TrimSpace(*in.InstanceName) != name
+	wasOpen := false
+	if renaming {
+		state, _ := c.service.ConnectionState(r.Context(), name)
+		wasOpen = state == "open"
+	}
+	inst, err := c.service.Update(r.Context(), name, in)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrInstanceNotFound):
+			writeError(w, http.StatusNotFound, err)
+		case errors.Is(err, repositories.ErrInstanceAlreadyExists), errors.Is(err, whatsapp.ErrDeviceExists):
+			writeError(w, http.StatusConflict, err)
+		default:
+			writeError(w, http.StatusBadRequest, err)
+		}
+		return
+	}
+	if renaming && wasOpen && c.bootstrap != nil {
+		if _, _, err := c.bootstrap.InitNewSession(r.Context(), inst.Name); err != nil {
+			log.Printf("instance %s renamed, but reconnect failed: %v", inst.Name, err)
+		} else {
+			inst.Status = "open"
+		}
+	}
+	inst.Webhook = inst.Webhook.Redacted()
+	inst.Proxy.Password = ""
+	writeJSON(w, http.StatusOK, inst)
+}
+
 func (c *InstanceController) Delete(w http.ResponseWriter, r *http.Request) {
 	name := extractInstanceName(r)
 	if name == "" {
@@ -224,6 +291,48 @@ func (c *InstanceController) Disconnect(w http.ResponseWriter, r *http.Request)
 	writeJSON(w, http.StatusOK, map[string]any{"Details": "Disconnected"})
 }
 
+// GET /instances/{name}/connectionState retorna o estado da conexão e a saúde supervisionada da sessão
+func (c *InstanceController) ConnectionState(w http.ResponseWriter, r *http.Request) {
+	name := extractInstanceName(r)
+	if name == "" {
+		writeError(w, http.StatusBadRequest, ErrInvalidParam)
+		return
+	}
+	resp, err := c.service.ConnectionDetails(r.Context(), name)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInstanceNotFound) {
+			writeError(w, http.StatusNotFound, err)
+		} else {
+			writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// TestProxy verifica o proxy da instância (ou o informado no corpo) fazendo uma requisição
+// ao destino. O corpo é opcional.
+func (c *InstanceController) TestProxy(w http.ResponseWriter, r *http.Request, name string) {
+	var in instance.ProxyTestInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	result, err := c.service.TestProxy(r.Context(), name, in)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrInstanceNotFound):
+			writeError(w, http.StatusNotFound, err)
+		case errors.Is(err, whatsapp.ErrInvalidProxy), errors.Is(err, services.ErrProxyTestTarget):
+			writeError(w, http.StatusBadRequest, err)
+		default:
+			writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
 // POST /instances/{name}/connect inicia ou retorna status de conexão (QR events curto polling)
 func (c *InstanceController) Connect(w http.ResponseWriter, r *http.Request) {
 	if c.bootstrap == nil {
@@ -242,7 +351,11 @@ func (c *InstanceController) Connect(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	qrChan, already, err := c.bootstrap.InitNewSession(ctx, name)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInstanceOwnedElsewhere) {
+			status = http.StatusConflict
+		}
+		writeError(w, status, err)
 		return
 	}
 	if already {
@@ -275,28 +388,16 @@ func (c *InstanceController) Connect(w http.ResponseWriter, r *http.Request) {
 			whatsapp.PrintQRASCII(item.Code)
 			pairingCode, pairErr := c.service.GeneratePairingCode(ctx, name, phone)
 			if pairErr != nil {
-				switch {
-				case errors.Is(pairErr, services.ErrPhoneNumberRequired):
-					// Número não disponível: apenas omite o pairing code
-				case errors.Is(pairErr, repositories.ErrInstanceNotFound):
-					writeError(w, http.StatusNotFound, pairErr)
-					return
-				case errors.Is(pairErr, whatsapp.ErrClientUnavailable):
-					writeError(w, http.StatusConflict, pairErr)
-					return
-				case errors.Is(pairErr, whatsapp.ErrNotFound):
-					writeError(w, http.StatusNotFound, pairErr)
-					return
-				case errors.Is(pairErr, wa.ErrPhoneNumberTooShort), errors.Is(pairErr, wa.ErrPhoneNumberIsNotInternational):
-					writeError(w, http.StatusBadRequest, pairErr)
-					return
-				default:
-					writeError(w, http.StatusInternalServerError, pairErr)
+				if !errors.Is(pairErr, services.ErrPhoneNumberRequired) {
+					writeError(w, pairingErrorStatus(pairErr), pairErr)
 					return
 				}
+				// Número não disponível: apenas omite o pairing code
 			} else if pairingCode != "" {
-				resp.PairingCode = strings.ReplaceAll(pairingCode, "-", "")
+				resp.PairingCode = formatPairingCode(pairingCode)
 			}
+			c.notifyQRCode(name, item.Code, resp.PairingCode)
+			go c.followQRChannel(name, qrChan, resp.PairingCode != "", 0, resp.PairingCode)
 		} else if item.Event == "timeout" {
 			resp.Message = "pairing timeout"
 		} else if item.Event == wa.QRChannelEventError && item.Error != nil {
@@ -414,6 +515,9 @@ func (c *InstanceController) SetSettings(w http.ResponseWriter, r *http.Request,
 		"read_status":       inst.Settings.ReadStatus,
 		"sync_full_history": inst.Settings.SyncFullHistory,
 	}
+	if proxy := inst.Proxy.Redacted(); proxy != nil {
+		settingsPayload["proxy"] = proxy
+	}
 	payload := map[string]any{
 		"settings": map[string]any{
 			"instanceName": inst.Name,
@@ -423,7 +527,37 @@ func (c *InstanceController) SetSettings(w http.ResponseWriter, r *http.Request,
 	writeJSON(w, http.StatusCreated, payload)
 }
 
-func (c *InstanceController) generateQRCode(ctx context.Context, name string) (*qrPayload, error) {
+// RotateToken gera um novo token para a instância. O corpo é opcional.
+func (c *InstanceController) RotateToken(w http.ResponseWriter, r *http.Request, name string) {
+	var in instance.RotateTokenInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := c.service.RotateToken(r.Context(), name, in)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrInstanceNotFound):
+			writeError(w, http.StatusNotFound, err)
+		case errors.Is(err, repositories.ErrTokenAlreadyExists):
+			writeError(w, http.StatusConflict, err)
+		case errors.Is(err, services.ErrInvalidGracePeriod):
+			writeError(w, http.StatusBadRequest, err)
+		default:
+			writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// generateQRCode conecta a sessão e aguarda o primeiro QR. Com autoPair também solicita o
+// código de pareamento para o número salvo na instância.
+func (c *InstanceController) generateQRCode(ctx context.Context, name string, autoPair bool) (*qrPayload, error) {
+	return c.startLogin(ctx, name, autoPair, 0)
+}
+
+func (c *InstanceController) startLogin(ctx context.Context, name string, autoPair bool, refreshes int) (*qrPayload, error) {
 	if c.bootstrap == nil {
 		return &qrPayload{Event: "bootstrap_unavailable"}, nil
 	}
@@ -461,6 +595,16 @@ func (c *InstanceController) generateQRCode(ctx context.Context, name string) (*
 					log.Printf("[QR] failed to cache code for %s: %v", name, err)
 				}
 				whatsapp.PrintQRASCII(item.Code)
+				if autoPair {
+					pairingCode, err := c.service.GeneratePairingCode(ctx, name, "")
+					if err != nil {
+						c.abortLogin(name, qrChan)
+						return nil, err
+					}
+					payload.PairingCode = formatPairingCode(pairingCode)
+				}
+				c.notifyQRCode(name, item.Code, payload.PairingCode)
+				go c.followQRChannel(name, qrChan, autoPair, refreshes, payload.PairingCode)
 				if payload.Link == "" {
 					if png, err := qrcode.Encode(item.Code, qrcode.Medium, 256); err == nil {
 						payload.Image = "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
@@ -483,11 +627,87 @@ func (c *InstanceController) generateQRCode(ctx context.Context, name string) (*
 			log.Printf("[QR] Evento recebido: %s, aguardando código...", item.Event)
 
 		case <-time.After(30 * time.Second):
+			c.abortLogin(name, qrChan)
 			return &qrPayload{Event: "timeout"}, nil
 		}
 	}
 }
 
+// abortLogin encerra uma tentativa de login que ninguém vai acompanhar: desconecta o client
+// e drena o QR channel, cujo handler do whatsmeow bloqueia quando o buffer enche.
+func (c *InstanceController) abortLogin(name string, qrChan <-chan wa.QRChannelItem) {
+	if err := c.service.Disconnect(context.Background(), name); err != nil {
+		log.Printf("[QR] failed to disconnect %s after aborted login: %v", name, err)
+	}
+	go func() {
+		for range qrChan {
+		}
+	}()
+}
+
+func (c *InstanceController) qrListener() services.QRCodeListener {
+	if c.bootstrap == nil {
+		return nil
+	}
+	return c.bootstrap.QREvents
+}
+
+func (c *InstanceController) notifyQRCode(name, code, pairingCode string) {
+	if listener := c.qrListener(); listener != nil {
+		go listener.HandleQRCode(context.Background(), name, code, pairingCode)
+	}
+}
+
+// followQRChannel continua consumindo o QR channel após a primeira resposta HTTP,
+// mantendo o cache atualizado e publicando cada novo código até o pareamento ou timeout.
+// Com autoPair, quando os QR codes se esgotam (o código de pareamento expira junto com o
+// websocket de login) uma nova conexão é aberta e um novo código de pareamento é publicado.
+func (c *InstanceController) followQRChannel(name string, qrChan <-chan wa.QRChannelItem, autoPair bool, refreshes int, pairingCode string) {
+	timedOut := false
+	for item := range qrChan {
+		if item.Event != "code" {
+			log.Printf("[QR] instance %s: evento %s", name, item.Event)
+			timedOut = item.Event == "timeout"
+			continue
+		}
+		if item.Code == "" {
+			continue
+		}
+		if _, err := c.service.CacheQRCode(context.Background(), name, item.Code); err != nil {
+			log.Printf("[QR] failed to cache code for %s: %v", name, err)
+		}
+		c.notifyQRCode(name, item.Code, pairingCode)
+	}
+	if !autoPair || !timedOut || refreshes >= maxPairingRefreshes {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := c.startLogin(ctx, name, true, refreshes+1); err != nil {
+		log.Printf("[QR] failed to refresh pairing code for %s: %v", name, err)
+	}
+}
+
+// formatPairingCode remove o separador do código retornado pelo whatsmeow (XXXX-XXXX).
+func formatPairingCode(code string) string {
+	return strings.ReplaceAll(code, "-", "")
+}
+
+// pairingErrorStatus traduz os erros de conexão/pareamento para o status HTTP.
+func pairingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrInstanceNotFound), errors.Is(err, whatsapp.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, whatsapp.ErrClientUnavailable), errors.Is(err, services.ErrInstanceOwnedElsewhere):
+		return http.StatusConflict
+	case errors.Is(err, wa.ErrPhoneNumberTooShort), errors.Is(err, wa.ErrPhoneNumberIsNotInternational),
+		errors.Is(err, whatsapp.ErrInvalidPairClient), errors.Is(err, services.ErrPhoneNumberRequired):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func (c *InstanceController) dispatchWebhookAsync(inst *instance.Instance, event string, data map[string]any) {
 	if c.webhook == nil || inst == nil {
 		return
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/gorilla/websocket"
+)
+
+// loginStreamPing mantém a conexão viva atrás de proxies que derrubam streams ociosos.
+const loginStreamPing = 25 * time.Second
+
+// LoginStreamController expõe o stream de QR/pairing/conexão de uma instância via SSE e WebSocket.
+type LoginStreamController struct {
+	streams  *services.LoginStreams
+	upgrader websocket.Upgrader
+}
+
+func NewLoginStreamController(streams *services.LoginStreams) *LoginStreamController {
+	return &LoginStreamController{
+		streams: streams,
+		// A API já libera CORS para qualquer origem; a autenticação é feita pelo token.
+		upgrader: websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }},
+	}
+}
+
+// GET /instances/{name}/qr/stream (Server-Sent Events)
+func (c *LoginStreamController) SSE(w http.ResponseWriter, r *http.Request, name string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming unsupported"))
+		return
+	}
+	frames, cancel := c.streams.Subscribe(name)
+	defer cancel()
+	snapshot, ok := c.streams.Snapshot(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, whatsapp.ErrNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(evt services.LoginEvent) bool {
+		data, err := json.Marshal(evt)
+		if err != nil {
+			return true
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evt.Event, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return !evt.Final()
+	}
+	for _, evt := range snapshot {
+		if !send(evt) {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(loginStreamPing)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case evt, ok := <-frames:
+			if !ok || !send(evt) {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// GET /instances/{name}/qr/ws (WebSocket, um quadro JSON por evento)
+func (c *LoginStreamController) WebSocket(w http.ResponseWriter, r *http.Request, name string) {
+	frames, cancel := c.streams.Subscribe(name)
+	defer cancel()
+	snapshot, ok := c.streams.Snapshot(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, whatsapp.ErrNotFound)
+		return
+	}
+	conn, err := c.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade já respondeu com o erro ao cliente
+		return
+	}
+	defer conn.Close()
+
+	// Leitura apenas para processar close/pong do cliente.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(evt services.LoginEvent) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if err := conn.WriteJSON(evt); err != nil {
+			return false
+		}
+		if evt.Final() {
+			_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "paired"), time.Now().Add(time.Second))
+			return false
+		}
+		return true
+	}
+	for _, evt := range snapshot {
+		if !send(evt) {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(loginStreamPing)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case evt, ok := <-frames:
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "subscriber too slow"), time.Now().Add(time.Second))
+				return
+			}
+			if !send(evt) {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/faeln1/go-whatsapp-api/internal/app/services"
 	"github.com/faeln1/go-whatsapp-api/internal/domain/message"
@@ -55,7 +57,7 @@ func (c *MessageController) SendText(w http.ResponseWriter, r *http.Request) {
 
 	out, err := c.service.SendText(r.Context(), in)
 	if err != nil {
-		writeError(w, mapMessageStatus(err), err)
+		writeMessageError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, out)
@@ -83,7 +85,7 @@ func (c *MessageController) SendStatus(w http.ResponseWriter, r *http.Request) {
 
 	out, err := c.service.SendStatus(r.Context(), in)
 	if err != nil {
-		writeError(w, mapMessageStatus(err), err)
+		writeMessageError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, out)
@@ -126,7 +128,7 @@ func (c *MessageController) SendMedia(w http.ResponseWriter, r *http.Request) {
 
 	out, err := c.service.SendMedia(r.Context(), in)
 	if err != nil {
-		writeError(w, mapMessageStatus(err), err)
+		writeMessageError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, out)
@@ -154,7 +156,7 @@ func (c *MessageController) SendAudio(w http.ResponseWriter, r *http.Request) {
 
 	out, err := c.service.SendAudio(r.Context(), in)
 	if err != nil {
-		writeError(w, mapMessageStatus(err), err)
+		writeMessageError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, out)
@@ -182,7 +184,7 @@ func (c *MessageController) SendSticker(w http.ResponseWriter, r *http.Request)
 
 	out, err := c.service.SendSticker(r.Context(), in)
 	if err != nil {
-		writeError(w, mapMessageStatus(err), err)
+		writeMessageError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, out)
@@ -210,7 +212,7 @@ func (c *MessageController) SendLocation(w http.ResponseWriter, r *http.Request)
 
 	out, err := c.service.SendLocation(r.Context(), in)
 	if err != nil {
-		writeError(w, mapMessageStatus(err), err)
+		writeMessageError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, out)
@@ -238,7 +240,7 @@ func (c *MessageController) SendContact(w http.ResponseWriter, r *http.Request)
 
 	out, err := c.service.SendContact(r.Context(), in)
 	if err != nil {
-		writeError(w, mapMessageStatus(err), err)
+		writeMessageError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, out)
@@ -266,7 +268,7 @@ func (c *MessageController) SendReaction(w http.ResponseWriter, r *http.Request)
 
 	out, err := c.service.SendReaction(r.Context(), in)
 	if err != nil {
-		writeError(w, mapMessageStatus(err), err)
+		writeMessageError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, out)
@@ -294,7 +296,7 @@ func (c *MessageController) SendPoll(w http.ResponseWriter, r *http.Request) {
 
 	out, err := c.service.SendPoll(r.Context(), in)
 	if err != nil {
-		writeError(w, mapMessageStatus(err), err)
+		writeMessageError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, out)
@@ -313,7 +315,7 @@ func (c *MessageController) SendList(w http.ResponseWriter, r *http.Request) {
 
 	out, err := c.service.SendList(r.Context(), in)
 	if err != nil {
-		writeError(w, mapMessageStatus(err), err)
+		writeMessageError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, out)
@@ -332,7 +334,7 @@ func (c *MessageController) SendButtons(w http.ResponseWriter, r *http.Request)
 
 	out, err := c.service.SendButtons(r.Context(), in)
 	if err != nil {
-		writeError(w, mapMessageStatus(err), err)
+		writeMessageError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, out)
@@ -354,6 +356,28 @@ func (c *MessageController) bindInstanceID(w http.ResponseWriter, r *http.Reques
 	return true
 }
 
+// writeMessageError responde o erro de envio; cota excedida vira 429.
+func writeMessageError(w http.ResponseWriter, err error) {
+	if writeQuotaError(w, err) {
+		return
+	}
+	writeError(w, mapMessageStatus(err), err)
+}
+
+// writeQuotaError responde cota excedida com 429 e Retry-After até a renovação da cota; false
+// quando err não é de cota.
+func writeQuotaError(w http.ResponseWriter, err error) bool {
+	var quotaErr *services.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+	if wait := time.Until(quotaErr.ResetAt); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+	}
+	writeJSON(w, http.StatusTooManyRequests, map[string]any{"error": err.Error(), "quota": quotaErr})
+	return true
+}
+
 func mapMessageStatus(err error) int {
 	msg := err.Error()
 	switch {
@@ -11,11 +11,12 @@ import (
 )
 
 type SettingsController struct {
-	service services.InstanceService
+	service  services.InstanceService
+	presence *services.PresenceKeeper
 }
 
-func NewSettingsController(s services.InstanceService) *SettingsController {
-	return &SettingsController{service: s}
+func NewSettingsController(s services.InstanceService, presence *services.PresenceKeeper) *SettingsController {
+	return &SettingsController{service: s, presence: presence}
 }
 
 func (c *SettingsController) Set(w http.ResponseWriter, r *http.Request, instanceName string) {
@@ -34,6 +35,9 @@ func (c *SettingsController) Set(w http.ResponseWriter, r *http.Request, instanc
 		}
 		return
 	}
+	if c.presence != nil {
+		c.presence.Apply(r.Context(), inst.Name)
+	}
 
 	payload := map[string]any{
 		"settings": map[string]any{
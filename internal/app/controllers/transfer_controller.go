@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/archive"
+)
+
+// TransferController expõe a exportação/importação de instâncias entre servidores.
+type TransferController struct {
+	service services.InstanceTransferService
+}
+
+func NewTransferController(s services.InstanceTransferService) *TransferController {
+	return &TransferController{service: s}
+}
+
+// POST /instances/{name}/export
+func (c *TransferController) Export(w http.ResponseWriter, r *http.Request, name string) {
+	var in instance.ExportInstanceInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := c.service.Export(r.Context(), name, in)
+	if err != nil {
+		writeError(w, transferErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// POST /instances/import
+func (c *TransferController) Import(w http.ResponseWriter, r *http.Request) {
+	var in instance.ImportInstanceInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := c.service.Import(r.Context(), in)
+	if err != nil {
+		writeError(w, transferErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func transferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrInstanceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrInstanceAlreadyExists), errors.Is(err, repositories.ErrTokenAlreadyExists),
+		errors.Is(err, whatsapp.ErrDeviceExists), errors.Is(err, whatsapp.ErrDeviceOwned), errors.Is(err, whatsapp.ErrDeviceNotPaired):
+		return http.StatusConflict
+	case errors.Is(err, archive.ErrPassphraseTooShort), errors.Is(err, archive.ErrInvalidArchive),
+		errors.Is(err, archive.ErrDecrypt), errors.Is(err, services.ErrUnsupportedArchive),
+		errors.Is(err, services.ErrInvalidInstanceName):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/usage"
+)
+
+// UsageController expõe o consumo de envios e as cotas de cada instância.
+type UsageController struct {
+	service services.UsageService
+}
+
+func NewUsageController(s services.UsageService) *UsageController {
+	return &UsageController{service: s}
+}
+
+// GET /instances/{name}/usage?days=30
+func (c *UsageController) Get(w http.ResponseWriter, r *http.Request, name string) {
+	days := 0
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("days must be a positive integer"))
+			return
+		}
+		days = n
+	}
+	report, err := c.service.Usage(r.Context(), name, days)
+	if err != nil {
+		writeError(w, usageErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// PUT /instances/{name}/usage/quota
+func (c *UsageController) SetQuota(w http.ResponseWriter, r *http.Request, name string) {
+	var in usage.SetQuotaInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	quota, err := c.service.SetQuota(r.Context(), name, in)
+	if err != nil {
+		writeError(w, usageErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, quota)
+}
+
+// DELETE /instances/{name}/usage/quota volta a instância para a cota padrão do servidor.
+func (c *UsageController) ResetQuota(w http.ResponseWriter, r *http.Request, name string) {
+	if err := c.service.ResetQuota(r.Context(), name); err != nil {
+		writeError(w, usageErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func usageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrInstanceNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -3,8 +3,10 @@ package controllers
 import (
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
 	"github.com/faeln1/go-whatsapp-api/internal/app/services"
@@ -73,6 +75,10 @@ func (c *WebhookController) Find(w http.ResponseWriter, r *http.Request, instanc
 		"events":          config.Events,
 		"webhookByEvents": config.ByEvents,
 		"webhookBase64":   config.Base64,
+		"signed":          config.Secret != "",
+	}
+	if expiresAt := config.PreviousSecretExpiresAt; config.PreviousSecret != "" && expiresAt != nil && time.Now().Before(*expiresAt) {
+		resp["previousSecretExpiresAt"] = expiresAt
 	}
 	if len(config.Headers) > 0 {
 		filtered := make(map[string]string, len(config.Headers))
@@ -84,3 +90,37 @@ func (c *WebhookController) Find(w http.ResponseWriter, r *http.Request, instanc
 
 	writeJSON(w, http.StatusOK, resp)
 }
+
+// POST /webhook/rotateSecret/{instance} gera (ou grava) o segredo HMAC das entregas. Com
+// gracePeriodSeconds o segredo anterior continua assinando durante a troca no receptor.
+func (c *WebhookController) RotateSecret(w http.ResponseWriter, r *http.Request, instanceName string) {
+	var in instance.RotateWebhookSecretInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := c.service.RotateWebhookSecret(r.Context(), instanceName, in)
+	if err != nil {
+		writeError(w, webhookErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// DELETE /webhook/secret/{instance} desativa a assinatura das entregas.
+func (c *WebhookController) DeleteSecret(w http.ResponseWriter, r *http.Request, instanceName string) {
+	if err := c.service.DeleteWebhookSecret(r.Context(), instanceName); err != nil {
+		writeError(w, webhookErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrInstanceNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+)
+
+// WebhookDeliveryController expõe o log de entregas de webhook: consulta, replay e evento de teste.
+type WebhookDeliveryController struct {
+	service services.WebhookDeliveryService
+}
+
+func NewWebhookDeliveryController(s services.WebhookDeliveryService) *WebhookDeliveryController {
+	return &WebhookDeliveryController{service: s}
+}
+
+// GET /webhook/deliveries/{instance}?event=&status=&subscriptionId=&since=&until=&limit=
+func (c *WebhookDeliveryController) List(w http.ResponseWriter, r *http.Request, instanceName string) {
+	q := r.URL.Query()
+	filter := webhook.DeliveryFilter{
+		SubscriptionID: strings.TrimSpace(q.Get("subscriptionId")),
+		Event:          strings.TrimSpace(q.Get("event")),
+		Status:         strings.TrimSpace(q.Get("status")),
+	}
+	var err error
+	if filter.Since, err = parseTimeParam(q.Get("since")); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("since: %w", err))
+		return
+	}
+	if filter.Until, err = parseTimeParam(q.Get("until")); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("until: %w", err))
+		return
+	}
+	if raw := q.Get("limit"); raw != "" {
+		if filter.Limit, err = strconv.Atoi(raw); err != nil || filter.Limit <= 0 {
+			writeError(w, http.StatusBadRequest, ErrInvalidParam)
+			return
+		}
+	}
+	records, err := c.service.List(r.Context(), instanceName, filter)
+	if err != nil {
+		writeError(w, deliveryErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deliveries": records, "count": len(records)})
+}
+
+// GET /webhook/deliveries/{instance}/{id} inclui o payload e cada tentativa.
+func (c *WebhookDeliveryController) Get(w http.ResponseWriter, r *http.Request, instanceName, id string) {
+	rec, err := c.service.Get(r.Context(), instanceName, id)
+	if err != nil {
+		writeError(w, deliveryErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+// POST /webhook/deliveries/{instance}/{id}/replay
+func (c *WebhookDeliveryController) Replay(w http.ResponseWriter, r *http.Request, instanceName, id string) {
+	delivery, err := c.service.Replay(r.Context(), instanceName, id)
+	if err != nil {
+		writeError(w, deliveryErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"queued": []string{delivery.ID}})
+}
+
+type replayRangeRequest struct {
+	Since          time.Time `json:"since"`
+	Until          time.Time `json:"until"`
+	Event          string    `json:"event"`
+	Status         string    `json:"status"`
+	SubscriptionID string    `json:"subscriptionId"`
+	Limit          int       `json:"limit"`
+}
+
+// POST /webhook/deliveries/{instance}/replay reenvia as entregas de um período.
+func (c *WebhookDeliveryController) ReplayRange(w http.ResponseWriter, r *http.Request, instanceName string) {
+	var in replayRangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	deliveries, err := c.service.ReplayRange(r.Context(), instanceName, webhook.DeliveryFilter{
+		SubscriptionID: strings.TrimSpace(in.SubscriptionID),
+		Event:          strings.TrimSpace(in.Event),
+		Status:         strings.TrimSpace(in.Status),
+		Since:          in.Since,
+		Until:          in.Until,
+		Limit:          in.Limit,
+	})
+	if err != nil {
+		writeError(w, deliveryErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"queued": deliveryIDs(deliveries)})
+}
+
+// POST /webhook/test/{instance} envia o evento webhook.test; sem subscriptionId vai para todas.
+func (c *WebhookDeliveryController) SendTest(w http.ResponseWriter, r *http.Request, instanceName string) {
+	var in struct {
+		SubscriptionID string `json:"subscriptionId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	deliveries, err := c.service.SendTest(r.Context(), instanceName, strings.TrimSpace(in.SubscriptionID))
+	if err != nil {
+		writeError(w, deliveryErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"queued": deliveryIDs(deliveries)})
+}
+
+func deliveryIDs(deliveries []*webhook.Delivery) []string {
+	ids := make([]string, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.ID)
+	}
+	return ids
+}
+
+func parseTimeParam(raw string) (time.Time, error) {
+	if raw = strings.TrimSpace(raw); raw == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
+
+func deliveryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrDeliveryRecordNotFound), errors.Is(err, services.ErrWebhookTargetUnavailable):
+		return http.StatusNotFound
+	default:
+		return webhookErrorStatus(err)
+	}
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+)
+
+// WebhookSubscriptionController expõe /instances/{name}/webhooks: várias URLs de webhook por
+// instância, cada uma com filtro de eventos, headers e segredo próprios.
+type WebhookSubscriptionController struct {
+	service services.WebhookSubscriptionService
+}
+
+func NewWebhookSubscriptionController(s services.WebhookSubscriptionService) *WebhookSubscriptionController {
+	return &WebhookSubscriptionController{service: s}
+}
+
+// GET /instances/{name}/webhooks
+func (c *WebhookSubscriptionController) List(w http.ResponseWriter, r *http.Request, instanceName string) {
+	subs, err := c.service.List(r.Context(), instanceName)
+	if err != nil {
+		writeError(w, subscriptionErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, subs)
+}
+
+// POST /instances/{name}/webhooks
+func (c *WebhookSubscriptionController) Create(w http.ResponseWriter, r *http.Request, instanceName string) {
+	var in webhook.SubscriptionInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	sub, err := c.service.Create(r.Context(), instanceName, in)
+	if err != nil {
+		writeError(w, subscriptionErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, sub)
+}
+
+// GET /instances/{name}/webhooks/{id}
+func (c *WebhookSubscriptionController) Get(w http.ResponseWriter, r *http.Request, instanceName, id string) {
+	sub, err := c.service.Get(r.Context(), instanceName, id)
+	if err != nil {
+		writeError(w, subscriptionErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// PATCH /instances/{name}/webhooks/{id} altera apenas os campos enviados.
+func (c *WebhookSubscriptionController) Update(w http.ResponseWriter, r *http.Request, instanceName, id string) {
+	var in webhook.SubscriptionInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	sub, err := c.service.Update(r.Context(), instanceName, id, in)
+	if err != nil {
+		writeError(w, subscriptionErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// DELETE /instances/{name}/webhooks/{id}
+func (c *WebhookSubscriptionController) Delete(w http.ResponseWriter, r *http.Request, instanceName, id string) {
+	if err := c.service.Delete(r.Context(), instanceName, id); err != nil {
+		writeError(w, subscriptionErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /instances/{name}/webhooks/{id}/rotateSecret
+func (c *WebhookSubscriptionController) RotateSecret(w http.ResponseWriter, r *http.Request, instanceName, id string) {
+	var in instance.RotateWebhookSecretInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := c.service.RotateSecret(r.Context(), instanceName, id, in)
+	if err != nil {
+		writeError(w, subscriptionErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func subscriptionErrorStatus(err error) int {
+	if errors.Is(err, repositories.ErrSubscriptionNotFound) {
+		return http.StatusNotFound
+	}
+	return webhookErrorStatus(err)
+}
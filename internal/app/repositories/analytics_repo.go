@@ -30,7 +30,8 @@ type AnalyticsRepository interface {
 }
 
 type analyticsRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	sqlite bool
 }
 
 func NewAnalyticsRepository(db *sql.DB) AnalyticsRepository {
@@ -357,13 +358,18 @@ func (r *analyticsRepository) GetInstanceMessageMetrics(ctx context.Context, ins
 		limit = 50
 	}
 
+	viewerKey := `split_part(split_part(COALESCE(mv.viewer_jid, ''), '@', 1), ':', 1)`
+	if r.sqlite {
+		viewerKey = sqliteViewerKey
+	}
+
 	query := `
 		SELECT 
 			mt.message_id,
 			mt.remote_jid,
 			mt.message_type,
 			mt.sent_at,
-			COUNT(DISTINCT NULLIF(split_part(split_part(COALESCE(mv.viewer_jid, ''), '@', 1), ':', 1), '')) AS view_count,
+			COUNT(DISTINCT NULLIF(` + viewerKey + `, '')) AS view_count,
 			COUNT(DISTINCT mr.id) AS reaction_count
 		FROM message_tracking mt
 		LEFT JOIN message_views mv ON mt.id = mv.message_track_id
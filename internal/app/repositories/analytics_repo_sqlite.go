@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// sqliteViewerKey equivale a split_part(split_part(viewer_jid, '@', 1), ':', 1) do PostgreSQL.
+var sqliteViewerKey = sqliteFirstPart(sqliteFirstPart("COALESCE(mv.viewer_jid, '')", "@"), ":")
+
+// sqliteFirstPart retorna a expressão SQLite do trecho de expr antes do primeiro sep.
+func sqliteFirstPart(expr, sep string) string {
+	return fmt.Sprintf("(CASE WHEN instr(%[1]s, '%[2]s') > 0 THEN substr(%[1]s, 1, instr(%[1]s, '%[2]s') - 1) ELSE %[1]s END)", expr, sep)
+}
+
+// NewSQLiteAnalyticsRepository builds an analytics repository backed by SQLite,
+// creating the tables equivalent to migrations/001_create_analytics_tables.sql.
+func NewSQLiteAnalyticsRepository(db *sql.DB) (AnalyticsRepository, error) {
+	if err := ensureSQLiteAnalyticsSchema(db); err != nil {
+		return nil, err
+	}
+	return &analyticsRepository{db: db, sqlite: true}, nil
+}
+
+func ensureSQLiteAnalyticsSchema(db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS message_tracking (
+            id TEXT PRIMARY KEY,
+            instance_id TEXT NOT NULL,
+            message_id TEXT NOT NULL,
+            remote_jid TEXT NOT NULL,
+            community_jid TEXT,
+            message_type TEXT NOT NULL,
+            content TEXT,
+            media_url TEXT,
+            caption TEXT,
+            sent_at TIMESTAMP NOT NULL,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_message_tracking_instance ON message_tracking(instance_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_tracking_message_id ON message_tracking(message_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_tracking_remote_jid ON message_tracking(remote_jid)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_tracking_community_jid ON message_tracking(community_jid)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_tracking_sent_at ON message_tracking(sent_at DESC)`,
+		`CREATE TABLE IF NOT EXISTS message_views (
+            id TEXT PRIMARY KEY,
+            message_track_id TEXT NOT NULL REFERENCES message_tracking(id) ON DELETE CASCADE,
+            viewer_jid TEXT NOT NULL,
+            viewer_name TEXT,
+            viewed_at TIMESTAMP NOT NULL,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            UNIQUE (message_track_id, viewer_jid)
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_message_views_track_id ON message_views(message_track_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_views_viewer_jid ON message_views(viewer_jid)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_views_viewed_at ON message_views(viewed_at DESC)`,
+		`CREATE TABLE IF NOT EXISTS message_reactions (
+            id TEXT PRIMARY KEY,
+            message_track_id TEXT NOT NULL REFERENCES message_tracking(id) ON DELETE CASCADE,
+            reactor_jid TEXT NOT NULL,
+            reactor_name TEXT,
+            reaction TEXT NOT NULL,
+            reacted_at TIMESTAMP NOT NULL,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            UNIQUE (message_track_id, reactor_jid)
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_message_reactions_track_id ON message_reactions(message_track_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_reactions_reactor_jid ON message_reactions(reactor_jid)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_reactions_reacted_at ON message_reactions(reacted_at DESC)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
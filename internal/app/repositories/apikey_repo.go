@@ -0,0 +1,209 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/apikey"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKeyRepository persiste as API keys com escopo (apenas o hash da chave).
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *apikey.APIKey) error
+	List(ctx context.Context) ([]*apikey.APIKey, error)
+	Update(ctx context.Context, key *apikey.APIKey) error
+	Delete(ctx context.Context, id string) error
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+}
+
+type inMemoryAPIKeyRepo struct {
+	mu   sync.RWMutex
+	keys map[string]apikey.APIKey
+}
+
+func NewInMemoryAPIKeyRepo() APIKeyRepository {
+	return &inMemoryAPIKeyRepo{keys: make(map[string]apikey.APIKey)}
+}
+
+func (r *inMemoryAPIKeyRepo) Create(ctx context.Context, key *apikey.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[key.ID] = *key
+	return nil
+}
+
+func (r *inMemoryAPIKeyRepo) List(ctx context.Context) ([]*apikey.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*apikey.APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		key := key
+		out = append(out, &key)
+	}
+	return out, nil
+}
+
+func (r *inMemoryAPIKeyRepo) Update(ctx context.Context, key *apikey.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.keys[key.ID]; !ok {
+		return ErrAPIKeyNotFound
+	}
+	r.keys[key.ID] = *key
+	return nil
+}
+
+func (r *inMemoryAPIKeyRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.keys[id]; !ok {
+		return ErrAPIKeyNotFound
+	}
+	delete(r.keys, id)
+	return nil
+}
+
+func (r *inMemoryAPIKeyRepo) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[id]
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	key.LastUsedAt = &at
+	r.keys[id] = key
+	return nil
+}
+
+// sqlAPIKeyRepo implementa APIKeyRepository com SQL comum a PostgreSQL e SQLite;
+// cada driver define apenas o próprio schema.
+type sqlAPIKeyRepo struct {
+	db *sql.DB
+}
+
+func (r *sqlAPIKeyRepo) Create(ctx context.Context, key *apikey.APIKey) error {
+	scopes, instances, err := marshalAPIKeyLists(key)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
+        INSERT INTO api_keys (id, name, key_prefix, key_hash, key_lookup, scopes, instances, expires_at, last_used_at, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		key.ID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.KeyLookup,
+		scopes,
+		instances,
+		nullableTime(key.ExpiresAt),
+		nullableTime(key.LastUsedAt),
+		key.CreatedAt.UTC(),
+		key.UpdatedAt.UTC(),
+	)
+	return err
+}
+
+func (r *sqlAPIKeyRepo) List(ctx context.Context) ([]*apikey.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, name, key_prefix, key_hash, key_lookup, scopes, instances, expires_at, last_used_at, created_at, updated_at
+        FROM api_keys
+        ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*apikey.APIKey
+	for rows.Next() {
+		var (
+			key        apikey.APIKey
+			scopes     string
+			instances  string
+			expiresAt  sql.NullTime
+			lastUsedAt sql.NullTime
+		)
+		if err := rows.Scan(&key.ID, &key.Name, &key.Prefix, &key.KeyHash, &key.KeyLookup, &scopes, &instances,
+			&expiresAt, &lastUsedAt, &key.CreatedAt, &key.UpdatedAt); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(scopes), &key.Scopes)
+		_ = json.Unmarshal([]byte(instances), &key.Instances)
+		if expiresAt.Valid {
+			v := expiresAt.Time
+			key.ExpiresAt = &v
+		}
+		if lastUsedAt.Valid {
+			v := lastUsedAt.Time
+			key.LastUsedAt = &v
+		}
+		keys = append(keys, &key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *sqlAPIKeyRepo) Update(ctx context.Context, key *apikey.APIKey) error {
+	scopes, instances, err := marshalAPIKeyLists(key)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE api_keys
+        SET name = $1, scopes = $2, instances = $3, expires_at = $4, updated_at = $5
+        WHERE id = $6`,
+		key.Name,
+		scopes,
+		instances,
+		nullableTime(key.ExpiresAt),
+		key.UpdatedAt.UTC(),
+		key.ID,
+	)
+	return apiKeyAffected(res, err)
+}
+
+func (r *sqlAPIKeyRepo) Delete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM api_keys WHERE id = $1`, id)
+	return apiKeyAffected(res, err)
+}
+
+func (r *sqlAPIKeyRepo) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, at.UTC(), id)
+	return apiKeyAffected(res, err)
+}
+
+func marshalAPIKeyLists(key *apikey.APIKey) (string, string, error) {
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	instances := key.Instances
+	if instances == nil {
+		instances = []string{}
+	}
+	scopesJSON, err := json.Marshal(scopes)
+	if err != nil {
+		return "", "", err
+	}
+	instancesJSON, err := json.Marshal(instances)
+	if err != nil {
+		return "", "", err
+	}
+	return string(scopesJSON), string(instancesJSON), nil
+}
+
+func apiKeyAffected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err == nil && affected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
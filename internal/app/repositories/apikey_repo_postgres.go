@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"database/sql"
+)
+
+// NewPostgresAPIKeyRepo builds an API key repository backed by PostgreSQL.
+func NewPostgresAPIKeyRepo(db *sql.DB) (APIKeyRepository, error) {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS api_keys (
+            id TEXT PRIMARY KEY,
+            name TEXT NOT NULL,
+            key_prefix TEXT NOT NULL DEFAULT '',
+            key_hash TEXT NOT NULL,
+            key_lookup TEXT NOT NULL,
+            scopes JSONB NOT NULL DEFAULT '[]'::jsonb,
+            instances JSONB NOT NULL DEFAULT '[]'::jsonb,
+            expires_at TIMESTAMPTZ NULL,
+            last_used_at TIMESTAMPTZ NULL,
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_api_keys_lookup ON api_keys (key_lookup)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	return &sqlAPIKeyRepo{db: db}, nil
+}
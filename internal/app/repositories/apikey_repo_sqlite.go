@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"database/sql"
+)
+
+// NewSQLiteAPIKeyRepo builds an API key repository backed by SQLite.
+func NewSQLiteAPIKeyRepo(db *sql.DB) (APIKeyRepository, error) {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS api_keys (
+            id TEXT PRIMARY KEY,
+            name TEXT NOT NULL,
+            key_prefix TEXT NOT NULL DEFAULT '',
+            key_hash TEXT NOT NULL,
+            key_lookup TEXT NOT NULL,
+            scopes TEXT NOT NULL DEFAULT '[]',
+            instances TEXT NOT NULL DEFAULT '[]',
+            expires_at TIMESTAMP NULL,
+            last_used_at TIMESTAMP NULL,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_api_keys_lookup ON api_keys (key_lookup)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	return &sqlAPIKeyRepo{db: db}, nil
+}
@@ -46,6 +46,12 @@ func (r *postgresCommunityMembershipRepo) ensureSchema() error {
 }
 
 func (r *postgresCommunityMembershipRepo) ReconcileMembers(ctx context.Context, instanceID, communityJID string, members []community.Member) (*CommunityMembershipSnapshot, error) {
+	return reconcileCommunityMembers(ctx, r.db, "FOR UPDATE", instanceID, communityJID, members)
+}
+
+// reconcileCommunityMembers contém a reconciliação comum a PostgreSQL e SQLite;
+// lockClause bloqueia as linhas lidas quando o banco suporta (SQLite serializa a transação inteira).
+func reconcileCommunityMembers(ctx context.Context, db *sql.DB, lockClause, instanceID, communityJID string, members []community.Member) (*CommunityMembershipSnapshot, error) {
 	trimmed := make(map[string]community.Member)
 	for _, member := range members {
 		id := strings.ToLower(strings.TrimSpace(member.JID))
@@ -69,7 +75,7 @@ func (r *postgresCommunityMembershipRepo) ReconcileMembers(ctx context.Context,
 		trimmed[id] = member
 	}
 
-	tx, err := r.db.BeginTx(ctx, nil)
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +90,7 @@ func (r *postgresCommunityMembershipRepo) ReconcileMembers(ctx context.Context,
         SELECT member_jid, left_at
         FROM community_members
         WHERE instance_id = $1 AND community_jid = $2
-        FOR UPDATE`, instanceID, communityJID)
+        `+lockClause, instanceID, communityJID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/community"
+)
+
+type sqliteCommunityMembershipRepo struct {
+	db *sql.DB
+}
+
+// NewSQLiteCommunityMembershipRepo builds a membership repository backed by SQLite.
+func NewSQLiteCommunityMembershipRepo(db *sql.DB) (CommunityMembershipRepository, error) {
+	repo := &sqliteCommunityMembershipRepo{db: db}
+	if err := repo.ensureSchema(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func (r *sqliteCommunityMembershipRepo) ensureSchema() error {
+	const createTable = `
+        CREATE TABLE IF NOT EXISTS community_members (
+            instance_id TEXT NOT NULL,
+            community_jid TEXT NOT NULL,
+            member_jid TEXT NOT NULL,
+            phone TEXT NOT NULL DEFAULT '',
+            display_name TEXT NOT NULL DEFAULT '',
+            is_admin BOOLEAN NOT NULL DEFAULT 0,
+            first_seen TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            last_seen TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            left_at TIMESTAMP NULL,
+            PRIMARY KEY (instance_id, community_jid, member_jid)
+        )`
+	if _, err := r.db.Exec(createTable); err != nil {
+		return err
+	}
+	if _, err := r.db.Exec(`CREATE INDEX IF NOT EXISTS idx_community_members_left ON community_members (instance_id, community_jid, left_at)`); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *sqliteCommunityMembershipRepo) ReconcileMembers(ctx context.Context, instanceID, communityJID string, members []community.Member) (*CommunityMembershipSnapshot, error) {
+	return reconcileCommunityMembers(ctx, r.db, "", instanceID, communityJID, members)
+}
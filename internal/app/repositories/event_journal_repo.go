@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+)
+
+// DefaultEventJournalLimit limita a leitura quando o filtro não informa Limit.
+const DefaultEventJournalLimit = 500
+
+// inMemoryEventJournalCap limita o journal em memória, que não tem outra poda além da retenção.
+const inMemoryEventJournalCap = 10000
+
+// EventJournalRepository guarda os eventos publicados nos streams SSE/WebSocket, para que um
+// cliente retome a partir do último cursor recebido.
+type EventJournalRepository interface {
+	// Append grava o evento e preenche Seq.
+	Append(ctx context.Context, e *webhook.JournalEvent) error
+	// After retorna, em ordem crescente de Seq, até Limit eventos posteriores a AfterSeq.
+	After(ctx context.Context, filter webhook.JournalFilter) ([]*webhook.JournalEvent, error)
+	// Last retorna o maior Seq gravado; 0 com o journal vazio.
+	Last(ctx context.Context) (int64, error)
+	// Prune remove eventos gravados antes de before.
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+func eventJournalLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultEventJournalLimit
+	}
+	return limit
+}
+
+type inMemoryEventJournalRepo struct {
+	mu     sync.RWMutex
+	seq    int64
+	events []*webhook.JournalEvent
+}
+
+func NewInMemoryEventJournalRepo() EventJournalRepository {
+	return &inMemoryEventJournalRepo{}
+}
+
+func (r *inMemoryEventJournalRepo) Append(ctx context.Context, e *webhook.JournalEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	e.Seq = r.seq
+	cp := *e
+	r.events = append(r.events, &cp)
+	if len(r.events) > inMemoryEventJournalCap {
+		r.events = append([]*webhook.JournalEvent(nil), r.events[len(r.events)-inMemoryEventJournalCap:]...)
+	}
+	return nil
+}
+
+func (r *inMemoryEventJournalRepo) After(ctx context.Context, filter webhook.JournalFilter) ([]*webhook.JournalEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	limit := eventJournalLimit(filter.Limit)
+	var out []*webhook.JournalEvent
+	for _, e := range r.events {
+		if len(out) >= limit {
+			break
+		}
+		if e.Seq <= filter.AfterSeq || !containsString(filter.Instances, e.InstanceName) || !containsString(filter.Events, e.Event) {
+			continue
+		}
+		cp := *e
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+func (r *inMemoryEventJournalRepo) Last(ctx context.Context) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.seq, nil
+}
+
+// containsString é verdadeiro para lista vazia, que não filtra.
+func containsString(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *inMemoryEventJournalRepo) Prune(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.events[:0]
+	for _, e := range r.events {
+		if !e.CreatedAt.Before(before) {
+			kept = append(kept, e)
+		}
+	}
+	removed := int64(len(r.events) - len(kept))
+	r.events = kept
+	return removed, nil
+}
+
+// sqlEventJournalRepo implementa EventJournalRepository com SQL comum a PostgreSQL e SQLite.
+type sqlEventJournalRepo struct {
+	db *sql.DB
+}
+
+func (r *sqlEventJournalRepo) Append(ctx context.Context, e *webhook.JournalEvent) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO event_journal (instance_id, instance_name, event, payload, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING seq`,
+		e.InstanceID, e.InstanceName, e.Event, string(e.Payload), e.CreatedAt.UTC()).Scan(&e.Seq)
+}
+
+func (r *sqlEventJournalRepo) After(ctx context.Context, filter webhook.JournalFilter) ([]*webhook.JournalEvent, error) {
+	args := []any{filter.AfterSeq}
+	query := `SELECT seq, instance_id, instance_name, event, payload, created_at FROM event_journal WHERE seq > $1`
+	in := func(column string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		placeholders := make([]string, len(values))
+		for i, v := range values {
+			args = append(args, v)
+			placeholders[i] = "$" + strconv.Itoa(len(args))
+		}
+		query += ` AND ` + column + ` IN (` + strings.Join(placeholders, ", ") + `)`
+	}
+	in("instance_name", filter.Instances)
+	in("event", filter.Events)
+	args = append(args, eventJournalLimit(filter.Limit))
+	query += ` ORDER BY seq LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*webhook.JournalEvent
+	for rows.Next() {
+		var (
+			e       webhook.JournalEvent
+			payload string
+		)
+		if err := rows.Scan(&e.Seq, &e.InstanceID, &e.InstanceName, &e.Event, &payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Payload = []byte(payload)
+		out = append(out, &e)
+	}
+	return out, rows.Err()
+}
+
+func (r *sqlEventJournalRepo) Last(ctx context.Context) (int64, error) {
+	var seq int64
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(seq), 0) FROM event_journal`).Scan(&seq)
+	return seq, err
+}
+
+func (r *sqlEventJournalRepo) Prune(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM event_journal WHERE created_at < $1`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
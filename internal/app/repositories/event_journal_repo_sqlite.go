@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"database/sql"
+)
+
+// NewSQLiteEventJournalRepo builds the event stream journal backed by SQLite.
+func NewSQLiteEventJournalRepo(db *sql.DB) (EventJournalRepository, error) {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS event_journal (
+            seq INTEGER PRIMARY KEY AUTOINCREMENT,
+            instance_id TEXT NOT NULL DEFAULT '',
+            instance_name TEXT NOT NULL,
+            event TEXT NOT NULL,
+            payload TEXT NOT NULL,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_event_journal_created ON event_journal (created_at)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	return &sqlEventJournalRepo{db: db}, nil
+}
@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+)
+
+var ErrSinkConfigNotFound = errors.New("event sink config not found")
+
+// EventSinkRepository guarda, por instância, quais destinos de eventos (Redis, ...) estão
+// habilitados e com quais eventos. Como as assinaturas, é ligado ao ID da instância.
+type EventSinkRepository interface {
+	Get(ctx context.Context, instanceID, sink string) (*webhook.SinkConfig, error)
+	// List retorna as configs da instância ordenadas pelo nome do destino.
+	List(ctx context.Context, instanceID string) ([]*webhook.SinkConfig, error)
+	Save(ctx context.Context, cfg *webhook.SinkConfig) error
+	DeleteByInstance(ctx context.Context, instanceID string) error
+}
+
+type inMemoryEventSinkRepo struct {
+	mu      sync.RWMutex
+	configs map[string]webhook.SinkConfig
+}
+
+func NewInMemoryEventSinkRepo() EventSinkRepository {
+	return &inMemoryEventSinkRepo{configs: make(map[string]webhook.SinkConfig)}
+}
+
+func (r *inMemoryEventSinkRepo) Get(ctx context.Context, instanceID, sink string) (*webhook.SinkConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cfg, ok := r.configs[instanceID+" "+sink]
+	if !ok {
+		return nil, ErrSinkConfigNotFound
+	}
+	return &cfg, nil
+}
+
+func (r *inMemoryEventSinkRepo) List(ctx context.Context, instanceID string) ([]*webhook.SinkConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*webhook.SinkConfig
+	for _, cfg := range r.configs {
+		if cfg.InstanceID == instanceID {
+			cp := cfg
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Sink < out[j].Sink })
+	return out, nil
+}
+
+func (r *inMemoryEventSinkRepo) Save(ctx context.Context, cfg *webhook.SinkConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.configs[cfg.InstanceID+" "+cfg.Sink] = *cfg
+	return nil
+}
+
+func (r *inMemoryEventSinkRepo) DeleteByInstance(ctx context.Context, instanceID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, cfg := range r.configs {
+		if cfg.InstanceID == instanceID {
+			delete(r.configs, key)
+		}
+	}
+	return nil
+}
+
+// sqlEventSinkRepo implementa EventSinkRepository com SQL comum a PostgreSQL e SQLite.
+type sqlEventSinkRepo struct {
+	db *sql.DB
+}
+
+func (r *sqlEventSinkRepo) Get(ctx context.Context, instanceID, sink string) (*webhook.SinkConfig, error) {
+	var (
+		cfg    = webhook.SinkConfig{InstanceID: instanceID, Sink: sink}
+		events string
+	)
+	err := r.db.QueryRowContext(ctx, `
+        SELECT enabled, events, updated_at FROM event_sink_configs
+        WHERE instance_id = $1 AND sink = $2`, instanceID, sink).Scan(&cfg.Enabled, &events, &cfg.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSinkConfigNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if events != "" {
+		_ = json.Unmarshal([]byte(events), &cfg.Events)
+	}
+	return &cfg, nil
+}
+
+func (r *sqlEventSinkRepo) List(ctx context.Context, instanceID string) ([]*webhook.SinkConfig, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT sink, enabled, events, updated_at FROM event_sink_configs
+        WHERE instance_id = $1 ORDER BY sink`, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*webhook.SinkConfig
+	for rows.Next() {
+		var (
+			cfg    = webhook.SinkConfig{InstanceID: instanceID}
+			events string
+		)
+		if err := rows.Scan(&cfg.Sink, &cfg.Enabled, &events, &cfg.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if events != "" {
+			_ = json.Unmarshal([]byte(events), &cfg.Events)
+		}
+		out = append(out, &cfg)
+	}
+	return out, rows.Err()
+}
+
+func (r *sqlEventSinkRepo) Save(ctx context.Context, cfg *webhook.SinkConfig) error {
+	events := cfg.Events
+	if events == nil {
+		events = []string{}
+	}
+	raw, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
+        INSERT INTO event_sink_configs (instance_id, sink, enabled, events, updated_at)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (instance_id, sink) DO UPDATE
+        SET enabled = excluded.enabled, events = excluded.events, updated_at = excluded.updated_at`,
+		cfg.InstanceID, cfg.Sink, cfg.Enabled, string(raw), cfg.UpdatedAt.UTC())
+	return err
+}
+
+func (r *sqlEventSinkRepo) DeleteByInstance(ctx context.Context, instanceID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM event_sink_configs WHERE instance_id = $1`, instanceID)
+	return err
+}
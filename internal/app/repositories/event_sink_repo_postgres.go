@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"database/sql"
+)
+
+// NewPostgresEventSinkRepo builds the event sink config repository backed by PostgreSQL.
+func NewPostgresEventSinkRepo(db *sql.DB) (EventSinkRepository, error) {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS event_sink_configs (
+            instance_id TEXT NOT NULL,
+            sink TEXT NOT NULL,
+            enabled BOOLEAN NOT NULL DEFAULT TRUE,
+            events TEXT NOT NULL DEFAULT '[]',
+            updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            PRIMARY KEY (instance_id, sink)
+        )`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	return &sqlEventSinkRepo{db: db}, nil
+}
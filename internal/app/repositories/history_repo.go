@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/history"
+)
+
+// HistoryRepository persiste chats, contatos e mensagens recebidos via history sync.
+type HistoryRepository interface {
+	SaveBatch(ctx context.Context, batch history.SyncBatch) error
+}
+
+// sqlHistoryRepo implementa HistoryRepository com SQL comum a PostgreSQL e SQLite;
+// cada driver define apenas o próprio schema.
+type sqlHistoryRepo struct {
+	db *sql.DB
+}
+
+func (r *sqlHistoryRepo) SaveBatch(ctx context.Context, batch history.SyncBatch) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, chat := range batch.Chats {
+		var lastMessageAt any
+		if chat.LastMessageAt != nil {
+			lastMessageAt = chat.LastMessageAt.UTC()
+		}
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO history_chats (instance_id, jid, name, unread_count, archived, pinned, last_message_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+            ON CONFLICT (instance_id, jid)
+            DO UPDATE SET name = CASE WHEN EXCLUDED.name <> '' THEN EXCLUDED.name ELSE history_chats.name END,
+                          unread_count = EXCLUDED.unread_count,
+                          archived = EXCLUDED.archived,
+                          pinned = EXCLUDED.pinned,
+                          last_message_at = COALESCE(EXCLUDED.last_message_at, history_chats.last_message_at),
+                          updated_at = EXCLUDED.updated_at`,
+			chat.InstanceID,
+			chat.JID,
+			chat.Name,
+			chat.UnreadCount,
+			chat.Archived,
+			chat.Pinned,
+			lastMessageAt,
+			chat.UpdatedAt.UTC(),
+		); err != nil {
+			return err
+		}
+	}
+
+	for _, contact := range batch.Contacts {
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO history_contacts (instance_id, jid, push_name, updated_at)
+            VALUES ($1, $2, $3, $4)
+            ON CONFLICT (instance_id, jid)
+            DO UPDATE SET push_name = EXCLUDED.push_name,
+                          updated_at = EXCLUDED.updated_at`,
+			contact.InstanceID,
+			contact.JID,
+			contact.PushName,
+			contact.UpdatedAt.UTC(),
+		); err != nil {
+			return err
+		}
+	}
+
+	for _, msg := range batch.Messages {
+		payload := msg.Payload
+		if len(payload) == 0 {
+			payload = []byte("{}")
+		}
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO history_messages (instance_id, remote_jid, message_id, from_me, participant, push_name, message_type, payload, message_timestamp)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+            ON CONFLICT (instance_id, remote_jid, message_id) DO NOTHING`,
+			msg.InstanceID,
+			msg.RemoteJID,
+			msg.MessageID,
+			msg.FromMe,
+			msg.Participant,
+			msg.PushName,
+			msg.MessageType,
+			string(payload),
+			msg.Timestamp.UTC(),
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"database/sql"
+)
+
+// NewPostgresHistoryRepo builds a history repository backed by PostgreSQL.
+func NewPostgresHistoryRepo(db *sql.DB) (HistoryRepository, error) {
+	if err := ensurePostgresHistorySchema(db); err != nil {
+		return nil, err
+	}
+	return &sqlHistoryRepo{db: db}, nil
+}
+
+func ensurePostgresHistorySchema(db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS history_chats (
+            instance_id TEXT NOT NULL,
+            jid TEXT NOT NULL,
+            name TEXT NOT NULL DEFAULT '',
+            unread_count INTEGER NOT NULL DEFAULT 0,
+            archived BOOLEAN NOT NULL DEFAULT FALSE,
+            pinned BOOLEAN NOT NULL DEFAULT FALSE,
+            last_message_at TIMESTAMPTZ NULL,
+            updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            PRIMARY KEY (instance_id, jid)
+        )`,
+		`CREATE TABLE IF NOT EXISTS history_contacts (
+            instance_id TEXT NOT NULL,
+            jid TEXT NOT NULL,
+            push_name TEXT NOT NULL DEFAULT '',
+            updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            PRIMARY KEY (instance_id, jid)
+        )`,
+		`CREATE TABLE IF NOT EXISTS history_messages (
+            instance_id TEXT NOT NULL,
+            remote_jid TEXT NOT NULL,
+            message_id TEXT NOT NULL,
+            from_me BOOLEAN NOT NULL DEFAULT FALSE,
+            participant TEXT NOT NULL DEFAULT '',
+            push_name TEXT NOT NULL DEFAULT '',
+            message_type TEXT NOT NULL DEFAULT '',
+            payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+            message_timestamp TIMESTAMPTZ NOT NULL,
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            PRIMARY KEY (instance_id, remote_jid, message_id)
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_history_messages_timestamp ON history_messages (instance_id, message_timestamp DESC)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
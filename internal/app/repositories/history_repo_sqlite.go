@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"database/sql"
+)
+
+// NewSQLiteHistoryRepo builds a history repository backed by SQLite.
+func NewSQLiteHistoryRepo(db *sql.DB) (HistoryRepository, error) {
+	if err := ensureSQLiteHistorySchema(db); err != nil {
+		return nil, err
+	}
+	return &sqlHistoryRepo{db: db}, nil
+}
+
+func ensureSQLiteHistorySchema(db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS history_chats (
+            instance_id TEXT NOT NULL,
+            jid TEXT NOT NULL,
+            name TEXT NOT NULL DEFAULT '',
+            unread_count INTEGER NOT NULL DEFAULT 0,
+            archived BOOLEAN NOT NULL DEFAULT 0,
+            pinned BOOLEAN NOT NULL DEFAULT 0,
+            last_message_at TIMESTAMP NULL,
+            updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (instance_id, jid)
+        )`,
+		`CREATE TABLE IF NOT EXISTS history_contacts (
+            instance_id TEXT NOT NULL,
+            jid TEXT NOT NULL,
+            push_name TEXT NOT NULL DEFAULT '',
+            updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (instance_id, jid)
+        )`,
+		`CREATE TABLE IF NOT EXISTS history_messages (
+            instance_id TEXT NOT NULL,
+            remote_jid TEXT NOT NULL,
+            message_id TEXT NOT NULL,
+            from_me BOOLEAN NOT NULL DEFAULT 0,
+            participant TEXT NOT NULL DEFAULT '',
+            push_name TEXT NOT NULL DEFAULT '',
+            message_type TEXT NOT NULL DEFAULT '',
+            payload TEXT NOT NULL DEFAULT '{}',
+            message_timestamp TIMESTAMP NOT NULL,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (instance_id, remote_jid, message_id)
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_history_messages_timestamp ON history_messages (instance_id, message_timestamp DESC)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
 )
@@ -20,6 +21,10 @@ type InstanceRepository interface {
 	GetByName(ctx context.Context, name string) (*instance.Instance, error)
 	Delete(ctx context.Context, name string) error
 	Update(ctx context.Context, inst *instance.Instance) error
+	// UpdateStatus grava apenas o estado da conexão, preservando alterações concorrentes feitas pela API.
+	UpdateStatus(ctx context.Context, name string, status instance.ConnectionStatus) error
+	// Rename altera o nome (chave) da instância; falha se o novo nome já existir.
+	Rename(ctx context.Context, oldName, newName string) error
 }
 
 type inMemoryInstanceRepo struct {
@@ -80,3 +85,35 @@ func (r *inMemoryInstanceRepo) Update(ctx context.Context, inst *instance.Instan
 	r.instances[inst.Name] = inst
 	return nil
 }
+
+func (r *inMemoryInstanceRepo) UpdateStatus(ctx context.Context, name string, status instance.ConnectionStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inst, ok := r.instances[name]
+	if !ok {
+		return ErrInstanceNotFound
+	}
+	inst.Status = status.Status
+	inst.DisconnectionReasonCode = status.DisconnectionReasonCode
+	inst.DisconnectionObject = status.DisconnectionObject
+	inst.DisconnectionAt = status.DisconnectionAt
+	inst.UpdatedAt = status.UpdatedAt
+	return nil
+}
+
+func (r *inMemoryInstanceRepo) Rename(ctx context.Context, oldName, newName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inst, ok := r.instances[oldName]
+	if !ok {
+		return ErrInstanceNotFound
+	}
+	if _, exists := r.instances[newName]; exists {
+		return ErrInstanceAlreadyExists
+	}
+	delete(r.instances, oldName)
+	inst.Name = newName
+	inst.UpdatedAt = time.Now().UTC()
+	r.instances[newName] = inst
+	return nil
+}
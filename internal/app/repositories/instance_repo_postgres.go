@@ -46,6 +46,17 @@ func (r *postgresInstanceRepo) ensureSchema() error {
 		"ALTER TABLE instances ADD COLUMN IF NOT EXISTS integration TEXT NOT NULL DEFAULT ''",
 		"ALTER TABLE instances ADD COLUMN IF NOT EXISTS settings JSONB NOT NULL DEFAULT '{}'::jsonb",
 		"ALTER TABLE instances ADD COLUMN IF NOT EXISTS webhook JSONB NOT NULL DEFAULT '{}'::jsonb",
+		"ALTER TABLE instances ADD COLUMN IF NOT EXISTS disconnection_reason_code INTEGER",
+		"ALTER TABLE instances ADD COLUMN IF NOT EXISTS disconnection_object TEXT",
+		"ALTER TABLE instances ADD COLUMN IF NOT EXISTS disconnection_at TIMESTAMPTZ",
+		"ALTER TABLE instances ADD COLUMN IF NOT EXISTS token_lookup TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE instances ADD COLUMN IF NOT EXISTS previous_token TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE instances ADD COLUMN IF NOT EXISTS previous_token_lookup TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE instances ADD COLUMN IF NOT EXISTS previous_token_expires_at TIMESTAMPTZ",
+		"ALTER TABLE instances ADD COLUMN IF NOT EXISTS proxy JSONB NOT NULL DEFAULT '{}'::jsonb",
+		"ALTER TABLE instances ADD COLUMN IF NOT EXISTS pairing JSONB NOT NULL DEFAULT '{}'::jsonb",
+		"ALTER TABLE instances ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '[]'::jsonb",
+		"ALTER TABLE instances ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb",
 	}
 	for _, stmt := range alterStatements {
 		if _, err := r.db.Exec(stmt); err != nil {
@@ -58,13 +69,21 @@ func (r *postgresInstanceRepo) ensureSchema() error {
 	if _, err := r.db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_instances_token ON instances (token)`); err != nil {
 		return err
 	}
+	if _, err := r.db.Exec(`CREATE INDEX IF NOT EXISTS idx_instances_token_lookup ON instances (token_lookup)`); err != nil {
+		return err
+	}
+	if _, err := r.db.Exec(`CREATE INDEX IF NOT EXISTS idx_instances_tags ON instances USING GIN (tags)`); err != nil {
+		return err
+	}
 	return nil
 }
 
 func (r *postgresInstanceRepo) Create(ctx context.Context, inst *instance.Instance) error {
 	const query = `
-        INSERT INTO instances (id, name, webhook_url, token, number, integration, settings, webhook, status, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+        INSERT INTO instances (id, name, webhook_url, token, number, integration, settings, webhook, status, created_at, updated_at,
+            disconnection_reason_code, disconnection_object, disconnection_at,
+            token_lookup, previous_token, previous_token_lookup, previous_token_expires_at, proxy, pairing, tags, metadata)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)`
 	settingsJSON, err := json.Marshal(inst.Settings)
 	if err != nil {
 		return err
@@ -77,11 +96,23 @@ func (r *postgresInstanceRepo) Create(ctx context.Context, inst *instance.Instan
 	if err != nil {
 		return err
 	}
+	proxyJSON, err := json.Marshal(inst.Proxy)
+	if err != nil {
+		return err
+	}
+	pairingJSON, err := json.Marshal(inst.Pairing)
+	if err != nil {
+		return err
+	}
+	tagsJSON, metadataJSON, err := marshalTagsMetadata(inst)
+	if err != nil {
+		return err
+	}
 	_, err = r.db.ExecContext(ctx, query,
 		string(inst.ID),
 		inst.Name,
 		inst.WebhookURL,
-		inst.Token,
+		inst.TokenHash,
 		inst.Number,
 		inst.Integration,
 		settingsJSON,
@@ -89,13 +120,26 @@ func (r *postgresInstanceRepo) Create(ctx context.Context, inst *instance.Instan
 		inst.Status,
 		inst.CreatedAt.UTC(),
 		inst.UpdatedAt.UTC(),
+		nullableInt(inst.DisconnectionReasonCode),
+		nullableString(inst.DisconnectionObject),
+		nullableTime(inst.DisconnectionAt),
+		inst.TokenLookup,
+		inst.PreviousTokenHash,
+		inst.PreviousTokenLookup,
+		nullableTime(inst.PreviousTokenExpiresAt),
+		proxyJSON,
+		pairingJSON,
+		tagsJSON,
+		metadataJSON,
 	)
 	return r.mapError(err)
 }
 
 func (r *postgresInstanceRepo) List(ctx context.Context) ([]*instance.Instance, error) {
 	const query = `
-        SELECT id, name, webhook_url, token, number, integration, settings, webhook, status, created_at, updated_at
+        SELECT id, name, webhook_url, token, number, integration, settings, webhook, status, created_at, updated_at,
+            disconnection_reason_code, disconnection_object, disconnection_at,
+            token_lookup, previous_token, previous_token_lookup, previous_token_expires_at, proxy, pairing, tags, metadata
         FROM instances
         ORDER BY created_at ASC`
 	rows, err := r.db.QueryContext(ctx, query)
@@ -118,14 +162,25 @@ func (r *postgresInstanceRepo) List(ctx context.Context) ([]*instance.Instance,
 			status      string
 			created     time.Time
 			updated     time.Time
+			discCode    sql.NullInt64
+			discObject  sql.NullString
+			discAt      sql.NullTime
+			tokenLookup string
+			prevToken   string
+			prevLookup  string
+			prevExpires sql.NullTime
+			proxyRaw    []byte
+			pairingRaw  []byte
+			tagsRaw     []byte
+			metaRaw     []byte
 		)
-		if err := rows.Scan(&id, &name, &webhook, &token, &number, &integration, &settingsRaw, &webhookRaw, &status, &created, &updated); err != nil {
+		if err := rows.Scan(&id, &name, &webhook, &token, &number, &integration, &settingsRaw, &webhookRaw, &status, &created, &updated, &discCode, &discObject, &discAt, &tokenLookup, &prevToken, &prevLookup, &prevExpires, &proxyRaw, &pairingRaw, &tagsRaw, &metaRaw); err != nil {
 			return nil, err
 		}
 		inst := &instance.Instance{
 			ID:          instance.ID(id),
 			Name:        name,
-			Token:       token,
+			TokenHash:   token,
 			Number:      number,
 			Integration: integration,
 			WebhookURL:  webhook,
@@ -133,12 +188,21 @@ func (r *postgresInstanceRepo) List(ctx context.Context) ([]*instance.Instance,
 			CreatedAt:   created,
 			UpdatedAt:   updated,
 		}
+		scanDisconnection(inst, discCode, discObject, discAt)
+		scanTokenCredentials(inst, tokenLookup, prevToken, prevLookup, prevExpires)
 		if len(settingsRaw) > 0 {
 			_ = json.Unmarshal(settingsRaw, &inst.Settings)
 		}
 		if len(webhookRaw) > 0 {
 			_ = json.Unmarshal(webhookRaw, &inst.Webhook)
 		}
+		if len(proxyRaw) > 0 {
+			_ = json.Unmarshal(proxyRaw, &inst.Proxy)
+		}
+		if len(pairingRaw) > 0 {
+			_ = json.Unmarshal(pairingRaw, &inst.Pairing)
+		}
+		scanTagsMetadata(inst, tagsRaw, metaRaw)
 		if inst.Webhook.URL == "" {
 			inst.Webhook.URL = inst.WebhookURL
 		}
@@ -152,7 +216,9 @@ func (r *postgresInstanceRepo) List(ctx context.Context) ([]*instance.Instance,
 
 func (r *postgresInstanceRepo) GetByName(ctx context.Context, name string) (*instance.Instance, error) {
 	const query = `
-        SELECT id, name, webhook_url, token, number, integration, settings, webhook, status, created_at, updated_at
+        SELECT id, name, webhook_url, token, number, integration, settings, webhook, status, created_at, updated_at,
+            disconnection_reason_code, disconnection_object, disconnection_at,
+            token_lookup, previous_token, previous_token_lookup, previous_token_expires_at, proxy, pairing, tags, metadata
         FROM instances
         WHERE name = $1`
 	var (
@@ -166,15 +232,26 @@ func (r *postgresInstanceRepo) GetByName(ctx context.Context, name string) (*ins
 		status      string
 		created     time.Time
 		updated     time.Time
+		discCode    sql.NullInt64
+		discObject  sql.NullString
+		discAt      sql.NullTime
+		tokenLookup string
+		prevToken   string
+		prevLookup  string
+		prevExpires sql.NullTime
+		proxyRaw    []byte
+		pairingRaw  []byte
+		tagsRaw     []byte
+		metaRaw     []byte
 	)
-	err := r.db.QueryRowContext(ctx, query, name).Scan(&id, &name, &webhook, &token, &number, &integration, &settingsRaw, &webhookRaw, &status, &created, &updated)
+	err := r.db.QueryRowContext(ctx, query, name).Scan(&id, &name, &webhook, &token, &number, &integration, &settingsRaw, &webhookRaw, &status, &created, &updated, &discCode, &discObject, &discAt, &tokenLookup, &prevToken, &prevLookup, &prevExpires, &proxyRaw, &pairingRaw, &tagsRaw, &metaRaw)
 	if err != nil {
 		return nil, r.mapError(err)
 	}
 	inst := &instance.Instance{
 		ID:          instance.ID(id),
 		Name:        name,
-		Token:       token,
+		TokenHash:   token,
 		Number:      number,
 		Integration: integration,
 		WebhookURL:  webhook,
@@ -182,12 +259,21 @@ func (r *postgresInstanceRepo) GetByName(ctx context.Context, name string) (*ins
 		CreatedAt:   created,
 		UpdatedAt:   updated,
 	}
+	scanDisconnection(inst, discCode, discObject, discAt)
+	scanTokenCredentials(inst, tokenLookup, prevToken, prevLookup, prevExpires)
 	if len(settingsRaw) > 0 {
 		_ = json.Unmarshal(settingsRaw, &inst.Settings)
 	}
 	if len(webhookRaw) > 0 {
 		_ = json.Unmarshal(webhookRaw, &inst.Webhook)
 	}
+	if len(proxyRaw) > 0 {
+		_ = json.Unmarshal(proxyRaw, &inst.Proxy)
+	}
+	if len(pairingRaw) > 0 {
+		_ = json.Unmarshal(pairingRaw, &inst.Pairing)
+	}
+	scanTagsMetadata(inst, tagsRaw, metaRaw)
 	if inst.Webhook.URL == "" {
 		inst.Webhook.URL = inst.WebhookURL
 	}
@@ -216,8 +302,19 @@ func (r *postgresInstanceRepo) Update(ctx context.Context, inst *instance.Instan
             settings = $5,
             webhook = $6,
             status = $7,
-            updated_at = $8
-        WHERE name = $9`
+            updated_at = $8,
+            disconnection_reason_code = $9,
+            disconnection_object = $10,
+            disconnection_at = $11,
+            token_lookup = $12,
+            previous_token = $13,
+            previous_token_lookup = $14,
+            previous_token_expires_at = $15,
+            proxy = $16,
+            pairing = $17,
+            tags = $18,
+            metadata = $19
+        WHERE name = $20`
 	settingsJSON, err := json.Marshal(inst.Settings)
 	if err != nil {
 		return err
@@ -230,15 +327,38 @@ func (r *postgresInstanceRepo) Update(ctx context.Context, inst *instance.Instan
 	if err != nil {
 		return err
 	}
+	proxyJSON, err := json.Marshal(inst.Proxy)
+	if err != nil {
+		return err
+	}
+	pairingJSON, err := json.Marshal(inst.Pairing)
+	if err != nil {
+		return err
+	}
+	tagsJSON, metadataJSON, err := marshalTagsMetadata(inst)
+	if err != nil {
+		return err
+	}
 	res, err := r.db.ExecContext(ctx, query,
 		inst.WebhookURL,
-		inst.Token,
+		inst.TokenHash,
 		inst.Number,
 		inst.Integration,
 		settingsJSON,
 		webhookJSON,
 		inst.Status,
 		inst.UpdatedAt.UTC(),
+		nullableInt(inst.DisconnectionReasonCode),
+		nullableString(inst.DisconnectionObject),
+		nullableTime(inst.DisconnectionAt),
+		inst.TokenLookup,
+		inst.PreviousTokenHash,
+		inst.PreviousTokenLookup,
+		nullableTime(inst.PreviousTokenExpiresAt),
+		proxyJSON,
+		pairingJSON,
+		tagsJSON,
+		metadataJSON,
 		inst.Name,
 	)
 	if err != nil {
@@ -251,6 +371,128 @@ func (r *postgresInstanceRepo) Update(ctx context.Context, inst *instance.Instan
 	return err
 }
 
+func (r *postgresInstanceRepo) UpdateStatus(ctx context.Context, name string, status instance.ConnectionStatus) error {
+	const query = `
+        UPDATE instances
+        SET status = $1,
+            updated_at = $2,
+            disconnection_reason_code = $3,
+            disconnection_object = $4,
+            disconnection_at = $5
+        WHERE name = $6`
+	res, err := r.db.ExecContext(ctx, query,
+		status.Status,
+		status.UpdatedAt.UTC(),
+		nullableInt(status.DisconnectionReasonCode),
+		nullableString(status.DisconnectionObject),
+		nullableTime(status.DisconnectionAt),
+		name,
+	)
+	if err != nil {
+		return r.mapError(err)
+	}
+	affected, err := res.RowsAffected()
+	if err == nil && affected == 0 {
+		return ErrInstanceNotFound
+	}
+	return err
+}
+
+func (r *postgresInstanceRepo) Rename(ctx context.Context, oldName, newName string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE instances SET name = $1, updated_at = $2 WHERE name = $3`, newName, time.Now().UTC(), oldName)
+	if err != nil {
+		return r.mapError(err)
+	}
+	affected, err := res.RowsAffected()
+	if err == nil && affected == 0 {
+		return ErrInstanceNotFound
+	}
+	return err
+}
+
+func scanDisconnection(inst *instance.Instance, code sql.NullInt64, object sql.NullString, at sql.NullTime) {
+	if code.Valid {
+		v := int(code.Int64)
+		inst.DisconnectionReasonCode = &v
+	}
+	if object.Valid {
+		v := object.String
+		inst.DisconnectionObject = &v
+	}
+	if at.Valid {
+		v := at.Time
+		inst.DisconnectionAt = &v
+	}
+}
+
+// scanTokenCredentials preenche o lookup do token e o token anterior ainda em período de carência.
+func scanTokenCredentials(inst *instance.Instance, lookup, previous, previousLookup string, previousExpires sql.NullTime) {
+	inst.TokenLookup = lookup
+	inst.PreviousTokenHash = previous
+	inst.PreviousTokenLookup = previousLookup
+	if previousExpires.Valid {
+		v := previousExpires.Time
+		inst.PreviousTokenExpiresAt = &v
+	}
+}
+
+// marshalTagsMetadata serializa tags e metadata garantindo JSON válido mesmo quando vazios.
+func marshalTagsMetadata(inst *instance.Instance) ([]byte, []byte, error) {
+	tags := inst.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	metadata := inst.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return nil, nil, err
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tagsJSON, metadataJSON, nil
+}
+
+func scanTagsMetadata(inst *instance.Instance, tagsRaw, metadataRaw []byte) {
+	if len(tagsRaw) > 0 {
+		_ = json.Unmarshal(tagsRaw, &inst.Tags)
+	}
+	if len(metadataRaw) > 0 {
+		_ = json.Unmarshal(metadataRaw, &inst.Metadata)
+	}
+	if len(inst.Tags) == 0 {
+		inst.Tags = nil
+	}
+	if len(inst.Metadata) == 0 {
+		inst.Metadata = nil
+	}
+}
+
+func nullableInt(v *int) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func nullableString(v *string) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func nullableTime(v *time.Time) any {
+	if v == nil {
+		return nil
+	}
+	return v.UTC()
+}
+
 func (r *postgresInstanceRepo) mapError(err error) error {
 	if err == nil {
 		return nil
@@ -0,0 +1,467 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type sqliteInstanceRepo struct {
+	db *sql.DB
+}
+
+func NewSQLiteInstanceRepo(db *sql.DB) (InstanceRepository, error) {
+	repo := &sqliteInstanceRepo{db: db}
+	if err := repo.ensureSchema(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func (r *sqliteInstanceRepo) ensureSchema() error {
+	const createTable = `
+        CREATE TABLE IF NOT EXISTS instances (
+            id TEXT PRIMARY KEY,
+            name TEXT NOT NULL UNIQUE,
+            webhook_url TEXT NOT NULL DEFAULT '',
+            token TEXT NOT NULL UNIQUE,
+            number TEXT NOT NULL DEFAULT '',
+            integration TEXT NOT NULL DEFAULT '',
+            settings TEXT NOT NULL DEFAULT '{}',
+            webhook TEXT NOT NULL DEFAULT '{}',
+            status TEXT NOT NULL DEFAULT 'active',
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            disconnection_reason_code INTEGER,
+            disconnection_object TEXT,
+            disconnection_at TIMESTAMP,
+            token_lookup TEXT NOT NULL DEFAULT '',
+            previous_token TEXT NOT NULL DEFAULT '',
+            previous_token_lookup TEXT NOT NULL DEFAULT '',
+            previous_token_expires_at TIMESTAMP,
+            proxy TEXT NOT NULL DEFAULT '{}',
+            pairing TEXT NOT NULL DEFAULT '{}',
+            tags TEXT NOT NULL DEFAULT '[]',
+            metadata TEXT NOT NULL DEFAULT '{}'
+        )`
+	if _, err := r.db.Exec(createTable); err != nil {
+		return err
+	}
+	// SQLite não tem ADD COLUMN IF NOT EXISTS: bancos antigos são completados coluna a coluna.
+	columns, err := sqliteColumns(r.db, "instances")
+	if err != nil {
+		return err
+	}
+	alterStatements := []struct{ column, stmt string }{
+		{"number", "ALTER TABLE instances ADD COLUMN number TEXT NOT NULL DEFAULT ''"},
+		{"integration", "ALTER TABLE instances ADD COLUMN integration TEXT NOT NULL DEFAULT ''"},
+		{"settings", "ALTER TABLE instances ADD COLUMN settings TEXT NOT NULL DEFAULT '{}'"},
+		{"webhook", "ALTER TABLE instances ADD COLUMN webhook TEXT NOT NULL DEFAULT '{}'"},
+		{"disconnection_reason_code", "ALTER TABLE instances ADD COLUMN disconnection_reason_code INTEGER"},
+		{"disconnection_object", "ALTER TABLE instances ADD COLUMN disconnection_object TEXT"},
+		{"disconnection_at", "ALTER TABLE instances ADD COLUMN disconnection_at TIMESTAMP"},
+		{"token_lookup", "ALTER TABLE instances ADD COLUMN token_lookup TEXT NOT NULL DEFAULT ''"},
+		{"previous_token", "ALTER TABLE instances ADD COLUMN previous_token TEXT NOT NULL DEFAULT ''"},
+		{"previous_token_lookup", "ALTER TABLE instances ADD COLUMN previous_token_lookup TEXT NOT NULL DEFAULT ''"},
+		{"previous_token_expires_at", "ALTER TABLE instances ADD COLUMN previous_token_expires_at TIMESTAMP"},
+		{"proxy", "ALTER TABLE instances ADD COLUMN proxy TEXT NOT NULL DEFAULT '{}'"},
+		{"pairing", "ALTER TABLE instances ADD COLUMN pairing TEXT NOT NULL DEFAULT '{}'"},
+		{"tags", "ALTER TABLE instances ADD COLUMN tags TEXT NOT NULL DEFAULT '[]'"},
+		{"metadata", "ALTER TABLE instances ADD COLUMN metadata TEXT NOT NULL DEFAULT '{}'"},
+	}
+	for _, alter := range alterStatements {
+		if columns[alter.column] {
+			continue
+		}
+		if _, err := r.db.Exec(alter.stmt); err != nil {
+			return err
+		}
+	}
+	if _, err := r.db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_instances_name ON instances (name)`); err != nil {
+		return err
+	}
+	if _, err := r.db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_instances_token ON instances (token)`); err != nil {
+		return err
+	}
+	if _, err := r.db.Exec(`CREATE INDEX IF NOT EXISTS idx_instances_token_lookup ON instances (token_lookup)`); err != nil {
+		return err
+	}
+	return nil
+}
+
+// sqliteColumns retorna as colunas existentes de uma tabela SQLite.
+func sqliteColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info($1)`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+func (r *sqliteInstanceRepo) Create(ctx context.Context, inst *instance.Instance) error {
+	const query = `
+        INSERT INTO instances (id, name, webhook_url, token, number, integration, settings, webhook, status, created_at, updated_at,
+            disconnection_reason_code, disconnection_object, disconnection_at,
+            token_lookup, previous_token, previous_token_lookup, previous_token_expires_at, proxy, pairing, tags, metadata)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)`
+	settingsJSON, err := json.Marshal(inst.Settings)
+	if err != nil {
+		return err
+	}
+	webhookPayload := inst.Webhook
+	if webhookPayload.URL == "" {
+		webhookPayload.URL = inst.WebhookURL
+	}
+	webhookJSON, err := json.Marshal(webhookPayload)
+	if err != nil {
+		return err
+	}
+	proxyJSON, err := json.Marshal(inst.Proxy)
+	if err != nil {
+		return err
+	}
+	pairingJSON, err := json.Marshal(inst.Pairing)
+	if err != nil {
+		return err
+	}
+	tagsJSON, metadataJSON, err := marshalTagsMetadata(inst)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query,
+		string(inst.ID),
+		inst.Name,
+		inst.WebhookURL,
+		inst.TokenHash,
+		inst.Number,
+		inst.Integration,
+		string(settingsJSON),
+		string(webhookJSON),
+		inst.Status,
+		inst.CreatedAt.UTC(),
+		inst.UpdatedAt.UTC(),
+		nullableInt(inst.DisconnectionReasonCode),
+		nullableString(inst.DisconnectionObject),
+		nullableTime(inst.DisconnectionAt),
+		inst.TokenLookup,
+		inst.PreviousTokenHash,
+		inst.PreviousTokenLookup,
+		nullableTime(inst.PreviousTokenExpiresAt),
+		string(proxyJSON),
+		string(pairingJSON),
+		string(tagsJSON),
+		string(metadataJSON),
+	)
+	return r.mapError(err)
+}
+
+func (r *sqliteInstanceRepo) List(ctx context.Context) ([]*instance.Instance, error) {
+	const query = `
+        SELECT id, name, webhook_url, token, number, integration, settings, webhook, status, created_at, updated_at,
+            disconnection_reason_code, disconnection_object, disconnection_at,
+            token_lookup, previous_token, previous_token_lookup, previous_token_expires_at, proxy, pairing, tags, metadata
+        FROM instances
+        ORDER BY created_at ASC`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, r.mapError(err)
+	}
+	defer rows.Close()
+
+	var results []*instance.Instance
+	for rows.Next() {
+		var (
+			id          string
+			name        string
+			webhook     string
+			token       string
+			number      string
+			integration string
+			settingsRaw []byte
+			webhookRaw  []byte
+			status      string
+			created     time.Time
+			updated     time.Time
+			discCode    sql.NullInt64
+			discObject  sql.NullString
+			discAt      sql.NullTime
+			tokenLookup string
+			prevToken   string
+			prevLookup  string
+			prevExpires sql.NullTime
+			proxyRaw    []byte
+			pairingRaw  []byte
+			tagsRaw     []byte
+			metaRaw     []byte
+		)
+		if err := rows.Scan(&id, &name, &webhook, &token, &number, &integration, &settingsRaw, &webhookRaw, &status, &created, &updated, &discCode, &discObject, &discAt, &tokenLookup, &prevToken, &prevLookup, &prevExpires, &proxyRaw, &pairingRaw, &tagsRaw, &metaRaw); err != nil {
+			return nil, err
+		}
+		inst := &instance.Instance{
+			ID:          instance.ID(id),
+			Name:        name,
+			TokenHash:   token,
+			Number:      number,
+			Integration: integration,
+			WebhookURL:  webhook,
+			Status:      status,
+			CreatedAt:   created,
+			UpdatedAt:   updated,
+		}
+		scanDisconnection(inst, discCode, discObject, discAt)
+		scanTokenCredentials(inst, tokenLookup, prevToken, prevLookup, prevExpires)
+		if len(settingsRaw) > 0 {
+			_ = json.Unmarshal(settingsRaw, &inst.Settings)
+		}
+		if len(webhookRaw) > 0 {
+			_ = json.Unmarshal(webhookRaw, &inst.Webhook)
+		}
+		if len(proxyRaw) > 0 {
+			_ = json.Unmarshal(proxyRaw, &inst.Proxy)
+		}
+		if len(pairingRaw) > 0 {
+			_ = json.Unmarshal(pairingRaw, &inst.Pairing)
+		}
+		scanTagsMetadata(inst, tagsRaw, metaRaw)
+		if inst.Webhook.URL == "" {
+			inst.Webhook.URL = inst.WebhookURL
+		}
+		results = append(results, inst)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *sqliteInstanceRepo) GetByName(ctx context.Context, name string) (*instance.Instance, error) {
+	const query = `
+        SELECT id, name, webhook_url, token, number, integration, settings, webhook, status, created_at, updated_at,
+            disconnection_reason_code, disconnection_object, disconnection_at,
+            token_lookup, previous_token, previous_token_lookup, previous_token_expires_at, proxy, pairing, tags, metadata
+        FROM instances
+        WHERE name = $1`
+	var (
+		id          string
+		webhook     string
+		token       string
+		number      string
+		integration string
+		settingsRaw []byte
+		webhookRaw  []byte
+		status      string
+		created     time.Time
+		updated     time.Time
+		discCode    sql.NullInt64
+		discObject  sql.NullString
+		discAt      sql.NullTime
+		tokenLookup string
+		prevToken   string
+		prevLookup  string
+		prevExpires sql.NullTime
+		proxyRaw    []byte
+		pairingRaw  []byte
+		tagsRaw     []byte
+		metaRaw     []byte
+	)
+	err := r.db.QueryRowContext(ctx, query, name).Scan(&id, &name, &webhook, &token, &number, &integration, &settingsRaw, &webhookRaw, &status, &created, &updated, &discCode, &discObject, &discAt, &tokenLookup, &prevToken, &prevLookup, &prevExpires, &proxyRaw, &pairingRaw, &tagsRaw, &metaRaw)
+	if err != nil {
+		return nil, r.mapError(err)
+	}
+	inst := &instance.Instance{
+		ID:          instance.ID(id),
+		Name:        name,
+		TokenHash:   token,
+		Number:      number,
+		Integration: integration,
+		WebhookURL:  webhook,
+		Status:      status,
+		CreatedAt:   created,
+		UpdatedAt:   updated,
+	}
+	scanDisconnection(inst, discCode, discObject, discAt)
+	scanTokenCredentials(inst, tokenLookup, prevToken, prevLookup, prevExpires)
+	if len(settingsRaw) > 0 {
+		_ = json.Unmarshal(settingsRaw, &inst.Settings)
+	}
+	if len(webhookRaw) > 0 {
+		_ = json.Unmarshal(webhookRaw, &inst.Webhook)
+	}
+	if len(proxyRaw) > 0 {
+		_ = json.Unmarshal(proxyRaw, &inst.Proxy)
+	}
+	if len(pairingRaw) > 0 {
+		_ = json.Unmarshal(pairingRaw, &inst.Pairing)
+	}
+	scanTagsMetadata(inst, tagsRaw, metaRaw)
+	if inst.Webhook.URL == "" {
+		inst.Webhook.URL = inst.WebhookURL
+	}
+	return inst, nil
+}
+
+func (r *sqliteInstanceRepo) Delete(ctx context.Context, name string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM instances WHERE name = $1`, name)
+	if err != nil {
+		return r.mapError(err)
+	}
+	affected, err := res.RowsAffected()
+	if err == nil && affected == 0 {
+		return ErrInstanceNotFound
+	}
+	return err
+}
+
+func (r *sqliteInstanceRepo) Update(ctx context.Context, inst *instance.Instance) error {
+	const query = `
+        UPDATE instances
+        SET webhook_url = $1,
+            token = $2,
+            number = $3,
+            integration = $4,
+            settings = $5,
+            webhook = $6,
+            status = $7,
+            updated_at = $8,
+            disconnection_reason_code = $9,
+            disconnection_object = $10,
+            disconnection_at = $11,
+            token_lookup = $12,
+            previous_token = $13,
+            previous_token_lookup = $14,
+            previous_token_expires_at = $15,
+            proxy = $16,
+            pairing = $17,
+            tags = $18,
+            metadata = $19
+        WHERE name = $20`
+	settingsJSON, err := json.Marshal(inst.Settings)
+	if err != nil {
+		return err
+	}
+	webhookPayload := inst.Webhook
+	if webhookPayload.URL == "" {
+		webhookPayload.URL = inst.WebhookURL
+	}
+	webhookJSON, err := json.Marshal(webhookPayload)
+	if err != nil {
+		return err
+	}
+	proxyJSON, err := json.Marshal(inst.Proxy)
+	if err != nil {
+		return err
+	}
+	pairingJSON, err := json.Marshal(inst.Pairing)
+	if err != nil {
+		return err
+	}
+	tagsJSON, metadataJSON, err := marshalTagsMetadata(inst)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx, query,
+		inst.WebhookURL,
+		inst.TokenHash,
+		inst.Number,
+		inst.Integration,
+		string(settingsJSON),
+		string(webhookJSON),
+		inst.Status,
+		inst.UpdatedAt.UTC(),
+		nullableInt(inst.DisconnectionReasonCode),
+		nullableString(inst.DisconnectionObject),
+		nullableTime(inst.DisconnectionAt),
+		inst.TokenLookup,
+		inst.PreviousTokenHash,
+		inst.PreviousTokenLookup,
+		nullableTime(inst.PreviousTokenExpiresAt),
+		string(proxyJSON),
+		string(pairingJSON),
+		string(tagsJSON),
+		string(metadataJSON),
+		inst.Name,
+	)
+	if err != nil {
+		return r.mapError(err)
+	}
+	affected, err := res.RowsAffected()
+	if err == nil && affected == 0 {
+		return ErrInstanceNotFound
+	}
+	return err
+}
+
+func (r *sqliteInstanceRepo) UpdateStatus(ctx context.Context, name string, status instance.ConnectionStatus) error {
+	const query = `
+        UPDATE instances
+        SET status = $1,
+            updated_at = $2,
+            disconnection_reason_code = $3,
+            disconnection_object = $4,
+            disconnection_at = $5
+        WHERE name = $6`
+	res, err := r.db.ExecContext(ctx, query,
+		status.Status,
+		status.UpdatedAt.UTC(),
+		nullableInt(status.DisconnectionReasonCode),
+		nullableString(status.DisconnectionObject),
+		nullableTime(status.DisconnectionAt),
+		name,
+	)
+	if err != nil {
+		return r.mapError(err)
+	}
+	affected, err := res.RowsAffected()
+	if err == nil && affected == 0 {
+		return ErrInstanceNotFound
+	}
+	return err
+}
+
+func (r *sqliteInstanceRepo) Rename(ctx context.Context, oldName, newName string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE instances SET name = $1, updated_at = $2 WHERE name = $3`, newName, time.Now().UTC(), oldName)
+	if err != nil {
+		return r.mapError(err)
+	}
+	affected, err := res.RowsAffected()
+	if err == nil && affected == 0 {
+		return ErrInstanceNotFound
+	}
+	return err
+}
+
+func (r *sqliteInstanceRepo) mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInstanceNotFound
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			if strings.Contains(sqliteErr.Error(), "instances.token") {
+				return ErrTokenAlreadyExists
+			}
+			return ErrInstanceAlreadyExists
+		}
+	}
+	return err
+}
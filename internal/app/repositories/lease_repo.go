@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/cluster"
+)
+
+var ErrLeaseNotFound = errors.New("instance lease not found")
+
+// LeaseRepository guarda os nós do cluster e o lease de cada instância. Um lease só pode
+// ser obtido por outro nó depois de expirar, o que evita duas conexões da mesma instância.
+type LeaseRepository interface {
+	// Heartbeat registra (ou atualiza) o nó como vivo.
+	Heartbeat(ctx context.Context, node cluster.Node) error
+	// Nodes lista os nós com heartbeat mais recente que maxAge.
+	Nodes(ctx context.Context, maxAge time.Duration) ([]cluster.Node, error)
+	// Acquire obtém ou renova o lease da instância para o nó; false quando outro nó o detém.
+	Acquire(ctx context.Context, instanceName, nodeID string, ttl time.Duration) (bool, error)
+	// Renew estende todos os leases do nó e retorna as instâncias que ele ainda detém.
+	Renew(ctx context.Context, nodeID string, ttl time.Duration) ([]string, error)
+	Release(ctx context.Context, instanceName, nodeID string) error
+	ReleaseAll(ctx context.Context, nodeID string) error
+	Get(ctx context.Context, instanceName string) (cluster.Lease, error)
+	List(ctx context.Context) ([]cluster.Lease, error)
+}
+
+type inMemoryLeaseRepo struct {
+	mu     sync.Mutex
+	nodes  map[string]cluster.Node
+	leases map[string]cluster.Lease
+	now    func() time.Time
+}
+
+// NewInMemoryLeaseRepo cria um repositório de leases em memória (um único processo/testes).
+func NewInMemoryLeaseRepo() LeaseRepository {
+	return &inMemoryLeaseRepo{nodes: map[string]cluster.Node{}, leases: map[string]cluster.Lease{}, now: time.Now}
+}
+
+func (r *inMemoryLeaseRepo) Heartbeat(ctx context.Context, node cluster.Node) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now().UTC()
+	if existing, ok := r.nodes[node.ID]; ok && !existing.StartedAt.IsZero() {
+		node.StartedAt = existing.StartedAt
+	} else if node.StartedAt.IsZero() {
+		node.StartedAt = now
+	}
+	node.HeartbeatAt = now
+	r.nodes[node.ID] = node
+	return nil
+}
+
+func (r *inMemoryLeaseRepo) Nodes(ctx context.Context, maxAge time.Duration) ([]cluster.Node, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cutoff := r.now().Add(-maxAge)
+	out := make([]cluster.Node, 0, len(r.nodes))
+	for _, node := range r.nodes {
+		if node.HeartbeatAt.After(cutoff) {
+			out = append(out, node)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (r *inMemoryLeaseRepo) Acquire(ctx context.Context, instanceName, nodeID string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now().UTC()
+	lease, ok := r.leases[instanceName]
+	if ok && lease.NodeID != nodeID && lease.Active(now) {
+		return false, nil
+	}
+	if !ok || lease.NodeID != nodeID {
+		lease = cluster.Lease{Instance: instanceName, NodeID: nodeID, AcquiredAt: now}
+	}
+	lease.ExpiresAt = now.Add(ttl)
+	r.leases[instanceName] = lease
+	return true, nil
+}
+
+func (r *inMemoryLeaseRepo) Renew(ctx context.Context, nodeID string, ttl time.Duration) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	expires := r.now().UTC().Add(ttl)
+	var held []string
+	for name, lease := range r.leases {
+		if lease.NodeID != nodeID {
+			continue
+		}
+		lease.ExpiresAt = expires
+		r.leases[name] = lease
+		held = append(held, name)
+	}
+	sort.Strings(held)
+	return held, nil
+}
+
+func (r *inMemoryLeaseRepo) Release(ctx context.Context, instanceName, nodeID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lease, ok := r.leases[instanceName]; ok && lease.NodeID == nodeID {
+		delete(r.leases, instanceName)
+	}
+	return nil
+}
+
+func (r *inMemoryLeaseRepo) ReleaseAll(ctx context.Context, nodeID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, lease := range r.leases {
+		if lease.NodeID == nodeID {
+			delete(r.leases, name)
+		}
+	}
+	delete(r.nodes, nodeID)
+	return nil
+}
+
+func (r *inMemoryLeaseRepo) Get(ctx context.Context, instanceName string) (cluster.Lease, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lease, ok := r.leases[instanceName]
+	if !ok {
+		return cluster.Lease{}, ErrLeaseNotFound
+	}
+	lease.NodeAddress = r.nodes[lease.NodeID].Address
+	return lease, nil
+}
+
+func (r *inMemoryLeaseRepo) List(ctx context.Context) ([]cluster.Lease, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]cluster.Lease, 0, len(r.leases))
+	for _, lease := range r.leases {
+		lease.NodeAddress = r.nodes[lease.NodeID].Address
+		out = append(out, lease)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Instance < out[j].Instance })
+	return out, nil
+}
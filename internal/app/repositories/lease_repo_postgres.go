@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/cluster"
+)
+
+type postgresLeaseRepo struct {
+	db *sql.DB
+}
+
+// NewPostgresLeaseRepo builds the cluster lease repository. All expirations use the database
+// clock so that skew between nodes does not matter.
+func NewPostgresLeaseRepo(db *sql.DB) (LeaseRepository, error) {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS cluster_nodes (
+            id TEXT PRIMARY KEY,
+            address TEXT NOT NULL DEFAULT '',
+            started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            heartbeat_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        )`,
+		`CREATE TABLE IF NOT EXISTS instance_leases (
+            instance_name TEXT PRIMARY KEY,
+            node_id TEXT NOT NULL,
+            acquired_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            expires_at TIMESTAMPTZ NOT NULL
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_instance_leases_node ON instance_leases (node_id)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	return &postgresLeaseRepo{db: db}, nil
+}
+
+func (r *postgresLeaseRepo) Heartbeat(ctx context.Context, node cluster.Node) error {
+	const query = `
+        INSERT INTO cluster_nodes (id, address, started_at, heartbeat_at)
+        VALUES ($1, $2, NOW(), NOW())
+        ON CONFLICT (id) DO UPDATE SET address = EXCLUDED.address, heartbeat_at = NOW()`
+	_, err := r.db.ExecContext(ctx, query, node.ID, node.Address)
+	return err
+}
+
+func (r *postgresLeaseRepo) Nodes(ctx context.Context, maxAge time.Duration) ([]cluster.Node, error) {
+	const query = `
+        SELECT id, address, started_at, heartbeat_at
+        FROM cluster_nodes
+        WHERE heartbeat_at > NOW() - ($1::bigint * INTERVAL '1 millisecond')
+        ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query, maxAge.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []cluster.Node
+	for rows.Next() {
+		var node cluster.Node
+		if err := rows.Scan(&node.ID, &node.Address, &node.StartedAt, &node.HeartbeatAt); err != nil {
+			return nil, err
+		}
+		out = append(out, node)
+	}
+	return out, rows.Err()
+}
+
+func (r *postgresLeaseRepo) Acquire(ctx context.Context, instanceName, nodeID string, ttl time.Duration) (bool, error) {
+	// O UPDATE só acontece se o lease já for do nó ou estiver expirado; caso contrário
+	// nenhuma linha volta e o lease continua com o dono atual.
+	const query = `
+        INSERT INTO instance_leases (instance_name, node_id, acquired_at, expires_at)
+        VALUES ($1, $2, NOW(), NOW() + ($3::bigint * INTERVAL '1 millisecond'))
+        ON CONFLICT (instance_name) DO UPDATE SET
+            node_id = EXCLUDED.node_id,
+            acquired_at = CASE WHEN instance_leases.node_id = EXCLUDED.node_id THEN instance_leases.acquired_at ELSE NOW() END,
+            expires_at = EXCLUDED.expires_at
+        WHERE instance_leases.node_id = EXCLUDED.node_id OR instance_leases.expires_at < NOW()
+        RETURNING node_id`
+	var owner string
+	err := r.db.QueryRowContext(ctx, query, instanceName, nodeID, ttl.Milliseconds()).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return owner == nodeID, nil
+}
+
+func (r *postgresLeaseRepo) Renew(ctx context.Context, nodeID string, ttl time.Duration) ([]string, error) {
+	const query = `
+        UPDATE instance_leases
+        SET expires_at = NOW() + ($2::bigint * INTERVAL '1 millisecond')
+        WHERE node_id = $1
+        RETURNING instance_name`
+	rows, err := r.db.QueryContext(ctx, query, nodeID, ttl.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var held []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		held = append(held, name)
+	}
+	return held, rows.Err()
+}
+
+func (r *postgresLeaseRepo) Release(ctx context.Context, instanceName, nodeID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM instance_leases WHERE instance_name = $1 AND node_id = $2`, instanceName, nodeID)
+	return err
+}
+
+func (r *postgresLeaseRepo) ReleaseAll(ctx context.Context, nodeID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM instance_leases WHERE node_id = $1`, nodeID); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `DELETE FROM cluster_nodes WHERE id = $1`, nodeID)
+	return err
+}
+
+func (r *postgresLeaseRepo) Get(ctx context.Context, instanceName string) (cluster.Lease, error) {
+	const query = `
+        SELECT l.instance_name, l.node_id, COALESCE(n.address, ''), l.acquired_at, l.expires_at
+        FROM instance_leases l
+        LEFT JOIN cluster_nodes n ON n.id = l.node_id
+        WHERE l.instance_name = $1`
+	var lease cluster.Lease
+	err := r.db.QueryRowContext(ctx, query, instanceName).Scan(&lease.Instance, &lease.NodeID, &lease.NodeAddress, &lease.AcquiredAt, &lease.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cluster.Lease{}, ErrLeaseNotFound
+	}
+	return lease, err
+}
+
+func (r *postgresLeaseRepo) List(ctx context.Context) ([]cluster.Lease, error) {
+	const query = `
+        SELECT l.instance_name, l.node_id, COALESCE(n.address, ''), l.acquired_at, l.expires_at
+        FROM instance_leases l
+        LEFT JOIN cluster_nodes n ON n.id = l.node_id
+        ORDER BY l.instance_name`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []cluster.Lease
+	for rows.Next() {
+		var lease cluster.Lease
+		if err := rows.Scan(&lease.Instance, &lease.NodeID, &lease.NodeAddress, &lease.AcquiredAt, &lease.ExpiresAt); err != nil {
+			return nil, err
+		}
+		out = append(out, lease)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/usage"
+)
+
+var ErrQuotaNotFound = errors.New("quota not found")
+
+// UsageRepository contabiliza os envios por instância, dia e tipo de mensagem e guarda as
+// cotas próprias de cada instância. As linhas são indexadas pelo ID da instância, que não muda
+// na renomeação. Dias são strings YYYY-MM-DD e os intervalos são inclusivos.
+type UsageRepository interface {
+	Increment(ctx context.Context, instanceID, day, messageType string) error
+	Count(ctx context.Context, instanceID, fromDay, toDay string) (int, error)
+	Daily(ctx context.Context, instanceID, fromDay, toDay string) ([]usage.DailyUsage, error)
+	GetQuota(ctx context.Context, instanceID string) (usage.Quota, error)
+	SetQuota(ctx context.Context, instanceID string, quota usage.Quota) error
+	DeleteQuota(ctx context.Context, instanceID string) error
+}
+
+type usageKey struct {
+	instance, day, messageType string
+}
+
+type inMemoryUsageRepo struct {
+	mu     sync.RWMutex
+	counts map[usageKey]int
+	quotas map[string]usage.Quota
+}
+
+func NewInMemoryUsageRepo() UsageRepository {
+	return &inMemoryUsageRepo{counts: make(map[usageKey]int), quotas: make(map[string]usage.Quota)}
+}
+
+func (r *inMemoryUsageRepo) Increment(ctx context.Context, instanceID, day, messageType string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counts[usageKey{instanceID, day, messageType}]++
+	return nil
+}
+
+func (r *inMemoryUsageRepo) Count(ctx context.Context, instanceID, fromDay, toDay string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	total := 0
+	for key, n := range r.counts {
+		if key.instance == instanceID && key.day >= fromDay && key.day <= toDay {
+			total += n
+		}
+	}
+	return total, nil
+}
+
+func (r *inMemoryUsageRepo) Daily(ctx context.Context, instanceID, fromDay, toDay string) ([]usage.DailyUsage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	days := make(map[string]*usage.DailyUsage)
+	for key, n := range r.counts {
+		if key.instance != instanceID || key.day < fromDay || key.day > toDay {
+			continue
+		}
+		addDailyUsage(days, key.day, key.messageType, n)
+	}
+	return sortedDailyUsage(days), nil
+}
+
+func (r *inMemoryUsageRepo) GetQuota(ctx context.Context, instanceID string) (usage.Quota, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	quota, ok := r.quotas[instanceID]
+	if !ok {
+		return usage.Quota{}, ErrQuotaNotFound
+	}
+	return quota, nil
+}
+
+func (r *inMemoryUsageRepo) SetQuota(ctx context.Context, instanceID string, quota usage.Quota) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.quotas[instanceID] = quota
+	return nil
+}
+
+func (r *inMemoryUsageRepo) DeleteQuota(ctx context.Context, instanceID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.quotas[instanceID]; !ok {
+		return ErrQuotaNotFound
+	}
+	delete(r.quotas, instanceID)
+	return nil
+}
+
+func addDailyUsage(days map[string]*usage.DailyUsage, day, messageType string, n int) {
+	entry, ok := days[day]
+	if !ok {
+		entry = &usage.DailyUsage{Day: day, ByType: make(map[string]int)}
+		days[day] = entry
+	}
+	entry.Total += n
+	entry.ByType[messageType] += n
+}
+
+func sortedDailyUsage(days map[string]*usage.DailyUsage) []usage.DailyUsage {
+	out := make([]usage.DailyUsage, 0, len(days))
+	for _, entry := range days {
+		out = append(out, *entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Day < out[j].Day })
+	return out
+}
+
+// sqlUsageRepo implementa UsageRepository com SQL comum a PostgreSQL e SQLite;
+// cada driver define apenas o próprio schema.
+type sqlUsageRepo struct {
+	db *sql.DB
+}
+
+func (r *sqlUsageRepo) Increment(ctx context.Context, instanceID, day, messageType string) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO instance_usage (instance_id, day, message_type, count, updated_at)
+        VALUES ($1, $2, $3, 1, $4)
+        ON CONFLICT (instance_id, day, message_type) DO UPDATE SET
+            count = instance_usage.count + 1,
+            updated_at = EXCLUDED.updated_at`,
+		instanceID, day, messageType, time.Now().UTC())
+	return err
+}
+
+func (r *sqlUsageRepo) Count(ctx context.Context, instanceID, fromDay, toDay string) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COALESCE(SUM(count), 0) FROM instance_usage
+        WHERE instance_id = $1 AND day >= $2 AND day <= $3`,
+		instanceID, fromDay, toDay).Scan(&total)
+	return total, err
+}
+
+func (r *sqlUsageRepo) Daily(ctx context.Context, instanceID, fromDay, toDay string) ([]usage.DailyUsage, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT day, message_type, count FROM instance_usage
+        WHERE instance_id = $1 AND day >= $2 AND day <= $3`,
+		instanceID, fromDay, toDay)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	days := make(map[string]*usage.DailyUsage)
+	for rows.Next() {
+		var (
+			day, messageType string
+			n                int
+		)
+		if err := rows.Scan(&day, &messageType, &n); err != nil {
+			return nil, err
+		}
+		addDailyUsage(days, day, messageType, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sortedDailyUsage(days), nil
+}
+
+func (r *sqlUsageRepo) GetQuota(ctx context.Context, instanceID string) (usage.Quota, error) {
+	var quota usage.Quota
+	err := r.db.QueryRowContext(ctx, `SELECT daily_limit, monthly_limit FROM instance_quotas WHERE instance_id = $1`, instanceID).
+		Scan(&quota.Daily, &quota.Monthly)
+	if errors.Is(err, sql.ErrNoRows) {
+		return usage.Quota{}, ErrQuotaNotFound
+	}
+	return quota, err
+}
+
+func (r *sqlUsageRepo) SetQuota(ctx context.Context, instanceID string, quota usage.Quota) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO instance_quotas (instance_id, daily_limit, monthly_limit, updated_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (instance_id) DO UPDATE SET
+            daily_limit = EXCLUDED.daily_limit,
+            monthly_limit = EXCLUDED.monthly_limit,
+            updated_at = EXCLUDED.updated_at`,
+		instanceID, quota.Daily, quota.Monthly, time.Now().UTC())
+	return err
+}
+
+func (r *sqlUsageRepo) DeleteQuota(ctx context.Context, instanceID string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM instance_quotas WHERE instance_id = $1`, instanceID)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return ErrQuotaNotFound
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"database/sql"
+)
+
+// NewPostgresUsageRepo builds the send usage/quota repository backed by PostgreSQL.
+func NewPostgresUsageRepo(db *sql.DB) (UsageRepository, error) {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS instance_usage (
+            instance_id TEXT NOT NULL,
+            day TEXT NOT NULL,
+            message_type TEXT NOT NULL,
+            count INTEGER NOT NULL DEFAULT 0,
+            updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            PRIMARY KEY (instance_id, day, message_type)
+        )`,
+		`CREATE TABLE IF NOT EXISTS instance_quotas (
+            instance_id TEXT PRIMARY KEY,
+            daily_limit INTEGER NOT NULL DEFAULT 0,
+            monthly_limit INTEGER NOT NULL DEFAULT 0,
+            updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        )`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	return &sqlUsageRepo{db: db}, nil
+}
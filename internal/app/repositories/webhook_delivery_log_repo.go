@@ -0,0 +1,338 @@
+package repositories
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+)
+
+var ErrDeliveryRecordNotFound = errors.New("webhook delivery record not found")
+
+// DefaultDeliveryLogLimit limita a listagem quando o filtro não informa Limit.
+const DefaultDeliveryLogLimit = 100
+
+// WebhookDeliveryLogRepository guarda o histórico das entregas de webhook e de cada tentativa,
+// para inspeção e replay.
+type WebhookDeliveryLogRepository interface {
+	// Record registra a entrega recém-enfileirada como pendente.
+	Record(ctx context.Context, d *webhook.Delivery) error
+	// RecordAttempt grava a tentativa e atualiza a situação da entrega.
+	RecordAttempt(ctx context.Context, a *webhook.DeliveryAttempt, status string) error
+	// SetStatus altera a situação sem registrar tentativa (ex.: entrega descartada).
+	SetStatus(ctx context.Context, id, status string) error
+	// List retorna as entregas mais recentes primeiro (ou as mais antigas, com Ascending), sem
+	// payload nem tentativas.
+	List(ctx context.Context, filter webhook.DeliveryFilter) ([]*webhook.DeliveryRecord, error)
+	// Get retorna a entrega com payload e tentativas.
+	Get(ctx context.Context, id string) (*webhook.DeliveryRecord, error)
+	// Prune remove entregas criadas antes de before.
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PayloadHash é o sha256 (hex) do corpo enviado.
+func PayloadHash(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func newDeliveryRecord(d *webhook.Delivery) webhook.DeliveryRecord {
+	return webhook.DeliveryRecord{
+		ID:             d.ID,
+		InstanceID:     d.InstanceID,
+		InstanceName:   d.InstanceName,
+		SubscriptionID: d.SubscriptionID,
+		Event:          d.Event,
+		URL:            d.URL,
+		PayloadHash:    PayloadHash(d.Payload),
+		Payload:        append([]byte(nil), d.Payload...),
+		Status:         webhook.StatusPending,
+		ReplayOf:       d.ReplayOf,
+		CreatedAt:      d.CreatedAt.UTC(),
+		UpdatedAt:      d.CreatedAt.UTC(),
+	}
+}
+
+func deliveryLogLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultDeliveryLogLimit
+	}
+	return limit
+}
+
+type inMemoryWebhookDeliveryLogRepo struct {
+	mu      sync.RWMutex
+	records map[string]*webhook.DeliveryRecord
+}
+
+func NewInMemoryWebhookDeliveryLogRepo() WebhookDeliveryLogRepository {
+	return &inMemoryWebhookDeliveryLogRepo{records: make(map[string]*webhook.DeliveryRecord)}
+}
+
+func (r *inMemoryWebhookDeliveryLogRepo) Record(ctx context.Context, d *webhook.Delivery) error {
+	rec := newDeliveryRecord(d)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[d.ID] = &rec
+	return nil
+}
+
+func (r *inMemoryWebhookDeliveryLogRepo) RecordAttempt(ctx context.Context, a *webhook.DeliveryAttempt, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec, ok := r.records[a.DeliveryID]
+	if !ok {
+		return ErrDeliveryRecordNotFound
+	}
+	rec.AttemptLog = append(rec.AttemptLog, *a)
+	rec.Attempts = a.Attempt
+	rec.LastStatus = a.StatusCode
+	rec.LastError = a.Error
+	rec.Status = status
+	rec.UpdatedAt = a.CreatedAt.UTC()
+	return nil
+}
+
+func (r *inMemoryWebhookDeliveryLogRepo) SetStatus(ctx context.Context, id, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec, ok := r.records[id]
+	if !ok {
+		return ErrDeliveryRecordNotFound
+	}
+	rec.Status = status
+	rec.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (r *inMemoryWebhookDeliveryLogRepo) List(ctx context.Context, filter webhook.DeliveryFilter) ([]*webhook.DeliveryRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*webhook.DeliveryRecord
+	for _, rec := range r.records {
+		if !matchesDeliveryFilter(rec, filter) {
+			continue
+		}
+		cp := *rec
+		cp.Payload = nil
+		cp.AttemptLog = nil
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if filter.Ascending {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+	if limit := deliveryLogLimit(filter.Limit); len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func matchesDeliveryFilter(rec *webhook.DeliveryRecord, f webhook.DeliveryFilter) bool {
+	switch {
+	case f.InstanceID != "" && rec.InstanceID != f.InstanceID:
+		return false
+	case f.SubscriptionID != "" && rec.SubscriptionID != f.SubscriptionID:
+		return false
+	case f.Event != "" && rec.Event != f.Event:
+		return false
+	case f.Status != "" && rec.Status != f.Status:
+		return false
+	case !f.Since.IsZero() && rec.CreatedAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !rec.CreatedAt.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+func (r *inMemoryWebhookDeliveryLogRepo) Get(ctx context.Context, id string) (*webhook.DeliveryRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rec, ok := r.records[id]
+	if !ok {
+		return nil, ErrDeliveryRecordNotFound
+	}
+	cp := *rec
+	cp.AttemptLog = append([]webhook.DeliveryAttempt(nil), rec.AttemptLog...)
+	return &cp, nil
+}
+
+func (r *inMemoryWebhookDeliveryLogRepo) Prune(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var removed int64
+	for id, rec := range r.records {
+		if rec.CreatedAt.Before(before) {
+			delete(r.records, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// sqlWebhookDeliveryLogRepo implementa WebhookDeliveryLogRepository com SQL comum a
+// PostgreSQL e SQLite.
+type sqlWebhookDeliveryLogRepo struct {
+	db *sql.DB
+}
+
+const deliveryLogColumns = `id, instance_id, instance_name, subscription_id, event, url, payload_hash, status, attempts, last_status, last_error, replay_of, created_at, updated_at`
+
+func (r *sqlWebhookDeliveryLogRepo) Record(ctx context.Context, d *webhook.Delivery) error {
+	rec := newDeliveryRecord(d)
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO webhook_delivery_log (`+deliveryLogColumns+`, payload)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		rec.ID, rec.InstanceID, rec.InstanceName, rec.SubscriptionID, rec.Event, rec.URL, rec.PayloadHash, rec.Status,
+		rec.Attempts, rec.LastStatus, rec.LastError, rec.ReplayOf, rec.CreatedAt, rec.UpdatedAt, string(rec.Payload))
+	return err
+}
+
+func (r *sqlWebhookDeliveryLogRepo) RecordAttempt(ctx context.Context, a *webhook.DeliveryAttempt, status string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, `
+        UPDATE webhook_delivery_log
+        SET attempts = $1, last_status = $2, last_error = $3, status = $4, updated_at = $5
+        WHERE id = $6`,
+		a.Attempt, a.StatusCode, a.Error, status, a.CreatedAt.UTC(), a.DeliveryID)
+	if err := deliveryRecordAffected(res, err); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, latency_ms, error, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)`,
+		a.DeliveryID, a.Attempt, a.StatusCode, a.LatencyMs, a.Error, a.CreatedAt.UTC()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sqlWebhookDeliveryLogRepo) SetStatus(ctx context.Context, id, status string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE webhook_delivery_log SET status = $1, updated_at = $2 WHERE id = $3`, status, time.Now().UTC(), id)
+	return deliveryRecordAffected(res, err)
+}
+
+func (r *sqlWebhookDeliveryLogRepo) List(ctx context.Context, filter webhook.DeliveryFilter) ([]*webhook.DeliveryRecord, error) {
+	query := `SELECT ` + deliveryLogColumns + ` FROM webhook_delivery_log WHERE 1 = 1`
+	var args []any
+	add := func(clause string, value any) {
+		args = append(args, value)
+		query += ` AND ` + clause + ` $` + strconv.Itoa(len(args))
+	}
+	if filter.InstanceID != "" {
+		add("instance_id =", filter.InstanceID)
+	}
+	if filter.SubscriptionID != "" {
+		add("subscription_id =", filter.SubscriptionID)
+	}
+	if filter.Event != "" {
+		add("event =", filter.Event)
+	}
+	if filter.Status != "" {
+		add("status =", filter.Status)
+	}
+	if !filter.Since.IsZero() {
+		add("created_at >=", filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		add("created_at <", filter.Until.UTC())
+	}
+	args = append(args, deliveryLogLimit(filter.Limit))
+	order := "DESC"
+	if filter.Ascending {
+		order = "ASC"
+	}
+	query += ` ORDER BY created_at ` + order + ` LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*webhook.DeliveryRecord
+	for rows.Next() {
+		var rec webhook.DeliveryRecord
+		if err := rows.Scan(&rec.ID, &rec.InstanceID, &rec.InstanceName, &rec.SubscriptionID, &rec.Event, &rec.URL, &rec.PayloadHash, &rec.Status,
+			&rec.Attempts, &rec.LastStatus, &rec.LastError, &rec.ReplayOf, &rec.CreatedAt, &rec.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, &rec)
+	}
+	return out, rows.Err()
+}
+
+func (r *sqlWebhookDeliveryLogRepo) Get(ctx context.Context, id string) (*webhook.DeliveryRecord, error) {
+	var (
+		rec     webhook.DeliveryRecord
+		payload string
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT `+deliveryLogColumns+`, payload FROM webhook_delivery_log WHERE id = $1`, id).Scan(
+		&rec.ID, &rec.InstanceID, &rec.InstanceName, &rec.SubscriptionID, &rec.Event, &rec.URL, &rec.PayloadHash, &rec.Status,
+		&rec.Attempts, &rec.LastStatus, &rec.LastError, &rec.ReplayOf, &rec.CreatedAt, &rec.UpdatedAt, &payload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeliveryRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	rec.Payload = []byte(payload)
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT attempt, status_code, latency_ms, error, created_at
+        FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY attempt`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		a := webhook.DeliveryAttempt{DeliveryID: id}
+		if err := rows.Scan(&a.Attempt, &a.StatusCode, &a.LatencyMs, &a.Error, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		rec.AttemptLog = append(rec.AttemptLog, a)
+	}
+	return &rec, rows.Err()
+}
+
+func (r *sqlWebhookDeliveryLogRepo) Prune(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `
+        DELETE FROM webhook_delivery_attempts
+        WHERE delivery_id IN (SELECT id FROM webhook_delivery_log WHERE created_at < $1)`, before.UTC()); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM webhook_delivery_log WHERE created_at < $1`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	removed, _ := res.RowsAffected()
+	return removed, tx.Commit()
+}
+
+func deliveryRecordAffected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return ErrDeliveryRecordNotFound
+	}
+	return nil
+}
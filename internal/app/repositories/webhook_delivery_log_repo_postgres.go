@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"database/sql"
+)
+
+// NewPostgresWebhookDeliveryLogRepo builds the webhook delivery log backed by PostgreSQL.
+func NewPostgresWebhookDeliveryLogRepo(db *sql.DB) (WebhookDeliveryLogRepository, error) {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS webhook_delivery_log (
+            id TEXT PRIMARY KEY,
+            instance_id TEXT NOT NULL DEFAULT '',
+            instance_name TEXT NOT NULL,
+            subscription_id TEXT NOT NULL DEFAULT '',
+            event TEXT NOT NULL,
+            url TEXT NOT NULL,
+            payload TEXT NOT NULL,
+            payload_hash TEXT NOT NULL,
+            status TEXT NOT NULL,
+            attempts INTEGER NOT NULL DEFAULT 0,
+            last_status INTEGER NOT NULL DEFAULT 0,
+            last_error TEXT NOT NULL DEFAULT '',
+            replay_of TEXT NOT NULL DEFAULT '',
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        )`,
+		`ALTER TABLE webhook_delivery_log ADD COLUMN IF NOT EXISTS instance_id TEXT NOT NULL DEFAULT ''`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_delivery_log_instance_id ON webhook_delivery_log (instance_id, created_at)`,
+		`CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+            id BIGSERIAL PRIMARY KEY,
+            delivery_id TEXT NOT NULL,
+            attempt INTEGER NOT NULL,
+            status_code INTEGER NOT NULL DEFAULT 0,
+            latency_ms BIGINT NOT NULL DEFAULT 0,
+            error TEXT NOT NULL DEFAULT '',
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts (delivery_id)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	return &sqlWebhookDeliveryLogRepo{db: db}, nil
+}
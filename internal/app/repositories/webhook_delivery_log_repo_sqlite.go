@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"database/sql"
+)
+
+// NewSQLiteWebhookDeliveryLogRepo builds the webhook delivery log backed by SQLite.
+func NewSQLiteWebhookDeliveryLogRepo(db *sql.DB) (WebhookDeliveryLogRepository, error) {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS webhook_delivery_log (
+            id TEXT PRIMARY KEY,
+            instance_id TEXT NOT NULL DEFAULT '',
+            instance_name TEXT NOT NULL,
+            subscription_id TEXT NOT NULL DEFAULT '',
+            event TEXT NOT NULL,
+            url TEXT NOT NULL,
+            payload TEXT NOT NULL,
+            payload_hash TEXT NOT NULL,
+            status TEXT NOT NULL,
+            attempts INTEGER NOT NULL DEFAULT 0,
+            last_status INTEGER NOT NULL DEFAULT 0,
+            last_error TEXT NOT NULL DEFAULT '',
+            replay_of TEXT NOT NULL DEFAULT '',
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            delivery_id TEXT NOT NULL,
+            attempt INTEGER NOT NULL,
+            status_code INTEGER NOT NULL DEFAULT 0,
+            latency_ms INTEGER NOT NULL DEFAULT 0,
+            error TEXT NOT NULL DEFAULT '',
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts (delivery_id)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	// Bancos anteriores à chave por id não têm instance_id; esses registros saem pela retenção.
+	columns, err := sqliteColumns(db, "webhook_delivery_log")
+	if err != nil {
+		return nil, err
+	}
+	if !columns["instance_id"] {
+		if _, err := db.Exec(`ALTER TABLE webhook_delivery_log ADD COLUMN instance_id TEXT NOT NULL DEFAULT ''`); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_webhook_delivery_log_instance_id ON webhook_delivery_log (instance_id, created_at)`); err != nil {
+		return nil, err
+	}
+	return &sqlWebhookDeliveryLogRepo{db: db}, nil
+}
@@ -0,0 +1,268 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+)
+
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+// WebhookOutboxRepository guarda as entregas de webhook pendentes (outbox) e as que
+// esgotaram as tentativas (dead-letter).
+type WebhookOutboxRepository interface {
+	Enqueue(ctx context.Context, d *webhook.Delivery) error
+	// Claim reserva por lockFor até limit entregas vencidas. Com ordered apenas a entrega mais
+	// antiga de cada OrderKey é elegível, preservando a ordem mesmo entre tentativas.
+	Claim(ctx context.Context, now time.Time, limit int, ordered bool, lockFor time.Duration) ([]*webhook.Delivery, error)
+	// Complete remove a entrega concluída do outbox.
+	Complete(ctx context.Context, id string) error
+	// Reschedule grava a tentativa com falha e libera a entrega para NextAttemptAt.
+	Reschedule(ctx context.Context, d *webhook.Delivery) error
+	// DeadLetter move a entrega do outbox para o dead-letter.
+	DeadLetter(ctx context.Context, d *webhook.Delivery) error
+}
+
+type outboxEntry struct {
+	seq         int64
+	delivery    webhook.Delivery
+	lockedUntil time.Time
+}
+
+type inMemoryWebhookOutboxRepo struct {
+	mu      sync.Mutex
+	seq     int64
+	pending map[string]*outboxEntry
+	dead    []webhook.Delivery
+}
+
+func NewInMemoryWebhookOutboxRepo() WebhookOutboxRepository {
+	return &inMemoryWebhookOutboxRepo{pending: make(map[string]*outboxEntry)}
+}
+
+func (r *inMemoryWebhookOutboxRepo) Enqueue(ctx context.Context, d *webhook.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	r.pending[d.ID] = &outboxEntry{seq: r.seq, delivery: *d}
+	return nil
+}
+
+func (r *inMemoryWebhookOutboxRepo) Claim(ctx context.Context, now time.Time, limit int, ordered bool, lockFor time.Duration) ([]*webhook.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]*outboxEntry, 0, len(r.pending))
+	for _, e := range r.pending {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+
+	var out []*webhook.Delivery
+	seenKey := make(map[string]bool)
+	for _, e := range entries {
+		if len(out) >= limit {
+			break
+		}
+		head := !seenKey[e.delivery.OrderKey]
+		seenKey[e.delivery.OrderKey] = true
+		if ordered && !head {
+			continue
+		}
+		if e.delivery.NextAttemptAt.After(now) || e.lockedUntil.After(now) {
+			continue
+		}
+		e.lockedUntil = now.Add(lockFor)
+		d := e.delivery
+		out = append(out, &d)
+	}
+	return out, nil
+}
+
+func (r *inMemoryWebhookOutboxRepo) Complete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.pending[id]; !ok {
+		return ErrDeliveryNotFound
+	}
+	delete(r.pending, id)
+	return nil
+}
+
+func (r *inMemoryWebhookOutboxRepo) Reschedule(ctx context.Context, d *webhook.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.pending[d.ID]
+	if !ok {
+		return ErrDeliveryNotFound
+	}
+	e.delivery.Attempts = d.Attempts
+	e.delivery.LastError = d.LastError
+	e.delivery.LastStatus = d.LastStatus
+	e.delivery.NextAttemptAt = d.NextAttemptAt
+	e.lockedUntil = time.Time{}
+	return nil
+}
+
+func (r *inMemoryWebhookOutboxRepo) DeadLetter(ctx context.Context, d *webhook.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.pending[d.ID]; !ok {
+		return ErrDeliveryNotFound
+	}
+	delete(r.pending, d.ID)
+	r.dead = append(r.dead, *d)
+	return nil
+}
+
+// sqlWebhookOutboxRepo implementa WebhookOutboxRepository com SQL comum a PostgreSQL e
+// SQLite; no Postgres o Claim usa SKIP LOCKED para vários nós consumirem o mesmo outbox.
+type sqlWebhookOutboxRepo struct {
+	db         *sql.DB
+	skipLocked bool
+}
+
+const outboxColumns = `id, instance_name, subscription_id, event, url, headers, payload, order_key, attempts, last_error, last_status, next_attempt_at, created_at`
+
+func (r *sqlWebhookOutboxRepo) Enqueue(ctx context.Context, d *webhook.Delivery) error {
+	headers, err := marshalDeliveryHeaders(d.Headers)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
+        INSERT INTO webhook_outbox (`+outboxColumns+`)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		d.ID, d.InstanceName, d.SubscriptionID, d.Event, d.URL, headers, string(d.Payload), d.OrderKey,
+		d.Attempts, d.LastError, d.LastStatus, d.NextAttemptAt.UTC(), d.CreatedAt.UTC())
+	return err
+}
+
+func (r *sqlWebhookOutboxRepo) Claim(ctx context.Context, now time.Time, limit int, ordered bool, lockFor time.Duration) ([]*webhook.Delivery, error) {
+	query := `
+        SELECT ` + outboxColumns + `
+        FROM webhook_outbox o
+        WHERE o.next_attempt_at <= $1 AND (o.locked_until IS NULL OR o.locked_until <= $1)`
+	if ordered {
+		query += `
+          AND NOT EXISTS (SELECT 1 FROM webhook_outbox p WHERE p.order_key = o.order_key AND p.seq < o.seq)`
+	}
+	query += `
+        ORDER BY o.seq
+        LIMIT $2`
+	if r.skipLocked {
+		query += ` FOR UPDATE SKIP LOCKED`
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, query, now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	var out []*webhook.Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	lockedUntil := now.Add(lockFor).UTC()
+	for _, d := range out {
+		if _, err := tx.ExecContext(ctx, `UPDATE webhook_outbox SET locked_until = $1 WHERE id = $2`, lockedUntil, d.ID); err != nil {
+			return nil, err
+		}
+	}
+	return out, tx.Commit()
+}
+
+func (r *sqlWebhookOutboxRepo) Complete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM webhook_outbox WHERE id = $1`, id)
+	return deliveryAffected(res, err)
+}
+
+func (r *sqlWebhookOutboxRepo) Reschedule(ctx context.Context, d *webhook.Delivery) error {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE webhook_outbox
+        SET attempts = $1, last_error = $2, last_status = $3, next_attempt_at = $4, locked_until = NULL
+        WHERE id = $5`,
+		d.Attempts, d.LastError, d.LastStatus, d.NextAttemptAt.UTC(), d.ID)
+	return deliveryAffected(res, err)
+}
+
+func (r *sqlWebhookOutboxRepo) DeadLetter(ctx context.Context, d *webhook.Delivery) error {
+	headers, err := marshalDeliveryHeaders(d.Headers)
+	if err != nil {
+		return err
+	}
+	failedAt := time.Now().UTC()
+	if d.FailedAt != nil {
+		failedAt = d.FailedAt.UTC()
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, `DELETE FROM webhook_outbox WHERE id = $1`, d.ID)
+	if err := deliveryAffected(res, err); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO webhook_dead_letters (id, instance_name, subscription_id, event, url, headers, payload, attempts, last_error, last_status, created_at, failed_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		d.ID, d.InstanceName, d.SubscriptionID, d.Event, d.URL, headers, string(d.Payload),
+		d.Attempts, d.LastError, d.LastStatus, d.CreatedAt.UTC(), failedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func scanDelivery(rows *sql.Rows) (*webhook.Delivery, error) {
+	var (
+		d       webhook.Delivery
+		headers string
+		payload string
+	)
+	if err := rows.Scan(&d.ID, &d.InstanceName, &d.SubscriptionID, &d.Event, &d.URL, &headers, &payload, &d.OrderKey,
+		&d.Attempts, &d.LastError, &d.LastStatus, &d.NextAttemptAt, &d.CreatedAt); err != nil {
+		return nil, err
+	}
+	if headers != "" {
+		_ = json.Unmarshal([]byte(headers), &d.Headers)
+	}
+	d.Payload = json.RawMessage(payload)
+	return &d, nil
+}
+
+func marshalDeliveryHeaders(headers map[string]string) (string, error) {
+	if len(headers) == 0 {
+		return "{}", nil
+	}
+	raw, err := json.Marshal(headers)
+	return string(raw), err
+}
+
+func deliveryAffected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err == nil && affected == 0 {
+		return ErrDeliveryNotFound
+	}
+	return err
+}
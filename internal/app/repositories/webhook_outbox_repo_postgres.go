@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"database/sql"
+)
+
+// NewPostgresWebhookOutboxRepo builds the webhook outbox/dead-letter repository backed by PostgreSQL.
+func NewPostgresWebhookOutboxRepo(db *sql.DB) (WebhookOutboxRepository, error) {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS webhook_outbox (
+            seq BIGSERIAL PRIMARY KEY,
+            id TEXT NOT NULL UNIQUE,
+            instance_name TEXT NOT NULL,
+            subscription_id TEXT NOT NULL DEFAULT 'default',
+            event TEXT NOT NULL,
+            url TEXT NOT NULL,
+            headers TEXT NOT NULL DEFAULT '{}',
+            payload TEXT NOT NULL,
+            order_key TEXT NOT NULL,
+            attempts INTEGER NOT NULL DEFAULT 0,
+            last_error TEXT NOT NULL DEFAULT '',
+            last_status INTEGER NOT NULL DEFAULT 0,
+            next_attempt_at TIMESTAMPTZ NOT NULL,
+            locked_until TIMESTAMPTZ,
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_outbox_due ON webhook_outbox (next_attempt_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_outbox_order ON webhook_outbox (order_key, seq)`,
+		`CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+            id TEXT PRIMARY KEY,
+            instance_name TEXT NOT NULL,
+            subscription_id TEXT NOT NULL DEFAULT 'default',
+            event TEXT NOT NULL,
+            url TEXT NOT NULL,
+            headers TEXT NOT NULL DEFAULT '{}',
+            payload TEXT NOT NULL,
+            attempts INTEGER NOT NULL DEFAULT 0,
+            last_error TEXT NOT NULL DEFAULT '',
+            last_status INTEGER NOT NULL DEFAULT 0,
+            created_at TIMESTAMPTZ NOT NULL,
+            failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        )`,
+		`ALTER TABLE webhook_outbox ADD COLUMN IF NOT EXISTS subscription_id TEXT NOT NULL DEFAULT 'default'`,
+		`ALTER TABLE webhook_dead_letters ADD COLUMN IF NOT EXISTS subscription_id TEXT NOT NULL DEFAULT 'default'`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_dead_letters_instance ON webhook_dead_letters (instance_name, failed_at)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	return &sqlWebhookOutboxRepo{db: db, skipLocked: true}, nil
+}
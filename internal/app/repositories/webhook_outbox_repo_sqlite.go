@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"database/sql"
+)
+
+// NewSQLiteWebhookOutboxRepo builds the webhook outbox/dead-letter repository backed by SQLite.
+func NewSQLiteWebhookOutboxRepo(db *sql.DB) (WebhookOutboxRepository, error) {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS webhook_outbox (
+            seq INTEGER PRIMARY KEY AUTOINCREMENT,
+            id TEXT NOT NULL UNIQUE,
+            instance_name TEXT NOT NULL,
+            subscription_id TEXT NOT NULL DEFAULT 'default',
+            event TEXT NOT NULL,
+            url TEXT NOT NULL,
+            headers TEXT NOT NULL DEFAULT '{}',
+            payload TEXT NOT NULL,
+            order_key TEXT NOT NULL,
+            attempts INTEGER NOT NULL DEFAULT 0,
+            last_error TEXT NOT NULL DEFAULT '',
+            last_status INTEGER NOT NULL DEFAULT 0,
+            next_attempt_at TIMESTAMP NOT NULL,
+            locked_until TIMESTAMP,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_outbox_due ON webhook_outbox (next_attempt_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_outbox_order ON webhook_outbox (order_key, seq)`,
+		`CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+            id TEXT PRIMARY KEY,
+            instance_name TEXT NOT NULL,
+            subscription_id TEXT NOT NULL DEFAULT 'default',
+            event TEXT NOT NULL,
+            url TEXT NOT NULL,
+            headers TEXT NOT NULL DEFAULT '{}',
+            payload TEXT NOT NULL,
+            attempts INTEGER NOT NULL DEFAULT 0,
+            last_error TEXT NOT NULL DEFAULT '',
+            last_status INTEGER NOT NULL DEFAULT 0,
+            created_at TIMESTAMP NOT NULL,
+            failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_dead_letters_instance ON webhook_dead_letters (instance_name, failed_at)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	// Bancos criados antes das assinaturas múltiplas não têm subscription_id.
+	for _, table := range []string{"webhook_outbox", "webhook_dead_letters"} {
+		columns, err := sqliteColumns(db, table)
+		if err != nil {
+			return nil, err
+		}
+		if !columns["subscription_id"] {
+			if _, err := db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN subscription_id TEXT NOT NULL DEFAULT 'default'`); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &sqlWebhookOutboxRepo{db: db}, nil
+}
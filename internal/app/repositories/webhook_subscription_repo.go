@@ -0,0 +1,220 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+)
+
+var ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+
+// WebhookSubscriptionRepository guarda as assinaturas de webhook adicionais de cada instância.
+// As assinaturas são ligadas ao ID da instância, que não muda quando ela é renomeada.
+type WebhookSubscriptionRepository interface {
+	List(ctx context.Context, instanceID string) ([]*webhook.Subscription, error)
+	Get(ctx context.Context, instanceID, id string) (*webhook.Subscription, error)
+	Create(ctx context.Context, sub *webhook.Subscription) error
+	Update(ctx context.Context, sub *webhook.Subscription) error
+	Delete(ctx context.Context, instanceID, id string) error
+	DeleteByInstance(ctx context.Context, instanceID string) error
+}
+
+type inMemoryWebhookSubscriptionRepo struct {
+	mu   sync.RWMutex
+	subs map[string]webhook.Subscription
+}
+
+func NewInMemoryWebhookSubscriptionRepo() WebhookSubscriptionRepository {
+	return &inMemoryWebhookSubscriptionRepo{subs: make(map[string]webhook.Subscription)}
+}
+
+func (r *inMemoryWebhookSubscriptionRepo) List(ctx context.Context, instanceID string) ([]*webhook.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*webhook.Subscription
+	for _, sub := range r.subs {
+		if sub.InstanceID == instanceID {
+			sub := sub
+			out = append(out, &sub)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *inMemoryWebhookSubscriptionRepo) Get(ctx context.Context, instanceID, id string) (*webhook.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sub, ok := r.subs[id]
+	if !ok || sub.InstanceID != instanceID {
+		return nil, ErrSubscriptionNotFound
+	}
+	return &sub, nil
+}
+
+func (r *inMemoryWebhookSubscriptionRepo) Create(ctx context.Context, sub *webhook.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs[sub.ID] = *sub
+	return nil
+}
+
+func (r *inMemoryWebhookSubscriptionRepo) Update(ctx context.Context, sub *webhook.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cur, ok := r.subs[sub.ID]; !ok || cur.InstanceID != sub.InstanceID {
+		return ErrSubscriptionNotFound
+	}
+	r.subs[sub.ID] = *sub
+	return nil
+}
+
+func (r *inMemoryWebhookSubscriptionRepo) Delete(ctx context.Context, instanceID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cur, ok := r.subs[id]; !ok || cur.InstanceID != instanceID {
+		return ErrSubscriptionNotFound
+	}
+	delete(r.subs, id)
+	return nil
+}
+
+func (r *inMemoryWebhookSubscriptionRepo) DeleteByInstance(ctx context.Context, instanceID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, sub := range r.subs {
+		if sub.InstanceID == instanceID {
+			delete(r.subs, id)
+		}
+	}
+	return nil
+}
+
+// sqlWebhookSubscriptionRepo implementa WebhookSubscriptionRepository com SQL comum a
+// PostgreSQL e SQLite; eventos e headers são gravados como JSON.
+type sqlWebhookSubscriptionRepo struct {
+	db *sql.DB
+}
+
+const subscriptionColumns = `id, instance_id, name, url, events, headers, enabled, secret, previous_secret, previous_secret_expires_at, created_at, updated_at`
+
+func (r *sqlWebhookSubscriptionRepo) List(ctx context.Context, instanceID string) ([]*webhook.Subscription, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE instance_id = $1 ORDER BY created_at`, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*webhook.Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sub)
+	}
+	return out, rows.Err()
+}
+
+func (r *sqlWebhookSubscriptionRepo) Get(ctx context.Context, instanceID, id string) (*webhook.Subscription, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE instance_id = $1 AND id = $2`, instanceID, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrSubscriptionNotFound
+	}
+	return scanSubscription(rows)
+}
+
+func (r *sqlWebhookSubscriptionRepo) Create(ctx context.Context, sub *webhook.Subscription) error {
+	events, headers, err := marshalSubscriptionFilters(sub)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
+        INSERT INTO webhook_subscriptions (`+subscriptionColumns+`)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		sub.ID, sub.InstanceID, sub.Name, sub.URL, events, headers, sub.Enabled,
+		sub.Secret, sub.PreviousSecret, sub.PreviousSecretExpiresAt, sub.CreatedAt.UTC(), sub.UpdatedAt.UTC())
+	return err
+}
+
+func (r *sqlWebhookSubscriptionRepo) Update(ctx context.Context, sub *webhook.Subscription) error {
+	events, headers, err := marshalSubscriptionFilters(sub)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE webhook_subscriptions
+        SET name = $1, url = $2, events = $3, headers = $4, enabled = $5,
+            secret = $6, previous_secret = $7, previous_secret_expires_at = $8, updated_at = $9
+        WHERE instance_id = $10 AND id = $11`,
+		sub.Name, sub.URL, events, headers, sub.Enabled,
+		sub.Secret, sub.PreviousSecret, sub.PreviousSecretExpiresAt, sub.UpdatedAt.UTC(), sub.InstanceID, sub.ID)
+	return subscriptionAffected(res, err)
+}
+
+func (r *sqlWebhookSubscriptionRepo) Delete(ctx context.Context, instanceID, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE instance_id = $1 AND id = $2`, instanceID, id)
+	return subscriptionAffected(res, err)
+}
+
+func (r *sqlWebhookSubscriptionRepo) DeleteByInstance(ctx context.Context, instanceID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE instance_id = $1`, instanceID)
+	return err
+}
+
+func scanSubscription(rows *sql.Rows) (*webhook.Subscription, error) {
+	var (
+		sub             webhook.Subscription
+		events, headers string
+		prevExpiresAt   sql.NullTime
+	)
+	if err := rows.Scan(&sub.ID, &sub.InstanceID, &sub.Name, &sub.URL, &events, &headers, &sub.Enabled,
+		&sub.Secret, &sub.PreviousSecret, &prevExpiresAt, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if events != "" {
+		_ = json.Unmarshal([]byte(events), &sub.Events)
+	}
+	if headers != "" {
+		_ = json.Unmarshal([]byte(headers), &sub.Headers)
+	}
+	if prevExpiresAt.Valid {
+		t := prevExpiresAt.Time
+		sub.PreviousSecretExpiresAt = &t
+	}
+	return &sub, nil
+}
+
+func marshalSubscriptionFilters(sub *webhook.Subscription) (string, string, error) {
+	events := sub.Events
+	if events == nil {
+		events = []string{}
+	}
+	rawEvents, err := json.Marshal(events)
+	if err != nil {
+		return "", "", err
+	}
+	headers, err := marshalDeliveryHeaders(sub.Headers)
+	return string(rawEvents), headers, err
+}
+
+func subscriptionAffected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"database/sql"
+)
+
+// NewPostgresWebhookSubscriptionRepo builds the webhook subscription repository backed by PostgreSQL.
+func NewPostgresWebhookSubscriptionRepo(db *sql.DB) (WebhookSubscriptionRepository, error) {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+            id TEXT PRIMARY KEY,
+            instance_id TEXT NOT NULL,
+            name TEXT NOT NULL DEFAULT '',
+            url TEXT NOT NULL,
+            events TEXT NOT NULL DEFAULT '[]',
+            headers TEXT NOT NULL DEFAULT '{}',
+            enabled BOOLEAN NOT NULL DEFAULT TRUE,
+            secret TEXT NOT NULL DEFAULT '',
+            previous_secret TEXT NOT NULL DEFAULT '',
+            previous_secret_expires_at TIMESTAMPTZ,
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_instance ON webhook_subscriptions (instance_id)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	return &sqlWebhookSubscriptionRepo{db: db}, nil
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/apikey"
+	"github.com/faeln1/go-whatsapp-api/pkg/apitoken"
+	"github.com/google/uuid"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// apiKeyPrefix identifica as API keys geradas pelo servidor.
+const apiKeyPrefix = "gwa_"
+
+// lastUsedPersistInterval limita a frequência de gravação do lastUsedAt de cada chave.
+const lastUsedPersistInterval = time.Minute
+
+var (
+	ErrAPIKeyNameRequired = errors.New("name is required")
+	ErrAPIKeyScopes       = errors.New("at least one valid scope is required")
+	ErrAPIKeyExpiry       = errors.New("expiresAt must be in the future")
+)
+
+// APIKeyService gerencia as API keys com escopo e as autentica a partir de um índice em memória.
+type APIKeyService interface {
+	Load(ctx context.Context) error
+	Create(ctx context.Context, in apikey.CreateAPIKeyInput) (*apikey.CreateAPIKeyResponse, error)
+	List(ctx context.Context) ([]*apikey.APIKey, error)
+	Get(ctx context.Context, id string) (*apikey.APIKey, error)
+	Update(ctx context.Context, id string, in apikey.UpdateAPIKeyInput) (*apikey.APIKey, error)
+	Delete(ctx context.Context, id string) error
+	// Authenticate retorna a chave correspondente ao token, se válida e não expirada.
+	Authenticate(token string) (*apikey.APIKey, bool)
+}
+
+type apiKeyService struct {
+	repo repositories.APIKeyRepository
+	log  waLog.Logger
+
+	mu        sync.RWMutex
+	byID      map[string]*apikey.APIKey
+	byLookup  map[string][]*apikey.APIKey
+	persisted map[string]time.Time // último lastUsedAt gravado por chave
+}
+
+func NewAPIKeyService(repo repositories.APIKeyRepository, log waLog.Logger) APIKeyService {
+	return &apiKeyService{
+		repo:      repo,
+		log:       log,
+		byID:      make(map[string]*apikey.APIKey),
+		byLookup:  make(map[string][]*apikey.APIKey),
+		persisted: make(map[string]time.Time),
+	}
+}
+
+func (s *apiKeyService) Load(ctx context.Context) error {
+	keys, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byID = make(map[string]*apikey.APIKey, len(keys))
+	s.byLookup = make(map[string][]*apikey.APIKey, len(keys))
+	for _, key := range keys {
+		s.indexLocked(key)
+	}
+	return nil
+}
+
+func (s *apiKeyService) Create(ctx context.Context, in apikey.CreateAPIKeyInput) (*apikey.CreateAPIKeyResponse, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return nil, ErrAPIKeyNameRequired
+	}
+	scopes, err := normalizeScopes(in.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(now) {
+		return nil, ErrAPIKeyExpiry
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	token := apiKeyPrefix + hex.EncodeToString(secret)
+	credential, err := apitoken.New(token)
+	if err != nil {
+		return nil, err
+	}
+	key := &apikey.APIKey{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Prefix:    token[:len(apiKeyPrefix)+8],
+		Scopes:    scopes,
+		Instances: normalizeInstances(in.Instances),
+		ExpiresAt: in.ExpiresAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+		KeyHash:   credential.Hash,
+		KeyLookup: credential.Lookup,
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.indexLocked(key)
+	s.mu.Unlock()
+	out := *key
+	return &apikey.CreateAPIKeyResponse{APIKey: &out, Key: token}, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context) ([]*apikey.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*apikey.APIKey, 0, len(s.byID))
+	for _, key := range s.byID {
+		k := *key
+		out = append(out, &k)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *apiKeyService) Get(ctx context.Context, id string) (*apikey.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.byID[id]
+	if !ok {
+		return nil, repositories.ErrAPIKeyNotFound
+	}
+	out := *key
+	return &out, nil
+}
+
+func (s *apiKeyService) Update(ctx context.Context, id string, in apikey.UpdateAPIKeyInput) (*apikey.APIKey, error) {
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	updated := *current
+	if in.Name != nil {
+		if updated.Name = strings.TrimSpace(*in.Name); updated.Name == "" {
+			return nil, ErrAPIKeyNameRequired
+		}
+	}
+	if in.Scopes != nil {
+		if updated.Scopes, err = normalizeScopes(*in.Scopes); err != nil {
+			return nil, err
+		}
+	}
+	if in.Instances != nil {
+		updated.Instances = normalizeInstances(*in.Instances)
+	}
+	now := time.Now().UTC()
+	if in.ClearExpiry {
+		updated.ExpiresAt = nil
+	} else if in.ExpiresAt != nil {
+		if !in.ExpiresAt.After(now) {
+			return nil, ErrAPIKeyExpiry
+		}
+		updated.ExpiresAt = in.ExpiresAt
+	}
+	updated.UpdatedAt = now
+	if err := s.repo.Update(ctx, &updated); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.unindexLocked(id)
+	s.indexLocked(&updated)
+	s.mu.Unlock()
+	out := updated
+	return &out, nil
+}
+
+func (s *apiKeyService) Delete(ctx context.Context, id string) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.unindexLocked(id)
+	delete(s.persisted, id)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(token string) (*apikey.APIKey, bool) {
+	if !strings.HasPrefix(token, apiKeyPrefix) {
+		return nil, false
+	}
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range s.byLookup[apitoken.Lookup(token)] {
+		credential := apitoken.Credential{Lookup: key.KeyLookup, Hash: key.KeyHash}
+		if !credential.Verify(token, now) {
+			continue
+		}
+		if key.Expired(now) {
+			return nil, false
+		}
+		key.LastUsedAt = &now
+		if now.Sub(s.persisted[key.ID]) >= lastUsedPersistInterval {
+			s.persisted[key.ID] = now
+			go s.persistLastUsed(key.ID, now)
+		}
+		out := *key
+		return &out, true
+	}
+	return nil, false
+}
+
+func (s *apiKeyService) persistLastUsed(id string, at time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.repo.TouchLastUsed(ctx, id, at); err != nil && !errors.Is(err, repositories.ErrAPIKeyNotFound) && s.log != nil {
+		s.log.Warnf("falha ao gravar lastUsedAt da api key %s: %v", id, err)
+	}
+}
+
+func (s *apiKeyService) indexLocked(key *apikey.APIKey) {
+	s.byID[key.ID] = key
+	s.byLookup[key.KeyLookup] = append(s.byLookup[key.KeyLookup], key)
+}
+
+func (s *apiKeyService) unindexLocked(id string) {
+	key, ok := s.byID[id]
+	if !ok {
+		return
+	}
+	delete(s.byID, id)
+	entries := slices.DeleteFunc(s.byLookup[key.KeyLookup], func(k *apikey.APIKey) bool { return k.ID == id })
+	if len(entries) == 0 {
+		delete(s.byLookup, key.KeyLookup)
+	} else {
+		s.byLookup[key.KeyLookup] = entries
+	}
+}
+
+func normalizeScopes(scopes []string) ([]string, error) {
+	out := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !apikey.ValidScope(scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrAPIKeyScopes, scope)
+		}
+		if !slices.Contains(out, scope) {
+			out = append(out, scope)
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrAPIKeyScopes
+	}
+	return out, nil
+}
+
+func normalizeInstances(instances []string) []string {
+	var out []string
+	for _, name := range instances {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(out, name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
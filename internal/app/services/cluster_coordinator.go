@@ -0,0 +1,355 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/cluster"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// ErrInstanceOwnedElsewhere indica que outro nó do cluster detém o lease da instância.
+var ErrInstanceOwnedElsewhere = errors.New("instance is owned by another cluster node")
+
+// SessionGuard autoriza (ou recusa) a conexão local de uma instância.
+type SessionGuard interface {
+	Claim(ctx context.Context, instanceName string) error
+}
+
+// InstanceOwners informa qual nó é dono de cada instância em modo cluster.
+type InstanceOwners interface {
+	NodeID() string
+	Owners(ctx context.Context) (map[string]cluster.Lease, error)
+}
+
+// LocalInstances informa se este nó detém o lease da instância.
+type LocalInstances interface {
+	Owns(instanceName string) bool
+}
+
+// ClusterCoordinator distribui as instâncias entre os nós usando leases: mantém o heartbeat
+// do nó, renova os leases próprios, assume instâncias sem dono (failover) e desconecta as
+// instâncias cujo lease foi perdido, evitando que dois nós disputem a mesma sessão.
+type ClusterCoordinator struct {
+	leases    repositories.LeaseRepository
+	instances repositories.InstanceRepository
+	waMgr     *whatsapp.Manager
+	node      cluster.Node
+	ttl       time.Duration
+	interval  time.Duration
+	log       waLog.Logger
+
+	// Start conecta uma instância recém-assumida por este nó (mesmo fluxo do restore).
+	Start func(ctx context.Context, inst *instance.Instance)
+
+	mu        sync.Mutex
+	owned     map[string]bool
+	lastRenew time.Time
+}
+
+func NewClusterCoordinator(leases repositories.LeaseRepository, instances repositories.InstanceRepository, waMgr *whatsapp.Manager, node cluster.Node, ttl, interval time.Duration, log waLog.Logger) *ClusterCoordinator {
+	if interval <= 0 || interval >= ttl {
+		interval = ttl / 3
+	}
+	return &ClusterCoordinator{
+		leases:    leases,
+		instances: instances,
+		waMgr:     waMgr,
+		node:      node,
+		ttl:       ttl,
+		interval:  interval,
+		log:       log,
+		owned:     make(map[string]bool),
+	}
+}
+
+func (c *ClusterCoordinator) NodeID() string {
+	return c.node.ID
+}
+
+// Claim obtém (ou renova) o lease da instância para este nó antes de conectá-la.
+func (c *ClusterCoordinator) Claim(ctx context.Context, instanceName string) error {
+	ok, err := c.leases.Acquire(ctx, instanceName, c.node.ID, c.ttl)
+	if err != nil {
+		return fmt.Errorf("acquire lease: %w", err)
+	}
+	if !ok {
+		return ErrInstanceOwnedElsewhere
+	}
+	c.mu.Lock()
+	c.owned[instanceName] = true
+	c.mu.Unlock()
+	return nil
+}
+
+// Owns é verdadeiro enquanto este nó detém o lease da instância.
+func (c *ClusterCoordinator) Owns(instanceName string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.owned[instanceName]
+}
+
+// HandleInstanceRename transfere o lease deste nó para o novo nome da instância; sem isso o
+// lease antigo só seria liberado no próximo Sync e o novo nome poderia ir para outro nó.
+func (c *ClusterCoordinator) HandleInstanceRename(ctx context.Context, oldName, newName string) {
+	c.mu.Lock()
+	owned := c.owned[oldName]
+	delete(c.owned, oldName)
+	c.mu.Unlock()
+	if !owned {
+		return
+	}
+	if err := c.Claim(ctx, newName); err != nil {
+		c.logf("failed to claim lease for renamed instance %s: %v", newName, err)
+	}
+	if err := c.leases.Release(ctx, oldName, c.node.ID); err != nil {
+		c.logf("failed to release lease for %s: %v", oldName, err)
+	}
+}
+
+// Owner retorna o lease ativo da instância; false quando nenhum nó a detém.
+func (c *ClusterCoordinator) Owner(ctx context.Context, instanceName string) (cluster.Lease, bool, error) {
+	lease, err := c.leases.Get(ctx, instanceName)
+	if errors.Is(err, repositories.ErrLeaseNotFound) {
+		return cluster.Lease{}, false, nil
+	}
+	if err != nil {
+		return cluster.Lease{}, false, err
+	}
+	return lease, lease.Active(time.Now()), nil
+}
+
+// RemoteOwner retorna o endereço do dono quando a instância pertence a outro nó vivo.
+func (c *ClusterCoordinator) RemoteOwner(ctx context.Context, instanceName string) (string, bool) {
+	lease, active, err := c.Owner(ctx, instanceName)
+	if err != nil {
+		if c.log != nil {
+			c.log.Warnf("failed to resolve owner of %s: %v", instanceName, err)
+		}
+		return "", false
+	}
+	if !active || lease.NodeID == c.node.ID || lease.NodeAddress == "" {
+		return "", false
+	}
+	return lease.NodeAddress, true
+}
+
+// Owners lista os leases ativos indexados pelo nome da instância.
+func (c *ClusterCoordinator) Owners(ctx context.Context) (map[string]cluster.Lease, error) {
+	leases, err := c.leases.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make(map[string]cluster.Lease, len(leases))
+	for _, lease := range leases {
+		if lease.Active(now) {
+			out[lease.Instance] = lease
+		}
+	}
+	return out, nil
+}
+
+// Run executa Sync periodicamente até o contexto ser cancelado.
+func (c *ClusterCoordinator) Run(ctx context.Context) {
+	c.Sync(ctx)
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Sync(ctx)
+		}
+	}
+}
+
+// Sync executa um ciclo: heartbeat, renovação dos leases, registro das instâncias no
+// Manager e aquisição de instâncias sem dono até a cota justa deste nó.
+func (c *ClusterCoordinator) Sync(ctx context.Context) {
+	if err := c.leases.Heartbeat(ctx, c.node); err != nil {
+		c.logf("heartbeat failed: %v", err)
+	}
+	c.renew(ctx)
+
+	instances, err := c.instances.List(ctx)
+	if err != nil {
+		c.logf("failed to list instances: %v", err)
+		return
+	}
+	known := make(map[string]bool, len(instances))
+	for _, inst := range instances {
+		known[inst.Name] = true
+		c.register(ctx, inst)
+	}
+	c.forgetDeleted(ctx, known)
+	c.acquire(ctx, instances)
+}
+
+// Shutdown desconecta as instâncias locais e libera os leases para que outro nó as assuma.
+func (c *ClusterCoordinator) Shutdown(ctx context.Context) {
+	c.mu.Lock()
+	owned := make([]string, 0, len(c.owned))
+	for name := range c.owned {
+		owned = append(owned, name)
+	}
+	c.owned = make(map[string]bool)
+	c.mu.Unlock()
+	for _, name := range owned {
+		c.stopLocal(name, "node_shutdown")
+	}
+	if err := c.leases.ReleaseAll(ctx, c.node.ID); err != nil {
+		c.logf("failed to release leases: %v", err)
+	}
+}
+
+func (c *ClusterCoordinator) renew(ctx context.Context) {
+	held, err := c.leases.Renew(ctx, c.node.ID, c.ttl)
+	now := time.Now()
+	if err != nil {
+		c.logf("lease renewal failed: %v", err)
+		c.mu.Lock()
+		expired := !c.lastRenew.IsZero() && now.Sub(c.lastRenew) >= c.ttl
+		c.mu.Unlock()
+		if expired {
+			// Sem renovar dentro do TTL outro nó pode assumir: soltar tudo antes disso.
+			c.dropOwned(nil, "lease_expired")
+		}
+		return
+	}
+	stillHeld := make(map[string]bool, len(held))
+	for _, name := range held {
+		stillHeld[name] = true
+	}
+	c.mu.Lock()
+	c.lastRenew = now
+	c.mu.Unlock()
+	c.dropOwned(stillHeld, "lease_lost")
+}
+
+// dropOwned para as instâncias locais que não estão em keep (todas quando keep é nil).
+func (c *ClusterCoordinator) dropOwned(keep map[string]bool, reason string) {
+	c.mu.Lock()
+	var lost []string
+	for name := range c.owned {
+		if !keep[name] {
+			lost = append(lost, name)
+			delete(c.owned, name)
+		}
+	}
+	c.mu.Unlock()
+	for _, name := range lost {
+		c.logf("lost lease for %s (%s); disconnecting", name, reason)
+		c.stopLocal(name, reason)
+	}
+}
+
+func (c *ClusterCoordinator) stopLocal(name, reason string) {
+	c.waMgr.MarkStopped(name, reason)
+	if sess, ok := c.waMgr.Get(name); ok && sess.Client != nil {
+		sess.Client.Disconnect()
+	}
+}
+
+// register mantém a sessão (e os tokens) de todas as instâncias no Manager local para que
+// a autenticação funcione em qualquer nó.
+func (c *ClusterCoordinator) register(ctx context.Context, inst *instance.Instance) {
+	credentials := InstanceCredentials(inst)
+	sess, err := c.waMgr.Create(ctx, inst.Name, credentials...)
+	if errors.Is(err, whatsapp.ErrAlreadyExists) {
+		_ = c.waMgr.SetTokens(inst.Name, credentials...)
+		return
+	}
+	if err != nil {
+		c.logf("failed to register %s: %v", inst.Name, err)
+		return
+	}
+	sess.ID = string(inst.ID)
+	sess.CreatedAt = inst.CreatedAt
+}
+
+// forgetDeleted libera os leases e as sessões de instâncias removidas do repositório.
+func (c *ClusterCoordinator) forgetDeleted(ctx context.Context, known map[string]bool) {
+	c.mu.Lock()
+	var released []string
+	for name := range c.owned {
+		if !known[name] {
+			released = append(released, name)
+			delete(c.owned, name)
+		}
+	}
+	c.mu.Unlock()
+	for _, name := range released {
+		if err := c.leases.Release(ctx, name, c.node.ID); err != nil {
+			c.logf("failed to release lease for %s: %v", name, err)
+		}
+	}
+	for _, sess := range c.waMgr.List(ctx) {
+		if !known[sess.Name] {
+			// Removida em outro nó
+			c.stopLocal(sess.Name, "deleted")
+			c.waMgr.Delete(sess.Name)
+		}
+	}
+}
+
+// acquire assume instâncias sem lease ativo até ceil(instâncias / nós vivos).
+func (c *ClusterCoordinator) acquire(ctx context.Context, instances []*instance.Instance) {
+	nodes, err := c.leases.Nodes(ctx, c.ttl)
+	if err != nil {
+		c.logf("failed to list cluster nodes: %v", err)
+		return
+	}
+	live := len(nodes)
+	if live == 0 {
+		live = 1
+	}
+	quota := (len(instances) + live - 1) / live
+
+	owners, err := c.Owners(ctx)
+	if err != nil {
+		c.logf("failed to list leases: %v", err)
+		return
+	}
+	c.mu.Lock()
+	held := len(c.owned)
+	c.mu.Unlock()
+	for _, inst := range instances {
+		lease, taken := owners[inst.Name]
+		c.mu.Lock()
+		mine := c.owned[inst.Name]
+		c.mu.Unlock()
+		switch {
+		case mine:
+			continue
+		case taken && lease.NodeID == c.node.ID:
+			// Lease ainda válido de uma execução anterior deste mesmo nó
+		case taken || held >= quota:
+			continue
+		}
+		if err := c.Claim(ctx, inst.Name); err != nil {
+			if !errors.Is(err, ErrInstanceOwnedElsewhere) {
+				c.logf("failed to claim %s: %v", inst.Name, err)
+			}
+			continue
+		}
+		held++
+		c.logf("acquired lease for %s", inst.Name)
+		if c.Start != nil {
+			go c.Start(context.Background(), inst)
+		}
+	}
+}
+
+func (c *ClusterCoordinator) logf(format string, args ...any) {
+	if c.log != nil {
+		c.log.Infof(format, args...)
+	}
+}
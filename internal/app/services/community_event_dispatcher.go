@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/faeln1/go-whatsapp-api/internal/domain/community"
+	"github.com/faeln1/go-whatsapp-api/pkg/webhooksig"
+	"github.com/google/uuid"
 	waLog "go.mau.fi/whatsmeow/util/log"
 )
 
@@ -19,20 +21,28 @@ type CommunityEventsDispatcher interface {
 }
 
 type communityEventsDispatcher struct {
-	client *http.Client
-	url    string
-	log    waLog.Logger
-	token  string
+	client  *http.Client
+	url     string
+	log     waLog.Logger
+	token   string
+	secrets []string
 }
 
-// NewCommunityEventsDispatcher cria um dispatcher com URL fixa (via env).
-func NewCommunityEventsDispatcher(url, token string, client *http.Client, log waLog.Logger) CommunityEventsDispatcher {
+// NewCommunityEventsDispatcher cria um dispatcher com URL fixa (via env). Com secrets as
+// entregas são assinadas com HMAC; informe o segredo anterior para assinar com os dois durante a rotação.
+func NewCommunityEventsDispatcher(url, token string, secrets []string, client *http.Client, log waLog.Logger) CommunityEventsDispatcher {
 	cleanURL := strings.TrimSpace(url)
 	cleanToken := strings.TrimSpace(token)
 	if client == nil {
 		client = &http.Client{Timeout: 10 * time.Second}
 	}
-	return &communityEventsDispatcher{client: client, url: cleanURL, log: log, token: cleanToken}
+	var cleanSecrets []string
+	for _, secret := range secrets {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			cleanSecrets = append(cleanSecrets, secret)
+		}
+	}
+	return &communityEventsDispatcher{client: client, url: cleanURL, log: log, token: cleanToken, secrets: cleanSecrets}
 }
 
 func (d *communityEventsDispatcher) Dispatch(ctx context.Context, events []community.MembershipEvent) error {
@@ -62,6 +72,7 @@ func (d *communityEventsDispatcher) Dispatch(ctx context.Context, events []commu
 	if d.token != "" {
 		req.Header.Set("Authorization", "Bearer "+d.token)
 	}
+	webhooksig.Apply(req.Header, uuid.NewString(), time.Now(), payload, d.secrets...)
 
 	if d.log != nil {
 		d.log.Debugf("enviando %d evento(s) de comunidade para %s", len(events), target)
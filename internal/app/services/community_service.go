@@ -126,7 +126,7 @@ func (s *communityService) Create(ctx context.Context, instanceID string, in com
 	}
 
 	if img := strings.TrimSpace(in.Image); img != "" {
-		if data, err := decodeImage(ctx, img); err != nil {
+		if data, err := decodeImage(ctx, s.waMgr.HTTPClient(sess.Name, 0), img); err != nil {
 			return nil, fmt.Errorf("invalid image payload: %w", err)
 		} else if len(data) > 0 {
 			if _, err := sess.Client.SetGroupPhoto(info.JID, data); err != nil {
@@ -490,7 +490,7 @@ func (s *communityService) UpdateImage(ctx context.Context, instanceID, communit
 		return nil, err
 	}
 
-	data, err := decodeImage(ctx, source)
+	data, err := decodeImage(ctx, s.waMgr.HTTPClient(sess.Name, 0), source)
 	if err != nil {
 		return nil, err
 	}
@@ -814,7 +814,7 @@ var (
 	errCommunityImageInvalid  = errors.New("community image is not a valid image")
 )
 
-func decodeImage(ctx context.Context, encoded string) ([]byte, error) {
+func decodeImage(ctx context.Context, client *http.Client, encoded string) ([]byte, error) {
 	source := strings.TrimSpace(encoded)
 	if source == "" {
 		return nil, nil
@@ -826,7 +826,7 @@ func decodeImage(ctx context.Context, encoded string) ([]byte, error) {
 	)
 	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
 		remote = true
-		data, err = downloadImage(ctx, source)
+		data, err = downloadImage(ctx, client, source)
 		if err != nil {
 			return nil, err
 		}
@@ -853,12 +853,12 @@ func decodeImage(ctx context.Context, encoded string) ([]byte, error) {
 	return normalized, nil
 }
 
-func downloadImage(ctx context.Context, url string) ([]byte, error) {
+func downloadImage(ctx context.Context, client *http.Client, url string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create image request: %w", err)
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download image: %w", err)
 	}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	qrcode "github.com/skip2/go-qrcode"
+	"go.mau.fi/whatsmeow/types/events"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+const (
+	eventConnectionUpdate = "connection.update"
+	eventQRCodeUpdated    = "qrcode.updated"
+)
+
+// Estados no formato Evolution (connection.update.state)
+const (
+	connectionStateOpen       = "open"
+	connectionStateClose      = "close"
+	connectionStateConnecting = "connecting"
+)
+
+// Códigos de statusReason no padrão Evolution/Baileys para eventos sem código próprio.
+const (
+	statusReasonOK               = 200
+	statusReasonLoggedOut        = 401
+	statusReasonTimedOut         = 408
+	statusReasonConnectionClosed = 428
+	statusReasonReplaced         = 440
+)
+
+// QRCodeListener recebe cada QR code (e pairing code, quando houver) gerado para uma instância.
+type QRCodeListener interface {
+	HandleQRCode(ctx context.Context, instanceName, code, pairingCode string)
+}
+
+// ConnectionLifecycle persiste o estado da conexão e publica connection.update / qrcode.updated.
+type ConnectionLifecycle struct {
+	repo       repositories.InstanceRepository
+	waMgr      *whatsapp.Manager
+	dispatcher WebhookDispatcher
+	log        waLog.Logger
+}
+
+func NewConnectionLifecycle(repo repositories.InstanceRepository, waMgr *whatsapp.Manager, dispatcher WebhookDispatcher, log waLog.Logger) *ConnectionLifecycle {
+	return &ConnectionLifecycle{repo: repo, waMgr: waMgr, dispatcher: dispatcher, log: log}
+}
+
+// connectionChange descreve como um evento do whatsmeow afeta a instância.
+type connectionChange struct {
+	state        string // estado Evolution enviado no webhook
+	status       string // status persistido na instância
+	statusReason int
+	reason       string
+	disconnected bool // registra DisconnectionReasonCode/DisconnectionAt
+}
+
+func classifyConnectionEvent(evt any) (connectionChange, bool) {
+	switch e := evt.(type) {
+	case *events.Connected:
+		return connectionChange{state: connectionStateOpen, status: "open", statusReason: statusReasonOK}, true
+	case *events.PairSuccess:
+		return connectionChange{state: connectionStateConnecting, status: "connecting", statusReason: statusReasonOK, reason: "pair_success"}, true
+	case *events.KeepAliveTimeout:
+		return connectionChange{state: connectionStateConnecting, status: "connecting", statusReason: statusReasonTimedOut, reason: "keepalive_timeout"}, true
+	case *events.KeepAliveRestored:
+		return connectionChange{state: connectionStateOpen, status: "open", statusReason: statusReasonOK, reason: "keepalive_restored"}, true
+	case *events.Disconnected:
+		return connectionChange{state: connectionStateClose, status: "disconnected", statusReason: statusReasonConnectionClosed, reason: "connection_closed", disconnected: true}, true
+	case *events.StreamReplaced:
+		return connectionChange{state: connectionStateClose, status: "disconnected", statusReason: statusReasonReplaced, reason: "stream_replaced", disconnected: true}, true
+	case *events.LoggedOut:
+		code := int(e.Reason)
+		if code == 0 {
+			code = statusReasonLoggedOut
+		}
+		return connectionChange{state: connectionStateClose, status: "logged_out", statusReason: code, reason: e.PermanentDisconnectDescription(), disconnected: true}, true
+	case *events.TemporaryBan:
+		return connectionChange{state: connectionStateClose, status: "disconnected", statusReason: int(events.ConnectFailureTempBanned), reason: e.String(), disconnected: true}, true
+	case *events.ConnectFailure:
+		status := "disconnected"
+		if e.Reason.IsLoggedOut() {
+			status = "logged_out"
+		}
+		return connectionChange{state: connectionStateClose, status: status, statusReason: int(e.Reason), reason: e.PermanentDisconnectDescription(), disconnected: true}, true
+	case *events.ClientOutdated:
+		return connectionChange{state: connectionStateClose, status: "disconnected", statusReason: int(events.ConnectFailureClientOutdated), reason: "client_outdated", disconnected: true}, true
+	}
+	return connectionChange{}, false
+}
+
+func (l *ConnectionLifecycle) HandleConnectionEvent(ctx context.Context, instanceName string, evt any) {
+	if l == nil || l.repo == nil {
+		return
+	}
+	change, ok := classifyConnectionEvent(evt)
+	if !ok {
+		return
+	}
+	if inst, payload := l.applyChange(ctx, instanceName, change, evt); inst != nil {
+		l.dispatch(inst, eventConnectionUpdate, payload)
+	}
+}
+
+// HandleShutdown registra e publica o fechamento da conexão no encerramento do servidor: o
+// Disconnect manual do whatsmeow não emite evento. O webhook é enfileirado antes de retornar,
+// para o drain do shutdown acompanhar a entrega.
+func (l *ConnectionLifecycle) HandleShutdown(ctx context.Context, instanceName string) {
+	if l == nil || l.repo == nil {
+		return
+	}
+	change := connectionChange{state: connectionStateClose, status: "disconnected", statusReason: statusReasonConnectionClosed, reason: "shutdown", disconnected: true}
+	inst, payload := l.applyChange(ctx, instanceName, change, nil)
+	if inst == nil || l.dispatcher == nil {
+		return
+	}
+	if _, err := l.dispatcher.Dispatch(ctx, inst, eventConnectionUpdate, payload); err != nil && l.log != nil {
+		l.log.Errorf("%s instance=%s dispatch error: %v", eventConnectionUpdate, inst.Name, err)
+	}
+}
+
+// applyChange persiste o estado da conexão e monta o payload do connection.update; retorna
+// nil quando a instância não existe.
+func (l *ConnectionLifecycle) applyChange(ctx context.Context, instanceName string, change connectionChange, evt any) (*instance.Instance, map[string]any) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	inst, err := l.repo.GetByName(ctx, instanceName)
+	if err != nil {
+		if !errors.Is(err, repositories.ErrInstanceNotFound) && l.log != nil {
+			l.log.Errorf("%s instance=%s repository error: %v", eventConnectionUpdate, instanceName, err)
+		}
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	status := instance.ConnectionStatus{
+		Status:                  change.status,
+		DisconnectionReasonCode: inst.DisconnectionReasonCode,
+		DisconnectionObject:     inst.DisconnectionObject,
+		DisconnectionAt:         inst.DisconnectionAt,
+		UpdatedAt:               now,
+	}
+	switch {
+	case change.disconnected:
+		code := change.statusReason
+		reason := change.reason
+		status.DisconnectionReasonCode = &code
+		status.DisconnectionObject = &reason
+		status.DisconnectionAt = &now
+	case change.state == connectionStateOpen:
+		status.DisconnectionReasonCode = nil
+		status.DisconnectionObject = nil
+		status.DisconnectionAt = nil
+	}
+	// Apenas o estado da conexão: um Update completo sobrescreveria alterações feitas pela API.
+	if err := l.repo.UpdateStatus(ctx, instanceName, status); err != nil && l.log != nil {
+		l.log.Errorf("%s instance=%s status update failed: %v", eventConnectionUpdate, instanceName, err)
+	}
+	inst.Status = status.Status
+	inst.UpdatedAt = now
+	inst.DisconnectionReasonCode = status.DisconnectionReasonCode
+	inst.DisconnectionObject = status.DisconnectionObject
+	inst.DisconnectionAt = status.DisconnectionAt
+
+	payload := map[string]any{
+		"instance":     inst.Name,
+		"state":        change.state,
+		"statusReason": change.statusReason,
+	}
+	if change.reason != "" {
+		payload["reason"] = change.reason
+	}
+	if wuid := l.ownerJID(instanceName, evt); wuid != "" {
+		payload["wuid"] = wuid
+	}
+	return inst, payload
+}
+
+// HandleQRCode publica qrcode.updated com o PNG em base64 (data URI) e o pairing code, se houver.
+func (l *ConnectionLifecycle) HandleQRCode(ctx context.Context, instanceName, code, pairingCode string) {
+	if l == nil || l.repo == nil || (code == "" && pairingCode == "") {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	inst, err := l.repo.GetByName(ctx, instanceName)
+	if err != nil {
+		if !errors.Is(err, repositories.ErrInstanceNotFound) && l.log != nil {
+			l.log.Errorf("%s instance=%s repository error: %v", eventQRCodeUpdated, instanceName, err)
+		}
+		return
+	}
+
+	qr := map[string]any{
+		"instance":    inst.Name,
+		"code":        nil,
+		"base64":      nil,
+		"pairingCode": nil,
+	}
+	if code != "" {
+		qr["code"] = code
+		if png, err := qrcode.Encode(code, qrcode.Medium, 256); err == nil {
+			qr["base64"] = "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+		} else if l.log != nil {
+			l.log.Warnf("%s instance=%s qr encode failed: %v", eventQRCodeUpdated, instanceName, err)
+		}
+	}
+	if pairingCode != "" {
+		qr["pairingCode"] = pairingCode
+	}
+	l.dispatch(inst, eventQRCodeUpdated, map[string]any{"qrcode": qr})
+}
+
+func (l *ConnectionLifecycle) ownerJID(instanceName string, evt any) string {
+	if e, ok := evt.(*events.PairSuccess); ok {
+		return e.ID.ToNonAD().String()
+	}
+	if l.waMgr == nil {
+		return ""
+	}
+	sess, ok := l.waMgr.Get(instanceName)
+	if !ok || sess == nil || sess.Client == nil || sess.Client.Store == nil || sess.Client.Store.ID == nil {
+		return ""
+	}
+	return sess.Client.Store.ID.ToNonAD().String()
+}
+
+func (l *ConnectionLifecycle) dispatch(inst *instance.Instance, event string, payload map[string]any) {
+	if l.dispatcher == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if _, err := l.dispatcher.Dispatch(ctx, inst, event, payload); err != nil && l.log != nil {
+			l.log.Errorf("%s instance=%s dispatch error: %v", event, inst.Name, err)
+		}
+	}()
+}
+
+var (
+	_ ConnectionEventListener = (*ConnectionLifecycle)(nil)
+	_ QRCodeListener          = (*ConnectionLifecycle)(nil)
+)
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestClassifyConnectionEvent(t *testing.T) {
+	cases := []struct {
+		name         string
+		evt          any
+		state        string
+		status       string
+		statusReason int
+		disconnected bool
+	}{
+		{"connected", &events.Connected{}, connectionStateOpen, "open", statusReasonOK, false},
+		{"disconnected", &events.Disconnected{}, connectionStateClose, "disconnected", statusReasonConnectionClosed, true},
+		{"stream replaced", &events.StreamReplaced{}, connectionStateClose, "disconnected", statusReasonReplaced, true},
+		{"logged out on stream error", &events.LoggedOut{}, connectionStateClose, "logged_out", statusReasonLoggedOut, true},
+		{"logged out on connect", &events.LoggedOut{OnConnect: true, Reason: events.ConnectFailureMainDeviceGone}, connectionStateClose, "logged_out", int(events.ConnectFailureMainDeviceGone), true},
+		{"temporary ban", &events.TemporaryBan{Code: events.TempBanSentToTooManyPeople}, connectionStateClose, "disconnected", int(events.ConnectFailureTempBanned), true},
+		{"keepalive timeout", &events.KeepAliveTimeout{}, connectionStateConnecting, "connecting", statusReasonTimedOut, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			change, ok := classifyConnectionEvent(tc.evt)
+			if !ok {
+				t.Fatalf("expected event to be classified")
+			}
+			if change.state != tc.state || change.status != tc.status || change.statusReason != tc.statusReason || change.disconnected != tc.disconnected {
+				t.Fatalf("unexpected change: %+v", change)
+			}
+		})
+	}
+
+	if _, ok := classifyConnectionEvent(&events.Message{}); ok {
+		t.Fatalf("message events must be ignored")
+	}
+}
+
+func TestConnectionLifecyclePersistsDisconnection(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewInMemoryInstanceRepo()
+	now := time.Now().UTC()
+	if err := repo.Create(ctx, &instance.Instance{ID: "id-1", Name: "inst", Token: "tok", Status: "pending_qr", CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	lifecycle := NewConnectionLifecycle(repo, nil, nil, nil)
+
+	lifecycle.HandleConnectionEvent(ctx, "inst", &events.LoggedOut{})
+	inst, _ := repo.GetByName(ctx, "inst")
+	if inst.Status != "logged_out" {
+		t.Fatalf("expected logged_out status, got %q", inst.Status)
+	}
+	if inst.DisconnectionReasonCode == nil || *inst.DisconnectionReasonCode != statusReasonLoggedOut || inst.DisconnectionAt == nil {
+		t.Fatalf("expected disconnection details to be recorded, got %+v", inst)
+	}
+
+	lifecycle.HandleConnectionEvent(ctx, "inst", &events.Connected{})
+	inst, _ = repo.GetByName(ctx, "inst")
+	if inst.Status != "open" || inst.DisconnectionReasonCode != nil || inst.DisconnectionAt != nil {
+		t.Fatalf("expected connection to clear disconnection details, got %+v", inst)
+	}
+}
+
+type recordingDispatcher struct {
+	events   []string
+	payloads []map[string]any
+}
+
+func (d *recordingDispatcher) Dispatch(_ context.Context, _ *instance.Instance, event string, payload map[string]any) (bool, error) {
+	d.events = append(d.events, event)
+	d.payloads = append(d.payloads, payload)
+	return true, nil
+}
+
+func TestConnectionLifecycleHandleShutdown(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewInMemoryInstanceRepo()
+	now := time.Now().UTC()
+	if err := repo.Create(ctx, &instance.Instance{ID: "id-1", Name: "inst", Token: "tok", Status: "open", CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	dispatcher := &recordingDispatcher{}
+	lifecycle := NewConnectionLifecycle(repo, nil, dispatcher, nil)
+
+	// O webhook é enfileirado de forma síncrona, antes de o shutdown drenar as entregas.
+	lifecycle.HandleShutdown(ctx, "inst")
+	if len(dispatcher.events) != 1 || dispatcher.events[0] != eventConnectionUpdate {
+		t.Fatalf("expected one connection.update, got %v", dispatcher.events)
+	}
+	payload := dispatcher.payloads[0]
+	if payload["state"] != connectionStateClose || payload["reason"] != "shutdown" {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+	inst, _ := repo.GetByName(ctx, "inst")
+	if inst.Status != "disconnected" || inst.DisconnectionAt == nil {
+		t.Fatalf("expected shutdown recorded, got %+v", inst)
+	}
+
+	lifecycle.HandleShutdown(ctx, "missing")
+	if len(dispatcher.events) != 1 {
+		t.Fatalf("expected no event for an unknown instance")
+	}
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+var ErrUnknownEventSink = errors.New("event sink not configured on this server")
+
+const (
+	// eventSinkBuffer é o número de publicações pendentes antes de descartar novos eventos.
+	eventSinkBuffer = 1024
+	// eventSinkConfigTTL é por quanto tempo a config de um destino fica em cache; alterações
+	// feitas neste nó valem na hora, as feitas em outros nós do cluster após o TTL.
+	eventSinkConfigTTL = 30 * time.Second
+	// eventSinkAttempts e eventSinkBackoff controlam as novas tentativas de uma publicação.
+	eventSinkAttempts = 3
+	eventSinkBackoff  = 200 * time.Millisecond
+	// eventSinkPublishTimeout limita cada tentativa, inclusive as do drain no shutdown.
+	eventSinkPublishTimeout = 10 * time.Second
+)
+
+// EventSink é um destino de eventos além dos webhooks (ex.: Redis Streams, filas). Recebe o
+// mesmo corpo JSON enviado aos webhooks.
+type EventSink interface {
+	Name() string
+	Publish(ctx context.Context, inst *instance.Instance, event string, body []byte) error
+}
+
+type sinkJob struct {
+	sink  EventSink
+	inst  instance.Instance
+	event string
+	body  []byte
+}
+
+type cachedSinkConfig struct {
+	cfg     *webhook.SinkConfig // nil quando não há config
+	expires time.Time
+}
+
+// EventSinks fica na frente do dispatcher de webhooks e publica cada evento também nos
+// destinos habilitados para a instância. A publicação é assíncrona, por um buffer limitado
+// consumido por Run, para não segurar o handler de eventos; falhas de um destino são
+// registradas e não afetam os webhooks nem os outros destinos.
+type EventSinks struct {
+	next    WebhookDispatcher
+	configs repositories.EventSinkRepository
+	sinks   map[string]EventSink
+	jobs    chan sinkJob
+	log     waLog.Logger
+
+	mu    sync.Mutex
+	cache map[string]cachedSinkConfig
+}
+
+func NewEventSinks(next WebhookDispatcher, configs repositories.EventSinkRepository, log waLog.Logger) *EventSinks {
+	return &EventSinks{
+		next:    next,
+		configs: configs,
+		sinks:   make(map[string]EventSink),
+		jobs:    make(chan sinkJob, eventSinkBuffer),
+		log:     log,
+		cache:   make(map[string]cachedSinkConfig),
+	}
+}
+
+// Register adiciona um destino; deve ser chamado antes de o servidor começar a receber eventos.
+func (s *EventSinks) Register(sink EventSink) {
+	s.sinks[sink.Name()] = sink
+}
+
+// Names retorna os destinos registrados, em ordem alfabética.
+func (s *EventSinks) Names() []string {
+	names := make([]string, 0, len(s.sinks))
+	for name := range s.sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Dispatch repassa o evento aos webhooks e enfileira a publicação nos destinos que o aceitam;
+// true indica que ao menos um webhook ou destino o recebeu na fila.
+func (s *EventSinks) Dispatch(ctx context.Context, inst *instance.Instance, event string, payload map[string]any) (bool, error) {
+	var (
+		delivered bool
+		err       error
+	)
+	if s.next != nil {
+		delivered, err = s.next.Dispatch(ctx, inst, event, payload)
+	}
+	if inst == nil {
+		return delivered, err
+	}
+	var body []byte
+	for _, name := range s.Names() {
+		if !s.accepts(ctx, inst, name, event) {
+			continue
+		}
+		if body == nil {
+			var berr error
+			if body, berr = webhookBody(inst, event, payload, time.Now().UTC()); berr != nil {
+				return delivered, berr
+			}
+		}
+		select {
+		case s.jobs <- sinkJob{sink: s.sinks[name], inst: *inst, event: event, body: body}:
+			delivered = true
+		default:
+			if s.log != nil {
+				s.log.Warnf("event sink %s buffer full, dropping instance=%s event=%s", name, inst.Name, event)
+			}
+		}
+	}
+	return delivered, err
+}
+
+// Run publica os eventos enfileirados até ctx ser cancelado; os que ainda estiverem no buffer
+// nesse momento recebem uma única tentativa antes de Run retornar.
+func (s *EventSinks) Run(ctx context.Context) {
+	for {
+		select {
+		case job := <-s.jobs:
+			s.publish(ctx, job, eventSinkAttempts)
+		case <-ctx.Done():
+			for {
+				select {
+				case job := <-s.jobs:
+					s.publish(ctx, job, 1)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// publish tenta até attempts vezes, com backoff exponencial; o cancelamento de ctx só
+// interrompe a espera entre tentativas.
+func (s *EventSinks) publish(ctx context.Context, job sinkJob, attempts int) {
+	delay := eventSinkBackoff
+	for attempt := 1; ; attempt++ {
+		pubCtx, cancel := context.WithTimeout(context.Background(), eventSinkPublishTimeout)
+		err := job.sink.Publish(pubCtx, &job.inst, job.event, job.body)
+		cancel()
+		if err == nil {
+			return
+		}
+		if attempt >= attempts {
+			if s.log != nil {
+				s.log.Warnf("event sink %s failed instance=%s event=%s attempts=%d: %v", job.sink.Name(), job.inst.Name, job.event, attempt, err)
+			}
+			return
+		}
+		select {
+		case <-ctx.Done():
+			attempts = attempt + 1
+		case <-time.After(delay):
+			delay *= 2
+		}
+	}
+}
+
+// HasAudience considera também os destinos habilitados para a instância.
+func (s *EventSinks) HasAudience(ctx context.Context, inst *instance.Instance) bool {
+	if inst == nil {
+		return false
+	}
+	for name := range s.sinks {
+		if cfg := s.config(ctx, inst, name); cfg != nil && cfg.Enabled {
+			return true
+		}
+	}
+	return s.next != nil && hasAudience(ctx, s.next, inst)
+}
+
+func (s *EventSinks) accepts(ctx context.Context, inst *instance.Instance, name, event string) bool {
+	cfg := s.config(ctx, inst, name)
+	if cfg == nil || !cfg.Enabled {
+		return false
+	}
+	return len(cfg.Events) == 0 || containsEvent(cfg.Events, event) || containsEvent(cfg.Events, "ALL")
+}
+
+// config lê a config do destino pelo cache; erros de leitura não entram no cache.
+func (s *EventSinks) config(ctx context.Context, inst *instance.Instance, name string) *webhook.SinkConfig {
+	if s.configs == nil {
+		return nil
+	}
+	key := string(inst.ID) + " " + name
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.cfg
+	}
+	cfg, err := s.configs.Get(ctx, string(inst.ID), name)
+	if err != nil && !errors.Is(err, repositories.ErrSinkConfigNotFound) {
+		if s.log != nil {
+			s.log.Warnf("event sink %s config lookup failed instance=%s: %v", name, inst.Name, err)
+		}
+		return nil
+	}
+	s.mu.Lock()
+	s.cache[key] = cachedSinkConfig{cfg: cfg, expires: now.Add(eventSinkConfigTTL)}
+	s.mu.Unlock()
+	return cfg
+}
+
+// forget descarta a config em cache após uma alteração feita por este nó.
+func (s *EventSinks) forget(instanceID, name string) {
+	s.mu.Lock()
+	delete(s.cache, instanceID+" "+name)
+	s.mu.Unlock()
+}
+
+// EventSinkService lê e altera a config de um destino de eventos de uma instância.
+type EventSinkService interface {
+	Find(ctx context.Context, instanceName, sink string) (*webhook.SinkConfig, error)
+	Set(ctx context.Context, instanceName, sink string, in webhook.SinkConfigInput) (*webhook.SinkConfig, error)
+}
+
+type eventSinkService struct {
+	instances repositories.InstanceRepository
+	configs   repositories.EventSinkRepository
+	sinks     *EventSinks
+}
+
+func NewEventSinkService(instances repositories.InstanceRepository, configs repositories.EventSinkRepository, sinks *EventSinks) EventSinkService {
+	return &eventSinkService{instances: instances, configs: configs, sinks: sinks}
+}
+
+// Find retorna a config salva; sem config o destino aparece desligado.
+func (s *eventSinkService) Find(ctx context.Context, instanceName, sink string) (*webhook.SinkConfig, error) {
+	inst, err := s.lookup(ctx, instanceName, sink)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := s.configs.Get(ctx, string(inst.ID), sink)
+	if errors.Is(err, repositories.ErrSinkConfigNotFound) {
+		return &webhook.SinkConfig{InstanceID: string(inst.ID), Sink: sink}, nil
+	}
+	return cfg, err
+}
+
+func (s *eventSinkService) Set(ctx context.Context, instanceName, sink string, in webhook.SinkConfigInput) (*webhook.SinkConfig, error) {
+	inst, err := s.lookup(ctx, instanceName, sink)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &webhook.SinkConfig{
+		InstanceID: string(inst.ID),
+		Sink:       sink,
+		Enabled:    in.Enabled,
+		UpdatedAt:  time.Now().UTC(),
+	}
+	for _, event := range in.Events {
+		if event = strings.TrimSpace(event); event != "" {
+			cfg.Events = append(cfg.Events, event)
+		}
+	}
+	if err := s.configs.Save(ctx, cfg); err != nil {
+		return nil, err
+	}
+	s.sinks.forget(cfg.InstanceID, sink)
+	return cfg, nil
+}
+
+func (s *eventSinkService) lookup(ctx context.Context, instanceName, sink string) (*instance.Instance, error) {
+	if s.sinks == nil || s.sinks.sinks[sink] == nil {
+		return nil, ErrUnknownEventSink
+	}
+	return s.instances.GetByName(ctx, instanceName)
+}
@@ -0,0 +1,417 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/message"
+	amqp "github.com/rabbitmq/amqp091-go"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// RabbitMQSinkName identifica o destino RabbitMQ nas rotas /rabbitmq/{set,find}/{instance}.
+const RabbitMQSinkName = "rabbitmq"
+
+var (
+	ErrRabbitMQNack        = errors.New("rabbitmq did not confirm the event")
+	ErrRabbitMQUnavailable = errors.New("rabbitmq publish channel unavailable")
+)
+
+const (
+	// rabbitMQCommandRetryDelay espera antes de devolver à fila um comando com falha transitória.
+	rabbitMQCommandRetryDelay = 5 * time.Second
+	// rabbitMQCommandMaxRetryDelay é a espera quando a cota só renova depois (dia/mês seguinte).
+	rabbitMQCommandMaxRetryDelay = time.Minute
+	// rabbitMQCommandForeignDelay espera antes de devolver um comando de instância de outro nó.
+	rabbitMQCommandForeignDelay = time.Second
+	rabbitMQMaxReconnectBackoff = 30 * time.Second
+)
+
+// rabbitMQCommandDelays são as esperas possíveis de um comando adiado; cada uma tem sua fila de
+// retry com TTL fixo, para que a expiração siga a ordem de chegada.
+var rabbitMQCommandDelays = []time.Duration{rabbitMQCommandForeignDelay, rabbitMQCommandRetryDelay, rabbitMQCommandMaxRetryDelay}
+
+// RabbitMQOptions configura a conexão AMQP 0-9-1 usada para publicar eventos e consumir comandos.
+type RabbitMQOptions struct {
+	URL            string
+	Exchange       string        // exchange topic dos eventos
+	ConfirmTimeout time.Duration // espera pelo publisher confirm de cada evento
+	CommandQueue   string        // fila de comandos de envio; vazio = sem consumidor
+	Prefetch       int
+}
+
+// RabbitMQ publica os eventos no exchange topic com routing key "{instância}.{evento}" e
+// aguarda o publisher confirm de cada um. Sem canal de publicação, Publish falha na hora e a
+// reconexão segue em segundo plano; o consumidor de comandos usa conexão própria e se
+// reconecta com backoff.
+type RabbitMQ struct {
+	opts  RabbitMQOptions
+	log   waLog.Logger
+	local LocalInstances
+
+	mu         sync.Mutex
+	conn       *amqp.Connection
+	ch         *amqp.Channel // canal de publicação, em modo confirm
+	connecting bool
+	closed     bool
+	done       chan struct{}
+}
+
+func NewRabbitMQ(opts RabbitMQOptions, log waLog.Logger) *RabbitMQ {
+	if opts.Exchange == "" {
+		opts.Exchange = "whatsapp.events"
+	}
+	if opts.ConfirmTimeout <= 0 {
+		opts.ConfirmTimeout = 5 * time.Second
+	}
+	if opts.Prefetch <= 0 {
+		opts.Prefetch = 10
+	}
+	return &RabbitMQ{opts: opts, log: log, done: make(chan struct{})}
+}
+
+// SetLocalInstances restringe o consumo de comandos às instâncias cujo lease este nó detém
+// (modo cluster); comandos das demais voltam à fila para o nó dono.
+func (r *RabbitMQ) SetLocalInstances(local LocalInstances) {
+	r.local = local
+}
+
+func (r *RabbitMQ) Name() string { return RabbitMQSinkName }
+
+// RabbitMQRoutingKey monta a routing key do evento, ex.: "loja.messages.upsert".
+func RabbitMQRoutingKey(instanceName, event string) string {
+	return instanceName + "." + event
+}
+
+func (r *RabbitMQ) Publish(ctx context.Context, inst *instance.Instance, event string, body []byte) error {
+	ch, err := r.channel()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.opts.ConfirmTimeout)
+	defer cancel()
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, r.opts.Exchange, RabbitMQRoutingKey(inst.Name, event), false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Type:         event,
+		Body:         body,
+	})
+	if err != nil {
+		r.reset(ch)
+		return err
+	}
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		r.reset(ch)
+		return err
+	}
+	if !acked {
+		return ErrRabbitMQNack
+	}
+	return nil
+}
+
+// Close encerra a conexão de publicação e a reconexão em andamento (shutdown).
+func (r *RabbitMQ) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	r.closed = true
+	close(r.done)
+	if r.conn != nil {
+		_ = r.conn.Close()
+	}
+	r.conn, r.ch = nil, nil
+}
+
+// Connect abre a conexão de publicação em segundo plano, com backoff até conseguir; não faz
+// nada se o canal já está aberto ou uma reconexão está em andamento.
+func (r *RabbitMQ) Connect() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.connecting || (r.ch != nil && !r.ch.IsClosed()) {
+		return
+	}
+	r.connecting = true
+	go r.reconnect()
+}
+
+// channel retorna o canal de publicação sem bloquear: quando caiu, dispara a reconexão e
+// retorna ErrRabbitMQUnavailable.
+func (r *RabbitMQ) channel() (*amqp.Channel, error) {
+	r.mu.Lock()
+	ch := r.ch
+	r.mu.Unlock()
+	if ch != nil && !ch.IsClosed() {
+		return ch, nil
+	}
+	r.Connect()
+	return nil, ErrRabbitMQUnavailable
+}
+
+func (r *RabbitMQ) reconnect() {
+	backoff := time.Second
+	for {
+		conn, ch, err := r.openPublisher()
+		r.mu.Lock()
+		if r.closed {
+			r.connecting = false
+			r.mu.Unlock()
+			if conn != nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		if err == nil {
+			r.conn, r.ch, r.connecting = conn, ch, false
+			r.mu.Unlock()
+			return
+		}
+		r.mu.Unlock()
+		if r.log != nil {
+			r.log.Warnf("rabbitmq publisher connection failed: %v; retrying in %s", err, backoff)
+		}
+		select {
+		case <-r.done:
+			r.mu.Lock()
+			r.connecting = false
+			r.mu.Unlock()
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > rabbitMQMaxReconnectBackoff {
+			backoff = rabbitMQMaxReconnectBackoff
+		}
+	}
+}
+
+// openPublisher abre o canal em modo confirm, discando uma nova conexão só quando a atual
+// caiu. Roda fora de mu para que Publish não espere pelo dial.
+func (r *RabbitMQ) openPublisher() (*amqp.Connection, *amqp.Channel, error) {
+	r.mu.Lock()
+	conn := r.conn
+	r.mu.Unlock()
+	if conn == nil || conn.IsClosed() {
+		var err error
+		if conn, err = amqp.Dial(r.opts.URL); err != nil {
+			return nil, nil, err
+		}
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+	if err := ch.ExchangeDeclare(r.opts.Exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+	return conn, ch, nil
+}
+
+// reset descarta o canal com falha e já inicia a reconexão para a próxima publicação.
+func (r *RabbitMQ) reset(ch *amqp.Channel) {
+	r.mu.Lock()
+	if r.ch == ch {
+		_ = ch.Close()
+		r.ch = nil
+	}
+	r.mu.Unlock()
+	r.Connect()
+}
+
+// RunCommands consome a fila de comandos até ctx ser cancelado, executando cada envio com
+// ExecuteMessageCommand. Comandos executados são confirmados (ack). Falhas transitórias
+// (instância desconectada, cota excedida) e comandos de instâncias de outro nó passam por uma
+// fila de retry com TTL e voltam à fila depois da espera; inválidos ou com falha definitiva são rejeitados sem requeue, indo para o
+// dead-letter exchange da fila quando configurado. Com reply_to, o resultado final é
+// publicado nessa fila com o mesmo correlation_id.
+func (r *RabbitMQ) RunCommands(ctx context.Context, messages MessageService) {
+	if r.opts.CommandQueue == "" {
+		return
+	}
+	backoff := time.Second
+	for ctx.Err() == nil {
+		err := r.consumeCommands(ctx, messages)
+		if ctx.Err() != nil {
+			return
+		}
+		if r.log != nil {
+			r.log.Warnf("rabbitmq command consumer stopped queue=%s: %v; retrying in %s", r.opts.CommandQueue, err, backoff)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > rabbitMQMaxReconnectBackoff {
+			backoff = rabbitMQMaxReconnectBackoff
+		}
+	}
+}
+
+func (r *RabbitMQ) consumeCommands(ctx context.Context, messages MessageService) error {
+	conn, err := amqp.Dial(r.opts.URL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+	if _, err := ch.QueueDeclare(r.opts.CommandQueue, true, false, false, false, nil); err != nil {
+		return err
+	}
+	for _, delay := range rabbitMQCommandDelays {
+		if _, err := ch.QueueDeclare(rabbitMQRetryQueue(r.opts.CommandQueue, delay), true, false, false, false, amqp.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": r.opts.CommandQueue,
+		}); err != nil {
+			return err
+		}
+	}
+	// Confirm garante que o comando adiado chegou à fila de retry antes do ack do original.
+	if err := ch.Confirm(false); err != nil {
+		return err
+	}
+	if err := ch.Qos(r.opts.Prefetch, 0, false); err != nil {
+		return err
+	}
+	deliveries, err := ch.ConsumeWithContext(ctx, r.opts.CommandQueue, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	if r.log != nil {
+		r.log.Infof("rabbitmq command consumer started queue=%s", r.opts.CommandQueue)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case d, ok := <-deliveries:
+			if !ok {
+				return errors.New("delivery channel closed")
+			}
+			r.handleCommand(ctx, ch, d, messages)
+		}
+	}
+}
+
+func (r *RabbitMQ) handleCommand(ctx context.Context, ch *amqp.Channel, d amqp.Delivery, messages MessageService) {
+	// O comando recebido vai até o fim mesmo com o consumo sendo encerrado.
+	ctx = context.WithoutCancel(ctx)
+	var cmd MessageCommand
+	result := MessageCommandResult{Status: "sent"}
+	err := json.Unmarshal(d.Body, &cmd)
+	if err == nil {
+		result.ID, result.Instance, result.Type = cmd.ID, cmd.Instance, cmd.Type
+		if name := strings.TrimSpace(cmd.Instance); name != "" && r.local != nil && !r.local.Owns(name) {
+			r.deferCommand(ctx, ch, d, rabbitMQCommandForeignDelay)
+			return
+		}
+		var out message.SendTextOutput
+		if out, err = ExecuteMessageCommand(ctx, messages, cmd); err == nil {
+			result.Message = &out
+		}
+	}
+	if delay, ok := commandRetryDelay(err, time.Now()); ok {
+		if r.log != nil {
+			r.log.Warnf("rabbitmq command deferred id=%s instance=%s type=%s: %v; requeue in %s", cmd.ID, cmd.Instance, cmd.Type, err, delay)
+		}
+		r.deferCommand(ctx, ch, d, delay)
+		return
+	}
+	if err != nil {
+		result.Status, result.Error = "error", err.Error()
+		if r.log != nil {
+			r.log.Warnf("rabbitmq command failed id=%s instance=%s type=%s: %v", cmd.ID, cmd.Instance, cmd.Type, err)
+		}
+		_ = d.Nack(false, false)
+	} else {
+		_ = d.Ack(false)
+	}
+	if d.ReplyTo == "" {
+		return
+	}
+	body, _ := json.Marshal(result)
+	if err := ch.PublishWithContext(ctx, "", d.ReplyTo, false, false, amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: d.CorrelationId,
+		Body:          body,
+	}); err != nil && r.log != nil {
+		r.log.Warnf("rabbitmq command reply failed reply_to=%s: %v", d.ReplyTo, err)
+	}
+}
+
+// rabbitMQRetryQueue é a fila sem consumidores onde o comando espera delay; ao expirar ele volta
+// para a fila de comandos pelo dead-letter, ex.: "whatsapp.commands.retry.5s".
+func rabbitMQRetryQueue(queue string, delay time.Duration) string {
+	return queue + ".retry." + delay.String()
+}
+
+// deferCommand republica o comando na fila de retry de delay e confirma o original, de modo que
+// o comando adiado não ocupa o prefetch do consumidor. Sem o confirm do broker o original volta
+// à fila de comandos na hora.
+func (r *RabbitMQ) deferCommand(ctx context.Context, ch *amqp.Channel, d amqp.Delivery, delay time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, r.opts.ConfirmTimeout)
+	defer cancel()
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", rabbitMQRetryQueue(r.opts.CommandQueue, delay), false, false, amqp.Publishing{
+		Headers:       d.Headers,
+		ContentType:   d.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: d.CorrelationId,
+		ReplyTo:       d.ReplyTo,
+		MessageId:     d.MessageId,
+		Timestamp:     d.Timestamp,
+		Type:          d.Type,
+		Body:          d.Body,
+	})
+	if err == nil {
+		var acked bool
+		if acked, err = confirm.WaitContext(ctx); err == nil && !acked {
+			err = ErrRabbitMQNack
+		}
+	}
+	if err != nil {
+		if r.log != nil {
+			r.log.Warnf("rabbitmq command defer failed: %v; requeueing", err)
+		}
+		_ = d.Nack(false, true)
+		return
+	}
+	_ = d.Ack(false)
+}
+
+// commandRetryDelay indica se a falha é transitória, sem nada enviado, e qual das
+// rabbitMQCommandDelays esperar antes de devolver o comando à fila.
+func commandRetryDelay(err error, now time.Time) (time.Duration, bool) {
+	var quotaErr *QuotaExceededError
+	switch {
+	case err == nil:
+		return 0, false
+	case errors.As(err, &quotaErr):
+		if quotaErr.ResetAt.Sub(now) <= rabbitMQCommandRetryDelay {
+			return rabbitMQCommandRetryDelay, true
+		}
+		return rabbitMQCommandMaxRetryDelay, true
+	case errors.Is(err, ErrInstanceNotConnected), errors.Is(err, ErrInstanceNotReady):
+		return rabbitMQCommandRetryDelay, true
+	default:
+		return 0, false
+	}
+}
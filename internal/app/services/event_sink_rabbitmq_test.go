@@ -0,0 +1,45 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCommandRetryDelay(t *testing.T) {
+	now := time.Date(2026, 3, 10, 23, 59, 30, 0, time.UTC)
+	cases := []struct {
+		name  string
+		err   error
+		delay time.Duration
+		retry bool
+	}{
+		{"sent", nil, 0, false},
+		{"disconnected", fmt.Errorf("send: %w", ErrInstanceNotConnected), rabbitMQCommandRetryDelay, true},
+		{"not ready", ErrInstanceNotReady, rabbitMQCommandRetryDelay, true},
+		{"quota renews soon", &QuotaExceededError{ResetAt: now.Add(3 * time.Second)}, rabbitMQCommandRetryDelay, true},
+		{"quota renews in a minute", &QuotaExceededError{ResetAt: now.Add(30 * time.Second)}, rabbitMQCommandMaxRetryDelay, true},
+		{"quota renews next month", &QuotaExceededError{ResetAt: now.AddDate(0, 1, 0)}, rabbitMQCommandMaxRetryDelay, true},
+		{"invalid", errors.New("number is required"), 0, false},
+		{"unknown type", ErrUnknownMessageCommand, 0, false},
+	}
+	for _, c := range cases {
+		delay, retry := commandRetryDelay(c.err, now)
+		if delay != c.delay || retry != c.retry {
+			t.Fatalf("%s: expected (%s, %v), got (%s, %v)", c.name, c.delay, c.retry, delay, retry)
+		}
+	}
+}
+
+func TestRabbitMQRetryQueue(t *testing.T) {
+	names := map[string]bool{}
+	for _, delay := range rabbitMQCommandDelays {
+		names[rabbitMQRetryQueue("whatsapp.commands", delay)] = true
+	}
+	for _, want := range []string{"whatsapp.commands.retry.1s", "whatsapp.commands.retry.5s", "whatsapp.commands.retry.1m0s"} {
+		if !names[want] {
+			t.Fatalf("expected retry queue %s, got %v", want, names)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStreamSinkName identifica o destino Redis Streams nas rotas /redis/{set,find}/{instance}.
+const RedisStreamSinkName = "redis"
+
+// RedisStreamOptions define para quais streams os eventos vão.
+type RedisStreamOptions struct {
+	Stream      string // stream global; com PerInstance, prefixo dos streams "<Stream>:<instância>"
+	PerInstance bool
+	MaxLen      int64 // corte aproximado (MAXLEN ~) a cada XADD; 0 = sem limite
+}
+
+// RedisStreamSink publica os eventos com XADD; os consumidores leem com consumer groups
+// (XREADGROUP) e confirmam com XACK. Campos de cada entrada: event, instance e payload (o
+// mesmo JSON do webhook).
+type RedisStreamSink struct {
+	client redis.UniversalClient
+	opts   RedisStreamOptions
+}
+
+func NewRedisStreamSink(client redis.UniversalClient, opts RedisStreamOptions) *RedisStreamSink {
+	if opts.Stream == "" {
+		opts.Stream = "whatsapp:events"
+	}
+	return &RedisStreamSink{client: client, opts: opts}
+}
+
+func (s *RedisStreamSink) Name() string { return RedisStreamSinkName }
+
+// StreamFor retorna o stream que recebe os eventos da instância.
+func (s *RedisStreamSink) StreamFor(instanceName string) string {
+	if s.opts.PerInstance {
+		return s.opts.Stream + ":" + instanceName
+	}
+	return s.opts.Stream
+}
+
+func (s *RedisStreamSink) Publish(ctx context.Context, inst *instance.Instance, event string, body []byte) error {
+	return s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.StreamFor(inst.Name),
+		MaxLen: s.opts.MaxLen,
+		Approx: s.opts.MaxLen > 0,
+		Values: []any{"event", event, "instance", inst.Name, "payload", string(body)},
+	}).Err()
+}
@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// eventStreamBuffer é o número de eventos pendentes por assinante antes de derrubá-lo.
+const eventStreamBuffer = 256
+
+// eventStreamBacklogLimit limita quantos eventos do journal são reenviados numa retomada.
+const eventStreamBacklogLimit = 500
+
+// eventStreamGapGrace é quanto o tail do journal espera por um seq ainda não visível (gravação
+// concorrente de outro nó ainda sem commit) antes de seguir adiante sem ele.
+const eventStreamGapGrace = 2 * time.Second
+
+// StreamGapEvent é o quadro enviado quando a retomada não coube no limite do backlog: os eventos
+// posteriores ao id do quadro não foram reenviados e o cliente deve retomar a partir dele.
+const StreamGapEvent = "stream.gap"
+
+var (
+	ErrStreamResumeUnavailable = errors.New("stream resume requires the event journal")
+	ErrInvalidStreamCursor     = errors.New("invalid stream cursor")
+)
+
+// StreamEvent é o quadro dos streams de eventos: o mesmo envelope do webhook (event, instance,
+// timestamp, data) mais o cursor id, a posição do evento no journal, usado para retomar o stream.
+type StreamEvent struct {
+	ID        string `json:"id"`
+	Event     string `json:"event"`
+	Instance  string `json:"instance"`
+	Timestamp string `json:"timestamp"`
+	Data      any    `json:"data"`
+}
+
+// EventStreamFilter seleciona os eventos de um stream; listas vazias não filtram.
+type EventStreamFilter struct {
+	Instances []string
+	Events    []string
+}
+
+func (f EventStreamFilter) matches(instanceName, event string) bool {
+	if len(f.Events) > 0 && !containsEvent(f.Events, event) {
+		return false
+	}
+	return f.includes(instanceName)
+}
+
+func (f EventStreamFilter) includes(instanceName string) bool {
+	if len(f.Instances) == 0 {
+		return true
+	}
+	for _, name := range f.Instances {
+		if name == instanceName {
+			return true
+		}
+	}
+	return false
+}
+
+type eventSubscriber struct {
+	ch     chan StreamEvent
+	filter EventStreamFilter
+}
+
+// EventStreams entrega os eventos das instâncias aos streams WebSocket/SSE abertos, como
+// alternativa aos webhooks para clientes que não recebem conexões. Fica na frente do
+// dispatcher de webhooks e repassa cada evento a ele.
+type EventStreams struct {
+	next      WebhookDispatcher
+	journal   repositories.EventJournalRepository
+	retention time.Duration
+	tail      time.Duration
+	log       waLog.Logger
+
+	mu   sync.Mutex
+	subs map[*eventSubscriber]struct{}
+}
+
+func NewEventStreams(next WebhookDispatcher, log waLog.Logger) *EventStreams {
+	return &EventStreams{next: next, log: log, subs: make(map[*eventSubscriber]struct{})}
+}
+
+// SetJournal habilita a retomada: cada evento é gravado no journal e os posteriores ao cursor são
+// lidos dele. Run remove os eventos mais antigos que retention (0 = sem limpeza).
+func (s *EventStreams) SetJournal(repo repositories.EventJournalRepository, retention time.Duration) {
+	s.journal = repo
+	s.retention = retention
+}
+
+// TailJournal faz os quadros ao vivo virem do journal, lido a cada interval, e não dos eventos
+// despachados neste nó. Em modo cluster o journal é compartilhado: o stream cobre as instâncias
+// de todos os nós, com a mesma ordem e os mesmos cursores da retomada. Como o assinante pode
+// estar em outro nó, todo evento passa a ter audiência e é gravado no journal.
+func (s *EventStreams) TailJournal(interval time.Duration) {
+	s.tail = interval
+}
+
+// Run poda o journal a cada hora e, com TailJournal, publica os eventos novos do journal até ctx
+// ser cancelado.
+func (s *EventStreams) Run(ctx context.Context) {
+	if s.journal == nil {
+		return
+	}
+	if s.tail > 0 {
+		go s.runTail(ctx)
+	}
+	if s.retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		removed, err := s.journal.Prune(ctx, time.Now().Add(-s.retention))
+		if err != nil && s.log != nil && ctx.Err() == nil {
+			s.log.Errorf("event journal prune failed: %v", err)
+		} else if removed > 0 && s.log != nil {
+			s.log.Infof("event journal pruned %d event(s)", removed)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch grava o evento no journal, repassa-o aos webhooks e o publica nos streams com a
+// posição do journal como cursor. Todo evento despachado entra no journal, tenha ou não um
+// webhook como destino; eventos de instâncias sem webhook, sink nem stream aberto não chegam a
+// ser gerados.
+func (s *EventStreams) Dispatch(ctx context.Context, inst *instance.Instance, event string, payload map[string]any) (bool, error) {
+	var cursor string
+	now := time.Now().UTC()
+	if inst != nil && s.journal != nil {
+		cursor = s.record(ctx, inst, event, payload, now)
+	}
+	var (
+		delivered bool
+		err       error
+	)
+	if s.next != nil {
+		delivered, err = s.next.Dispatch(ctx, inst, event, payload)
+	}
+	// Com o tail, o quadro vem do journal; só o evento que não foi gravado sai daqui.
+	if inst != nil && (s.tail <= 0 || cursor == "") {
+		s.publish(StreamEvent{
+			ID:        cursor,
+			Event:     event,
+			Instance:  inst.Name,
+			Timestamp: now.Format(time.RFC3339),
+			Data:      payload,
+		})
+	}
+	return delivered, err
+}
+
+// record grava o evento no journal e retorna o cursor; sem gravação o quadro segue sem cursor.
+func (s *EventStreams) record(ctx context.Context, inst *instance.Instance, event string, payload map[string]any, now time.Time) string {
+	body, err := webhookBody(inst, event, payload, now)
+	if err == nil {
+		entry := &webhook.JournalEvent{
+			InstanceID:   string(inst.ID),
+			InstanceName: inst.Name,
+			Event:        canonicalEventName(event),
+			Payload:      body,
+			CreatedAt:    now,
+		}
+		if err = s.journal.Append(ctx, entry); err == nil {
+			return formatStreamCursor(entry.Seq)
+		}
+	}
+	if s.log != nil {
+		s.log.Warnf("event journal append failed instance=%s event=%s: %v", inst.Name, event, err)
+	}
+	return ""
+}
+
+// HasAudience considera também os streams abertos para a instância.
+func (s *EventStreams) HasAudience(ctx context.Context, inst *instance.Instance) bool {
+	if inst == nil {
+		return false
+	}
+	if s.tail > 0 && s.journal != nil {
+		return true
+	}
+	s.mu.Lock()
+	for sub := range s.subs {
+		if sub.filter.includes(inst.Name) {
+			s.mu.Unlock()
+			return true
+		}
+	}
+	s.mu.Unlock()
+	return s.next != nil && hasAudience(ctx, s.next, inst)
+}
+
+// Subscribe abre um stream. O canal é fechado quando o assinante não acompanha o ritmo;
+// cancel deve ser chamado ao encerrar.
+func (s *EventStreams) Subscribe(filter EventStreamFilter) (<-chan StreamEvent, func()) {
+	sub := &eventSubscriber{ch: make(chan StreamEvent, eventStreamBuffer), filter: filter}
+	s.mu.Lock()
+	s.subs[sub] = struct{}{}
+	s.mu.Unlock()
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() { s.remove(sub) })
+	}
+}
+
+// Backlog retorna, do mais antigo para o mais recente, até eventStreamBacklogLimit eventos do
+// journal posteriores ao cursor. Quando há mais eventos que o limite, o último quadro é um
+// StreamGapEvent com o cursor a partir do qual o cliente deve retomar.
+func (s *EventStreams) Backlog(ctx context.Context, filter EventStreamFilter, cursor string) ([]StreamEvent, error) {
+	if s.journal == nil {
+		return nil, ErrStreamResumeUnavailable
+	}
+	after, err := parseStreamCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	query := webhook.JournalFilter{AfterSeq: after, Instances: filter.Instances, Limit: eventStreamBacklogLimit + 1}
+	for _, event := range filter.Events {
+		query.Events = append(query.Events, canonicalEventName(event))
+	}
+	entries, err := s.journal.After(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	truncated := len(entries) > eventStreamBacklogLimit
+	if truncated {
+		entries = entries[:eventStreamBacklogLimit]
+	}
+	out := make([]StreamEvent, 0, len(entries)+1)
+	for _, entry := range entries {
+		if frame, ok := journalFrame(entry); ok {
+			out = append(out, frame)
+		}
+	}
+	if truncated {
+		last := formatStreamCursor(entries[len(entries)-1].Seq)
+		out = append(out, StreamEvent{
+			ID:        last,
+			Event:     StreamGapEvent,
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Data:      map[string]any{"reason": "backlog_limit", "limit": eventStreamBacklogLimit, "resumeFrom": last},
+		})
+	}
+	return out, nil
+}
+
+// journalFrame monta o quadro a partir do corpo gravado no journal.
+func journalFrame(entry *webhook.JournalEvent) (StreamEvent, bool) {
+	var body struct {
+		Event     string          `json:"event"`
+		Instance  string          `json:"instance"`
+		Timestamp string          `json:"timestamp"`
+		Data      json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(entry.Payload, &body); err != nil {
+		return StreamEvent{}, false
+	}
+	return StreamEvent{
+		ID:        formatStreamCursor(entry.Seq),
+		Event:     body.Event,
+		Instance:  body.Instance,
+		Timestamp: body.Timestamp,
+		Data:      body.Data,
+	}, true
+}
+
+// runTail publica, a partir do fim atual do journal, cada evento gravado por qualquer nó.
+func (s *EventStreams) runTail(ctx context.Context) {
+	ticker := time.NewTicker(s.tail)
+	defer ticker.Stop()
+	var (
+		last    int64
+		started bool
+	)
+	for {
+		if !started {
+			seq, err := s.journal.Last(ctx)
+			if err == nil {
+				last, started = seq, true
+			} else if s.log != nil && ctx.Err() == nil {
+				s.log.Errorf("event journal tail failed: %v", err)
+			}
+		} else {
+			last = s.tailFrom(ctx, last)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tailFrom publica os eventos posteriores a last e retorna o novo fim. Um seq ausente recente
+// pode ser gravação ainda sem commit: a leitura para nele até eventStreamGapGrace.
+func (s *EventStreams) tailFrom(ctx context.Context, last int64) int64 {
+	for {
+		entries, err := s.journal.After(ctx, webhook.JournalFilter{AfterSeq: last, Limit: eventStreamBacklogLimit})
+		if err != nil {
+			if s.log != nil && ctx.Err() == nil {
+				s.log.Errorf("event journal tail failed: %v", err)
+			}
+			return last
+		}
+		for _, entry := range entries {
+			if entry.Seq != last+1 && time.Since(entry.CreatedAt) < eventStreamGapGrace {
+				return last
+			}
+			if frame, ok := journalFrame(entry); ok {
+				s.publish(frame)
+			}
+			last = entry.Seq
+		}
+		if len(entries) < eventStreamBacklogLimit {
+			return last
+		}
+	}
+}
+
+// CloseAll encerra todos os streams abertos (shutdown); os handlers terminam a resposta.
+func (s *EventStreams) CloseAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subs {
+		close(sub.ch)
+		delete(s.subs, sub)
+	}
+}
+
+func (s *EventStreams) publish(evt StreamEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subs {
+		if !sub.filter.matches(evt.Instance, evt.Event) {
+			continue
+		}
+		select {
+		case sub.ch <- evt:
+		default:
+			// Assinante lento: encerra o stream; o cliente retoma a partir do último cursor.
+			if s.log != nil {
+				s.log.Warnf("event stream dropped instance=%s: subscriber too slow", evt.Instance)
+			}
+			delete(s.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+func (s *EventStreams) remove(sub *eventSubscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[sub]; !ok {
+		return
+	}
+	delete(s.subs, sub)
+	close(sub.ch)
+}
+
+// O cursor é a posição (seq) do evento no journal, crescente na ordem de gravação.
+func formatStreamCursor(seq int64) string {
+	return strconv.FormatInt(seq, 10)
+}
+
+func parseStreamCursor(cursor string) (int64, error) {
+	seq, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidStreamCursor, cursor)
+	}
+	return seq, nil
+}
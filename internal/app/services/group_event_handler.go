@@ -0,0 +1,369 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+const (
+	eventGroupsUpsert            = "groups.upsert"
+	eventGroupsUpdate            = "groups.update"
+	eventGroupParticipantsUpdate = "group-participants.update"
+)
+
+// Ações de group-participants.update no formato Evolution/Baileys.
+const (
+	participantActionAdd     = "add"
+	participantActionRemove  = "remove"
+	participantActionPromote = "promote"
+	participantActionDemote  = "demote"
+)
+
+// GroupEventListener consome os eventos de grupo do WhatsApp publicados no webhook da instância.
+type GroupEventListener interface {
+	HandleGroupInfo(ctx context.Context, instanceName string, evt *events.GroupInfo)
+	HandleJoinedGroup(ctx context.Context, instanceName string, evt *events.JoinedGroup)
+	HandleGroupPicture(ctx context.Context, instanceName string, evt *events.Picture)
+}
+
+// groupParticipant é um participante com telefone e nome resolvidos para o webhook.
+type groupParticipant struct {
+	JID   string
+	Phone string
+	Name  string
+}
+
+// participantResolver resolve o JID (LID → telefone), o telefone e o nome de um participante.
+type participantResolver func(jid types.JID) groupParticipant
+
+// GroupEventHandler publica groups.upsert, groups.update e group-participants.update.
+type GroupEventHandler struct {
+	repo       repositories.InstanceRepository
+	waMgr      *whatsapp.Manager
+	dispatcher WebhookDispatcher
+	log        waLog.Logger
+}
+
+func NewGroupEventHandler(repo repositories.InstanceRepository, waMgr *whatsapp.Manager, dispatcher WebhookDispatcher, log waLog.Logger) *GroupEventHandler {
+	return &GroupEventHandler{repo: repo, waMgr: waMgr, dispatcher: dispatcher, log: log}
+}
+
+// HandleGroupInfo publica as mudanças de participantes (uma entrega por ação) e as de
+// metadados e configurações do grupo.
+func (h *GroupEventHandler) HandleGroupInfo(ctx context.Context, instanceName string, evt *events.GroupInfo) {
+	if evt == nil {
+		return
+	}
+	inst, client, ok := h.prepare(ctx, instanceName)
+	if !ok {
+		return
+	}
+	resolve := newParticipantResolver(ctx, client)
+	for _, payload := range buildGroupParticipantsUpdates(evt, resolve) {
+		h.dispatch(inst, eventGroupParticipantsUpdate, payload)
+	}
+	if payload := buildGroupUpdate(evt, resolve); payload != nil {
+		h.dispatch(inst, eventGroupsUpdate, payload)
+	}
+}
+
+// HandleJoinedGroup publica groups.upsert quando a instância entra ou é adicionada a um grupo.
+func (h *GroupEventHandler) HandleJoinedGroup(ctx context.Context, instanceName string, evt *events.JoinedGroup) {
+	if evt == nil {
+		return
+	}
+	inst, client, ok := h.prepare(ctx, instanceName)
+	if !ok {
+		return
+	}
+	h.dispatch(inst, eventGroupsUpsert, buildGroupUpsert(evt, newParticipantResolver(ctx, client)))
+}
+
+// HandleGroupPicture publica groups.update para troca ou remoção da foto de um grupo; fotos de
+// contatos são ignoradas.
+func (h *GroupEventHandler) HandleGroupPicture(ctx context.Context, instanceName string, evt *events.Picture) {
+	if evt == nil || evt.JID.Server != types.GroupServer {
+		return
+	}
+	inst, client, ok := h.prepare(ctx, instanceName)
+	if !ok {
+		return
+	}
+	h.dispatch(inst, eventGroupsUpdate, buildGroupPictureUpdate(evt, newParticipantResolver(ctx, client)))
+}
+
+// prepare carrega a instância e a sessão e confirma que alguém vai receber o evento.
+func (h *GroupEventHandler) prepare(ctx context.Context, instanceName string) (*instance.Instance, *whatsmeow.Client, bool) {
+	if h == nil || h.repo == nil || h.waMgr == nil || h.dispatcher == nil {
+		return nil, nil, false
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	sess, ok := h.waMgr.Get(instanceName)
+	if !ok || sess == nil || sess.Client == nil {
+		return nil, nil, false
+	}
+	inst, err := h.repo.GetByName(ctx, instanceName)
+	if err != nil {
+		if !errors.Is(err, repositories.ErrInstanceNotFound) && h.log != nil {
+			h.log.Errorf("group events instance=%s repository error: %v", instanceName, err)
+		}
+		return nil, nil, false
+	}
+	if !hasAudience(ctx, h.dispatcher, inst) {
+		return nil, nil, false
+	}
+	return inst, sess.Client, true
+}
+
+func (h *GroupEventHandler) dispatch(inst *instance.Instance, event string, payload map[string]any) {
+	dispatchCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := h.dispatcher.Dispatch(dispatchCtx, inst, event, payload); err != nil {
+		if h.log != nil {
+			h.log.Errorf("%s instance=%s group=%v dispatch error: %v", event, inst.Name, payload["id"], err)
+		}
+	} else if h.log != nil {
+		h.log.Debugf("%s instance=%s group=%v", event, inst.Name, payload["id"])
+	}
+}
+
+// newParticipantResolver resolve os participantes como resolveMemberContact, buscando o nome
+// na agenda do device.
+func newParticipantResolver(ctx context.Context, client *whatsmeow.Client) participantResolver {
+	return func(jid types.JID) groupParticipant {
+		jidStr, phone := resolveMemberContact(ctx, client, jid)
+		p := groupParticipant{JID: jidStr, Phone: phone}
+		if client == nil || client.Store == nil || client.Store.Contacts == nil {
+			return p
+		}
+		lookup := jid.ToNonAD()
+		if phone != "" {
+			lookup = types.NewJID(phone, types.DefaultUserServer)
+		}
+		if contact, err := client.Store.Contacts.GetContact(ctx, lookup); err == nil {
+			p.Name = contactDisplayName(contact)
+		}
+		return p
+	}
+}
+
+func contactDisplayName(contact types.ContactInfo) string {
+	for _, name := range []string{contact.FullName, contact.PushName, contact.BusinessName, contact.FirstName} {
+		if name = strings.TrimSpace(name); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// buildGroupParticipantsUpdates monta um group-participants.update para cada ação presente no evento.
+func buildGroupParticipantsUpdates(evt *events.GroupInfo, resolve participantResolver) []map[string]any {
+	changes := []struct {
+		action string
+		jids   []types.JID
+	}{
+		{participantActionAdd, evt.Join},
+		{participantActionRemove, evt.Leave},
+		{participantActionPromote, evt.Promote},
+		{participantActionDemote, evt.Demote},
+	}
+	var out []map[string]any
+	for _, change := range changes {
+		if len(change.jids) == 0 {
+			continue
+		}
+		ids := make([]string, 0, len(change.jids))
+		data := make([]map[string]any, 0, len(change.jids))
+		for _, jid := range change.jids {
+			p := resolve(jid)
+			ids = append(ids, p.JID)
+			data = append(data, participantPayload(p))
+		}
+		payload := groupEventBase(evt.JID, evt.Sender, evt.SenderPN, evt.Timestamp, resolve)
+		payload["action"] = change.action
+		payload["participants"] = ids
+		payload["participantsData"] = data
+		if change.action == participantActionAdd && evt.JoinReason != "" {
+			payload["reason"] = evt.JoinReason
+		}
+		out = append(out, payload)
+	}
+	return out
+}
+
+// buildGroupUpdate monta o groups.update com os metadados e configurações alterados; nil
+// quando o evento só traz mudanças de participantes.
+func buildGroupUpdate(evt *events.GroupInfo, resolve participantResolver) map[string]any {
+	payload := groupEventBase(evt.JID, evt.Sender, evt.SenderPN, evt.Timestamp, resolve)
+	base := len(payload)
+	if evt.Name != nil {
+		payload["subject"] = evt.Name.Name
+		if !evt.Name.NameSetBy.IsEmpty() {
+			payload["subjectOwner"] = resolve(evt.Name.NameSetBy).JID
+		}
+		if !evt.Name.NameSetAt.IsZero() {
+			payload["subjectTime"] = evt.Name.NameSetAt.Unix()
+		}
+	}
+	if evt.Topic != nil {
+		if evt.Topic.TopicDeleted {
+			payload["desc"] = ""
+		} else {
+			payload["desc"] = evt.Topic.Topic
+		}
+		if evt.Topic.TopicID != "" {
+			payload["descId"] = evt.Topic.TopicID
+		}
+		if !evt.Topic.TopicSetBy.IsEmpty() {
+			payload["descOwner"] = resolve(evt.Topic.TopicSetBy).JID
+		}
+	}
+	if evt.Locked != nil {
+		payload["restrict"] = evt.Locked.IsLocked
+	}
+	if evt.Announce != nil {
+		payload["announce"] = evt.Announce.IsAnnounce
+	}
+	if evt.Ephemeral != nil {
+		duration := uint32(0)
+		if evt.Ephemeral.IsEphemeral {
+			duration = evt.Ephemeral.DisappearingTimer
+		}
+		payload["ephemeralDuration"] = duration
+	}
+	if evt.MembershipApprovalMode != nil {
+		mode := "off"
+		if evt.MembershipApprovalMode.IsJoinApprovalRequired {
+			mode = "on"
+		}
+		payload["joinApprovalMode"] = mode
+	}
+	if evt.NewInviteLink != nil {
+		payload["inviteCode"] = extractInviteCode(*evt.NewInviteLink)
+	}
+	if evt.Delete != nil && evt.Delete.Deleted {
+		payload["deleted"] = true
+		if evt.Delete.DeleteReason != "" {
+			payload["deleteReason"] = evt.Delete.DeleteReason
+		}
+	}
+	if len(payload) == base {
+		return nil
+	}
+	return payload
+}
+
+// buildGroupPictureUpdate monta o groups.update de troca ou remoção da foto do grupo.
+func buildGroupPictureUpdate(evt *events.Picture, resolve participantResolver) map[string]any {
+	var author *types.JID
+	if !evt.Author.IsEmpty() {
+		author = &evt.Author
+	}
+	payload := groupEventBase(evt.JID, author, nil, evt.Timestamp, resolve)
+	payload["pictureRemoved"] = evt.Remove
+	if !evt.Remove && evt.PictureID != "" {
+		payload["pictureId"] = evt.PictureID
+	}
+	return payload
+}
+
+// buildGroupUpsert monta o groups.upsert com os metadados do grupo em que a instância entrou.
+func buildGroupUpsert(evt *events.JoinedGroup, resolve participantResolver) map[string]any {
+	info := evt.GroupInfo
+	participants := make([]map[string]any, 0, len(info.Participants))
+	for _, part := range info.Participants {
+		p := resolve(part.JID)
+		if p.Phone == "" && !part.PhoneNumber.IsEmpty() {
+			p.Phone = part.PhoneNumber.User
+		}
+		if p.Name == "" {
+			p.Name = strings.TrimSpace(part.DisplayName)
+		}
+		entry := participantPayload(p)
+		switch {
+		case part.IsSuperAdmin:
+			entry["admin"] = "superadmin"
+		case part.IsAdmin:
+			entry["admin"] = "admin"
+		default:
+			entry["admin"] = nil
+		}
+		participants = append(participants, entry)
+	}
+	payload := groupEventBase(info.JID, evt.Sender, evt.SenderPN, time.Time{}, resolve)
+	payload["subject"] = info.GroupName.Name
+	payload["subjectOwner"] = jidString(info.GroupName.NameSetBy)
+	payload["subjectTime"] = unixOrZero(info.GroupName.NameSetAt)
+	payload["size"] = len(info.Participants)
+	payload["creation"] = unixOrZero(info.GroupCreated)
+	payload["owner"] = jidString(info.OwnerJID)
+	payload["desc"] = info.GroupTopic.Topic
+	payload["descId"] = info.GroupTopic.TopicID
+	payload["restrict"] = info.GroupLocked.IsLocked
+	payload["announce"] = info.GroupAnnounce.IsAnnounce
+	payload["isCommunity"] = info.GroupParent.IsParent
+	payload["linkedParent"] = jidString(info.GroupLinkedParent.LinkedParentJID)
+	payload["participants"] = participants
+	if evt.Reason != "" {
+		payload["reason"] = evt.Reason
+	}
+	if evt.Type != "" {
+		payload["type"] = evt.Type
+	}
+	return payload
+}
+
+// groupEventBase traz os campos comuns: id do grupo, autor da mudança e horário.
+func groupEventBase(group types.JID, sender, senderPN *types.JID, ts time.Time, resolve participantResolver) map[string]any {
+	if ts.IsZero() {
+		ts = time.Now().UTC()
+	}
+	payload := map[string]any{
+		"id":        group.String(),
+		"timestamp": ts.Unix(),
+	}
+	switch {
+	case senderPN != nil && !senderPN.IsEmpty():
+		payload["author"] = senderPN.ToNonAD().String()
+		payload["authorPhone"] = senderPN.User
+	case sender != nil && !sender.IsEmpty():
+		author := resolve(sender.ToNonAD())
+		payload["author"] = author.JID
+		if author.Phone != "" {
+			payload["authorPhone"] = author.Phone
+		}
+	}
+	return payload
+}
+
+func participantPayload(p groupParticipant) map[string]any {
+	entry := map[string]any{"id": p.JID}
+	if p.Phone != "" {
+		entry["phoneNumber"] = p.Phone
+	}
+	if p.Name != "" {
+		entry["name"] = p.Name
+	}
+	return entry
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+var _ GroupEventListener = (*GroupEventHandler)(nil)
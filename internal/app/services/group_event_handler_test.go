@@ -0,0 +1,136 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// stubResolver resolve LIDs conhecidos para o telefone, como resolveMemberContact.
+func stubResolver(pn map[string]string, names map[string]string) participantResolver {
+	return func(jid types.JID) groupParticipant {
+		p := groupParticipant{JID: jid.String()}
+		if phone, ok := pn[jid.User]; ok {
+			p.JID = types.NewJID(phone, types.DefaultUserServer).String()
+			p.Phone = phone
+		} else if jid.Server == types.DefaultUserServer {
+			p.Phone = jid.User
+		}
+		p.Name = names[p.Phone]
+		return p
+	}
+}
+
+func TestBuildGroupParticipantsUpdates(t *testing.T) {
+	group := types.NewJID("120363000000000001", types.GroupServer)
+	admin := types.NewJID("5511999990000", types.DefaultUserServer)
+	lid := types.NewJID("123456789", types.HiddenUserServer)
+	ts := time.Unix(1700000000, 0)
+	evt := &events.GroupInfo{
+		JID:        group,
+		Sender:     &admin,
+		Timestamp:  ts,
+		JoinReason: "invite",
+		Join:       []types.JID{lid},
+		Leave:      []types.JID{types.NewJID("5511888880000", types.DefaultUserServer)},
+		Promote:    []types.JID{lid},
+	}
+	resolve := stubResolver(map[string]string{"123456789": "5511777770000"}, map[string]string{"5511777770000": "Maria"})
+
+	updates := buildGroupParticipantsUpdates(evt, resolve)
+	if len(updates) != 3 {
+		t.Fatalf("expected add, remove and promote updates, got %d", len(updates))
+	}
+	add := updates[0]
+	if add["action"] != participantActionAdd || add["id"] != group.String() || add["reason"] != "invite" {
+		t.Fatalf("unexpected add update: %+v", add)
+	}
+	if add["author"] != admin.String() || add["authorPhone"] != "5511999990000" || add["timestamp"] != ts.Unix() {
+		t.Fatalf("unexpected author fields: %+v", add)
+	}
+	if got := add["participants"]; !reflect.DeepEqual(got, []string{"5511777770000@s.whatsapp.net"}) {
+		t.Fatalf("expected LID resolved to phone number, got %v", got)
+	}
+	data := add["participantsData"].([]map[string]any)
+	if data[0]["phoneNumber"] != "5511777770000" || data[0]["name"] != "Maria" {
+		t.Fatalf("unexpected participant data: %+v", data)
+	}
+	if updates[1]["action"] != participantActionRemove || updates[1]["reason"] != nil {
+		t.Fatalf("unexpected remove update: %+v", updates[1])
+	}
+	if updates[2]["action"] != participantActionPromote {
+		t.Fatalf("unexpected promote update: %+v", updates[2])
+	}
+
+	// Mudanças só de participantes não geram groups.update.
+	if payload := buildGroupUpdate(evt, resolve); payload != nil {
+		t.Fatalf("expected no groups.update, got %+v", payload)
+	}
+}
+
+func TestBuildGroupUpdate(t *testing.T) {
+	group := types.NewJID("120363000000000001", types.GroupServer)
+	lid := types.NewJID("123456789", types.HiddenUserServer)
+	link := "https://chat.whatsapp.com/AbCdEf123"
+	evt := &events.GroupInfo{
+		JID:                    group,
+		Sender:                 &lid,
+		Name:                   &types.GroupName{Name: "Vendas", NameSetBy: lid},
+		Topic:                  &types.GroupTopic{Topic: "Equipe comercial", TopicID: "t1"},
+		Locked:                 &types.GroupLocked{IsLocked: true},
+		Announce:               &types.GroupAnnounce{IsAnnounce: false},
+		Ephemeral:              &types.GroupEphemeral{IsEphemeral: true, DisappearingTimer: 86400},
+		MembershipApprovalMode: &types.GroupMembershipApprovalMode{IsJoinApprovalRequired: true},
+		NewInviteLink:          &link,
+	}
+	payload := buildGroupUpdate(evt, stubResolver(map[string]string{"123456789": "5511777770000"}, nil))
+	want := map[string]any{
+		"subject":           "Vendas",
+		"subjectOwner":      "5511777770000@s.whatsapp.net",
+		"desc":              "Equipe comercial",
+		"descId":            "t1",
+		"restrict":          true,
+		"announce":          false,
+		"ephemeralDuration": uint32(86400),
+		"joinApprovalMode":  "on",
+		"inviteCode":        "AbCdEf123",
+		"author":            "5511777770000@s.whatsapp.net",
+		"authorPhone":       "5511777770000",
+	}
+	for key, value := range want {
+		if payload[key] != value {
+			t.Fatalf("%s: expected %v, got %v", key, value, payload[key])
+		}
+	}
+
+	picture := buildGroupPictureUpdate(&events.Picture{JID: group, Author: lid, Remove: true}, stubResolver(nil, nil))
+	if picture["pictureRemoved"] != true || picture["id"] != group.String() || picture["pictureId"] != nil {
+		t.Fatalf("unexpected picture update: %+v", picture)
+	}
+}
+
+func TestBuildGroupUpsert(t *testing.T) {
+	group := types.NewJID("120363000000000001", types.GroupServer)
+	owner := types.NewJID("5511999990000", types.DefaultUserServer)
+	evt := &events.JoinedGroup{Reason: "invite", GroupInfo: types.GroupInfo{
+		JID:        group,
+		OwnerJID:   owner,
+		GroupName:  types.GroupName{Name: "Vendas"},
+		GroupTopic: types.GroupTopic{Topic: "Equipe comercial"},
+		Participants: []types.GroupParticipant{
+			{JID: owner, IsSuperAdmin: true},
+			{JID: types.NewJID("5511888880000", types.DefaultUserServer), DisplayName: "João"},
+		},
+	}}
+	payload := buildGroupUpsert(evt, stubResolver(nil, nil))
+	if payload["id"] != group.String() || payload["subject"] != "Vendas" || payload["size"] != 2 || payload["owner"] != owner.String() || payload["reason"] != "invite" {
+		t.Fatalf("unexpected groups.upsert: %+v", payload)
+	}
+	participants := payload["participants"].([]map[string]any)
+	if participants[0]["admin"] != "superadmin" || participants[1]["admin"] != nil || participants[1]["name"] != "João" {
+		t.Fatalf("unexpected participants: %+v", participants)
+	}
+}
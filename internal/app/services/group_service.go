@@ -52,18 +52,24 @@ type GroupService interface {
 	UpdateSetting(ctx context.Context, in group.UpdateSettingInput) (group.Group, error)
 	ToggleEphemeral(ctx context.Context, in group.ToggleEphemeralInput) (group.Group, error)
 	LeaveGroup(ctx context.Context, in group.LeaveGroupInput) error
+	// SetUsage faz o envio de convites contar na cota da instância, como os demais envios.
+	SetUsage(usage UsageService)
 }
 
+// groupImageTimeout limita o download de fotos de grupo informadas por URL.
+const groupImageTimeout = 30 * time.Second
+
 type groupService struct {
-	waMgr      *whatsapp.Manager
-	httpClient *http.Client
+	waMgr *whatsapp.Manager
+	usage UsageService
 }
 
 func NewGroupService(waMgr *whatsapp.Manager) GroupService {
-	return &groupService{
-		waMgr:      waMgr,
-		httpClient: &http.Client{Timeout: 30 * time.Second},
-	}
+	return &groupService{waMgr: waMgr}
+}
+
+func (s *groupService) SetUsage(usage UsageService) {
+	s.usage = usage
 }
 
 func (s *groupService) Create(ctx context.Context, in group.CreateInput) (group.Group, error) {
@@ -101,7 +107,7 @@ func (s *groupService) Create(ctx context.Context, in group.CreateInput) (group.
 	}
 
 	if img := strings.TrimSpace(in.ProfilePicture); img != "" {
-		if err := s.applyGroupPhoto(ctx, sess.Client, info.JID, img); err != nil {
+		if err := s.applyGroupPhoto(ctx, sess.Name, sess.Client, info.JID, img); err != nil {
 			return empty, fmt.Errorf("%w: %v", ErrGroupPicture, err)
 		}
 	}
@@ -130,7 +136,7 @@ func (s *groupService) UpdatePicture(ctx context.Context, in group.UpdatePicture
 		return empty, err
 	}
 
-	if err := s.applyGroupPhoto(ctx, sess.Client, jid, in.Image); err != nil {
+	if err := s.applyGroupPhoto(ctx, sess.Name, sess.Client, jid, in.Image); err != nil {
 		return empty, fmt.Errorf("%w: %v", ErrGroupPicture, err)
 	}
 
@@ -278,7 +284,10 @@ func (s *groupService) SendInvite(ctx context.Context, in group.SendInviteInput)
 			}
 		}
 
-		if _, err := sess.Client.SendMessage(ctx, target, msg); err != nil {
+		if _, err := sendCounted(ctx, s.usage, sess, target, msg, "groupInvite"); err != nil {
+			if errors.Is(err, ErrQuotaExceeded) {
+				return out, err
+			}
 			return out, fmt.Errorf("%w: %v", ErrGroupSendInvite, err)
 		}
 	}
@@ -356,8 +365,8 @@ func (s *groupService) parseInviteTargets(raw []string) ([]types.JID, error) {
 	return out, nil
 }
 
-func (s *groupService) applyGroupPhoto(ctx context.Context, client *whatsmeow.Client, jid types.JID, value string) error {
-	data, err := s.fetchImage(ctx, value)
+func (s *groupService) applyGroupPhoto(ctx context.Context, instanceName string, client *whatsmeow.Client, jid types.JID, value string) error {
+	data, err := s.fetchImage(ctx, instanceName, value)
 	if err != nil {
 		return err
 	}
@@ -370,7 +379,7 @@ func (s *groupService) applyGroupPhoto(ctx context.Context, client *whatsmeow.Cl
 	return nil
 }
 
-func (s *groupService) fetchImage(ctx context.Context, raw string) ([]byte, error) {
+func (s *groupService) fetchImage(ctx context.Context, instanceName, raw string) ([]byte, error) {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" {
 		return nil, ErrGroupInvalidImage
@@ -380,7 +389,7 @@ func (s *groupService) fetchImage(ctx context.Context, raw string) ([]byte, erro
 		if err != nil {
 			return nil, fmt.Errorf("%w: %v", ErrGroupInvalidImage, err)
 		}
-		resp, err := s.httpClient.Do(req)
+		resp, err := s.waMgr.HTTPClient(instanceName, groupImageTimeout).Do(req)
 		if err != nil {
 			return nil, fmt.Errorf("%w: %v", ErrGroupInvalidImage, err)
 		}
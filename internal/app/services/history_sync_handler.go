@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/history"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// historyWebhookChunkSize limita a quantidade de mensagens por webhook messaging-history.set.
+const historyWebhookChunkSize = 500
+
+const eventMessagingHistorySet = "messaging-history.set"
+
+// HistorySyncListener consome os eventos de sincronização de histórico enviados pelo celular.
+type HistorySyncListener interface {
+	HandleHistorySync(ctx context.Context, instanceName string, evt *events.HistorySync)
+}
+
+type HistorySyncHandler struct {
+	repo       repositories.InstanceRepository
+	history    repositories.HistoryRepository
+	waMgr      *whatsapp.Manager
+	dispatcher WebhookDispatcher
+	log        waLog.Logger
+}
+
+func NewHistorySyncHandler(repo repositories.InstanceRepository, historyRepo repositories.HistoryRepository, waMgr *whatsapp.Manager, dispatcher WebhookDispatcher, log waLog.Logger) *HistorySyncHandler {
+	return &HistorySyncHandler{
+		repo:       repo,
+		history:    historyRepo,
+		waMgr:      waMgr,
+		dispatcher: dispatcher,
+		log:        log,
+	}
+}
+
+type historySyncResult struct {
+	batch    history.SyncBatch
+	messages []map[string]any
+}
+
+func (h *HistorySyncHandler) HandleHistorySync(ctx context.Context, instanceName string, evt *events.HistorySync) {
+	if h == nil || evt == nil || evt.Data == nil || h.repo == nil || h.waMgr == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	sess, ok := h.waMgr.Get(instanceName)
+	if !ok || sess == nil || sess.Client == nil {
+		return
+	}
+	inst, err := h.repo.GetByName(ctx, instanceName)
+	if err != nil {
+		if !errors.Is(err, repositories.ErrInstanceNotFound) && h.log != nil {
+			h.log.Errorf("%s instance=%s repository error: %v", eventMessagingHistorySet, instanceName, err)
+		}
+		return
+	}
+
+	data := evt.Data
+	result := h.parse(sess.Client, inst, data)
+	syncType := strings.ToLower(data.GetSyncType().String())
+
+	_ = h.waMgr.UpdateHistorySyncState(instanceName, func(state *whatsapp.HistorySyncState) {
+		state.SyncType = syncType
+		state.ChunkOrder = data.GetChunkOrder()
+		if data.Progress != nil {
+			state.Progress = data.GetProgress()
+		}
+		state.Chats += len(result.batch.Chats)
+		state.Contacts += len(result.batch.Contacts)
+		state.Messages += len(result.batch.Messages)
+		state.UpdatedAt = time.Now().UTC()
+	})
+
+	if h.log != nil {
+		h.log.Infof("%s instance=%s type=%s chunk=%d progress=%d chats=%d contacts=%d messages=%d",
+			eventMessagingHistorySet, instanceName, syncType, data.GetChunkOrder(), data.GetProgress(),
+			len(result.batch.Chats), len(result.batch.Contacts), len(result.batch.Messages))
+	}
+
+	if h.history != nil {
+		if err := h.history.SaveBatch(ctx, result.batch); err != nil && h.log != nil {
+			h.log.Errorf("%s instance=%s storage error: %v", eventMessagingHistorySet, instanceName, err)
+		}
+	}
+
+	h.dispatchChunks(inst, data, syncType, result)
+}
+
+func (h *HistorySyncHandler) parse(client *whatsmeow.Client, inst *instance.Instance, data *waHistorySync.HistorySync) historySyncResult {
+	var result historySyncResult
+	now := time.Now().UTC()
+	instanceID := string(inst.ID)
+
+	for _, conv := range data.GetConversations() {
+		chatJID, err := types.ParseJID(conv.GetID())
+		if err != nil || chatJID.IsEmpty() {
+			continue
+		}
+		if inst.Settings.GroupsIgnore && chatJID.Server == types.GroupServer {
+			continue
+		}
+
+		name := strings.TrimSpace(conv.GetName())
+		if name == "" {
+			name = strings.TrimSpace(conv.GetDisplayName())
+		}
+		chat := history.Chat{
+			InstanceID:  instanceID,
+			JID:         chatJID.String(),
+			Name:        name,
+			UnreadCount: int(conv.GetUnreadCount()),
+			Archived:    conv.GetArchived(),
+			Pinned:      conv.GetPinned() > 0,
+			UpdatedAt:   now,
+		}
+		lastTS := conv.GetConversationTimestamp()
+		if lastTS == 0 {
+			lastTS = conv.GetLastMsgTimestamp()
+		}
+		if lastTS > 0 {
+			ts := time.Unix(int64(lastTS), 0).UTC()
+			chat.LastMessageAt = &ts
+		}
+		result.batch.Chats = append(result.batch.Chats, chat)
+
+		for _, item := range conv.GetMessages() {
+			webMsg := item.GetMessage()
+			if webMsg == nil {
+				continue
+			}
+			msgEvt, err := client.ParseWebMessage(chatJID, webMsg)
+			if err != nil {
+				if h.log != nil {
+					h.log.Debugf("%s instance=%s chat=%s parse error: %v", eventMessagingHistorySet, inst.Name, chatJID, err)
+				}
+				continue
+			}
+			msgEvt.UnwrapRaw()
+			if msgEvt.Message == nil {
+				continue
+			}
+			messageType := detectMessageType(msgEvt.Message)
+			payload, err := buildMessagePayload(inst, msgEvt, messageType)
+			if err != nil {
+				continue
+			}
+			raw, err := json.Marshal(payload)
+			if err != nil {
+				continue
+			}
+			ts := msgEvt.Info.Timestamp
+			if ts.IsZero() {
+				ts = now
+			}
+			participant := ""
+			if msgEvt.Info.IsGroup && !msgEvt.Info.Sender.IsEmpty() {
+				participant = msgEvt.Info.Sender.String()
+			}
+			result.batch.Messages = append(result.batch.Messages, history.Message{
+				InstanceID:  instanceID,
+				MessageID:   string(msgEvt.Info.ID),
+				RemoteJID:   chatJID.String(),
+				FromMe:      msgEvt.Info.IsFromMe,
+				Participant: participant,
+				PushName:    msgEvt.Info.PushName,
+				MessageType: messageType,
+				Payload:     raw,
+				Timestamp:   ts.UTC(),
+			})
+			result.messages = append(result.messages, payload)
+		}
+	}
+
+	for _, pn := range data.GetPushnames() {
+		jid := strings.TrimSpace(pn.GetID())
+		if jid == "" {
+			continue
+		}
+		result.batch.Contacts = append(result.batch.Contacts, history.Contact{
+			InstanceID: instanceID,
+			JID:        jid,
+			PushName:   strings.TrimSpace(pn.GetPushname()),
+			UpdatedAt:  now,
+		})
+	}
+
+	return result
+}
+
+// dispatchChunks envia messaging-history.set em blocos; chats e contatos seguem no primeiro bloco.
+func (h *HistorySyncHandler) dispatchChunks(inst *instance.Instance, data *waHistorySync.HistorySync, syncType string, result historySyncResult) {
+	if h.dispatcher == nil {
+		return
+	}
+	if len(result.batch.Chats) == 0 && len(result.batch.Contacts) == 0 && len(result.messages) == 0 {
+		return
+	}
+
+	chunks := chunkMessagePayloads(result.messages, historyWebhookChunkSize)
+	if len(chunks) == 0 {
+		chunks = [][]map[string]any{{}}
+	}
+	finished := data.GetProgress() >= 100
+	for i, chunk := range chunks {
+		payload := map[string]any{
+			"messages":   chunk,
+			"chats":      []history.Chat{},
+			"contacts":   []history.Contact{},
+			"syncType":   syncType,
+			"chunkOrder": data.GetChunkOrder(),
+			"progress":   data.GetProgress(),
+			"part":       i + 1,
+			"parts":      len(chunks),
+			"isLatest":   finished && i == len(chunks)-1,
+		}
+		if i == 0 {
+			if len(result.batch.Chats) > 0 {
+				payload["chats"] = result.batch.Chats
+			}
+			if len(result.batch.Contacts) > 0 {
+				payload["contacts"] = result.batch.Contacts
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		_, err := h.dispatcher.Dispatch(ctx, inst, eventMessagingHistorySet, payload)
+		cancel()
+		if err != nil && h.log != nil {
+			h.log.Errorf("%s instance=%s dispatch error (part %d/%d): %v", eventMessagingHistorySet, inst.Name, i+1, len(chunks), err)
+		}
+	}
+}
+
+func chunkMessagePayloads(items []map[string]any, size int) [][]map[string]any {
+	if size <= 0 || len(items) == 0 {
+		return nil
+	}
+	chunks := make([][]map[string]any, 0, (len(items)+size-1)/size)
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		chunks = append(chunks, items[start:end])
+	}
+	return chunks
+}
+
+var _ HistorySyncListener = (*HistorySyncHandler)(nil)
@@ -0,0 +1,25 @@
+package services
+
+import "testing"
+
+func TestChunkMessagePayloads(t *testing.T) {
+	items := make([]map[string]any, 7)
+	for i := range items {
+		items[i] = map[string]any{"i": i}
+	}
+
+	chunks := chunkMessagePayloads(items, 3)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	if len(chunks[0]) != 3 || len(chunks[1]) != 3 || len(chunks[2]) != 1 {
+		t.Fatalf("unexpected chunk sizes: %d %d %d", len(chunks[0]), len(chunks[1]), len(chunks[2]))
+	}
+	if chunks[2][0]["i"] != 6 {
+		t.Fatalf("expected last item to be preserved, got %v", chunks[2][0]["i"])
+	}
+
+	if got := chunkMessagePayloads(nil, 3); got != nil {
+		t.Fatalf("expected nil for empty input, got %v", got)
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+)
+
+// defaultProxyTestURL é o destino usado no teste de proxy quando nenhum é informado.
+const defaultProxyTestURL = "https://web.whatsapp.com"
+
+const proxyTestTimeout = 15 * time.Second
+
+// proxyTestHosts restringe o destino do teste a endpoints do WhatsApp: com um destino livre o
+// endpoint viraria um scanner da rede interna (o erro e a latência voltam para o chamador).
+var proxyTestHosts = map[string]bool{
+	"web.whatsapp.com": true,
+	"www.whatsapp.com": true,
+	"g.whatsapp.net":   true,
+	"mmg.whatsapp.net": true,
+}
+
+// ErrProxyTestTarget indica um targetUrl fora dos endpoints do WhatsApp.
+var ErrProxyTestTarget = errors.New("proxy test target must be a WhatsApp endpoint")
+
+// proxyTestTarget valida o destino do teste; vazio usa defaultProxyTestURL.
+func proxyTestTarget(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return defaultProxyTestURL, nil
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.User != nil || parsed.Port() != "" {
+		return "", ErrProxyTestTarget
+	}
+	if !proxyTestHosts[strings.ToLower(parsed.Hostname())] {
+		return "", ErrProxyTestTarget
+	}
+	return parsed.String(), nil
+}
+
+// normalizeProxy limpa e valida a configuração de proxy recebida na API.
+func normalizeProxy(in instance.InstanceProxy) (instance.InstanceProxy, error) {
+	in.URL = strings.TrimSpace(in.URL)
+	in.Username = strings.TrimSpace(in.Username)
+	if !in.Enabled && in.URL == "" {
+		return instance.InstanceProxy{}, nil
+	}
+	if _, err := whatsapp.ProxyAddress(in.URL, in.Username, in.Password); err != nil {
+		return instance.InstanceProxy{}, err
+	}
+	return in, nil
+}
+
+// proxyAddress retorna a URL completa do proxy ativo da instância ("" = conexão direta).
+func proxyAddress(p instance.InstanceProxy) (string, error) {
+	if !p.Enabled || p.URL == "" {
+		return "", nil
+	}
+	return whatsapp.ProxyAddress(p.URL, p.Username, p.Password)
+}
+
+func (s *instanceService) TestProxy(ctx context.Context, name string, in instance.ProxyTestInput) (*instance.ProxyTestResult, error) {
+	inst, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	cfg := inst.Proxy
+	if in.Proxy != nil {
+		cfg = *in.Proxy
+		cfg.Enabled = true
+	}
+	cfg, err = normalizeProxy(cfg)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := proxyAddress(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if addr == "" {
+		return nil, whatsapp.ErrInvalidProxy
+	}
+	target, err := proxyTestTarget(in.TargetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &instance.ProxyTestResult{InstanceName: inst.Name, Proxy: redactProxy(addr), TargetURL: target}
+	transport, err := whatsapp.NewProxyTransport(addr)
+	if err != nil {
+		return nil, err
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Timeout: proxyTestTimeout, Transport: transport}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	result.StatusCode = resp.StatusCode
+	result.OK = resp.StatusCode < http.StatusInternalServerError
+	return result, nil
+}
+
+// redactProxy remove as credenciais da URL do proxy.
+func redactProxy(addr string) string {
+	parsed, err := url.Parse(addr)
+	if err != nil {
+		return ""
+	}
+	parsed.User = nil
+	return parsed.String()
+}
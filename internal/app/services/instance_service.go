@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/cluster"
 	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
 	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
 	"github.com/faeln1/go-whatsapp-api/pkg/storage"
@@ -19,8 +21,9 @@ import (
 
 type InstanceService interface {
 	Create(ctx context.Context, in instance.CreateInstanceInput) (*instance.Instance, error)
-	List(ctx context.Context) ([]*instance.InstanceListResponse, error)
+	List(ctx context.Context, filter instance.ListFilter) ([]*instance.InstanceListResponse, error)
 	GetByID(ctx context.Context, id string) (*instance.InstanceListResponse, error)
+	Update(ctx context.Context, name string, in instance.UpdateInstanceInput) (*instance.Instance, error)
 	Delete(ctx context.Context, name string) error
 	Logout(ctx context.Context, name string) error
 	Disconnect(ctx context.Context, name string) error
@@ -29,21 +32,70 @@ type InstanceService interface {
 	GeneratePairingCode(ctx context.Context, name string, phone string) (string, error)
 	Restart(ctx context.Context, name string) (string, error)
 	ConnectionState(ctx context.Context, name string) (string, error)
+	ConnectionDetails(ctx context.Context, name string) (*instance.ConnectionStateResponse, error)
 	SetPresence(ctx context.Context, name string, presence string) error
 	SetWebhook(ctx context.Context, name string, in instance.SetWebhookInput) (*instance.Instance, error)
 	SetSettings(ctx context.Context, name string, in instance.SetSettingsInput) (*instance.Instance, error)
 	GetWebhook(ctx context.Context, name string) (instance.InstanceWebhook, error)
+	RotateWebhookSecret(ctx context.Context, name string, in instance.RotateWebhookSecretInput) (*instance.RotateWebhookSecretResponse, error)
+	DeleteWebhookSecret(ctx context.Context, name string) error
 	GetSettings(ctx context.Context, name string) (instance.InstanceSettings, error)
+	RotateToken(ctx context.Context, name string, in instance.RotateTokenInput) (*instance.RotateTokenResponse, error)
+	TestProxy(ctx context.Context, name string, in instance.ProxyTestInput) (*instance.ProxyTestResult, error)
+	// SetOwners habilita o modo cluster: a listagem passa a informar o nó dono de cada instância.
+	SetOwners(owners InstanceOwners)
+	// SetDeviceStore permite que a renomeação mova também o device store do whatsmeow.
+	SetDeviceStore(devices DeviceStoreRenamer)
+	// SetWebhookSubscriptions faz a remoção da instância apagar também suas assinaturas de webhook.
+	SetWebhookSubscriptions(subs repositories.WebhookSubscriptionRepository)
+	// SetEventSinks faz a remoção da instância apagar também as configs dos destinos de eventos.
+	SetEventSinks(sinks repositories.EventSinkRepository)
+	// AddRenameListener registra quem mantém estado por nome (presence keeper, lease do cluster).
+	AddRenameListener(listener InstanceRenameListener)
+}
+
+// InstanceRenameListener move para o novo nome o estado mantido pelo nome da instância.
+type InstanceRenameListener interface {
+	HandleInstanceRename(ctx context.Context, oldName, newName string)
+}
+
+// DeviceStoreRenamer move o device store de uma instância para o novo nome.
+type DeviceStoreRenamer interface {
+	RenameDevice(ctx context.Context, oldName, newName string) error
 }
 
 type instanceService struct {
 	repo    repositories.InstanceRepository
 	waMgr   *whatsapp.Manager
 	storage storage.Service
+	owners  InstanceOwners
+	devices DeviceStoreRenamer
+	subs    repositories.WebhookSubscriptionRepository
+	sinks   repositories.EventSinkRepository
+	renames []InstanceRenameListener
 }
 
 var ErrPhoneNumberRequired = errors.New("phone number is required")
 
+// ErrInvalidInstanceName indica um nome que não pode virar caminho de arquivo (device store
+// em {DATA_DIR}/{nome}.db) nem segmento de rota.
+var ErrInvalidInstanceName = errors.New("invalid instance name: path separators, leading dots and control characters are not allowed")
+
+const maxInstanceNameLength = 100
+
+// ValidateInstanceName valida o nome de uma instância nova ou renomeada.
+func ValidateInstanceName(name string) error {
+	if name == "" || len(name) > maxInstanceNameLength || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return ErrInvalidInstanceName
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return ErrInvalidInstanceName
+		}
+	}
+	return nil
+}
+
 func NewInstanceService(repo repositories.InstanceRepository, waMgr *whatsapp.Manager, storage storage.Service) InstanceService {
 	return &instanceService{repo: repo, waMgr: waMgr, storage: storage}
 }
@@ -53,9 +105,12 @@ func (s *instanceService) Create(ctx context.Context, in instance.CreateInstance
 	if name == "" {
 		return nil, errors.New("instanceName is required")
 	}
-	token := strings.TrimSpace(in.Token)
-	if token == "" {
-		token = uuid.NewString()
+	if err := ValidateInstanceName(name); err != nil {
+		return nil, err
+	}
+	token, credential, err := s.newInstanceToken(in.Token)
+	if err != nil {
+		return nil, err
 	}
 	now := time.Now().UTC()
 	settings := instance.InstanceSettings{}
@@ -88,21 +143,46 @@ func (s *instanceService) Create(ctx context.Context, in instance.CreateInstance
 		webhook = *in.Webhook
 	}
 	webhook.URL = strings.TrimSpace(webhook.URL)
+	// O segredo informado na criação segue a mesma regra da rotação; segredo anterior só
+	// existe a partir de uma rotação.
+	webhook.Secret = strings.TrimSpace(webhook.Secret)
+	if webhook.Secret != "" && len(webhook.Secret) < minWebhookSecretLength {
+		return nil, ErrWebhookSecretTooShort
+	}
+	webhook.PreviousSecret = ""
+	webhook.PreviousSecretExpiresAt = nil
 	legacyWebhook := strings.TrimSpace(in.WebhookURL)
 	if webhook.URL == "" && legacyWebhook != "" {
 		webhook.URL = legacyWebhook
 	}
+	var proxy instance.InstanceProxy
+	if in.Proxy != nil {
+		if proxy, err = normalizeProxy(*in.Proxy); err != nil {
+			return nil, err
+		}
+	}
+	var pairing instance.InstancePairing
+	if in.Pairing != nil {
+		if pairing, err = normalizePairing(*in.Pairing); err != nil {
+			return nil, err
+		}
+	}
 	number := strings.TrimSpace(in.Number)
 	integration := strings.TrimSpace(in.Integration)
 	inst := &instance.Instance{
 		ID:          instance.ID(uuid.NewString()),
 		Name:        name,
-		Token:       token,
+		TokenHash:   credential.Hash,
+		TokenLookup: credential.Lookup,
 		Number:      number,
 		Integration: integration,
 		WebhookURL:  webhook.URL,
 		Settings:    settings,
 		Webhook:     webhook,
+		Proxy:       proxy,
+		Pairing:     pairing,
+		Tags:        normalizeTags(in.Tags),
+		Metadata:    mergeMetadata(nil, in.Metadata),
 		CreatedAt:   now,
 		UpdatedAt:   now,
 		Status:      "pending_qr",
@@ -110,37 +190,57 @@ func (s *instanceService) Create(ctx context.Context, in instance.CreateInstance
 	if err := s.repo.Create(ctx, inst); err != nil {
 		return nil, err
 	}
-	sess, err := s.waMgr.Create(ctx, inst.Name, token)
+	sess, err := s.waMgr.Create(ctx, inst.Name, credential)
+	if errors.Is(err, whatsapp.ErrAlreadyExists) {
+		// Em modo cluster o coordenador pode registrar a sessão logo após o insert no repositório.
+		var ok bool
+		if sess, ok = s.waMgr.Get(inst.Name); ok {
+			err = s.waMgr.SetTokens(inst.Name, credential)
+		}
+	}
 	if err != nil {
 		_ = s.repo.Delete(ctx, inst.Name)
 		return nil, err
 	}
 	sess.ID = string(inst.ID)
 	sess.CreatedAt = inst.CreatedAt
-	sess.Token = token
-	return inst, nil
+	if addr, _ := proxyAddress(inst.Proxy); addr != "" {
+		if err := s.waMgr.SetProxy(inst.Name, addr); err != nil {
+			return nil, err
+		}
+	}
+	// O token em texto puro só é devolvido nesta resposta.
+	created := *inst
+	created.Token = token
+	return &created, nil
 }
 
-func (s *instanceService) List(ctx context.Context) ([]*instance.InstanceListResponse, error) {
+func (s *instanceService) List(ctx context.Context, filter instance.ListFilter) ([]*instance.InstanceListResponse, error) {
 	instances, err := s.repo.List(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	responses := make([]*instance.InstanceListResponse, 0, len(instances))
+	owners := s.clusterOwners(ctx)
 
 	// Convert each instance to the Evolution API format
 	for _, inst := range instances {
+		if !hasAllTags(inst.Tags, filter.Tags) {
+			continue
+		}
 		sess, _ := s.waMgr.Get(inst.Name)
+		owner := s.ownerOf(owners, inst.Name)
+		remote := owner != nil && !owner.Local
 		currentState := determineConnectionState(inst, sess)
+		if remote {
+			// O estado real está no nó dono; usar o último status gravado por ele.
+			currentState = mapToEvolutionStatus(inst.Status)
+		}
 
-		// Update status if it changed (async to not block response)
-		if inst.Status != currentState {
-			inst.Status = currentState
-			inst.UpdatedAt = time.Now().UTC()
-			go func(i *instance.Instance) {
-				_ = s.repo.Update(context.Background(), i)
-			}(inst)
+		// Update status if it changed
+		if !remote && inst.Status != currentState {
+			s.saveStatus(ctx, inst, currentState)
 		}
 
 		// Build response with Evolution API structure
@@ -150,13 +250,16 @@ func (s *instanceService) List(ctx context.Context) ([]*instance.InstanceListRes
 			ConnectionStatus:        currentState,
 			Integration:             inst.Integration,
 			Number:                  inst.Number,
-			Token:                   inst.Token,
 			ClientName:              "evolution_exchange",
-			DisconnectionReasonCode: nil,
-			DisconnectionObject:     nil,
-			DisconnectionAt:         nil,
+			DisconnectionReasonCode: inst.DisconnectionReasonCode,
+			DisconnectionObject:     inst.DisconnectionObject,
+			DisconnectionAt:         inst.DisconnectionAt,
 			CreatedAt:               inst.CreatedAt,
 			UpdatedAt:               inst.UpdatedAt,
+			Proxy:                   inst.Proxy.Redacted(),
+			Owner:                   owner,
+			Tags:                    inst.Tags,
+			Metadata:                inst.Metadata,
 		}
 
 		// Add profile information if available (skip expensive network calls)
@@ -176,6 +279,9 @@ func (s *instanceService) List(ctx context.Context) ([]*instance.InstanceListRes
 			// Skip profile picture fetch in list endpoint - too slow for batch operations
 			// Use the dedicated profile endpoint for individual profile pictures
 		}
+		if !statusMatches(currentState, filter.Status) || !ownerJIDMatches(response.OwnerJID, filter.OwnerJID) {
+			continue
+		}
 
 		// Add settings details
 		settingID := fmt.Sprintf("setting-%s", inst.ID)
@@ -193,6 +299,10 @@ func (s *instanceService) List(ctx context.Context) ([]*instance.InstanceListRes
 			InstanceID:      string(inst.ID),
 		}
 
+		response.Presence = s.presenceState(inst.Name)
+		response.HistorySync = s.historySyncState(inst.Name)
+		response.Health = s.healthState(inst.Name)
+
 		// Add counts (default to 0 for now)
 		response.Count = &instance.InstanceCount{
 			Message: 0,
@@ -216,13 +326,16 @@ func (s *instanceService) GetByID(ctx context.Context, id string) (*instance.Ins
 	for _, inst := range instances {
 		if string(inst.ID) == id {
 			sess, _ := s.waMgr.Get(inst.Name)
+			owner := s.ownerOf(s.clusterOwners(ctx), inst.Name)
+			remote := owner != nil && !owner.Local
 			currentState := determineConnectionState(inst, sess)
+			if remote {
+				currentState = mapToEvolutionStatus(inst.Status)
+			}
 
 			// Update status if it changed
-			if inst.Status != currentState {
-				inst.Status = currentState
-				inst.UpdatedAt = time.Now().UTC()
-				_ = s.repo.Update(ctx, inst)
+			if !remote && inst.Status != currentState {
+				s.saveStatus(ctx, inst, currentState)
 			}
 
 			// Build response with Evolution API structure
@@ -232,12 +345,15 @@ func (s *instanceService) GetByID(ctx context.Context, id string) (*instance.Ins
 				ConnectionStatus:        currentState,
 				Integration:             inst.Integration,
 				Number:                  inst.Number,
-				Token:                   inst.Token,
-				DisconnectionReasonCode: nil,
-				DisconnectionObject:     nil,
-				DisconnectionAt:         nil,
+				DisconnectionReasonCode: inst.DisconnectionReasonCode,
+				DisconnectionObject:     inst.DisconnectionObject,
+				DisconnectionAt:         inst.DisconnectionAt,
 				CreatedAt:               inst.CreatedAt,
 				UpdatedAt:               inst.UpdatedAt,
+				Proxy:                   inst.Proxy.Redacted(),
+				Owner:                   owner,
+				Tags:                    inst.Tags,
+				Metadata:                inst.Metadata,
 			}
 
 			// Add profile information if available
@@ -276,6 +392,10 @@ func (s *instanceService) GetByID(ctx context.Context, id string) (*instance.Ins
 				InstanceID:      string(inst.ID),
 			}
 
+			response.Presence = s.presenceState(inst.Name)
+			response.HistorySync = s.historySyncState(inst.Name)
+			response.Health = s.healthState(inst.Name)
+
 			// Add counts (default to 0 for now)
 			response.Count = &instance.InstanceCount{
 				Message: 0,
@@ -290,10 +410,196 @@ func (s *instanceService) GetByID(ctx context.Context, id string) (*instance.Ins
 	return nil, repositories.ErrInstanceNotFound
 }
 
+// Update aplica o PATCH da instância. A renomeação desconecta a sessão e move o registro,
+// o device store e o estado em memória para o novo nome; a reconexão fica com o chamador.
+func (s *instanceService) Update(ctx context.Context, name string, in instance.UpdateInstanceInput) (*instance.Instance, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("instance name is required")
+	}
+	inst, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	newName := name
+	if in.InstanceName != nil {
+		if newName = strings.TrimSpace(*in.InstanceName); newName == "" {
+			return nil, errors.New("instanceName cannot be empty")
+		}
+		if newName != name {
+			if err := ValidateInstanceName(newName); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if in.Number != nil {
+		inst.Number = strings.TrimSpace(*in.Number)
+	}
+	if in.Integration != nil {
+		inst.Integration = strings.TrimSpace(*in.Integration)
+	}
+	if in.Tags != nil {
+		inst.Tags = normalizeTags(*in.Tags)
+	}
+	if len(in.Metadata) > 0 {
+		inst.Metadata = mergeMetadata(inst.Metadata, in.Metadata)
+	}
+	if newName != name {
+		if err := s.rename(ctx, name, newName); err != nil {
+			return nil, err
+		}
+		inst.Name = newName
+		if !strings.EqualFold(inst.Status, "logged_out") {
+			inst.Status = "disconnected"
+		}
+	}
+	inst.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(ctx, inst); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+func (s *instanceService) rename(ctx context.Context, oldName, newName string) error {
+	if _, err := s.repo.GetByName(ctx, newName); err == nil {
+		return repositories.ErrInstanceAlreadyExists
+	} else if !errors.Is(err, repositories.ErrInstanceNotFound) {
+		return err
+	}
+	if _, exists := s.waMgr.Get(newName); exists {
+		return repositories.ErrInstanceAlreadyExists
+	}
+	// O cliente atual usa o device store do nome antigo: encerrar antes de mover.
+	s.waMgr.MarkStopped(oldName, "rename")
+	if sess, ok := s.waMgr.Get(oldName); ok && sess.Client != nil {
+		sess.Client.Disconnect()
+	}
+	if s.devices != nil {
+		if err := s.devices.RenameDevice(ctx, oldName, newName); err != nil {
+			return fmt.Errorf("move device store: %w", err)
+		}
+	}
+	if err := s.repo.Rename(ctx, oldName, newName); err != nil {
+		if s.devices != nil {
+			_ = s.devices.RenameDevice(ctx, newName, oldName)
+		}
+		return err
+	}
+	if err := s.waMgr.Rename(oldName, newName); err != nil && !errors.Is(err, whatsapp.ErrNotFound) {
+		return err
+	}
+	for _, listener := range s.renames {
+		listener.HandleInstanceRename(ctx, oldName, newName)
+	}
+	return nil
+}
+
+// normalizeTags remove espaços, vazios e duplicadas mantendo a ordem informada.
+func normalizeTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// mergeMetadata aplica patch sobre current; valores null removem a chave.
+func mergeMetadata(current, patch map[string]any) map[string]any {
+	out := make(map[string]any, len(current)+len(patch))
+	for k, v := range current {
+		out[k] = v
+	}
+	for k, v := range patch {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		if v == nil {
+			delete(out, k)
+			continue
+		}
+		out[k] = v
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func hasAllTags(tags, wanted []string) bool {
+	for _, w := range wanted {
+		found := false
+		for _, tag := range tags {
+			if strings.EqualFold(tag, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// statusMatches compara o connectionStatus aceitando os sinônimos da Evolution API.
+func statusMatches(state, wanted string) bool {
+	switch wanted = strings.ToLower(strings.TrimSpace(wanted)); wanted {
+	case "":
+		return true
+	case "close", "logged_out":
+		wanted = "closed"
+	case "connected":
+		wanted = "open"
+	}
+	return state == wanted
+}
+
+// ownerJIDMatches aceita o JID completo ou apenas o número (sem device e servidor).
+func ownerJIDMatches(ownerJID, wanted string) bool {
+	wanted = strings.TrimSpace(wanted)
+	if wanted == "" {
+		return true
+	}
+	if ownerJID == "" {
+		return false
+	}
+	return ownerJID == wanted || jidUser(ownerJID) == jidUser(wanted)
+}
+
+func jidUser(jid string) string {
+	if i := strings.IndexByte(jid, '@'); i >= 0 {
+		jid = jid[:i]
+	}
+	if i := strings.IndexByte(jid, ':'); i >= 0 {
+		jid = jid[:i]
+	}
+	return strings.TrimPrefix(jid, "+")
+}
+
 func (s *instanceService) Delete(ctx context.Context, name string) error {
+	inst, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return err
+	}
 	if err := s.repo.Delete(ctx, name); err != nil {
 		return err
 	}
+	if s.subs != nil {
+		_ = s.subs.DeleteByInstance(ctx, string(inst.ID))
+	}
+	if s.sinks != nil {
+		_ = s.sinks.DeleteByInstance(ctx, string(inst.ID))
+	}
 	if sess, ok := s.waMgr.Get(name); ok && sess.Client != nil {
 		sess.Client.Disconnect()
 	}
@@ -308,6 +614,7 @@ func (s *instanceService) Logout(ctx context.Context, name string) error {
 	}
 	inst.Status = "logged_out"
 	inst.UpdatedAt = time.Now().UTC()
+	s.waMgr.MarkStopped(name, "logout")
 	// Encerrar sessão completamente
 	if sess, ok := s.waMgr.Get(name); ok && sess.Client != nil {
 		_ = sess.Client.Logout(ctx)
@@ -320,6 +627,7 @@ func (s *instanceService) Disconnect(ctx context.Context, name string) error {
 	if err != nil {
 		return err
 	}
+	s.waMgr.MarkStopped(name, "manual_disconnect")
 	if sess, ok := s.waMgr.Get(name); ok && sess.Client != nil {
 		sess.Client.Disconnect()
 	}
@@ -378,7 +686,7 @@ func (s *instanceService) GeneratePairingCode(ctx context.Context, name string,
 		return "", ErrPhoneNumberRequired
 	}
 
-	pairingCode, err := s.waMgr.GeneratePairingCode(ctx, name, phone)
+	pairingCode, err := s.waMgr.GeneratePairingCode(ctx, name, phone, inst.Pairing.ClientType, inst.Pairing.DisplayName)
 	if err != nil {
 		return "", err
 	}
@@ -394,6 +702,16 @@ func (s *instanceService) GeneratePairingCode(ctx context.Context, name string,
 	return pairingCode, nil
 }
 
+// normalizePairing valida o tipo de cliente usado no pareamento por código.
+func normalizePairing(in instance.InstancePairing) (instance.InstancePairing, error) {
+	in.ClientType = strings.ToLower(strings.TrimSpace(in.ClientType))
+	in.DisplayName = strings.TrimSpace(in.DisplayName)
+	if _, err := whatsapp.PairClientType(in.ClientType); err != nil {
+		return instance.InstancePairing{}, err
+	}
+	return in, nil
+}
+
 func (s *instanceService) Restart(ctx context.Context, name string) (string, error) {
 	name = strings.TrimSpace(name)
 	if name == "" {
@@ -448,6 +766,22 @@ func (s *instanceService) ConnectionState(ctx context.Context, name string) (str
 	return state, nil
 }
 
+// ConnectionDetails retorna o estado no formato Evolution junto da máquina de saúde do supervisor.
+func (s *instanceService) ConnectionDetails(ctx context.Context, name string) (*instance.ConnectionStateResponse, error) {
+	state, err := s.ConnectionState(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	return &instance.ConnectionStateResponse{
+		Instance: instance.ConnectionStateInstance{
+			InstanceName: name,
+			State:        state,
+			Health:       s.healthState(name),
+		},
+	}, nil
+}
+
 func (s *instanceService) SetPresence(ctx context.Context, name string, presence string) error {
 	name = strings.TrimSpace(name)
 	if name == "" {
@@ -562,11 +896,27 @@ func (s *instanceService) SetSettings(ctx context.Context, name string, in insta
 	if inst.Settings.MsgCall == "" {
 		return nil, errors.New("msgCall is required")
 	}
+	if in.Proxy != nil {
+		if inst.Proxy, err = normalizeProxy(*in.Proxy); err != nil {
+			return nil, err
+		}
+	}
+	if in.Pairing != nil {
+		if inst.Pairing, err = normalizePairing(*in.Pairing); err != nil {
+			return nil, err
+		}
+	}
 	inst.UpdatedAt = time.Now().UTC()
 
 	if err := s.repo.Update(ctx, inst); err != nil {
 		return nil, err
 	}
+	if in.Proxy != nil {
+		addr, _ := proxyAddress(inst.Proxy)
+		if err := s.waMgr.SetProxy(name, addr); err != nil {
+			return nil, err
+		}
+	}
 	return inst, nil
 }
 
@@ -597,6 +947,133 @@ func (s *instanceService) GetSettings(ctx context.Context, name string) (instanc
 	return inst.Settings, nil
 }
 
+func (s *instanceService) presenceState(name string) *instance.InstancePresence {
+	state, ok := s.waMgr.GetPresenceState(name)
+	if !ok {
+		return nil
+	}
+	out := &instance.InstancePresence{
+		Status:    state.Status,
+		Reason:    state.Reason,
+		LastError: state.LastError,
+	}
+	if !state.StartedAt.IsZero() {
+		startedAt := state.StartedAt
+		out.StartedAt = &startedAt
+	}
+	if !state.LastSentAt.IsZero() {
+		lastSentAt := state.LastSentAt
+		out.LastSentAt = &lastSentAt
+	}
+	return out
+}
+
+func (s *instanceService) historySyncState(name string) *instance.InstanceHistorySync {
+	state, ok := s.waMgr.GetHistorySyncState(name)
+	if !ok {
+		return nil
+	}
+	return &instance.InstanceHistorySync{
+		SyncType:   state.SyncType,
+		ChunkOrder: state.ChunkOrder,
+		Progress:   state.Progress,
+		Chats:      state.Chats,
+		Contacts:   state.Contacts,
+		Messages:   state.Messages,
+		UpdatedAt:  state.UpdatedAt,
+	}
+}
+
+func (s *instanceService) healthState(name string) *instance.InstanceHealth {
+	state, ok := s.waMgr.GetHealth(name)
+	if !ok {
+		return nil
+	}
+	out := &instance.InstanceHealth{
+		State:     state.State,
+		Reason:    state.Reason,
+		Code:      state.Code,
+		Attempts:  state.Attempts,
+		Terminal:  state.Terminal,
+		LastError: state.LastError,
+		UpdatedAt: state.UpdatedAt,
+	}
+	if !state.NextRetryAt.IsZero() {
+		t := state.NextRetryAt
+		out.NextRetryAt = &t
+	}
+	if !state.LastConnectedAt.IsZero() {
+		t := state.LastConnectedAt
+		out.LastConnectedAt = &t
+	}
+	if !state.LastDisconnect.IsZero() {
+		t := state.LastDisconnect
+		out.LastDisconnect = &t
+	}
+	return out
+}
+
+func (s *instanceService) SetOwners(owners InstanceOwners) {
+	s.owners = owners
+}
+
+func (s *instanceService) SetDeviceStore(devices DeviceStoreRenamer) {
+	s.devices = devices
+}
+
+func (s *instanceService) SetWebhookSubscriptions(subs repositories.WebhookSubscriptionRepository) {
+	s.subs = subs
+}
+
+func (s *instanceService) SetEventSinks(sinks repositories.EventSinkRepository) {
+	s.sinks = sinks
+}
+
+func (s *instanceService) AddRenameListener(listener InstanceRenameListener) {
+	if listener != nil {
+		s.renames = append(s.renames, listener)
+	}
+}
+
+// clusterOwners retorna os leases ativos; nil fora do modo cluster ou em caso de erro.
+func (s *instanceService) clusterOwners(ctx context.Context) map[string]cluster.Lease {
+	if s.owners == nil {
+		return nil
+	}
+	owners, err := s.owners.Owners(ctx)
+	if err != nil {
+		return nil
+	}
+	return owners
+}
+
+func (s *instanceService) ownerOf(owners map[string]cluster.Lease, name string) *instance.InstanceOwner {
+	lease, ok := owners[name]
+	if !ok {
+		return nil
+	}
+	return &instance.InstanceOwner{
+		NodeID:         lease.NodeID,
+		Address:        lease.NodeAddress,
+		Local:          lease.NodeID == s.owners.NodeID(),
+		LeaseExpiresAt: lease.ExpiresAt,
+	}
+}
+
+// saveStatus grava só o estado da conexão: um Update completo, feito a partir da cópia lida na
+// listagem, desfaria token, segredo, settings ou tags alterados em paralelo pela API.
+func (s *instanceService) saveStatus(ctx context.Context, inst *instance.Instance, state string) {
+	inst.Status = state
+	inst.UpdatedAt = time.Now().UTC()
+	_ = s.repo.UpdateStatus(ctx, inst.Name, instance.ConnectionStatus{
+		Status:                  state,
+		DisconnectionReasonCode: inst.DisconnectionReasonCode,
+		DisconnectionObject:     inst.DisconnectionObject,
+		DisconnectionAt:         inst.DisconnectionAt,
+		UpdatedAt:               inst.UpdatedAt,
+	})
+}
+
 func determineConnectionState(inst *instance.Instance, sess *whatsapp.Session) string {
 	if inst != nil && strings.EqualFold(inst.Status, "logged_out") {
 		return "closed"
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/pkg/apitoken"
+	"github.com/google/uuid"
+)
+
+// maxTokenGracePeriod limita por quanto tempo o token anterior segue aceito após a rotação.
+const maxTokenGracePeriod = 7 * 24 * time.Hour
+
+var ErrInvalidGracePeriod = errors.New("gracePeriodSeconds must be between 0 and 604800")
+
+// newInstanceToken gera o hash do token informado (ou de um UUID) e verifica se já está em uso.
+func (s *instanceService) newInstanceToken(token string) (string, apitoken.Credential, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		token = uuid.NewString()
+	}
+	if _, taken := s.waMgr.ValidateToken(token); taken {
+		return "", apitoken.Credential{}, repositories.ErrTokenAlreadyExists
+	}
+	credential, err := apitoken.New(token)
+	if err != nil {
+		return "", apitoken.Credential{}, err
+	}
+	return token, credential, nil
+}
+
+func (s *instanceService) RotateToken(ctx context.Context, name string, in instance.RotateTokenInput) (*instance.RotateTokenResponse, error) {
+	grace := time.Duration(in.GracePeriodSeconds) * time.Second
+	if in.GracePeriodSeconds < 0 || grace > maxTokenGracePeriod {
+		return nil, ErrInvalidGracePeriod
+	}
+	inst, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	token, credential, err := s.newInstanceToken(in.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	updated := *inst
+	updated.PreviousTokenHash = ""
+	updated.PreviousTokenLookup = ""
+	updated.PreviousTokenExpiresAt = nil
+	if grace > 0 {
+		expiresAt := now.Add(grace)
+		updated.PreviousTokenHash = inst.TokenHash
+		updated.PreviousTokenLookup = inst.TokenLookup
+		updated.PreviousTokenExpiresAt = &expiresAt
+	}
+	updated.TokenHash = credential.Hash
+	updated.TokenLookup = credential.Lookup
+	updated.UpdatedAt = now
+	if err := s.repo.Update(ctx, &updated); err != nil {
+		return nil, err
+	}
+	if err := s.waMgr.SetTokens(name, InstanceCredentials(&updated)...); err != nil {
+		return nil, err
+	}
+	return &instance.RotateTokenResponse{
+		InstanceName:           name,
+		Token:                  token,
+		PreviousTokenExpiresAt: updated.PreviousTokenExpiresAt,
+	}, nil
+}
+
+// InstanceCredentials retorna as credenciais aceitas para a instância: o token atual e,
+// enquanto não expirar, o token anterior à última rotação.
+func InstanceCredentials(inst *instance.Instance) []apitoken.Credential {
+	var credentials []apitoken.Credential
+	if inst.TokenHash != "" {
+		credentials = append(credentials, apitoken.Credential{Lookup: inst.TokenLookup, Hash: inst.TokenHash})
+	}
+	if inst.PreviousTokenHash != "" && inst.PreviousTokenExpiresAt != nil && time.Now().Before(*inst.PreviousTokenExpiresAt) {
+		credentials = append(credentials, apitoken.Credential{
+			Lookup:    inst.PreviousTokenLookup,
+			Hash:      inst.PreviousTokenHash,
+			ExpiresAt: *inst.PreviousTokenExpiresAt,
+		})
+	}
+	return credentials
+}
+
+// HashLegacyTokens converte tokens gravados em texto puro (versões anteriores) para hash.
+func HashLegacyTokens(ctx context.Context, repo repositories.InstanceRepository) (int, error) {
+	instances, err := repo.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	converted := 0
+	for _, inst := range instances {
+		if inst.TokenHash == "" || apitoken.IsHash(inst.TokenHash) {
+			continue
+		}
+		credential, err := apitoken.New(inst.TokenHash)
+		if err != nil {
+			return converted, err
+		}
+		inst.TokenHash = credential.Hash
+		inst.TokenLookup = credential.Lookup
+		if err := repo.Update(ctx, inst); err != nil {
+			return converted, err
+		}
+		converted++
+	}
+	return converted, nil
+}
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/usage"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/apitoken"
+	"github.com/faeln1/go-whatsapp-api/pkg/archive"
+	"github.com/google/uuid"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// instanceArchiveVersion identifica o formato do conteúdo do arquivo de migração. A versão 2
+// acrescenta assinaturas de webhook, destinos de eventos e cota; arquivos da versão 1 continuam
+// aceitos na importação.
+const instanceArchiveVersion = 2
+
+var ErrUnsupportedArchive = errors.New("unsupported archive version")
+
+// InstanceTransferService exporta e importa instâncias (configuração, token e device store)
+// para migração entre servidores sem novo pareamento.
+type InstanceTransferService interface {
+	Export(ctx context.Context, name string, in instance.ExportInstanceInput) (*instance.ExportInstanceResponse, error)
+	Import(ctx context.Context, in instance.ImportInstanceInput) (*instance.ImportInstanceResponse, error)
+	// SetWebhookSubscriptions inclui as assinaturas de webhook no arquivo.
+	SetWebhookSubscriptions(subs repositories.WebhookSubscriptionRepository)
+	// SetEventSinks inclui as configs dos destinos de eventos no arquivo.
+	SetEventSinks(sinks repositories.EventSinkRepository)
+	// SetUsage inclui a cota própria da instância no arquivo.
+	SetUsage(repo repositories.UsageRepository)
+}
+
+type instanceArchive struct {
+	Version    int                      `json:"version"`
+	ExportedAt time.Time                `json:"exportedAt"`
+	Instance   instance.Instance        `json:"instance"`
+	Device     *whatsapp.DeviceSnapshot `json:"device"`
+	// Credentials leva o hash do token (o texto puro não é armazenado)
+	Credentials   *archiveCredentials   `json:"credentials,omitempty"`
+	Subscriptions []archiveSubscription `json:"subscriptions,omitempty"`
+	Sinks         []webhook.SinkConfig  `json:"sinks,omitempty"`
+	Quota         *usage.Quota          `json:"quota,omitempty"`
+}
+
+// archiveSubscription leva também os segredos de assinatura, omitidos no JSON da API.
+type archiveSubscription struct {
+	webhook.Subscription
+	Secret         string `json:"secret,omitempty"`
+	PreviousSecret string `json:"previousSecret,omitempty"`
+}
+
+type archiveCredentials struct {
+	TokenHash              string     `json:"tokenHash"`
+	TokenLookup            string     `json:"tokenLookup"`
+	PreviousTokenHash      string     `json:"previousTokenHash,omitempty"`
+	PreviousTokenLookup    string     `json:"previousTokenLookup,omitempty"`
+	PreviousTokenExpiresAt *time.Time `json:"previousTokenExpiresAt,omitempty"`
+}
+
+type instanceTransferService struct {
+	repo      repositories.InstanceRepository
+	instances InstanceService
+	waMgr     *whatsapp.Manager
+	bootstrap *SessionBootstrap
+	log       waLog.Logger
+	subs      repositories.WebhookSubscriptionRepository
+	sinks     repositories.EventSinkRepository
+	usage     repositories.UsageRepository
+}
+
+func NewInstanceTransferService(repo repositories.InstanceRepository, instances InstanceService, waMgr *whatsapp.Manager, bootstrap *SessionBootstrap, log waLog.Logger) InstanceTransferService {
+	return &instanceTransferService{repo: repo, instances: instances, waMgr: waMgr, bootstrap: bootstrap, log: log}
+}
+
+func (s *instanceTransferService) SetWebhookSubscriptions(subs repositories.WebhookSubscriptionRepository) {
+	s.subs = subs
+}
+
+func (s *instanceTransferService) SetEventSinks(sinks repositories.EventSinkRepository) {
+	s.sinks = sinks
+}
+
+func (s *instanceTransferService) SetUsage(repo repositories.UsageRepository) {
+	s.usage = repo
+}
+
+func (s *instanceTransferService) Export(ctx context.Context, name string, in instance.ExportInstanceInput) (*instance.ExportInstanceResponse, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("instance name is required")
+	}
+	if len(in.Passphrase) < archive.MinPassphraseLength {
+		return nil, archive.ErrPassphraseTooShort
+	}
+	inst, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	device, err := s.bootstrap.StoreFactory.ExportDevice(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	content := instanceArchive{
+		Version:    instanceArchiveVersion,
+		ExportedAt: now,
+		Instance:   *inst,
+		Device:     device,
+		Credentials: &archiveCredentials{
+			TokenHash:              inst.TokenHash,
+			TokenLookup:            inst.TokenLookup,
+			PreviousTokenHash:      inst.PreviousTokenHash,
+			PreviousTokenLookup:    inst.PreviousTokenLookup,
+			PreviousTokenExpiresAt: inst.PreviousTokenExpiresAt,
+		},
+	}
+	if err := s.exportExtras(ctx, string(inst.ID), &content); err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := archive.Seal(in.Passphrase, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.Disconnect {
+		if err := s.instances.Disconnect(ctx, name); err != nil {
+			return nil, fmt.Errorf("archive created but disconnect failed: %w", err)
+		}
+	}
+	if s.log != nil {
+		s.log.Infof("instância %s exportada (device %s, %d bytes)", name, device.JID, len(sealed))
+	}
+	return &instance.ExportInstanceResponse{
+		InstanceName: inst.Name,
+		Archive:      base64.StdEncoding.EncodeToString(sealed),
+		ExportedAt:   now,
+	}, nil
+}
+
+func (s *instanceTransferService) Import(ctx context.Context, in instance.ImportInstanceInput) (*instance.ImportInstanceResponse, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(in.Archive))
+	if err != nil {
+		return nil, archive.ErrInvalidArchive
+	}
+	payload, err := archive.Open(in.Passphrase, raw)
+	if err != nil {
+		return nil, err
+	}
+	var content instanceArchive
+	if err := json.Unmarshal(payload, &content); err != nil {
+		return nil, archive.ErrInvalidArchive
+	}
+	if content.Version < 1 || content.Version > instanceArchiveVersion {
+		return nil, ErrUnsupportedArchive
+	}
+	if content.Device == nil {
+		return nil, whatsapp.ErrDeviceNotPaired
+	}
+
+	inst := content.Instance
+	sourceID := inst.ID
+	if name := strings.TrimSpace(in.InstanceName); name != "" && name != inst.Name {
+		// Renomeada: novo ID para não colidir com a instância de origem no mesmo banco.
+		inst.Name = name
+		inst.ID = instance.ID(uuid.NewString())
+	}
+	if err := applyArchiveCredentials(&inst, content.Credentials); err != nil {
+		return nil, err
+	}
+	if inst.Name == "" || inst.TokenHash == "" {
+		return nil, archive.ErrInvalidArchive
+	}
+	if err := ValidateInstanceName(inst.Name); err != nil {
+		return nil, err
+	}
+	if s.waMgr.TokenLookupInUse(inst.TokenLookup) {
+		return nil, repositories.ErrTokenAlreadyExists
+	}
+	if _, err := s.repo.GetByName(ctx, inst.Name); err == nil {
+		return nil, repositories.ErrInstanceAlreadyExists
+	} else if !errors.Is(err, repositories.ErrInstanceNotFound) {
+		return nil, err
+	}
+
+	stores := s.bootstrap.StoreFactory
+	if err := stores.ImportDevice(ctx, inst.Name, content.Device); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	inst.Status = "connecting"
+	inst.UpdatedAt = now
+	inst.DisconnectionReasonCode = nil
+	inst.DisconnectionObject = nil
+	inst.DisconnectionAt = nil
+	if err := s.repo.Create(ctx, &inst); err != nil {
+		s.rollbackDevice(inst.Name)
+		return nil, err
+	}
+	if err := s.importExtras(ctx, &inst, inst.ID != sourceID, &content); err != nil {
+		s.rollbackExtras(string(inst.ID))
+		_ = s.repo.Delete(ctx, inst.Name)
+		s.rollbackDevice(inst.Name)
+		return nil, err
+	}
+	sess, err := s.waMgr.Create(ctx, inst.Name, InstanceCredentials(&inst)...)
+	if err != nil {
+		s.rollbackExtras(string(inst.ID))
+		_ = s.repo.Delete(ctx, inst.Name)
+		s.rollbackDevice(inst.Name)
+		return nil, err
+	}
+	sess.ID = string(inst.ID)
+	sess.CreatedAt = inst.CreatedAt
+
+	resp := &instance.ImportInstanceResponse{
+		InstanceName: inst.Name,
+		InstanceID:   string(inst.ID),
+		OwnerJID:     content.Device.JID,
+		Status:       inst.Status,
+	}
+	// O device importado já tem ID, então a sessão conecta sem QR.
+	if _, _, err := s.bootstrap.InitNewSession(ctx, inst.Name); err != nil {
+		// A instância fica cadastrada; o supervisor ou um novo connect podem retomar a sessão.
+		if s.log != nil {
+			s.log.Errorf("instância %s importada, mas a conexão falhou: %v", inst.Name, err)
+		}
+		resp.Status = "disconnected"
+		return resp, nil
+	}
+	if s.log != nil {
+		s.log.Infof("instância %s importada (device %s)", inst.Name, content.Device.JID)
+	}
+	return resp, nil
+}
+
+// applyArchiveCredentials copia o hash do token do arquivo. Arquivos sem credenciais
+// (exportados antes do hash) trazem o token em texto puro, que é convertido aqui.
+func applyArchiveCredentials(inst *instance.Instance, creds *archiveCredentials) error {
+	if creds != nil {
+		inst.TokenHash = creds.TokenHash
+		inst.TokenLookup = creds.TokenLookup
+		inst.PreviousTokenHash = creds.PreviousTokenHash
+		inst.PreviousTokenLookup = creds.PreviousTokenLookup
+		inst.PreviousTokenExpiresAt = creds.PreviousTokenExpiresAt
+	} else if inst.Token != "" {
+		credential, err := apitoken.New(inst.Token)
+		if err != nil {
+			return err
+		}
+		inst.TokenHash = credential.Hash
+		inst.TokenLookup = credential.Lookup
+	}
+	inst.Token = ""
+	if !apitoken.IsHash(inst.TokenHash) || inst.TokenLookup == "" {
+		return archive.ErrInvalidArchive
+	}
+	return nil
+}
+
+// exportExtras acrescenta ao arquivo o que fica fora da linha da instância: assinaturas de
+// webhook (com segredos), configs dos destinos de eventos e a cota própria.
+func (s *instanceTransferService) exportExtras(ctx context.Context, instanceID string, content *instanceArchive) error {
+	if s.subs != nil {
+		subs, err := s.subs.List(ctx, instanceID)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subs {
+			content.Subscriptions = append(content.Subscriptions, archiveSubscription{Subscription: *sub, Secret: sub.Secret, PreviousSecret: sub.PreviousSecret})
+		}
+	}
+	if s.sinks != nil {
+		sinks, err := s.sinks.List(ctx, instanceID)
+		if err != nil {
+			return err
+		}
+		for _, cfg := range sinks {
+			content.Sinks = append(content.Sinks, *cfg)
+		}
+	}
+	if s.usage != nil {
+		quota, err := s.usage.GetQuota(ctx, instanceID)
+		if err == nil {
+			content.Quota = &quota
+		} else if !errors.Is(err, repositories.ErrQuotaNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// importExtras recria sob o ID da instância importada as assinaturas, destinos e cota do
+// arquivo. Com novo ID (importação renomeada) as assinaturas também ganham novos IDs, que são
+// únicos no banco.
+func (s *instanceTransferService) importExtras(ctx context.Context, inst *instance.Instance, newIDs bool, content *instanceArchive) error {
+	instanceID := string(inst.ID)
+	if s.subs != nil {
+		for _, archived := range content.Subscriptions {
+			sub := archived.Subscription
+			sub.InstanceID = instanceID
+			sub.Secret = archived.Secret
+			sub.PreviousSecret = archived.PreviousSecret
+			if newIDs {
+				sub.ID = uuid.NewString()
+			}
+			if err := s.subs.Create(ctx, &sub); err != nil {
+				return fmt.Errorf("import webhook subscription %s: %w", archived.ID, err)
+			}
+		}
+	}
+	if s.sinks != nil {
+		for _, cfg := range content.Sinks {
+			cfg.InstanceID = instanceID
+			if err := s.sinks.Save(ctx, &cfg); err != nil {
+				return fmt.Errorf("import event sink %s: %w", cfg.Sink, err)
+			}
+		}
+	}
+	if s.usage != nil && content.Quota != nil {
+		if err := s.usage.SetQuota(ctx, instanceID, *content.Quota); err != nil {
+			return fmt.Errorf("import quota: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *instanceTransferService) rollbackExtras(instanceID string) {
+	ctx := context.Background()
+	if s.subs != nil {
+		_ = s.subs.DeleteByInstance(ctx, instanceID)
+	}
+	if s.sinks != nil {
+		_ = s.sinks.DeleteByInstance(ctx, instanceID)
+	}
+	if s.usage != nil {
+		_ = s.usage.DeleteQuota(ctx, instanceID)
+	}
+}
+
+func (s *instanceTransferService) rollbackDevice(name string) {
+	if err := s.bootstrap.StoreFactory.DeleteDevice(context.Background(), name); err != nil && s.log != nil {
+		s.log.Warnf("falha ao remover device store importado de %s: %v", name, err)
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/pkg/webhooksig"
+)
+
+// minWebhookSecretLength evita segredos informados pelo cliente fáceis de adivinhar.
+const minWebhookSecretLength = 16
+
+var ErrWebhookSecretTooShort = errors.New("secret must have at least 16 characters")
+
+// rotateWebhookSecret calcula o novo segredo e, com período de carência, mantém o atual
+// como anterior até previousExpiresAt.
+func rotateWebhookSecret(current string, in instance.RotateWebhookSecretInput, now time.Time) (secret, previous string, previousExpiresAt *time.Time, err error) {
+	grace := time.Duration(in.GracePeriodSeconds) * time.Second
+	if in.GracePeriodSeconds < 0 || grace > maxTokenGracePeriod {
+		return "", "", nil, ErrInvalidGracePeriod
+	}
+	secret = strings.TrimSpace(in.Secret)
+	if secret == "" {
+		if secret, err = webhooksig.NewSecret(); err != nil {
+			return "", "", nil, err
+		}
+	} else if len(secret) < minWebhookSecretLength {
+		return "", "", nil, ErrWebhookSecretTooShort
+	}
+	if grace > 0 && current != "" {
+		expiresAt := now.Add(grace)
+		return secret, current, &expiresAt, nil
+	}
+	return secret, "", nil, nil
+}
+
+func (s *instanceService) RotateWebhookSecret(ctx context.Context, name string, in instance.RotateWebhookSecretInput) (*instance.RotateWebhookSecretResponse, error) {
+	inst, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	secret, previous, expiresAt, err := rotateWebhookSecret(inst.Webhook.Secret, in, now)
+	if err != nil {
+		return nil, err
+	}
+	inst.Webhook.Secret = secret
+	inst.Webhook.PreviousSecret = previous
+	inst.Webhook.PreviousSecretExpiresAt = expiresAt
+	inst.UpdatedAt = now
+	if err := s.repo.Update(ctx, inst); err != nil {
+		return nil, err
+	}
+	return &instance.RotateWebhookSecretResponse{
+		InstanceName:            inst.Name,
+		Secret:                  secret,
+		PreviousSecretExpiresAt: expiresAt,
+	}, nil
+}
+
+// DeleteWebhookSecret remove os segredos; as entregas seguintes deixam de ser assinadas.
+func (s *instanceService) DeleteWebhookSecret(ctx context.Context, name string) error {
+	inst, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	inst.Webhook.Secret = ""
+	inst.Webhook.PreviousSecret = ""
+	inst.Webhook.PreviousSecretExpiresAt = nil
+	inst.UpdatedAt = time.Now().UTC()
+	return s.repo.Update(ctx, inst)
+}
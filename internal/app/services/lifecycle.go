@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+)
+
+// Lifecycle acompanha o trabalho assíncrono em andamento (handlers de eventos, entregas de
+// webhook) e coordena o encerramento: ao iniciar o drain novos envios são recusados e
+// Wait aguarda o trabalho pendente terminar.
+type Lifecycle struct {
+	draining atomic.Bool
+
+	mu     sync.Mutex
+	active int
+	idle   chan struct{} // fechado quando active volta a zero
+}
+
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// Go executa fn em uma goroutine acompanhada. Um Lifecycle nil apenas dispara a goroutine.
+func (l *Lifecycle) Go(fn func()) {
+	if l == nil {
+		go fn()
+		return
+	}
+	done := l.track()
+	go func() {
+		defer done()
+		fn()
+	}()
+}
+
+func (l *Lifecycle) track() func() {
+	l.mu.Lock()
+	l.active++
+	if l.active == 1 {
+		l.idle = make(chan struct{})
+	}
+	l.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.active--
+			if l.active == 0 {
+				close(l.idle)
+			}
+			l.mu.Unlock()
+		})
+	}
+}
+
+// Pending retorna quantas tarefas acompanhadas ainda estão em execução.
+func (l *Lifecycle) Pending() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active
+}
+
+// BeginDrain passa a recusar novos envios; eventos já recebidos continuam sendo processados.
+func (l *Lifecycle) BeginDrain() {
+	l.draining.Store(true)
+}
+
+func (l *Lifecycle) Draining() bool {
+	return l != nil && l.draining.Load()
+}
+
+// Wait bloqueia até não haver tarefas pendentes ou o contexto expirar.
+func (l *Lifecycle) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	if l.active == 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	idle := l.idle
+	l.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d task(s) still running: %w", l.Pending(), ctx.Err())
+	}
+}
+
+// TrackWebhooks faz as entregas de webhook contarem como trabalho pendente no drain.
+func (l *Lifecycle) TrackWebhooks(next WebhookDispatcher) WebhookDispatcher {
+	if l == nil || next == nil {
+		return next
+	}
+	return &trackedWebhookDispatcher{next: next, lifecycle: l}
+}
+
+type trackedWebhookDispatcher struct {
+	next      WebhookDispatcher
+	lifecycle *Lifecycle
+}
+
+func (d *trackedWebhookDispatcher) Dispatch(ctx context.Context, inst *instance.Instance, event string, payload map[string]any) (bool, error) {
+	done := d.lifecycle.track()
+	defer done()
+	return d.next.Dispatch(ctx, inst, event, payload)
+}
+
+func (d *trackedWebhookDispatcher) HasAudience(ctx context.Context, inst *instance.Instance) bool {
+	return hasAudience(ctx, d.next, inst)
+}
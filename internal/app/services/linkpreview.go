@@ -24,7 +24,7 @@ type SimpleLinkPreview struct {
 }
 
 // fetchSimpleLinkPreview fetches basic link preview data from HTML meta tags
-func fetchSimpleLinkPreview(ctx context.Context, targetURL string) (*SimpleLinkPreview, error) {
+func fetchSimpleLinkPreview(ctx context.Context, client *http.Client, targetURL string) (*SimpleLinkPreview, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
 	if err != nil {
 		return nil, err
@@ -32,10 +32,6 @@ func fetchSimpleLinkPreview(ctx context.Context, targetURL string) (*SimpleLinkP
 
 	req.Header.Set("User-Agent", "WhatsApp/2.23.20")
 
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
-
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -71,7 +67,7 @@ func fetchSimpleLinkPreview(ctx context.Context, targetURL string) (*SimpleLinkP
 
 	// Download image if available
 	if preview.ImageURL != "" {
-		if imgData, err := downloadImageForPreview(ctx, preview.ImageURL); err == nil {
+		if imgData, err := downloadImageForPreview(ctx, client, preview.ImageURL); err == nil {
 			preview.ImageData = imgData
 		}
 	}
@@ -118,16 +114,12 @@ func findMetaContent(html, pattern string) string {
 	return strings.TrimSpace(html[start : start+end])
 }
 
-func downloadImageForPreview(ctx context.Context, imageURL string) ([]byte, error) {
+func downloadImageForPreview(ctx context.Context, client *http.Client, imageURL string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
-
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -165,7 +157,8 @@ func downloadImageForPreview(ctx context.Context, imageURL string) ([]byte, erro
 // Enhanced generateLinkPreview that uses simple HTML parsing as fallback
 func (s *messageService) generateLinkPreviewEnhanced(ctx context.Context, sess *whatsapp.Session, targetURL string) (*LinkPreviewData, error) {
 	// Try simple HTML-based preview first
-	simple, err := fetchSimpleLinkPreview(ctx, targetURL)
+	// O preview é buscado pelo proxy da instância, quando configurado
+	simple, err := fetchSimpleLinkPreview(ctx, s.waMgr.HTTPClient(sess.Name, 5*time.Second), targetURL)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	qrcode "github.com/skip2/go-qrcode"
+	"go.mau.fi/whatsmeow/types/events"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// Eventos publicados no stream de login (SSE/WebSocket)
+const (
+	LoginEventQRCode     = "qrcode"
+	LoginEventConnection = "connection"
+	LoginEventSuccess    = "success"
+)
+
+// loginStreamBuffer é o número de quadros pendentes por assinante antes de derrubá-lo.
+const loginStreamBuffer = 32
+
+// LoginEvent é um quadro do stream de login de uma instância.
+type LoginEvent struct {
+	Event        string    `json:"event"`
+	Instance     string    `json:"instance"`
+	Timestamp    time.Time `json:"timestamp"`
+	Code         string    `json:"code,omitempty"`
+	Base64       string    `json:"base64,omitempty"` // PNG do QR em data URI
+	Link         string    `json:"link,omitempty"`   // URL do QR quando há armazenamento externo
+	PairingCode  string    `json:"pairingCode,omitempty"`
+	State        string    `json:"state,omitempty"`
+	StatusReason int       `json:"statusReason,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	WUID         string    `json:"wuid,omitempty"`
+}
+
+// Final indica que o stream deve ser encerrado após este quadro.
+func (e LoginEvent) Final() bool {
+	return e.Event == LoginEventSuccess
+}
+
+// LoginStreams distribui QR codes, pairing codes e mudanças de conexão para os streams
+// abertos de cada instância. Fica na frente dos listeners do bootstrap e repassa os eventos.
+type LoginStreams struct {
+	waMgr *whatsapp.Manager
+	qr    QRCodeListener
+	conn  ConnectionEventListener
+	log   waLog.Logger
+
+	mu   sync.Mutex
+	subs map[string]map[chan LoginEvent]struct{}
+}
+
+func NewLoginStreams(waMgr *whatsapp.Manager, qr QRCodeListener, conn ConnectionEventListener, log waLog.Logger) *LoginStreams {
+	return &LoginStreams{waMgr: waMgr, qr: qr, conn: conn, log: log, subs: make(map[string]map[chan LoginEvent]struct{})}
+}
+
+// Subscribe abre um stream para a instância. O canal é fechado quando o assinante não
+// acompanha o ritmo; cancel deve ser chamado ao encerrar.
+func (s *LoginStreams) Subscribe(name string) (<-chan LoginEvent, func()) {
+	ch := make(chan LoginEvent, loginStreamBuffer)
+	s.mu.Lock()
+	if s.subs[name] == nil {
+		s.subs[name] = make(map[chan LoginEvent]struct{})
+	}
+	s.subs[name][ch] = struct{}{}
+	s.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() { s.remove(name, ch) })
+	}
+}
+
+// Snapshot retorna o estado atual da instância (conexão e último QR) para quem acabou de se conectar.
+func (s *LoginStreams) Snapshot(name string) ([]LoginEvent, bool) {
+	sess, ok := s.waMgr.Get(name)
+	if !ok || sess == nil {
+		return nil, false
+	}
+	now := time.Now().UTC()
+	state := LoginEvent{Event: LoginEventConnection, Instance: name, Timestamp: now, State: connectionStateClose}
+	if client := sess.Client; client != nil {
+		if client.IsConnected() && client.IsLoggedIn() {
+			state.State = connectionStateOpen
+			success := LoginEvent{Event: LoginEventSuccess, Instance: name, Timestamp: now, State: connectionStateOpen}
+			if client.Store != nil && client.Store.ID != nil {
+				success.WUID = client.Store.ID.ToNonAD().String()
+			}
+			return []LoginEvent{state, success}, true
+		}
+		if client.IsConnected() {
+			state.State = connectionStateConnecting
+		}
+	}
+	out := []LoginEvent{state}
+	if cached, ok := s.waMgr.GetLastQR(name); ok && cached != "" {
+		out = append(out, qrLoginEvent(name, cached, ""))
+	}
+	return out, true
+}
+
+func (s *LoginStreams) HandleQRCode(ctx context.Context, instanceName, code, pairingCode string) {
+	if s.qr != nil {
+		s.qr.HandleQRCode(ctx, instanceName, code, pairingCode)
+	}
+	if code == "" && pairingCode == "" {
+		return
+	}
+	s.publish(qrLoginEvent(instanceName, code, pairingCode))
+}
+
+func (s *LoginStreams) HandleConnectionEvent(ctx context.Context, instanceName string, evt any) {
+	if s.conn != nil {
+		s.conn.HandleConnectionEvent(ctx, instanceName, evt)
+	}
+	change, ok := classifyConnectionEvent(evt)
+	if !ok {
+		return
+	}
+	now := time.Now().UTC()
+	frame := LoginEvent{
+		Event:        LoginEventConnection,
+		Instance:     instanceName,
+		Timestamp:    now,
+		State:        change.state,
+		StatusReason: change.statusReason,
+		Reason:       change.reason,
+	}
+	if e, ok := evt.(*events.PairSuccess); ok {
+		frame.WUID = e.ID.ToNonAD().String()
+	}
+	s.publish(frame)
+	if _, ok := evt.(*events.Connected); ok {
+		success := LoginEvent{Event: LoginEventSuccess, Instance: instanceName, Timestamp: now, State: connectionStateOpen}
+		if sess, ok := s.waMgr.Get(instanceName); ok && sess.Client != nil && sess.Client.Store != nil && sess.Client.Store.ID != nil {
+			success.WUID = sess.Client.Store.ID.ToNonAD().String()
+		}
+		s.publish(success)
+	}
+}
+
+func (s *LoginStreams) publish(evt LoginEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subs[evt.Instance] {
+		select {
+		case ch <- evt:
+		default:
+			// Assinante lento: encerra o stream em vez de perder quadros silenciosamente.
+			if s.log != nil {
+				s.log.Warnf("login stream for %s dropped: subscriber too slow", evt.Instance)
+			}
+			delete(s.subs[evt.Instance], ch)
+			close(ch)
+		}
+	}
+}
+
+func (s *LoginStreams) remove(name string, ch chan LoginEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subs := s.subs[name]
+	if _, ok := subs[ch]; !ok {
+		return
+	}
+	delete(subs, ch)
+	close(ch)
+	if len(subs) == 0 {
+		delete(s.subs, name)
+	}
+}
+
+// CloseAll encerra todos os streams abertos (shutdown); os handlers terminam a resposta.
+func (s *LoginStreams) CloseAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, subs := range s.subs {
+		for ch := range subs {
+			close(ch)
+		}
+		delete(s.subs, name)
+	}
+}
+
+// qrLoginEvent monta o quadro de QR; value pode ser o código bruto ou a URL do QR armazenado.
+func qrLoginEvent(name, value, pairingCode string) LoginEvent {
+	frame := LoginEvent{Event: LoginEventQRCode, Instance: name, Timestamp: time.Now().UTC(), PairingCode: pairingCode}
+	switch {
+	case value == "":
+	case strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") || strings.HasPrefix(value, "/"):
+		frame.Link = value
+	default:
+		frame.Code = value
+		if png, err := qrcode.Encode(value, qrcode.Medium, 256); err == nil {
+			frame.Base64 = "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+		}
+	}
+	return frame
+}
+
+var (
+	_ ConnectionEventListener = (*LoginStreams)(nil)
+	_ QRCodeListener          = (*LoginStreams)(nil)
+)
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/message"
+)
+
+var ErrUnknownMessageCommand = errors.New("unknown message command type")
+
+// MessageCommand é um envio pedido fora da API HTTP (ex.: pela fila de comandos do RabbitMQ).
+// Type é o nome da rota (sendText, sendMedia, ...) e Data o mesmo corpo de
+// /message/{type}/{instance}.
+type MessageCommand struct {
+	ID       string          `json:"id,omitempty"`
+	Instance string          `json:"instance"`
+	Type     string          `json:"type"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// MessageCommandResult é a resposta de um comando, enviada ao reply_to quando informado.
+type MessageCommandResult struct {
+	ID       string                  `json:"id,omitempty"`
+	Instance string                  `json:"instance"`
+	Type     string                  `json:"type"`
+	Status   string                  `json:"status"` // sent ou error
+	Error    string                  `json:"error,omitempty"`
+	Message  *message.SendTextOutput `json:"message,omitempty"`
+}
+
+type messageCommandFunc func(ctx context.Context, svc MessageService, body []byte) (message.SendTextOutput, error)
+
+var messageCommands = map[string]messageCommandFunc{
+	"sendText":          messageCommand(MessageService.SendText),
+	"sendMedia":         messageCommand(MessageService.SendMedia),
+	"sendStatus":        messageCommand(MessageService.SendStatus),
+	"sendWhatsAppAudio": messageCommand(MessageService.SendAudio),
+	"sendSticker":       messageCommand(MessageService.SendSticker),
+	"sendLocation":      messageCommand(MessageService.SendLocation),
+	"sendContact":       messageCommand(MessageService.SendContact),
+	"sendReaction":      messageCommand(MessageService.SendReaction),
+	"sendPoll":          messageCommand(MessageService.SendPoll),
+	"sendList":          messageCommand(MessageService.SendList),
+	"sendButtons":       messageCommand(MessageService.SendButtons),
+}
+
+func messageCommand[T any](send func(MessageService, context.Context, T) (message.SendTextOutput, error)) messageCommandFunc {
+	return func(ctx context.Context, svc MessageService, body []byte) (message.SendTextOutput, error) {
+		var in T
+		if err := json.Unmarshal(body, &in); err != nil {
+			return message.SendTextOutput{}, fmt.Errorf("invalid command data: %w", err)
+		}
+		return send(svc, ctx, in)
+	}
+}
+
+// ExecuteMessageCommand executa o envio com as mesmas regras das rotas HTTP: a instância vem
+// do comando (como do path), linkPreview é ligado por padrão em sendText e sendMedia e as
+// cotas de envio valem normalmente.
+func ExecuteMessageCommand(ctx context.Context, svc MessageService, cmd MessageCommand) (message.SendTextOutput, error) {
+	run, ok := messageCommands[cmd.Type]
+	if !ok {
+		return message.SendTextOutput{}, fmt.Errorf("%w: %q", ErrUnknownMessageCommand, cmd.Type)
+	}
+	instanceName := strings.TrimSpace(cmd.Instance)
+	if instanceName == "" {
+		return message.SendTextOutput{}, errors.New("command instance is required")
+	}
+	raw := map[string]any{}
+	if len(cmd.Data) > 0 {
+		if err := json.Unmarshal(cmd.Data, &raw); err != nil {
+			return message.SendTextOutput{}, fmt.Errorf("invalid command data: %w", err)
+		}
+	}
+	if raw == nil {
+		raw = map[string]any{}
+	}
+	raw["instanceId"] = instanceName
+	if _, exists := raw["linkPreview"]; !exists && (cmd.Type == "sendText" || cmd.Type == "sendMedia") {
+		raw["linkPreview"] = true
+	}
+	body, err := json.Marshal(raw)
+	if err != nil {
+		return message.SendTextOutput{}, err
+	}
+	return run(ctx, svc, body)
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,14 +16,22 @@ import (
 	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
 	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
 	"github.com/faeln1/go-whatsapp-api/pkg/storage"
+	"go.mau.fi/whatsmeow"
 	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 	waLog "go.mau.fi/whatsmeow/util/log"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
 
+// DefaultMaxBase64Bytes limita a mídia embutida no webhook quando webhookBase64 está ativo.
+const DefaultMaxBase64Bytes int64 = 5 << 20
+
 type MessageEventHandler struct {
+	// MaxBase64Bytes limita as mídias embutidas em base64 (0 = DefaultMaxBase64Bytes, negativo = sem limite)
+	MaxBase64Bytes int64
+
 	repo             repositories.InstanceRepository
 	waMgr            *whatsapp.Manager
 	storage          storage.Service
@@ -62,7 +71,14 @@ func (h *MessageEventHandler) HandleMessage(ctx context.Context, instanceName st
 	if inst == nil {
 		return
 	}
-	if inst.Webhook.URL == "" && inst.WebhookURL == "" {
+	if shouldIgnoreGroupMessage(inst.Settings, evt) {
+		if h.log != nil {
+			h.log.Debugf("messages.upsert instance=%s chat=%s ignored (groupsIgnore)", inst.Name, evt.Info.Chat)
+		}
+		return
+	}
+	h.markReadBySettings(inst, sess, evt)
+	if !hasAudience(ctx, h.dispatcher, inst) {
 		return
 	}
 
@@ -74,7 +90,7 @@ func (h *MessageEventHandler) HandleMessage(ctx context.Context, instanceName st
 		evt.Message = evt.RawMessage
 	}
 
-	uploads := h.replaceMedia(ctx, inst, sess, evt)
+	media, encoded := h.replaceMedia(ctx, inst, sess, evt)
 
 	messageType := detectMessageType(evt.Message)
 
@@ -87,13 +103,96 @@ func (h *MessageEventHandler) HandleMessage(ctx context.Context, instanceName st
 		h.log.Debugf("messages.upsert instance=%s chat=%s id=%s type=%s", inst.Name, evt.Info.Chat, evt.Info.ID, messageType)
 	}
 
-	messageMap, err := protoToMap(evt.Message)
+	payload, err := buildMessagePayload(inst, evt, messageType)
 	if err != nil {
 		if h.log != nil {
 			h.log.Errorf("messages.upsert instance=%s marshal error: %v", instanceName, err)
 		}
 		return
 	}
+	if len(media) > 0 {
+		payload["media"] = media
+	}
+	if encoded != "" {
+		// Mesmo formato da Evolution API: o conteúdo vai em message.base64
+		if message, ok := payload["message"].(map[string]any); ok {
+			message["base64"] = encoded
+		} else {
+			payload["message"] = map[string]any{"base64": encoded}
+		}
+	}
+
+	dispatchCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	targetURL := strings.TrimSpace(inst.Webhook.URL)
+	if targetURL == "" {
+		targetURL = strings.TrimSpace(inst.WebhookURL)
+	}
+	delivered, err := h.dispatcher.Dispatch(dispatchCtx, inst, "messages.upsert", payload)
+	if err != nil {
+		if h.log != nil {
+			h.log.Errorf("messages.upsert instance=%s dispatch error: %v", instanceName, err)
+		}
+	} else if delivered {
+		if h.log != nil {
+			h.log.Debugf("messages.upsert instance=%s dispatched to %s", inst.Name, targetURL)
+		}
+	} else if h.log != nil {
+		h.log.Debugf("messages.upsert instance=%s webhook skipped (disabled or filtered)", inst.Name)
+	}
+}
+
+// shouldIgnoreGroupMessage indica se a mensagem deve ser descartada por causa da configuração groupsIgnore.
+func shouldIgnoreGroupMessage(settings instance.InstanceSettings, evt *events.Message) bool {
+	if !settings.GroupsIgnore || evt == nil {
+		return false
+	}
+	return evt.Info.IsGroup || evt.Info.Chat.Server == types.GroupServer
+}
+
+// shouldMarkRead decide se a mensagem recebida deve ser marcada como lida conforme readMessages/readStatus.
+func shouldMarkRead(settings instance.InstanceSettings, evt *events.Message) bool {
+	if evt == nil || evt.Info.IsFromMe || evt.Info.ID == "" {
+		return false
+	}
+	if evt.Info.Chat == types.StatusBroadcastJID {
+		return settings.ReadStatus
+	}
+	if evt.Info.Chat.Server == types.BroadcastServer {
+		return false
+	}
+	return settings.ReadMessages
+}
+
+func (h *MessageEventHandler) markReadBySettings(inst *instance.Instance, sess *whatsapp.Session, evt *events.Message) {
+	if !shouldMarkRead(inst.Settings, evt) || sess == nil || sess.Client == nil {
+		return
+	}
+	ts := evt.Info.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	sender := types.EmptyJID
+	if evt.Info.IsGroup || evt.Info.Chat == types.StatusBroadcastJID {
+		sender = evt.Info.Sender
+	}
+	if err := sess.Client.MarkRead([]types.MessageID{evt.Info.ID}, ts, evt.Info.Chat, sender); err != nil {
+		if h.log != nil {
+			h.log.Warnf("messages.upsert instance=%s mark read failed id=%s: %v", inst.Name, evt.Info.ID, err)
+		}
+		return
+	}
+	if h.log != nil {
+		h.log.Debugf("messages.upsert instance=%s marked read chat=%s id=%s", inst.Name, evt.Info.Chat, evt.Info.ID)
+	}
+}
+
+// buildMessagePayload monta o payload no formato Evolution (messages.upsert) para a mensagem.
+func buildMessagePayload(inst *instance.Instance, evt *events.Message, messageType string) (map[string]any, error) {
+	messageMap, err := protoToMap(evt.Message)
+	if err != nil {
+		return nil, err
+	}
 
 	contextMap := extractContextInfo(evt.Message)
 
@@ -154,128 +253,136 @@ func (h *MessageEventHandler) HandleMessage(ctx context.Context, instanceName st
 	if len(contextMap) > 0 {
 		payload["contextInfo"] = contextMap
 	}
-	if len(uploads) > 0 {
-		payload["media"] = uploads
-	}
+	return payload, nil
+}
 
-	dispatchCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	targetURL := strings.TrimSpace(inst.Webhook.URL)
-	if targetURL == "" {
-		targetURL = strings.TrimSpace(inst.WebhookURL)
+// inboundMedia descreve uma mídia recebida: como baixá-la, a extensão padrão e onde gravar a URL.
+type inboundMedia struct {
+	kind        string
+	media       whatsmeow.DownloadableMessage
+	mimeType    string
+	fallbackExt string
+	fileName    string
+	fileLength  uint64 // tamanho declarado na mensagem; 0 quando ausente
+	setURL      func(string)
+}
+
+func inboundMediaOf(msg *waProto.Message) []inboundMedia {
+	var out []inboundMedia
+	if image := msg.GetImageMessage(); image != nil {
+		out = append(out, inboundMedia{"image", image, image.GetMimetype(), ".jpg", "", image.GetFileLength(), func(u string) { image.URL = proto.String(u) }})
 	}
-	delivered, err := h.dispatcher.Dispatch(dispatchCtx, inst, "messages.upsert", payload)
-	if err != nil {
-		if h.log != nil {
-			h.log.Errorf("messages.upsert instance=%s dispatch error: %v", instanceName, err)
-		}
-	} else if delivered {
-		if h.log != nil {
-			h.log.Debugf("messages.upsert instance=%s dispatched to %s", inst.Name, targetURL)
-		}
-	} else if h.log != nil {
-		h.log.Debugf("messages.upsert instance=%s webhook skipped (disabled or filtered)", inst.Name)
+	if audio := msg.GetAudioMessage(); audio != nil {
+		out = append(out, inboundMedia{"audio", audio, audio.GetMimetype(), ".ogg", "", audio.GetFileLength(), func(u string) { audio.URL = proto.String(u) }})
+	}
+	if video := msg.GetVideoMessage(); video != nil {
+		out = append(out, inboundMedia{"video", video, video.GetMimetype(), ".mp4", "", video.GetFileLength(), func(u string) { video.URL = proto.String(u) }})
+	}
+	if doc := msg.GetDocumentMessage(); doc != nil {
+		out = append(out, inboundMedia{"document", doc, doc.GetMimetype(), ".bin", doc.GetFileName(), doc.GetFileLength(), func(u string) { doc.URL = proto.String(u) }})
+	}
+	if sticker := msg.GetStickerMessage(); sticker != nil {
+		out = append(out, inboundMedia{"sticker", sticker, sticker.GetMimetype(), ".webp", "", sticker.GetFileLength(), func(u string) { sticker.URL = proto.String(u) }})
 	}
+	return out
 }
 
-func (h *MessageEventHandler) replaceMedia(ctx context.Context, inst *instance.Instance, sess *whatsapp.Session, evt *events.Message) []map[string]string {
-	if h.storage == nil || sess == nil || sess.Client == nil || evt.Message == nil {
-		return nil
+// mediaDownloader baixa e decifra uma mídia recebida, como whatsmeow.Client.Download.
+type mediaDownloader func(ctx context.Context, msg whatsmeow.DownloadableMessage) ([]byte, error)
+
+// replaceMedia baixa a mídia recebida, envia ao object storage (trocando a URL da mensagem pela
+// do storage) e, com webhookBase64, embute o conteúdo em base64 quando cabe no limite.
+func (h *MessageEventHandler) replaceMedia(ctx context.Context, inst *instance.Instance, sess *whatsapp.Session, evt *events.Message) ([]map[string]any, string) {
+	if (h.storage == nil && !inst.Webhook.Base64) || sess == nil || sess.Client == nil || evt.Message == nil {
+		return nil, ""
 	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	return h.collectMedia(ctx, inst, evt, sess.Client.Download)
+}
 
-	var uploads []map[string]string
-	msg := evt.Message
-
-	if image := msg.GetImageMessage(); image != nil {
-		if data, err := sess.Client.Download(ctx, image); err != nil {
-			if h.log != nil {
-				h.log.Warnf("messages.upsert instance=%s image download failed: %v", inst.Name, err)
-			}
-		} else if len(data) > 0 {
-			if url, ct, err := h.putMedia(ctx, inst, evt, data, image.GetMimetype(), ".jpg", ""); err != nil {
-				if h.log != nil {
-					h.log.Errorf("messages.upsert instance=%s image upload failed: %v", inst.Name, err)
-				}
-			} else if url != "" {
-				image.URL = proto.String(url)
-				uploads = append(uploads, map[string]string{"type": "image", "url": url, "mimeType": ct})
-			}
+// collectMedia monta uma entrada de "media" por mídia da mensagem. A primeira mídia embutida
+// segue em message.base64 (formato da Evolution API) e as demais levam o base64 na própria
+// entrada. Mídias acima do limite seguem com a URL do storage e, sem storage, com
+// mediaTooLarge e o tamanho; nesse caso o tamanho declarado evita baixá-las à toa.
+func (h *MessageEventHandler) collectMedia(ctx context.Context, inst *instance.Instance, evt *events.Message, download mediaDownloader) ([]map[string]any, string) {
+	embed := inst.Webhook.Base64
+	limit := h.base64Limit()
+	var (
+		media   []map[string]any
+		encoded string
+	)
+	for _, m := range inboundMediaOf(evt.Message) {
+		entry := map[string]any{"type": m.kind}
+		if m.mimeType != "" {
+			entry["mimeType"] = m.mimeType
 		}
-	}
-
-	if audio := msg.GetAudioMessage(); audio != nil {
-		if data, err := sess.Client.Download(ctx, audio); err != nil {
-			if h.log != nil {
-				h.log.Warnf("messages.upsert instance=%s audio download failed: %v", inst.Name, err)
-			}
-		} else if len(data) > 0 {
-			if url, ct, err := h.putMedia(ctx, inst, evt, data, audio.GetMimetype(), ".ogg", ""); err != nil {
-				if h.log != nil {
-					h.log.Errorf("messages.upsert instance=%s audio upload failed: %v", inst.Name, err)
-				}
-			} else if url != "" {
-				audio.URL = proto.String(url)
-				uploads = append(uploads, map[string]string{"type": "audio", "url": url, "mimeType": ct})
-			}
+		if embed && h.storage == nil && limit > 0 && m.fileLength > uint64(limit) {
+			h.markTooLarge(inst, entry, m.kind, int64(m.fileLength), limit)
+			media = append(media, entry)
+			continue
 		}
-	}
-
-	if video := msg.GetVideoMessage(); video != nil {
-		if data, err := sess.Client.Download(ctx, video); err != nil {
+		data, err := download(ctx, m.media)
+		if err != nil {
 			if h.log != nil {
-				h.log.Warnf("messages.upsert instance=%s video download failed: %v", inst.Name, err)
-			}
-		} else if len(data) > 0 {
-			if url, ct, err := h.putMedia(ctx, inst, evt, data, video.GetMimetype(), ".mp4", ""); err != nil {
-				if h.log != nil {
-					h.log.Errorf("messages.upsert instance=%s video upload failed: %v", inst.Name, err)
-				}
-			} else if url != "" {
-				video.URL = proto.String(url)
-				uploads = append(uploads, map[string]string{"type": "video", "url": url, "mimeType": ct})
+				h.log.Warnf("messages.upsert instance=%s %s download failed: %v", inst.Name, m.kind, err)
 			}
+			continue
 		}
-	}
-
-	if doc := msg.GetDocumentMessage(); doc != nil {
-		if data, err := sess.Client.Download(ctx, doc); err != nil {
-			if h.log != nil {
-				h.log.Warnf("messages.upsert instance=%s document download failed: %v", inst.Name, err)
+		if len(data) == 0 {
+			continue
+		}
+		if embed {
+			if limit > 0 && int64(len(data)) > limit {
+				h.markTooLarge(inst, entry, m.kind, int64(len(data)), limit)
+			} else if encoded == "" {
+				encoded = base64.StdEncoding.EncodeToString(data)
+			} else {
+				entry["base64"] = base64.StdEncoding.EncodeToString(data)
 			}
-		} else if len(data) > 0 {
-			fileName := doc.GetFileName()
-			if url, ct, err := h.putMedia(ctx, inst, evt, data, doc.GetMimetype(), ".bin", fileName); err != nil {
+		}
+		if h.storage != nil {
+			if url, ct, err := h.putMedia(ctx, inst, evt, data, m.mimeType, m.fallbackExt, m.fileName); err != nil {
 				if h.log != nil {
-					h.log.Errorf("messages.upsert instance=%s document upload failed: %v", inst.Name, err)
+					h.log.Errorf("messages.upsert instance=%s %s upload failed: %v", inst.Name, m.kind, err)
 				}
 			} else if url != "" {
-				doc.URL = proto.String(url)
-				uploads = append(uploads, map[string]string{"type": "document", "url": url, "mimeType": ct})
+				m.setURL(url)
+				entry["url"] = url
+				entry["mimeType"] = ct
 			}
 		}
+		if hasMediaResult(entry) {
+			media = append(media, entry)
+		}
 	}
+	return media, encoded
+}
 
-	if sticker := msg.GetStickerMessage(); sticker != nil {
-		if data, err := sess.Client.Download(ctx, sticker); err != nil {
-			if h.log != nil {
-				h.log.Warnf("messages.upsert instance=%s sticker download failed: %v", inst.Name, err)
-			}
-		} else if len(data) > 0 {
-			if url, ct, err := h.putMedia(ctx, inst, evt, data, sticker.GetMimetype(), ".webp", ""); err != nil {
-				if h.log != nil {
-					h.log.Errorf("messages.upsert instance=%s sticker upload failed: %v", inst.Name, err)
-				}
-			} else if url != "" {
-				sticker.URL = proto.String(url)
-				uploads = append(uploads, map[string]string{"type": "sticker", "url": url, "mimeType": ct})
-			}
-		}
+// hasMediaResult indica se a entrada tem URL, conteúdo ou marcador a entregar.
+func hasMediaResult(entry map[string]any) bool {
+	_, url := entry["url"]
+	_, data := entry["base64"]
+	_, tooLarge := entry["mediaTooLarge"]
+	return url || data || tooLarge
+}
+
+// markTooLarge sinaliza na entrada que a mídia não foi embutida por exceder o limite; com
+// storage a entrada ainda recebe a URL.
+func (h *MessageEventHandler) markTooLarge(inst *instance.Instance, entry map[string]any, kind string, size, limit int64) {
+	entry["mediaTooLarge"] = true
+	entry["fileLength"] = size
+	if h.log != nil {
+		h.log.Infof("messages.upsert instance=%s %s has %d bytes, above base64 limit %d; not embedding", inst.Name, kind, size, limit)
 	}
+}
 
-	return uploads
+func (h *MessageEventHandler) base64Limit() int64 {
+	if h.MaxBase64Bytes != 0 {
+		return h.MaxBase64Bytes
+	}
+	return DefaultMaxBase64Bytes
 }
 
 func (h *MessageEventHandler) putMedia(ctx context.Context, inst *instance.Instance, evt *events.Message, data []byte, mimeType, fallbackExt, fileName string) (string, string, error) {
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/pkg/storage"
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+type stubStorage struct{ keys []string }
+
+func (s *stubStorage) PutObject(ctx context.Context, in storage.UploadInput) (string, error) {
+	s.keys = append(s.keys, in.Key)
+	return "https://storage.example.com/" + in.Key, nil
+}
+
+func (s *stubStorage) DeleteObject(ctx context.Context, key string) error { return nil }
+
+// stubDownload devolve conteúdo do tamanho pedido por mídia e conta os downloads.
+func stubDownload(sizes map[string]int, calls *int) mediaDownloader {
+	return func(ctx context.Context, msg whatsmeow.DownloadableMessage) ([]byte, error) {
+		*calls++
+		kind := "image"
+		if _, ok := msg.(*waProto.DocumentMessage); ok {
+			kind = "document"
+		}
+		return make([]byte, sizes[kind]), nil
+	}
+}
+
+func mediaEvent(imageLength, docLength uint64) *events.Message {
+	evt := &events.Message{Message: &waProto.Message{
+		ImageMessage:    &waProto.ImageMessage{Mimetype: proto.String("image/jpeg"), FileLength: proto.Uint64(imageLength)},
+		DocumentMessage: &waProto.DocumentMessage{Mimetype: proto.String("application/pdf"), FileName: proto.String("nota.pdf"), FileLength: proto.Uint64(docLength)},
+	}}
+	evt.Info.ID = "MSG1"
+	return evt
+}
+
+func TestCollectMediaEmbedsEveryItemUnderTheCap(t *testing.T) {
+	inst := &instance.Instance{Name: "shop", Webhook: instance.InstanceWebhook{Base64: true}}
+	h := &MessageEventHandler{MaxBase64Bytes: 100}
+	var calls int
+	media, encoded := h.collectMedia(context.Background(), inst, mediaEvent(10, 20), stubDownload(map[string]int{"image": 10, "document": 20}, &calls))
+	if encoded != base64.StdEncoding.EncodeToString(make([]byte, 10)) {
+		t.Fatalf("expected first media in message.base64, got %q", encoded)
+	}
+	// A segunda mídia também é embutida, na própria entrada.
+	if len(media) != 1 || media[0]["type"] != "document" || media[0]["base64"] != base64.StdEncoding.EncodeToString(make([]byte, 20)) {
+		t.Fatalf("expected document embedded in its media entry, got %+v", media)
+	}
+}
+
+func TestCollectMediaAboveCapWithoutStorage(t *testing.T) {
+	inst := &instance.Instance{Name: "shop", Webhook: instance.InstanceWebhook{Base64: true}}
+	h := &MessageEventHandler{MaxBase64Bytes: 100}
+	var calls int
+	// O tamanho declarado da imagem já excede o limite: nada é baixado para ela.
+	media, encoded := h.collectMedia(context.Background(), inst, mediaEvent(500, 20), stubDownload(map[string]int{"image": 500, "document": 20}, &calls))
+	if calls != 1 {
+		t.Fatalf("expected only the document to be downloaded, got %d downloads", calls)
+	}
+	if encoded != base64.StdEncoding.EncodeToString(make([]byte, 20)) {
+		t.Fatalf("expected document in message.base64, got %q", encoded)
+	}
+	if len(media) != 1 || media[0]["type"] != "image" || media[0]["mediaTooLarge"] != true || media[0]["fileLength"] != int64(500) {
+		t.Fatalf("expected mediaTooLarge marker for the image, got %+v", media)
+	}
+
+	// Sem tamanho declarado o limite vale sobre o conteúdo baixado.
+	calls = 0
+	media, encoded = h.collectMedia(context.Background(), inst, mediaEvent(0, 0), stubDownload(map[string]int{"image": 500, "document": 500}, &calls))
+	if calls != 2 || encoded != "" || len(media) != 2 || media[0]["mediaTooLarge"] != true || media[1]["mediaTooLarge"] != true {
+		t.Fatalf("expected both media marked too large after download, got calls=%d encoded=%q media=%+v", calls, encoded, media)
+	}
+}
+
+func TestCollectMediaAboveCapFallsBackToStorageURL(t *testing.T) {
+	inst := &instance.Instance{Name: "shop", Webhook: instance.InstanceWebhook{Base64: true}}
+	store := &stubStorage{}
+	h := &MessageEventHandler{MaxBase64Bytes: 100, storage: store}
+	var calls int
+	media, encoded := h.collectMedia(context.Background(), inst, mediaEvent(500, 0), stubDownload(map[string]int{"image": 500}, &calls))
+	if encoded != "" {
+		t.Fatalf("expected nothing embedded above the cap")
+	}
+	if len(media) != 1 || media[0]["mediaTooLarge"] != true || media[0]["url"] == nil {
+		t.Fatalf("expected storage URL alongside the marker, got %+v", media)
+	}
+	if len(store.keys) != 1 {
+		t.Fatalf("expected one upload, got %v", store.keys)
+	}
+}
+
+func TestCollectMediaUnlimited(t *testing.T) {
+	inst := &instance.Instance{Name: "shop", Webhook: instance.InstanceWebhook{Base64: true}}
+	h := &MessageEventHandler{MaxBase64Bytes: -1}
+	var calls int
+	media, encoded := h.collectMedia(context.Background(), inst, mediaEvent(uint64(DefaultMaxBase64Bytes)*2, 0), stubDownload(map[string]int{"image": int(DefaultMaxBase64Bytes) + 1}, &calls))
+	if encoded == "" || len(media) != 0 {
+		t.Fatalf("expected media embedded without limit, got encoded=%d media=%+v", len(encoded), media)
+	}
+}
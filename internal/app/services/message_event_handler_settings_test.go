@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestShouldIgnoreGroupMessage(t *testing.T) {
+	group := types.NewJID("123456789", types.GroupServer)
+	user := types.NewJID("5511999999999", types.DefaultUserServer)
+
+	tests := []struct {
+		name     string
+		settings instance.InstanceSettings
+		chat     types.JID
+		isGroup  bool
+		want     bool
+	}{
+		{name: "groups allowed", settings: instance.InstanceSettings{}, chat: group, isGroup: true, want: false},
+		{name: "group ignored", settings: instance.InstanceSettings{GroupsIgnore: true}, chat: group, isGroup: true, want: true},
+		{name: "direct chat kept", settings: instance.InstanceSettings{GroupsIgnore: true}, chat: user, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evt := &events.Message{}
+			evt.Info.Chat = tt.chat
+			evt.Info.IsGroup = tt.isGroup
+			if got := shouldIgnoreGroupMessage(tt.settings, evt); got != tt.want {
+				t.Errorf("shouldIgnoreGroupMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldMarkRead(t *testing.T) {
+	user := types.NewJID("5511999999999", types.DefaultUserServer)
+
+	tests := []struct {
+		name     string
+		settings instance.InstanceSettings
+		chat     types.JID
+		fromMe   bool
+		want     bool
+	}{
+		{name: "read messages disabled", settings: instance.InstanceSettings{}, chat: user, want: false},
+		{name: "read messages enabled", settings: instance.InstanceSettings{ReadMessages: true}, chat: user, want: true},
+		{name: "own message skipped", settings: instance.InstanceSettings{ReadMessages: true}, chat: user, fromMe: true, want: false},
+		{name: "status needs readStatus", settings: instance.InstanceSettings{ReadMessages: true}, chat: types.StatusBroadcastJID, want: false},
+		{name: "status read", settings: instance.InstanceSettings{ReadStatus: true}, chat: types.StatusBroadcastJID, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evt := &events.Message{}
+			evt.Info.ID = "ABC123"
+			evt.Info.Chat = tt.chat
+			evt.Info.IsFromMe = tt.fromMe
+			if got := shouldMarkRead(tt.settings, evt); got != tt.want {
+				t.Errorf("shouldMarkRead() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
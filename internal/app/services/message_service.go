@@ -36,17 +36,26 @@ type MessageService interface {
 	SendPoll(ctx context.Context, in message.SendPollInput) (message.SendTextOutput, error)
 	SendList(ctx context.Context, in message.SendListInput) (message.SendTextOutput, error)
 	SendButtons(ctx context.Context, in message.SendButtonInput) (message.SendTextOutput, error)
+	// SetUsage habilita a contabilização de envios e as cotas por instância.
+	SetUsage(usage UsageService)
 }
 
 type messageService struct {
 	waMgr   *whatsapp.Manager
 	storage storage.Service
+	usage   UsageService
 }
 
 const maxMediaSizeBytes = 64 * 1024 * 1024 // 64MB limit per attachment
 
 var errMediaTooLarge = errors.New("media payload exceeds 64MB limit")
 
+// Erros de sessão indisponível: nada foi enviado e o envio pode ser repetido depois.
+var (
+	ErrInstanceNotReady     = errors.New("instance client not ready")
+	ErrInstanceNotConnected = errors.New("instance not connected")
+)
+
 func NewMessageService(waMgr *whatsapp.Manager, storage storage.Service) MessageService {
 	return &messageService{waMgr: waMgr, storage: storage}
 }
@@ -124,7 +133,7 @@ func (s *messageService) SendText(ctx context.Context, in message.SendTextInput)
 
 	// TODO: Implementar mentionsEveryOne, mentioned, quoted
 
-	msgID, err := sess.Client.SendMessage(ctx, jid, msg)
+	msgID, err := s.sendMessage(ctx, sess, jid, msg, "text")
 	if err != nil {
 		return out, err
 	}
@@ -198,7 +207,7 @@ func (s *messageService) SendMedia(ctx context.Context, in message.SendMediaInpu
 
 	msg, messageType := buildMediaMessage(uploadResp, kind, mimeType, fileName, caption)
 
-	msgID, err := sess.Client.SendMessage(ctx, jid, msg)
+	msgID, err := s.sendMessage(ctx, sess, jid, msg, kind)
 	if err != nil {
 		return out, err
 	}
@@ -246,7 +255,7 @@ func (s *messageService) extractMediaPayload(ctx context.Context, in message.Sen
 		return nil, "", "", errors.New("media field is required")
 	}
 	if strings.HasPrefix(media, "http://") || strings.HasPrefix(media, "https://") {
-		return s.downloadMedia(ctx, media, in.FileName, mimeType)
+		return s.downloadMedia(ctx, in.InstanceID, media, in.FileName, mimeType)
 	}
 
 	data, name, detectedMime, err := decodeBase64Media(media, in.FileName, mimeType)
@@ -262,12 +271,13 @@ func (s *messageService) extractMediaPayload(ctx context.Context, in message.Sen
 	return data, name, mimeType, nil
 }
 
-func (s *messageService) downloadMedia(ctx context.Context, mediaURL, fallbackName, currentMime string) ([]byte, string, string, error) {
+func (s *messageService) downloadMedia(ctx context.Context, instanceName, mediaURL, fallbackName, currentMime string) ([]byte, string, string, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mediaURL, nil)
 	if err != nil {
 		return nil, "", "", fmt.Errorf("failed to create media request: %w", err)
 	}
-	resp, err := http.DefaultClient.Do(req)
+	// Downloads seguem o proxy da instância (sem timeout fixo, como o client padrão)
+	resp, err := s.waMgr.HTTPClient(instanceName, 0).Do(req)
 	if err != nil {
 		return nil, "", "", fmt.Errorf("failed to fetch media: %w", err)
 	}
@@ -549,7 +559,7 @@ func (s *messageService) SendStatus(ctx context.Context, in message.SendStatusIn
 		messageType = "extendedTextMessage"
 
 	case "image":
-		data, _, mimeType, err := s.extractStatusMedia(ctx, statusMsg.Content)
+		data, _, mimeType, err := s.extractStatusMedia(ctx, in.InstanceID, statusMsg.Content)
 		if err != nil {
 			return out, fmt.Errorf("failed to extract image: %w", err)
 		}
@@ -582,7 +592,7 @@ func (s *messageService) SendStatus(ctx context.Context, in message.SendStatusIn
 		messageType = "imageMessage"
 
 	case "video":
-		data, _, mimeType, err := s.extractStatusMedia(ctx, statusMsg.Content)
+		data, _, mimeType, err := s.extractStatusMedia(ctx, in.InstanceID, statusMsg.Content)
 		if err != nil {
 			return out, fmt.Errorf("failed to extract video: %w", err)
 		}
@@ -615,7 +625,7 @@ func (s *messageService) SendStatus(ctx context.Context, in message.SendStatusIn
 		messageType = "videoMessage"
 
 	case "audio":
-		data, _, mimeType, err := s.extractStatusMedia(ctx, statusMsg.Content)
+		data, _, mimeType, err := s.extractStatusMedia(ctx, in.InstanceID, statusMsg.Content)
 		if err != nil {
 			return out, fmt.Errorf("failed to extract audio: %w", err)
 		}
@@ -649,7 +659,7 @@ func (s *messageService) SendStatus(ctx context.Context, in message.SendStatusIn
 	}
 
 	// Send to status broadcast
-	resp, err := sess.Client.SendMessage(ctx, statusJID, protoMsg)
+	resp, err := s.sendMessage(ctx, sess, statusJID, protoMsg, "status")
 	if err != nil {
 		return out, fmt.Errorf("failed to send status: %w", err)
 	}
@@ -676,7 +686,7 @@ func (s *messageService) SendStatus(ctx context.Context, in message.SendStatusIn
 	return out, nil
 }
 
-func (s *messageService) extractStatusMedia(ctx context.Context, content string) ([]byte, string, string, error) {
+func (s *messageService) extractStatusMedia(ctx context.Context, instanceName, content string) ([]byte, string, string, error) {
 	content = strings.TrimSpace(content)
 	if content == "" {
 		return nil, "", "", errors.New("content is required for media status")
@@ -684,7 +694,7 @@ func (s *messageService) extractStatusMedia(ctx context.Context, content string)
 
 	// Check if it's a URL
 	if strings.HasPrefix(content, "http://") || strings.HasPrefix(content, "https://") {
-		return s.downloadMedia(ctx, content, "", "")
+		return s.downloadMedia(ctx, instanceName, content, "", "")
 	}
 
 	// Otherwise, treat as base64
@@ -730,7 +740,7 @@ func (s *messageService) SendAudio(ctx context.Context, in message.SendAudioInpu
 	}
 
 	// Extract audio data (URL or base64)
-	data, _, mimeType, err := s.extractStatusMedia(ctx, in.AudioMessage.Audio)
+	data, _, mimeType, err := s.extractStatusMedia(ctx, in.InstanceID, in.AudioMessage.Audio)
 	if err != nil {
 		return out, fmt.Errorf("failed to extract audio: %w", err)
 	}
@@ -773,7 +783,7 @@ func (s *messageService) SendAudio(ctx context.Context, in message.SendAudioInpu
 	}
 
 	// Send message
-	resp, err := sess.Client.SendMessage(ctx, dest, protoMsg)
+	resp, err := s.sendMessage(ctx, sess, dest, protoMsg, "audio")
 	if err != nil {
 		return out, fmt.Errorf("failed to send audio: %w", err)
 	}
@@ -829,7 +839,7 @@ func (s *messageService) SendSticker(ctx context.Context, in message.SendSticker
 	}
 
 	// Extract sticker image data (URL or base64)
-	data, _, mimeType, err := s.extractStatusMedia(ctx, in.StickerMessage.Image)
+	data, _, mimeType, err := s.extractStatusMedia(ctx, in.InstanceID, in.StickerMessage.Image)
 	if err != nil {
 		return out, fmt.Errorf("failed to extract sticker image: %w", err)
 	}
@@ -866,7 +876,7 @@ func (s *messageService) SendSticker(ctx context.Context, in message.SendSticker
 	}
 
 	// Send message
-	resp, err := sess.Client.SendMessage(ctx, dest, protoMsg)
+	resp, err := s.sendMessage(ctx, sess, dest, protoMsg, "sticker")
 	if err != nil {
 		return out, fmt.Errorf("failed to send sticker: %w", err)
 	}
@@ -937,7 +947,7 @@ func (s *messageService) SendLocation(ctx context.Context, in message.SendLocati
 	}
 
 	// Send message
-	resp, err := sess.Client.SendMessage(ctx, dest, protoMsg)
+	resp, err := s.sendMessage(ctx, sess, dest, protoMsg, "location")
 	if err != nil {
 		return out, fmt.Errorf("failed to send location: %w", err)
 	}
@@ -1020,7 +1030,7 @@ func (s *messageService) SendContact(ctx context.Context, in message.SendContact
 		}
 	}
 
-	resp, err := sess.Client.SendMessage(ctx, dest, protoMsg)
+	resp, err := s.sendMessage(ctx, sess, dest, protoMsg, "contact")
 	if err != nil {
 		return out, fmt.Errorf("failed to send contact: %w", err)
 	}
@@ -1119,7 +1129,7 @@ func (s *messageService) SendReaction(ctx context.Context, in message.SendReacti
 	}
 
 	// Send reaction
-	resp, err := sess.Client.SendMessage(ctx, destJID, protoMsg)
+	resp, err := s.sendMessage(ctx, sess, destJID, protoMsg, "reaction")
 	if err != nil {
 		return out, fmt.Errorf("failed to send reaction: %w", err)
 	}
@@ -1203,7 +1213,7 @@ func (s *messageService) SendPoll(ctx context.Context, in message.SendPollInput)
 	}
 
 	// Send message
-	resp, err := sess.Client.SendMessage(ctx, dest, protoMsg)
+	resp, err := s.sendMessage(ctx, sess, dest, protoMsg, "poll")
 	if err != nil {
 		return out, fmt.Errorf("failed to send poll: %w", err)
 	}
@@ -1278,6 +1288,31 @@ func (s *messageService) SendButtons(ctx context.Context, in message.SendButtonI
 	return out, errors.New("buttons sending not implemented yet")
 }
 
+func (s *messageService) SetUsage(usage UsageService) {
+	s.usage = usage
+}
+
+// sendMessage envia a mensagem respeitando a cota da instância; o envio só é contabilizado
+// quando o WhatsApp o aceita.
+func (s *messageService) sendMessage(ctx context.Context, sess *whatsapp.Session, to types.JID, msg *waProto.Message, messageType string) (whatsmeow.SendResponse, error) {
+	return sendCounted(ctx, s.usage, sess, to, msg, messageType)
+}
+
+// sendCounted envia a mensagem reservando-a antes na cota da instância; todo envio de mensagem
+// (inclusive fora do messageService) passa por aqui. Sem usage o envio não é contabilizado.
+func sendCounted(ctx context.Context, usage UsageService, sess *whatsapp.Session, to types.JID, msg *waProto.Message, messageType string) (whatsmeow.SendResponse, error) {
+	if usage == nil {
+		return sess.Client.SendMessage(ctx, to, msg)
+	}
+	done, err := usage.Reserve(ctx, sess.ID, sess.Name, messageType)
+	if err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	resp, err := sess.Client.SendMessage(ctx, to, msg)
+	done(err == nil)
+	return resp, err
+}
+
 func (s *messageService) readySession(instanceID string) (*whatsapp.Session, error) {
 	cleaned := strings.TrimSpace(instanceID)
 	if cleaned == "" {
@@ -1288,10 +1323,10 @@ func (s *messageService) readySession(instanceID string) (*whatsapp.Session, err
 		return nil, errors.New("instance not found")
 	}
 	if sess.Client == nil {
-		return nil, errors.New("instance client not ready")
+		return nil, ErrInstanceNotReady
 	}
 	if !sess.Client.IsConnected() {
-		return nil, errors.New("instance not connected")
+		return nil, ErrInstanceNotConnected
 	}
 	return sess, nil
 }
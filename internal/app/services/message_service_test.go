@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// exhaustedUsage recusa toda reserva, como uma instância sem cota.
+type exhaustedUsage struct {
+	UsageService
+	reserved []string
+}
+
+func (u *exhaustedUsage) Reserve(ctx context.Context, instanceID, instanceName, messageType string) (func(bool), error) {
+	u.reserved = append(u.reserved, messageType)
+	return nil, &QuotaExceededError{Instance: instanceName, Period: "daily"}
+}
+
+func TestSendCountedReservesBeforeSending(t *testing.T) {
+	usage := &exhaustedUsage{}
+	// Sem cliente: a reserva recusada impede que o envio chegue a ser tentado.
+	sess := &whatsapp.Session{ID: "id-1", Name: "shop"}
+	to := types.NewJID("5511999999999", types.DefaultUserServer)
+	_, err := sendCounted(context.Background(), usage, sess, to, &waProto.Message{}, "groupInvite")
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	if len(usage.reserved) != 1 || usage.reserved[0] != "groupInvite" {
+		t.Fatalf("expected one groupInvite reservation, got %v", usage.reserved)
+	}
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+const defaultPresenceInterval = 4 * time.Minute
+
+const (
+	presenceStatusRunning = "running"
+	presenceStatusWaiting = "waiting"
+	presenceStatusStopped = "stopped"
+)
+
+// ConnectionEventListener consome eventos de ciclo de vida da conexão (Connected, Disconnected, LoggedOut...).
+type ConnectionEventListener interface {
+	HandleConnectionEvent(ctx context.Context, instanceName string, evt any)
+}
+
+// PresenceKeeper mantém as instâncias com alwaysOnline marcadas como disponíveis.
+// Cada instância ganha um loop próprio que reenvia PresenceAvailable periodicamente.
+type PresenceKeeper struct {
+	repo     repositories.InstanceRepository
+	waMgr    *whatsapp.Manager
+	interval time.Duration
+	log      waLog.Logger
+
+	mu    sync.Mutex
+	loops map[string]*presenceLoop
+}
+
+type presenceLoop struct {
+	cancel    context.CancelFunc
+	startedAt time.Time
+}
+
+func NewPresenceKeeper(repo repositories.InstanceRepository, waMgr *whatsapp.Manager, interval time.Duration, log waLog.Logger) *PresenceKeeper {
+	if interval <= 0 {
+		interval = defaultPresenceInterval
+	}
+	return &PresenceKeeper{
+		repo:     repo,
+		waMgr:    waMgr,
+		interval: interval,
+		log:      log,
+		loops:    make(map[string]*presenceLoop),
+	}
+}
+
+func (k *PresenceKeeper) HandleConnectionEvent(ctx context.Context, instanceName string, evt any) {
+	if k == nil {
+		return
+	}
+	switch evt.(type) {
+	case *events.Connected:
+		k.Apply(ctx, instanceName)
+	case *events.LoggedOut:
+		k.Stop(instanceName, "logged_out")
+	}
+}
+
+// Apply inicia ou encerra o keeper conforme a configuração alwaysOnline persistida.
+// Ao ser iniciado o keeper envia PresenceAvailable imediatamente.
+func (k *PresenceKeeper) Apply(ctx context.Context, instanceName string) {
+	if k == nil || k.repo == nil || k.waMgr == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	inst, err := k.repo.GetByName(ctx, instanceName)
+	if err != nil {
+		if !errors.Is(err, repositories.ErrInstanceNotFound) && k.log != nil {
+			k.log.Warnf("presence instance=%s repository error: %v", instanceName, err)
+		}
+		return
+	}
+	if !inst.Settings.AlwaysOnline {
+		if k.Stop(instanceName, "disabled") {
+			k.sendUnavailable(instanceName)
+		}
+		return
+	}
+	k.start(instanceName)
+}
+
+// Stop encerra o keeper da instância, retornando true se havia um loop ativo.
+func (k *PresenceKeeper) Stop(instanceName, reason string) bool {
+	if k == nil {
+		return false
+	}
+	k.mu.Lock()
+	loop, ok := k.loops[instanceName]
+	if ok {
+		delete(k.loops, instanceName)
+	}
+	k.mu.Unlock()
+	if !ok {
+		return false
+	}
+	loop.cancel()
+	k.recordStopped(instanceName, loop, reason)
+	if k.log != nil {
+		k.log.Infof("presence instance=%s keeper stopped (%s)", instanceName, reason)
+	}
+	return true
+}
+
+// HandleInstanceRename encerra o loop do nome antigo e o reinicia no novo nome, se ativo.
+func (k *PresenceKeeper) HandleInstanceRename(ctx context.Context, oldName, newName string) {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	loop, ok := k.loops[oldName]
+	if ok {
+		delete(k.loops, oldName)
+	}
+	k.mu.Unlock()
+	if !ok {
+		return
+	}
+	// O estado de presença já foi movido com a sessão; só o loop precisa trocar de nome.
+	loop.cancel()
+	k.Apply(ctx, newName)
+}
+
+func (k *PresenceKeeper) start(instanceName string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	loop := &presenceLoop{cancel: cancel, startedAt: time.Now().UTC()}
+
+	k.mu.Lock()
+	if previous, ok := k.loops[instanceName]; ok {
+		previous.cancel()
+		loop.startedAt = previous.startedAt
+	}
+	k.loops[instanceName] = loop
+	k.mu.Unlock()
+
+	if k.log != nil {
+		k.log.Infof("presence instance=%s keeper started (interval %s)", instanceName, k.interval)
+	}
+	go k.run(ctx, instanceName, loop)
+}
+
+func (k *PresenceKeeper) run(ctx context.Context, instanceName string, loop *presenceLoop) {
+	ticker := time.NewTicker(k.interval)
+	defer ticker.Stop()
+	for {
+		if reason, keep := k.tick(ctx, instanceName, loop); !keep {
+			k.finish(instanceName, loop, reason)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick reenvia a presença e indica se o loop deve continuar.
+func (k *PresenceKeeper) tick(ctx context.Context, instanceName string, loop *presenceLoop) (string, bool) {
+	sess, ok := k.waMgr.Get(instanceName)
+	if !ok || sess == nil || sess.Client == nil {
+		return "session_removed", false
+	}
+	if sess.Client.Store == nil || sess.Client.Store.ID == nil {
+		return "logged_out", false
+	}
+	inst, err := k.repo.GetByName(ctx, instanceName)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInstanceNotFound) {
+			return "instance_removed", false
+		}
+		if k.log != nil {
+			k.log.Warnf("presence instance=%s repository error: %v", instanceName, err)
+		}
+		return "", true
+	}
+	if !inst.Settings.AlwaysOnline {
+		k.sendUnavailable(instanceName)
+		return "disabled", false
+	}
+
+	state, _ := k.waMgr.GetPresenceState(instanceName)
+	state.StartedAt = loop.startedAt
+	state.Reason = ""
+	if !sess.Client.IsConnected() || !sess.Client.IsLoggedIn() {
+		state.Status = presenceStatusWaiting
+		_ = k.waMgr.SetPresenceState(instanceName, state)
+		return "", true
+	}
+
+	state.Status = presenceStatusRunning
+	if err := sess.Client.SendPresence(types.PresenceAvailable); err != nil {
+		state.LastError = err.Error()
+		if k.log != nil {
+			k.log.Warnf("presence instance=%s send available failed: %v", instanceName, err)
+		}
+	} else {
+		state.LastError = ""
+		state.LastSentAt = time.Now().UTC()
+	}
+	_ = k.waMgr.SetPresenceState(instanceName, state)
+	return "", true
+}
+
+func (k *PresenceKeeper) finish(instanceName string, loop *presenceLoop, reason string) {
+	loop.cancel()
+	k.mu.Lock()
+	current, ok := k.loops[instanceName]
+	if !ok || current != loop {
+		// Loop substituído ou já encerrado via Stop.
+		k.mu.Unlock()
+		return
+	}
+	delete(k.loops, instanceName)
+	k.mu.Unlock()
+	k.recordStopped(instanceName, loop, reason)
+	if k.log != nil {
+		k.log.Infof("presence instance=%s keeper stopped (%s)", instanceName, reason)
+	}
+}
+
+func (k *PresenceKeeper) recordStopped(instanceName string, loop *presenceLoop, reason string) {
+	state, _ := k.waMgr.GetPresenceState(instanceName)
+	state.Status = presenceStatusStopped
+	state.Reason = reason
+	state.StartedAt = loop.startedAt
+	_ = k.waMgr.SetPresenceState(instanceName, state)
+}
+
+func (k *PresenceKeeper) sendUnavailable(instanceName string) {
+	sess, ok := k.waMgr.Get(instanceName)
+	if !ok || sess == nil || sess.Client == nil || !sess.Client.IsConnected() || !sess.Client.IsLoggedIn() {
+		return
+	}
+	if err := sess.Client.SendPresence(types.PresenceUnavailable); err != nil && k.log != nil {
+		k.log.Warnf("presence instance=%s send unavailable failed: %v", instanceName, err)
+	}
+}
+
+var (
+	_ ConnectionEventListener = (*PresenceKeeper)(nil)
+	_ InstanceRenameListener  = (*PresenceKeeper)(nil)
+)
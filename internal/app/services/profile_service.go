@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/faeln1/go-whatsapp-api/internal/domain/profile"
 	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
@@ -220,7 +221,7 @@ func (s *profileService) UpdateProfilePicture(ctx context.Context, in profile.Up
 	}
 
 	// Download image from URL
-	imageBytes, err := downloadImageFromURL(picture)
+	imageBytes, err := downloadImageFromURL(s.waMgr.HTTPClient(sess.Name, 30*time.Second), picture)
 	if err != nil {
 		return empty, fmt.Errorf("failed to download image: %w", err)
 	}
@@ -385,8 +386,8 @@ func (s *profileService) parseUserJID(raw string) (types.JID, error) {
 }
 
 // downloadImageFromURL downloads an image from a URL and returns the bytes
-func downloadImageFromURL(url string) ([]byte, error) {
-	resp, err := http.Get(url)
+func downloadImageFromURL(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch image: %w", err)
 	}
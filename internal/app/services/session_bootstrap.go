@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
 	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
 	"github.com/faeln1/go-whatsapp-api/pkg/eventlog"
 	"go.mau.fi/whatsmeow"
@@ -30,7 +31,21 @@ type SessionBootstrap struct {
 	Events        MessageEventListener
 	ReceiptEvents ReceiptEventListener
 	GroupEvents   CommunityEventListener
-	EventLogger   *eventlog.Writer
+	// GroupWebhooks publica groups.upsert, groups.update e group-participants.update na instância.
+	GroupWebhooks  GroupEventListener
+	PresenceEvents ConnectionEventListener
+	HistoryEvents  HistorySyncListener
+	EventLogger    *eventlog.Writer
+	// ConnectionEvents é chamado de forma síncrona para preservar a ordem dos eventos de conexão.
+	ConnectionEvents ConnectionEventListener
+	QREvents         QRCodeListener
+	Supervisor       *whatsapp.Supervisor
+	// Instances permite consultar as configurações da instância (ex.: syncFullHistory) no pareamento.
+	Instances repositories.InstanceRepository
+	// Guard (modo cluster) garante o lease da instância antes de conectar.
+	Guard SessionGuard
+	// Lifecycle acompanha os handlers disparados para que o shutdown aguarde o término.
+	Lifecycle *Lifecycle
 }
 
 func NewSessionBootstrap(f *whatsapp.StoreFactory, m *whatsapp.Manager, log waLog.Logger, events MessageEventListener, eventLogger *eventlog.Writer) *SessionBootstrap {
@@ -39,20 +54,34 @@ func NewSessionBootstrap(f *whatsapp.StoreFactory, m *whatsapp.Manager, log waLo
 
 // InitNewSession cria (ou carrega) device store e gera QR channel se necessário.
 func (b *SessionBootstrap) InitNewSession(ctx context.Context, instanceName string) (qr <-chan whatsmeow.QRChannelItem, alreadyLogged bool, err error) {
-	container, err := b.StoreFactory.NewDeviceStore(ctx, instanceName)
-	if err != nil {
-		return nil, false, err
+	if b.Guard != nil {
+		if err := b.Guard.Claim(ctx, instanceName); err != nil {
+			return nil, false, err
+		}
 	}
-	device, err := container.GetFirstDevice(ctx)
+	device, err := b.StoreFactory.Device(ctx, instanceName)
 	if err != nil {
 		return nil, false, err
 	}
 	client := whatsmeow.NewClient(device, b.Log.Sub("Client"))
+	if err := b.applyProxy(ctx, instanceName, client); err != nil {
+		return nil, false, err
+	}
+	if b.StoreFactory.Shared() {
+		// Device compartilhado no Postgres: registrar o JID pareado da instância.
+		client.AddEventHandler(func(evt any) {
+			if e, ok := evt.(*events.PairSuccess); ok {
+				if err := b.StoreFactory.BindDevice(context.Background(), instanceName, e.ID); err != nil && b.Log != nil {
+					b.Log.Errorf("failed to bind device %s to instance %s: %v", e.ID, instanceName, err)
+				}
+			}
+		})
+	}
 
-	if b.Events != nil || b.ReceiptEvents != nil || b.GroupEvents != nil || (b.EventLogger != nil && b.EventLogger.Enabled()) {
+	if b.Events != nil || b.ReceiptEvents != nil || b.GroupEvents != nil || b.GroupWebhooks != nil || b.PresenceEvents != nil || b.HistoryEvents != nil || b.ConnectionEvents != nil || (b.EventLogger != nil && b.EventLogger.Enabled()) {
 		client.AddEventHandler(func(evt any) {
 			if b.EventLogger != nil && b.EventLogger.Enabled() {
-				go b.writeEventLog(instanceName, evt)
+				b.Lifecycle.Go(func() { b.writeEventLog(instanceName, evt) })
 			}
 			switch e := evt.(type) {
 			case *events.Message:
@@ -63,28 +92,62 @@ func (b *SessionBootstrap) InitNewSession(ctx context.Context, instanceName stri
 				if dup == nil {
 					return
 				}
-				go b.Events.HandleMessage(context.Background(), instanceName, dup)
+				b.Lifecycle.Go(func() { b.Events.HandleMessage(context.Background(), instanceName, dup) })
 
 			case *events.Receipt:
 				if b.ReceiptEvents != nil {
-					go b.ReceiptEvents.HandleReceipt(context.Background(), instanceName, e)
+					b.Lifecycle.Go(func() { b.ReceiptEvents.HandleReceipt(context.Background(), instanceName, e) })
 				}
 
 			case *events.GroupInfo:
-				if b.GroupEvents == nil {
-					return
+				if b.GroupEvents != nil {
+					if dup := cloneGroupInfoEvent(e); dup != nil {
+						b.Lifecycle.Go(func() { b.GroupEvents.HandleGroupInfo(context.Background(), instanceName, dup) })
+					}
 				}
-				dup := cloneGroupInfoEvent(e)
-				if dup == nil {
-					return
+				if b.GroupWebhooks != nil {
+					if dup := cloneGroupInfoEvent(e); dup != nil {
+						b.Lifecycle.Go(func() { b.GroupWebhooks.HandleGroupInfo(context.Background(), instanceName, dup) })
+					}
+				}
+
+			case *events.JoinedGroup:
+				if b.GroupWebhooks != nil {
+					b.Lifecycle.Go(func() { b.GroupWebhooks.HandleJoinedGroup(context.Background(), instanceName, e) })
+				}
+
+			case *events.Picture:
+				if b.GroupWebhooks != nil {
+					b.Lifecycle.Go(func() { b.GroupWebhooks.HandleGroupPicture(context.Background(), instanceName, e) })
+				}
+
+			case *events.HistorySync:
+				if b.HistoryEvents != nil {
+					b.Lifecycle.Go(func() { b.HistoryEvents.HandleHistorySync(context.Background(), instanceName, e) })
+				}
+
+			case *events.Connected, *events.LoggedOut:
+				if b.ConnectionEvents != nil {
+					b.ConnectionEvents.HandleConnectionEvent(context.Background(), instanceName, e)
+				}
+				if b.PresenceEvents != nil {
+					b.Lifecycle.Go(func() { b.PresenceEvents.HandleConnectionEvent(context.Background(), instanceName, e) })
+				}
+
+			case *events.Disconnected, *events.PairSuccess, *events.StreamReplaced, *events.TemporaryBan,
+				*events.ConnectFailure, *events.ClientOutdated, *events.KeepAliveTimeout, *events.KeepAliveRestored:
+				if b.ConnectionEvents != nil {
+					b.ConnectionEvents.HandleConnectionEvent(context.Background(), instanceName, e)
 				}
-				go b.GroupEvents.HandleGroupInfo(context.Background(), instanceName, dup)
 			}
 		})
 	} else {
 		client.AddEventHandler(func(evt any) {})
 	}
 
+	// O supervisor assume a reconexão antes do primeiro Connect
+	b.Supervisor.Watch(instanceName, client)
+
 	var qrChan <-chan whatsmeow.QRChannelItem
 	if device.ID == nil { // novo login
 		// IMPORTANTE: Pegar o QR Channel ANTES de conectar
@@ -92,7 +155,8 @@ func (b *SessionBootstrap) InitNewSession(ctx context.Context, instanceName stri
 		if err != nil {
 			return nil, false, fmt.Errorf("failed to get QR channel: %w", err)
 		}
-		// ENTÃO conectar para que o QR seja gerado
+		// ENTÃO conectar para que o QR seja gerado; as DeviceProps do registro definem o histórico solicitado
+		whatsapp.ApplyHistorySyncProps(client, b.syncFullHistory(ctx, instanceName))
 		if err = client.Connect(); err != nil {
 			return nil, false, fmt.Errorf("connect failed: %w", err)
 		}
@@ -109,9 +173,43 @@ func (b *SessionBootstrap) InitNewSession(ctx context.Context, instanceName stri
 	} else {
 		b.Manager.StartEventLoop(&whatsapp.Session{Name: instanceName, Client: client})
 	}
+	b.Supervisor.Refresh(instanceName, client)
 	return qrChan, device.ID != nil, nil
 }
 
+// applyProxy configura o proxy da instância no websocket e no transporte de mídia do client.
+// Um proxy inválido impede a conexão para não sair pela rede direta.
+func (b *SessionBootstrap) applyProxy(ctx context.Context, instanceName string, client *whatsmeow.Client) error {
+	if b.Instances != nil {
+		inst, err := b.Instances.GetByName(ctx, instanceName)
+		if err == nil {
+			addr, err := proxyAddress(inst.Proxy)
+			if err != nil {
+				return fmt.Errorf("invalid proxy for instance %s: %w", instanceName, err)
+			}
+			if err := b.Manager.SetProxy(instanceName, addr); err != nil {
+				return fmt.Errorf("invalid proxy for instance %s: %w", instanceName, err)
+			}
+		}
+	}
+	addr := b.Manager.Proxy(instanceName)
+	if addr == "" {
+		return nil
+	}
+	return client.SetProxyAddress(addr)
+}
+
+func (b *SessionBootstrap) syncFullHistory(ctx context.Context, instanceName string) bool {
+	if b.Instances == nil {
+		return false
+	}
+	inst, err := b.Instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return false
+	}
+	return inst.Settings.SyncFullHistory
+}
+
 func cloneMessageEvent(evt *events.Message) *events.Message {
 	if evt == nil {
 		return nil
@@ -146,6 +244,12 @@ func cloneGroupInfoEvent(evt *events.GroupInfo) *events.GroupInfo {
 	if len(evt.Leave) > 0 {
 		dup.Leave = append([]types.JID(nil), evt.Leave...)
 	}
+	if len(evt.Promote) > 0 {
+		dup.Promote = append([]types.JID(nil), evt.Promote...)
+	}
+	if len(evt.Demote) > 0 {
+		dup.Demote = append([]types.JID(nil), evt.Demote...)
+	}
 	return &dup
 }
 
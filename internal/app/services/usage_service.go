@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/usage"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// ErrQuotaExceeded é o erro base de cota; use errors.As com *QuotaExceededError para os detalhes.
+var ErrQuotaExceeded = errors.New("send quota exceeded")
+
+// QuotaExceededError informa qual cota da instância foi atingida e quando ela renova.
+type QuotaExceededError struct {
+	Instance string    `json:"instanceName"`
+	Period   string    `json:"period"` // daily ou monthly
+	Limit    int       `json:"limit"`
+	Used     int       `json:"used"`
+	ResetAt  time.Time `json:"resetAt"`
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s send quota exceeded for instance %s (%d/%d)", e.Period, e.Instance, e.Used, e.Limit)
+}
+
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+const (
+	defaultUsageHistoryDays = 30
+	maxUsageHistoryDays     = 366
+)
+
+// UsageService contabiliza os envios por instância e aplica as cotas diária e mensal. Consumo
+// e cotas são indexados pelo ID da instância: renomear não zera o uso nem perde a cota.
+type UsageService interface {
+	// Reserve confere a cota e reserva um envio; done(true) contabiliza o envio e
+	// done(false) devolve a reserva quando o envio falha. instanceID vazio é resolvido pelo nome.
+	Reserve(ctx context.Context, instanceID, instanceName, messageType string) (done func(sent bool), err error)
+	Usage(ctx context.Context, instanceName string, days int) (*usage.Report, error)
+	SetQuota(ctx context.Context, instanceName string, in usage.SetQuotaInput) (usage.Quota, error)
+	// ResetQuota remove a cota própria; a instância volta a usar a cota padrão do servidor.
+	ResetQuota(ctx context.Context, instanceName string) error
+}
+
+type usageService struct {
+	repo      repositories.UsageRepository
+	instances repositories.InstanceRepository
+	defaults  usage.Quota
+	loc       *time.Location
+	log       waLog.Logger
+	now       func() time.Time
+
+	mu       sync.Mutex
+	counters map[string]*usageCounter // por ID da instância
+}
+
+// usageCounter mantém em memória o consumo do período corrente (enviados + reservados),
+// evitando consultar o banco a cada envio. É recarregado na virada do dia.
+type usageCounter struct {
+	day     string
+	daily   int
+	monthly int
+	quota   usage.Quota
+}
+
+func NewUsageService(repo repositories.UsageRepository, instances repositories.InstanceRepository, defaults usage.Quota, loc *time.Location, log waLog.Logger) UsageService {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &usageService{
+		repo:      repo,
+		instances: instances,
+		defaults:  defaults,
+		loc:       loc,
+		log:       log,
+		now:       time.Now,
+		counters:  make(map[string]*usageCounter),
+	}
+}
+
+func (s *usageService) Reserve(ctx context.Context, instanceID, instanceName, messageType string) (func(sent bool), error) {
+	if instanceID == "" {
+		inst, err := s.instances.GetByName(ctx, instanceName)
+		if err != nil {
+			return nil, err
+		}
+		instanceID = string(inst.ID)
+	}
+	now := s.now().In(s.loc)
+	c, err := s.counter(ctx, instanceID, now)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c.quota.Daily > 0 && c.daily >= c.quota.Daily {
+		return nil, &QuotaExceededError{Instance: instanceName, Period: usage.PeriodDaily, Limit: c.quota.Daily, Used: c.daily, ResetAt: nextDay(now)}
+	}
+	if c.quota.Monthly > 0 && c.monthly >= c.quota.Monthly {
+		return nil, &QuotaExceededError{Instance: instanceName, Period: usage.PeriodMonthly, Limit: c.quota.Monthly, Used: c.monthly, ResetAt: nextMonth(now)}
+	}
+	c.daily++
+	c.monthly++
+	day := c.day
+
+	var once sync.Once
+	return func(sent bool) {
+		once.Do(func() {
+			if !sent {
+				s.mu.Lock()
+				if cur := s.counters[instanceID]; cur == c && c.day == day {
+					c.daily--
+					c.monthly--
+				}
+				s.mu.Unlock()
+				return
+			}
+			// O envio já aconteceu: gravar mesmo que a requisição tenha sido cancelada.
+			if err := s.repo.Increment(context.Background(), instanceID, day, messageType); err != nil && s.log != nil {
+				s.log.Errorf("failed to record usage for %s: %v", instanceName, err)
+			}
+		})
+	}, nil
+}
+
+// counter retorna o contador do período corrente. A carga do banco (na primeira reserva do
+// dia) acontece fora de s.mu, para não travar os envios das outras instâncias.
+func (s *usageService) counter(ctx context.Context, instanceID string, now time.Time) (*usageCounter, error) {
+	day, month := now.Format("2006-01-02"), now.Format("2006-01")
+	s.mu.Lock()
+	c, ok := s.counters[instanceID]
+	s.mu.Unlock()
+	if ok && c.day == day {
+		return c, nil
+	}
+	quota, _, err := s.quotaFor(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	daily, err := s.repo.Count(ctx, instanceID, day, day)
+	if err != nil {
+		return nil, err
+	}
+	monthly, err := s.repo.Count(ctx, instanceID, month+"-01", month+"-31")
+	if err != nil {
+		return nil, err
+	}
+	loaded := &usageCounter{day: day, daily: daily, monthly: monthly, quota: quota}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Outra reserva concorrente pode ter carregado o mesmo dia: manter a que já conta reservas.
+	if c, ok := s.counters[instanceID]; ok && c.day == day {
+		return c, nil
+	}
+	s.counters[instanceID] = loaded
+	return loaded, nil
+}
+
+func (s *usageService) quotaFor(ctx context.Context, instanceID string) (usage.Quota, bool, error) {
+	quota, err := s.repo.GetQuota(ctx, instanceID)
+	if errors.Is(err, repositories.ErrQuotaNotFound) {
+		return s.defaults, false, nil
+	}
+	if err != nil {
+		return usage.Quota{}, false, err
+	}
+	return quota, true, nil
+}
+
+func (s *usageService) Usage(ctx context.Context, instanceName string, days int) (*usage.Report, error) {
+	inst, err := s.instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	instanceID := string(inst.ID)
+	if days <= 0 {
+		days = defaultUsageHistoryDays
+	}
+	if days > maxUsageHistoryDays {
+		days = maxUsageHistoryDays
+	}
+	now := s.now().In(s.loc)
+	today, month := now.Format("2006-01-02"), now.Format("2006-01")
+	from := now.AddDate(0, 0, -(days - 1)).Format("2006-01-02")
+
+	quota, custom, err := s.quotaFor(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.repo.Daily(ctx, instanceID, from, today)
+	if err != nil {
+		return nil, err
+	}
+	monthly, err := s.repo.Count(ctx, instanceID, month+"-01", month+"-31")
+	if err != nil {
+		return nil, err
+	}
+	report := &usage.Report{
+		InstanceName:   instanceName,
+		Quota:          quota,
+		QuotaSource:    "default",
+		Month:          monthly,
+		DailyResetAt:   nextDay(now),
+		MonthlyResetAt: nextMonth(now),
+		History:        history,
+	}
+	if custom {
+		report.QuotaSource = "instance"
+	}
+	if n := len(history); n > 0 && history[n-1].Day == today {
+		report.Today = history[n-1].Total
+	}
+	if quota.Daily > 0 {
+		remaining := max(quota.Daily-report.Today, 0)
+		report.RemainingToday = &remaining
+	}
+	if quota.Monthly > 0 {
+		remaining := max(quota.Monthly-report.Month, 0)
+		report.RemainingMonth = &remaining
+	}
+	return report, nil
+}
+
+func (s *usageService) SetQuota(ctx context.Context, instanceName string, in usage.SetQuotaInput) (usage.Quota, error) {
+	inst, err := s.instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return usage.Quota{}, err
+	}
+	instanceID := string(inst.ID)
+	quota, _, err := s.quotaFor(ctx, instanceID)
+	if err != nil {
+		return usage.Quota{}, err
+	}
+	if in.Daily != nil {
+		quota.Daily = *in.Daily
+	}
+	if in.Monthly != nil {
+		quota.Monthly = *in.Monthly
+	}
+	if quota.Daily < 0 || quota.Monthly < 0 {
+		return usage.Quota{}, errors.New("quota limits must be zero (unlimited) or positive")
+	}
+	if err := s.repo.SetQuota(ctx, instanceID, quota); err != nil {
+		return usage.Quota{}, err
+	}
+	s.mu.Lock()
+	if c, ok := s.counters[instanceID]; ok {
+		c.quota = quota
+	}
+	s.mu.Unlock()
+	return quota, nil
+}
+
+func (s *usageService) ResetQuota(ctx context.Context, instanceName string) error {
+	inst, err := s.instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+	instanceID := string(inst.ID)
+	if err := s.repo.DeleteQuota(ctx, instanceID); err != nil && !errors.Is(err, repositories.ErrQuotaNotFound) {
+		return err
+	}
+	s.mu.Lock()
+	if c, ok := s.counters[instanceID]; ok {
+		c.quota = s.defaults
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+func nextDay(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+}
+
+func nextMonth(now time.Time) time.Time {
+	y, m, _ := now.Date()
+	return time.Date(y, m+1, 1, 0, 0, 0, 0, now.Location())
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+)
+
+// MaxWebhookReplayBatch limita quantas entregas um replay por período reenfileira de uma vez.
+const MaxWebhookReplayBatch = 500
+
+var ErrReplayRangeRequired = errors.New("since is required to replay a time range")
+
+// WebhookDeliveryService consulta o log de entregas de uma instância e reenvia entregas
+// (individualmente ou por período) pela fila de webhooks.
+type WebhookDeliveryService interface {
+	List(ctx context.Context, instanceName string, filter webhook.DeliveryFilter) ([]*webhook.DeliveryRecord, error)
+	Get(ctx context.Context, instanceName, id string) (*webhook.DeliveryRecord, error)
+	Replay(ctx context.Context, instanceName, id string) (*webhook.Delivery, error)
+	ReplayRange(ctx context.Context, instanceName string, filter webhook.DeliveryFilter) ([]*webhook.Delivery, error)
+	SendTest(ctx context.Context, instanceName, subscriptionID string) ([]*webhook.Delivery, error)
+}
+
+type webhookDeliveryService struct {
+	instances repositories.InstanceRepository
+	log       repositories.WebhookDeliveryLogRepository
+	queue     *WebhookQueue
+}
+
+func NewWebhookDeliveryService(instances repositories.InstanceRepository, log repositories.WebhookDeliveryLogRepository, queue *WebhookQueue) WebhookDeliveryService {
+	return &webhookDeliveryService{instances: instances, log: log, queue: queue}
+}
+
+// O log é consultado pelo id da instância, de modo que o histórico acompanha renomeações e não
+// passa para outra instância que reutilize o nome. Entregas ao webhook global não são da
+// instância: as registradas antes de saírem do log também ficam de fora.
+func (s *webhookDeliveryService) List(ctx context.Context, instanceName string, filter webhook.DeliveryFilter) ([]*webhook.DeliveryRecord, error) {
+	inst, err := s.instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	if filter.SubscriptionID == webhook.GlobalSubscriptionID {
+		return []*webhook.DeliveryRecord{}, nil
+	}
+	filter.InstanceID = string(inst.ID)
+	records, err := s.log.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*webhook.DeliveryRecord, 0, len(records))
+	for _, rec := range records {
+		if rec.SubscriptionID != webhook.GlobalSubscriptionID {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+func (s *webhookDeliveryService) Get(ctx context.Context, instanceName, id string) (*webhook.DeliveryRecord, error) {
+	inst, err := s.instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	return s.record(ctx, inst, id)
+}
+
+func (s *webhookDeliveryService) record(ctx context.Context, inst *instance.Instance, id string) (*webhook.DeliveryRecord, error) {
+	rec, err := s.log.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rec.InstanceID != string(inst.ID) || rec.SubscriptionID == webhook.GlobalSubscriptionID {
+		return nil, repositories.ErrDeliveryRecordNotFound
+	}
+	return rec, nil
+}
+
+func (s *webhookDeliveryService) Replay(ctx context.Context, instanceName, id string) (*webhook.Delivery, error) {
+	inst, err := s.instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := s.record(ctx, inst, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.queue.Replay(ctx, inst, rec)
+}
+
+// ReplayRange reenvia, da mais antiga para a mais recente, as entregas do período que atendem ao
+// filtro, limitadas às MaxWebhookReplayBatch primeiras a partir de since. Entregas de
+// assinaturas removidas ou desativadas são ignoradas.
+func (s *webhookDeliveryService) ReplayRange(ctx context.Context, instanceName string, filter webhook.DeliveryFilter) ([]*webhook.Delivery, error) {
+	if filter.Since.IsZero() {
+		return nil, ErrReplayRangeRequired
+	}
+	inst, err := s.instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	filter.InstanceID = string(inst.ID)
+	filter.Ascending = true
+	if filter.Limit <= 0 || filter.Limit > MaxWebhookReplayBatch {
+		filter.Limit = MaxWebhookReplayBatch
+	}
+	records, err := s.log.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	replayed := []*webhook.Delivery{}
+	for _, record := range records {
+		rec, err := s.log.Get(ctx, record.ID)
+		if err != nil {
+			return replayed, err
+		}
+		delivery, err := s.queue.Replay(ctx, inst, rec)
+		if errors.Is(err, ErrWebhookTargetUnavailable) {
+			continue
+		}
+		if err != nil {
+			return replayed, err
+		}
+		replayed = append(replayed, delivery)
+	}
+	return replayed, nil
+}
+
+func (s *webhookDeliveryService) SendTest(ctx context.Context, instanceName, subscriptionID string) ([]*webhook.Delivery, error) {
+	inst, err := s.instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	return s.queue.SendTest(ctx, inst, subscriptionID)
+}
@@ -12,6 +12,9 @@ import (
 	"time"
 
 	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+	"github.com/faeln1/go-whatsapp-api/pkg/webhooksig"
+	"github.com/google/uuid"
 	waLog "go.mau.fi/whatsmeow/util/log"
 )
 
@@ -19,6 +22,20 @@ type WebhookDispatcher interface {
 	Dispatch(ctx context.Context, inst *instance.Instance, event string, payload map[string]any) (bool, error)
 }
 
+// WebhookAudience é implementado pelos dispatchers que sabem se a instância tem algum destino
+// (URL, assinatura ou stream aberto), para evitar preparar eventos que ninguém vai receber.
+type WebhookAudience interface {
+	HasAudience(ctx context.Context, inst *instance.Instance) bool
+}
+
+// hasAudience consulta o dispatcher; sem WebhookAudience vale a URL configurada na instância.
+func hasAudience(ctx context.Context, d WebhookDispatcher, inst *instance.Instance) bool {
+	if audience, ok := d.(WebhookAudience); ok {
+		return audience.HasAudience(ctx, inst)
+	}
+	return strings.TrimSpace(inst.Webhook.URL) != "" || strings.TrimSpace(inst.WebhookURL) != ""
+}
+
 type webhookDispatcher struct {
 	client *http.Client
 	log    waLog.Logger
@@ -31,64 +48,138 @@ func NewWebhookDispatcher(client *http.Client, log waLog.Logger) WebhookDispatch
 	return &webhookDispatcher{client: client, log: log}
 }
 
+// Dispatch entrega diretamente, numa única tentativa, apenas à config legada da instância.
 func (d *webhookDispatcher) Dispatch(ctx context.Context, inst *instance.Instance, event string, payload map[string]any) (bool, error) {
 	if inst == nil {
 		return false, errors.New("instance is nil")
 	}
-	cfg := inst.Webhook
-	targetURL := strings.TrimSpace(cfg.URL)
-	if targetURL == "" {
+	deliveries, err := newWebhookDeliveries(inst, webhookTargets(inst, nil), event, payload, d.log)
+	if err != nil || len(deliveries) == 0 {
+		return false, err
+	}
+	// Sem outbox os headers não são persistidos e podem seguir direto na entrega.
+	delivery := deliveries[0]
+	delivery.Headers = inst.Webhook.Headers
+	if d.log != nil {
+		d.log.Debugf("webhook dispatch start instance=%s event=%s url=%s", inst.Name, event, delivery.URL)
+	}
+	status, err := postWebhook(ctx, d.client, delivery, inst.Webhook.SigningSecrets(time.Now())...)
+	if err != nil {
 		if d.log != nil {
-			d.log.Debugf("webhook skipping instance=%s event=%s: no URL configured", inst.Name, event)
+			d.log.Warnf("webhook dispatch failed instance=%s event=%s url=%s status=%d err=%v", inst.Name, event, delivery.URL, status, err)
 		}
-		return false, nil
+		return false, err
 	}
-	if cfg.ByEvents && len(cfg.Events) > 0 && !containsEvent(cfg.Events, event) && !containsEvent(cfg.Events, "ALL") {
-		if d.log != nil {
-			d.log.Debugf("webhook skipping instance=%s event=%s: filtered by events", inst.Name, event)
+	if d.log != nil {
+		d.log.Debugf("webhook dispatch success instance=%s event=%s url=%s status=%d", inst.Name, event, delivery.URL, status)
+	}
+	return true, nil
+}
+
+// webhookTarget é um destino de entrega: a config legada da instância (assinatura "default")
+// ou uma das assinaturas adicionais.
+type webhookTarget struct {
+	subscriptionID string
+	url            string
+	events         []string // vazio = todos
+}
+
+func (t webhookTarget) accepts(event string) bool {
+	return len(t.events) == 0 || containsEvent(t.events, event) || containsEvent(t.events, "ALL")
+}
+
+func webhookTargets(inst *instance.Instance, subs []*webhook.Subscription) []webhookTarget {
+	var targets []webhookTarget
+	if url := strings.TrimSpace(inst.Webhook.URL); url != "" {
+		target := webhookTarget{subscriptionID: webhook.DefaultSubscriptionID, url: url}
+		if inst.Webhook.ByEvents {
+			target.events = inst.Webhook.Events
+		}
+		targets = append(targets, target)
+	}
+	for _, sub := range subs {
+		if sub == nil || !sub.Enabled || strings.TrimSpace(sub.URL) == "" {
+			continue
+		}
+		targets = append(targets, webhookTarget{subscriptionID: sub.ID, url: strings.TrimSpace(sub.URL), events: sub.Events})
+	}
+	return targets
+}
+
+// newWebhookDeliveries monta uma entrega para cada destino que aceita o evento; o corpo é o
+// mesmo, mas cada entrega tem id e ordem próprios para falhar e ser repetida de forma independente.
+func newWebhookDeliveries(inst *instance.Instance, candidates []webhookTarget, event string, payload map[string]any, log waLog.Logger) ([]*webhook.Delivery, error) {
+	var targets []webhookTarget
+	for _, target := range candidates {
+		if target.accepts(event) {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		if log != nil {
+			log.Debugf("webhook skipping instance=%s event=%s: no matching URL", inst.Name, event)
 		}
-		return false, nil
+		return nil, nil
 	}
-	body := map[string]any{
+	now := time.Now().UTC()
+	buf, err := webhookBody(inst, event, payload, now)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]*webhook.Delivery, 0, len(targets))
+	for _, target := range targets {
+		deliveries = append(deliveries, newWebhookDelivery(inst, target, event, buf, now))
+	}
+	return deliveries, nil
+}
+
+// webhookBody monta o corpo enviado aos receptores, no formato da Evolution API.
+func webhookBody(inst *instance.Instance, event string, payload map[string]any, now time.Time) ([]byte, error) {
+	return json.Marshal(map[string]any{
 		"event":     event,
 		"instance":  inst.Name,
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"timestamp": now.Format(time.RFC3339),
 		"data":      payload,
+	})
+}
+
+func newWebhookDelivery(inst *instance.Instance, target webhookTarget, event string, body []byte, now time.Time) *webhook.Delivery {
+	return &webhook.Delivery{
+		ID:             uuid.NewString(),
+		InstanceID:     string(inst.ID),
+		InstanceName:   inst.Name,
+		SubscriptionID: target.subscriptionID,
+		Event:          event,
+		URL:            target.url,
+		Payload:        body,
+		OrderKey:       inst.Name + " " + target.subscriptionID,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
 	}
-	buf, err := json.Marshal(body)
-	if err != nil {
-		return false, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(buf))
+}
+
+// postWebhook faz uma tentativa de entrega; respostas fora de 2xx são erro. Cada tentativa
+// leva o id da entrega e um timestamp novo, assinados com os segredos informados.
+func postWebhook(ctx context.Context, client *http.Client, d *webhook.Delivery, secrets ...string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
 	if err != nil {
-		return false, err
+		return 0, err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	for k, v := range cfg.Headers {
+	for k, v := range d.Headers {
 		req.Header.Set(k, v)
 	}
-	if d.log != nil {
-		d.log.Debugf("webhook dispatch start instance=%s event=%s url=%s", inst.Name, event, targetURL)
-	}
-	resp, err := d.client.Do(req)
+	webhooksig.Apply(req.Header, d.ID, time.Now(), d.Payload, secrets...)
+	resp, err := client.Do(req)
 	if err != nil {
-		if d.log != nil {
-			d.log.Warnf("webhook dispatch error instance=%s event=%s url=%s err=%v", inst.Name, event, targetURL, err)
-		}
-		return false, err
+		return 0, err
 	}
 	defer resp.Body.Close()
 	io.Copy(io.Discard, resp.Body)
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if d.log != nil {
-			d.log.Warnf("webhook dispatch failed instance=%s event=%s url=%s status=%d", inst.Name, event, targetURL, resp.StatusCode)
-		}
-		return false, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
 	}
-	if d.log != nil {
-		d.log.Debugf("webhook dispatch success instance=%s event=%s url=%s status=%d", inst.Name, event, targetURL, resp.StatusCode)
-	}
-	return true, nil
+	return resp.StatusCode, nil
 }
 
 func containsEvent(list []string, target string) bool {
@@ -111,6 +202,7 @@ func canonicalEventName(value string) string {
 	}
 	lower := strings.ToLower(cleaned)
 	lower = strings.ReplaceAll(lower, "_", ".")
+	lower = strings.ReplaceAll(lower, "-", ".")
 	lower = strings.ReplaceAll(lower, " ", "")
 	return lower
 }
@@ -0,0 +1,506 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// WebhookRetryPolicy define quantas vezes uma entrega é tentada e o backoff entre tentativas.
+type WebhookRetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultWebhookRetryPolicy é usada quando o servidor não configura a fila.
+func DefaultWebhookRetryPolicy() WebhookRetryPolicy {
+	return WebhookRetryPolicy{MaxAttempts: 8, InitialBackoff: 5 * time.Second, MaxBackoff: 10 * time.Minute}
+}
+
+// Backoff retorna o atraso após a tentativa (1-based): InitialBackoff * 2^(attempt-1), limitado a
+// MaxBackoff, com jitter — metade do atraso é fixa e a outra metade aleatória.
+func (p WebhookRetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	if delay <= 0 {
+		return 0
+	}
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			delay = p.MaxBackoff
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// WebhookQueueOptions configura os workers da fila de webhooks.
+type WebhookQueueOptions struct {
+	Retry        WebhookRetryPolicy
+	Workers      int           // entregas simultâneas
+	Ordered      bool          // preserva a ordem por instância/URL; uma entrega com falha segura as seguintes
+	PollInterval time.Duration // intervalo de varredura do outbox por entregas vencidas
+	LogRetention time.Duration // por quanto tempo o log de entregas é mantido (0 = sem limpeza)
+}
+
+// WebhookQueue é o WebhookDispatcher durável: Dispatch grava a entrega no outbox e Run a
+// entrega em segundo plano, repetindo com backoff até esgotar as tentativas (dead-letter).
+// Entregas pendentes sobrevivem a reinícios e são retomadas pelo próximo Run.
+type WebhookQueue struct {
+	outbox        repositories.WebhookOutboxRepository
+	instances     repositories.InstanceRepository
+	subscriptions repositories.WebhookSubscriptionRepository
+	deliveryLog   repositories.WebhookDeliveryLogRepository
+	global        GlobalWebhook
+	client        *http.Client
+	opts          WebhookQueueOptions
+	lockFor       time.Duration
+	log           waLog.Logger
+	wake          chan struct{}
+}
+
+func NewWebhookQueue(outbox repositories.WebhookOutboxRepository, client *http.Client, opts WebhookQueueOptions, log waLog.Logger) *WebhookQueue {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.Retry.MaxAttempts <= 0 {
+		opts.Retry.MaxAttempts = DefaultWebhookRetryPolicy().MaxAttempts
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	// A reserva precisa durar mais que uma tentativa; se o processo cair, a entrega volta a ficar livre.
+	lockFor := time.Minute
+	if client.Timeout > 0 {
+		lockFor += client.Timeout
+	}
+	return &WebhookQueue{
+		outbox:  outbox,
+		client:  client,
+		opts:    opts,
+		lockFor: lockFor,
+		log:     log,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// SetInstances habilita a assinatura HMAC: os segredos são lidos da instância a cada tentativa,
+// de modo que retries após uma rotação já usam o segredo novo.
+func (q *WebhookQueue) SetInstances(repo repositories.InstanceRepository) {
+	q.instances = repo
+}
+
+// SetSubscriptions faz Dispatch distribuir os eventos também para as assinaturas adicionais.
+func (q *WebhookQueue) SetSubscriptions(repo repositories.WebhookSubscriptionRepository) {
+	q.subscriptions = repo
+}
+
+// SetDeliveryLog registra cada entrega e tentativa (status, latência e erro) para inspeção e replay.
+func (q *WebhookQueue) SetDeliveryLog(repo repositories.WebhookDeliveryLogRepository) {
+	q.deliveryLog = repo
+}
+
+// GlobalWebhook é o webhook do servidor que recebe os eventos de todas as instâncias, no mesmo
+// envelope e além das assinaturas de cada uma.
+type GlobalWebhook struct {
+	URL     string
+	Token   string   // enviado como Authorization: Bearer
+	Events  []string // vazio = todos os eventos
+	Secrets []string // segredo HMAC atual e, durante a rotação, o anterior
+}
+
+// SetGlobal habilita o webhook global; com URL vazia ele fica desligado.
+func (q *WebhookQueue) SetGlobal(global GlobalWebhook) {
+	global.URL = strings.TrimSpace(global.URL)
+	global.Token = strings.TrimSpace(global.Token)
+	var secrets []string
+	for _, secret := range global.Secrets {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+	global.Secrets = secrets
+	q.global = global
+}
+
+// Dispatch grava uma entrega no outbox para cada assinatura que aceita o evento; true indica
+// que ao menos uma foi enfileirada, não entregue.
+func (q *WebhookQueue) Dispatch(ctx context.Context, inst *instance.Instance, event string, payload map[string]any) (bool, error) {
+	if inst == nil {
+		return false, errors.New("instance is nil")
+	}
+	deliveries, err := newWebhookDeliveries(inst, q.targets(ctx, inst), event, payload, q.log)
+	if err != nil || len(deliveries) == 0 {
+		return false, err
+	}
+	if err := q.enqueue(ctx, deliveries); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// HasAudience indica se a instância tem a config legada, alguma assinatura ativa ou se há
+// webhook global.
+func (q *WebhookQueue) HasAudience(ctx context.Context, inst *instance.Instance) bool {
+	return inst != nil && len(q.targets(ctx, inst)) > 0
+}
+
+// ErrWebhookTargetUnavailable indica que a assinatura foi removida, desativada ou está sem URL.
+var ErrWebhookTargetUnavailable = errors.New("webhook subscription not found or disabled")
+
+// Replay enfileira novamente o corpo original de uma entrega registrada, como uma entrega nova
+// para a URL atual da assinatura; os headers são resolvidos na tentativa.
+func (q *WebhookQueue) Replay(ctx context.Context, inst *instance.Instance, rec *webhook.DeliveryRecord) (*webhook.Delivery, error) {
+	target, ok := q.target(ctx, inst, rec.SubscriptionID)
+	if !ok {
+		return nil, ErrWebhookTargetUnavailable
+	}
+	delivery := newWebhookDelivery(inst, target, rec.Event, rec.Payload, time.Now().UTC())
+	delivery.ReplayOf = rec.ID
+	if err := q.enqueue(ctx, []*webhook.Delivery{delivery}); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// WebhookTestEvent é o evento enviado pelo endpoint de teste; ignora o filtro de eventos.
+const WebhookTestEvent = "webhook.test"
+
+// SendTest enfileira um evento de teste para a assinatura informada ou, sem subscriptionID,
+// para todas as assinaturas ativas da instância. O webhook global não é um destino da
+// instância e não pode ser testado por aqui.
+func (q *WebhookQueue) SendTest(ctx context.Context, inst *instance.Instance, subscriptionID string) ([]*webhook.Delivery, error) {
+	var targets []webhookTarget
+	if subscriptionID != "" {
+		target, ok := q.target(ctx, inst, subscriptionID)
+		if !ok {
+			return nil, ErrWebhookTargetUnavailable
+		}
+		targets = append(targets, target)
+	} else {
+		targets = webhookTargets(inst, q.subscriptionsOf(ctx, inst))
+	}
+	if len(targets) == 0 {
+		return nil, ErrWebhookTargetUnavailable
+	}
+	now := time.Now().UTC()
+	body, err := webhookBody(inst, WebhookTestEvent, map[string]any{"test": true}, now)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]*webhook.Delivery, 0, len(targets))
+	for _, target := range targets {
+		deliveries = append(deliveries, newWebhookDelivery(inst, target, WebhookTestEvent, body, now))
+	}
+	if err := q.enqueue(ctx, deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (q *WebhookQueue) subscriptionsOf(ctx context.Context, inst *instance.Instance) []*webhook.Subscription {
+	if q.subscriptions == nil {
+		return nil
+	}
+	subs, err := q.subscriptions.List(ctx, string(inst.ID))
+	if err != nil && q.log != nil {
+		q.log.Errorf("webhook subscriptions lookup failed instance=%s: %v", inst.Name, err)
+	}
+	return subs
+}
+
+// targets são os destinos da instância acrescidos do webhook global, quando configurado.
+func (q *WebhookQueue) targets(ctx context.Context, inst *instance.Instance) []webhookTarget {
+	targets := webhookTargets(inst, q.subscriptionsOf(ctx, inst))
+	if q.global.URL != "" {
+		targets = append(targets, webhookTarget{subscriptionID: webhook.GlobalSubscriptionID, url: q.global.URL, events: q.global.Events})
+	}
+	return targets
+}
+
+// target resolve um destino da própria instância para teste e replay; o webhook global fica
+// de fora, já que o token da instância não deve acioná-lo.
+func (q *WebhookQueue) target(ctx context.Context, inst *instance.Instance, subscriptionID string) (webhookTarget, bool) {
+	if subscriptionID == "" {
+		subscriptionID = webhook.DefaultSubscriptionID
+	}
+	for _, target := range webhookTargets(inst, q.subscriptionsOf(ctx, inst)) {
+		if target.subscriptionID == subscriptionID {
+			return target, true
+		}
+	}
+	return webhookTarget{}, false
+}
+
+// logged indica se a entrega vai para o log de entregas. As do webhook global ficam de fora:
+// o log é consultado com o token da instância e exporia a URL do servidor.
+func (q *WebhookQueue) logged(d *webhook.Delivery) bool {
+	return q.deliveryLog != nil && d.SubscriptionID != webhook.GlobalSubscriptionID
+}
+
+// enqueue registra cada entrega no log antes de gravá-la no outbox, para que um worker nunca
+// registre uma tentativa de entrega ainda ausente do log.
+func (q *WebhookQueue) enqueue(ctx context.Context, deliveries []*webhook.Delivery) error {
+	for _, delivery := range deliveries {
+		logged := false
+		if q.logged(delivery) {
+			if err := q.deliveryLog.Record(ctx, delivery); err != nil {
+				if q.log != nil {
+					q.log.Warnf("webhook delivery log failed id=%s: %v", delivery.ID, err)
+				}
+			} else {
+				logged = true
+			}
+		}
+		if err := q.outbox.Enqueue(ctx, delivery); err != nil {
+			if q.log != nil {
+				q.log.Errorf("webhook enqueue failed instance=%s subscription=%s event=%s: %v", delivery.InstanceName, delivery.SubscriptionID, delivery.Event, err)
+			}
+			if logged {
+				_ = q.deliveryLog.SetStatus(ctx, delivery.ID, webhook.StatusDiscarded)
+			}
+			return err
+		}
+		if q.log != nil {
+			q.log.Debugf("webhook queued id=%s instance=%s subscription=%s event=%s url=%s", delivery.ID, delivery.InstanceName, delivery.SubscriptionID, delivery.Event, delivery.URL)
+		}
+	}
+	q.notify()
+	return nil
+}
+
+// Run processa o outbox até ctx ser cancelado e aguarda as entregas em andamento antes de retornar.
+func (q *WebhookQueue) Run(ctx context.Context) {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+	slots := make(chan struct{}, q.opts.Workers)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	// O log de entregas é podado a cada hora, mantendo LogRetention de histórico.
+	var prune <-chan time.Time
+	if q.deliveryLog != nil && q.opts.LogRetention > 0 {
+		pruneTicker := time.NewTicker(time.Hour)
+		defer pruneTicker.Stop()
+		prune = pruneTicker.C
+		q.pruneLog(ctx)
+	}
+	for {
+		q.drain(ctx, slots, &wg)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		case <-prune:
+			q.pruneLog(ctx)
+		}
+	}
+}
+
+func (q *WebhookQueue) pruneLog(ctx context.Context) {
+	removed, err := q.deliveryLog.Prune(ctx, time.Now().Add(-q.opts.LogRetention))
+	if q.log == nil {
+		return
+	}
+	if err != nil {
+		q.log.Errorf("webhook delivery log prune failed: %v", err)
+	} else if removed > 0 {
+		q.log.Infof("webhook delivery log pruned %d record(s)", removed)
+	}
+}
+
+// drain reserva e entrega as entregas vencidas, respeitando o limite de workers.
+func (q *WebhookQueue) drain(ctx context.Context, slots chan struct{}, wg *sync.WaitGroup) {
+	for ctx.Err() == nil {
+		free := cap(slots) - len(slots)
+		if free == 0 {
+			return
+		}
+		batch, err := q.outbox.Claim(ctx, time.Now(), free, q.opts.Ordered, q.lockFor)
+		if err != nil {
+			if q.log != nil && ctx.Err() == nil {
+				q.log.Errorf("webhook outbox claim failed: %v", err)
+			}
+			return
+		}
+		if len(batch) == 0 {
+			return
+		}
+		for _, d := range batch {
+			slots <- struct{}{}
+			wg.Add(1)
+			go func(d *webhook.Delivery) {
+				defer wg.Done()
+				defer func() {
+					<-slots
+					q.notify()
+				}()
+				q.deliver(d)
+			}(d)
+		}
+	}
+}
+
+// deliver faz uma tentativa e grava o resultado. A tentativa não usa o ctx do Run para que o
+// shutdown conclua as entregas iniciadas; o timeout do client limita a espera.
+func (q *WebhookQueue) deliver(d *webhook.Delivery) {
+	ctx := context.Background()
+	started := time.Now()
+	status, err := q.attempt(ctx, d)
+	latency := time.Since(started)
+	if errors.Is(err, errSubscriptionGone) {
+		// A assinatura foi removida depois do enfileiramento: descartar em vez de insistir.
+		if err := q.outbox.Complete(ctx, d.ID); err != nil && q.log != nil {
+			q.log.Errorf("webhook discard failed id=%s: %v", d.ID, err)
+		}
+		if q.log != nil {
+			q.log.Infof("webhook discarded id=%s instance=%s subscription=%s: subscription removed", d.ID, d.InstanceName, d.SubscriptionID)
+		}
+		if q.logged(d) {
+			_ = q.deliveryLog.SetStatus(ctx, d.ID, webhook.StatusDiscarded)
+		}
+		return
+	}
+	if err == nil {
+		if err := q.outbox.Complete(ctx, d.ID); err != nil && q.log != nil {
+			q.log.Errorf("webhook complete failed id=%s: %v", d.ID, err)
+		}
+		q.recordAttempt(ctx, d, d.Attempts+1, status, latency, nil, webhook.StatusDelivered)
+		if q.log != nil {
+			q.log.Debugf("webhook delivered id=%s instance=%s event=%s status=%d attempt=%d", d.ID, d.InstanceName, d.Event, status, d.Attempts+1)
+		}
+		return
+	}
+
+	d.Attempts++
+	d.LastError = err.Error()
+	d.LastStatus = status
+	if d.Attempts >= q.opts.Retry.MaxAttempts {
+		failedAt := time.Now().UTC()
+		d.FailedAt = &failedAt
+		if err := q.outbox.DeadLetter(ctx, d); err != nil && q.log != nil {
+			q.log.Errorf("webhook dead-letter failed id=%s: %v", d.ID, err)
+		}
+		q.recordAttempt(ctx, d, d.Attempts, status, latency, err, webhook.StatusFailed)
+		if q.log != nil {
+			q.log.Warnf("webhook dead-lettered id=%s instance=%s event=%s url=%s attempts=%d err=%s", d.ID, d.InstanceName, d.Event, d.URL, d.Attempts, d.LastError)
+		}
+		return
+	}
+	delay := q.opts.Retry.Backoff(d.Attempts)
+	d.NextAttemptAt = time.Now().Add(delay)
+	if err := q.outbox.Reschedule(ctx, d); err != nil && q.log != nil {
+		q.log.Errorf("webhook reschedule failed id=%s: %v", d.ID, err)
+	}
+	q.recordAttempt(ctx, d, d.Attempts, status, latency, err, webhook.StatusPending)
+	if q.log != nil {
+		q.log.Warnf("webhook attempt failed id=%s instance=%s event=%s url=%s attempt=%d retry_in=%s err=%s", d.ID, d.InstanceName, d.Event, d.URL, d.Attempts, delay, d.LastError)
+	}
+}
+
+// recordAttempt grava a tentativa no log de entregas, quando a entrega é registrada nele.
+func (q *WebhookQueue) recordAttempt(ctx context.Context, d *webhook.Delivery, attempt, status int, latency time.Duration, err error, state string) {
+	if !q.logged(d) {
+		return
+	}
+	a := &webhook.DeliveryAttempt{
+		DeliveryID: d.ID,
+		Attempt:    attempt,
+		StatusCode: status,
+		LatencyMs:  latency.Milliseconds(),
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err != nil {
+		a.Error = err.Error()
+	}
+	if err := q.deliveryLog.RecordAttempt(ctx, a, state); err != nil && q.log != nil {
+		q.log.Warnf("webhook delivery log failed id=%s: %v", d.ID, err)
+	}
+}
+
+var errSubscriptionGone = errors.New("webhook subscription removed")
+
+func (q *WebhookQueue) attempt(ctx context.Context, d *webhook.Delivery) (int, error) {
+	if d.SubscriptionID == webhook.GlobalSubscriptionID {
+		return q.attemptGlobal(ctx, d)
+	}
+	headers, secrets, err := q.receiverConfig(ctx, d)
+	if err != nil {
+		return 0, err
+	}
+	if headers == nil {
+		return postWebhook(ctx, q.client, d, secrets...)
+	}
+	resolved := *d
+	resolved.Headers = headers
+	return postWebhook(ctx, q.client, &resolved, secrets...)
+}
+
+// attemptGlobal entrega ao webhook global. O bearer token é aplicado na tentativa, sem ser
+// gravado no outbox; sem webhook global configurado a entrega é descartada.
+func (q *WebhookQueue) attemptGlobal(ctx context.Context, d *webhook.Delivery) (int, error) {
+	if q.global.URL == "" {
+		return 0, errSubscriptionGone
+	}
+	if q.global.Token == "" {
+		return postWebhook(ctx, q.client, d, q.global.Secrets...)
+	}
+	authorized := *d
+	authorized.Headers = map[string]string{"Authorization": "Bearer " + q.global.Token}
+	return postWebhook(ctx, q.client, &authorized, q.global.Secrets...)
+}
+
+// receiverConfig lê os headers e os segredos vigentes da assinatura da entrega. Os headers
+// costumam carregar credenciais do receptor e por isso não são gravados no outbox; instâncias
+// removidas recebem a entrega sem headers nem assinatura.
+func (q *WebhookQueue) receiverConfig(ctx context.Context, d *webhook.Delivery) (map[string]string, []string, error) {
+	if q.instances == nil {
+		return nil, nil, nil
+	}
+	inst, err := q.instances.GetByName(ctx, d.InstanceName)
+	if errors.Is(err, repositories.ErrInstanceNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("load webhook config: %w", err)
+	}
+	if d.SubscriptionID == "" || d.SubscriptionID == webhook.DefaultSubscriptionID {
+		return inst.Webhook.Headers, inst.Webhook.SigningSecrets(time.Now()), nil
+	}
+	if q.subscriptions == nil {
+		return nil, nil, nil
+	}
+	sub, err := q.subscriptions.Get(ctx, string(inst.ID), d.SubscriptionID)
+	if errors.Is(err, repositories.ErrSubscriptionNotFound) {
+		return nil, nil, errSubscriptionGone
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("load webhook config: %w", err)
+	}
+	return sub.Headers, sub.SigningSecrets(time.Now()), nil
+}
+
+func (q *WebhookQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidWebhookURL = errors.New("url must be an absolute http(s) URL")
+
+// WebhookSubscriptionService administra os endpoints de webhook de cada instância. A config
+// legada (/webhook/set) aparece como a assinatura "default"; as demais ficam no repositório.
+type WebhookSubscriptionService interface {
+	List(ctx context.Context, instanceName string) ([]*webhook.Subscription, error)
+	Get(ctx context.Context, instanceName, id string) (*webhook.Subscription, error)
+	Create(ctx context.Context, instanceName string, in webhook.SubscriptionInput) (*webhook.Subscription, error)
+	Update(ctx context.Context, instanceName, id string, in webhook.SubscriptionInput) (*webhook.Subscription, error)
+	Delete(ctx context.Context, instanceName, id string) error
+	RotateSecret(ctx context.Context, instanceName, id string, in instance.RotateWebhookSecretInput) (*instance.RotateWebhookSecretResponse, error)
+}
+
+type webhookSubscriptionService struct {
+	instances repositories.InstanceRepository
+	subs      repositories.WebhookSubscriptionRepository
+}
+
+func NewWebhookSubscriptionService(instances repositories.InstanceRepository, subs repositories.WebhookSubscriptionRepository) WebhookSubscriptionService {
+	return &webhookSubscriptionService{instances: instances, subs: subs}
+}
+
+// defaultSubscription apresenta a config legada da instância como assinatura.
+func defaultSubscription(inst *instance.Instance) *webhook.Subscription {
+	cfg := inst.Webhook
+	sub := &webhook.Subscription{
+		ID:         webhook.DefaultSubscriptionID,
+		InstanceID: string(inst.ID),
+		URL:        cfg.URL,
+		Headers:    cfg.Headers,
+		Enabled:    cfg.Enabled,
+		Signed:     cfg.Secret != "",
+		CreatedAt:  inst.CreatedAt,
+		UpdatedAt:  inst.UpdatedAt,
+	}
+	if cfg.ByEvents {
+		sub.Events = cfg.Events
+	}
+	if len(cfg.SigningSecrets(time.Now())) > 1 {
+		sub.PreviousSecretExpiresAt = cfg.PreviousSecretExpiresAt
+	}
+	return sub
+}
+
+func (s *webhookSubscriptionService) List(ctx context.Context, instanceName string) ([]*webhook.Subscription, error) {
+	inst, err := s.instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	out := []*webhook.Subscription{}
+	if inst.Webhook.URL != "" {
+		out = append(out, defaultSubscription(inst))
+	}
+	subs, err := s.subs.List(ctx, string(inst.ID))
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		out = append(out, presentSubscription(sub))
+	}
+	return out, nil
+}
+
+func (s *webhookSubscriptionService) Get(ctx context.Context, instanceName, id string) (*webhook.Subscription, error) {
+	inst, err := s.instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	if id == webhook.DefaultSubscriptionID {
+		if inst.Webhook.URL == "" {
+			return nil, repositories.ErrSubscriptionNotFound
+		}
+		return defaultSubscription(inst), nil
+	}
+	sub, err := s.subs.Get(ctx, string(inst.ID), id)
+	if err != nil {
+		return nil, err
+	}
+	return presentSubscription(sub), nil
+}
+
+func (s *webhookSubscriptionService) Create(ctx context.Context, instanceName string, in webhook.SubscriptionInput) (*webhook.Subscription, error) {
+	if in.URL == nil {
+		return nil, ErrInvalidWebhookURL
+	}
+	inst, err := s.instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	sub := &webhook.Subscription{
+		ID:         uuid.NewString(),
+		InstanceID: string(inst.ID),
+		Enabled:    true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := applySubscriptionInput(sub, in); err != nil {
+		return nil, err
+	}
+	if in.Secret != nil {
+		secret := strings.TrimSpace(*in.Secret)
+		if secret != "" && len(secret) < minWebhookSecretLength {
+			return nil, ErrWebhookSecretTooShort
+		}
+		sub.Secret = secret
+	}
+	if err := s.subs.Create(ctx, sub); err != nil {
+		return nil, err
+	}
+	return presentSubscription(sub), nil
+}
+
+func (s *webhookSubscriptionService) Update(ctx context.Context, instanceName, id string, in webhook.SubscriptionInput) (*webhook.Subscription, error) {
+	if in.Secret != nil {
+		return nil, errors.New("use rotateSecret to change the signing secret")
+	}
+	inst, err := s.instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if id == webhook.DefaultSubscriptionID {
+		// A assinatura default é a config legada: alterar aqui equivale ao /webhook/set.
+		sub := defaultSubscription(inst)
+		if err := applySubscriptionInput(sub, in); err != nil {
+			return nil, err
+		}
+		inst.Webhook.URL = sub.URL
+		if in.Events != nil {
+			inst.Webhook.Events = sub.Events
+			inst.Webhook.ByEvents = len(sub.Events) > 0
+		}
+		inst.Webhook.Headers = sub.Headers
+		inst.Webhook.Enabled = sub.Enabled
+		inst.WebhookURL = sub.URL
+		inst.UpdatedAt = now
+		if err := s.instances.Update(ctx, inst); err != nil {
+			return nil, err
+		}
+		return defaultSubscription(inst), nil
+	}
+	sub, err := s.subs.Get(ctx, string(inst.ID), id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySubscriptionInput(sub, in); err != nil {
+		return nil, err
+	}
+	sub.UpdatedAt = now
+	if err := s.subs.Update(ctx, sub); err != nil {
+		return nil, err
+	}
+	return presentSubscription(sub), nil
+}
+
+func (s *webhookSubscriptionService) Delete(ctx context.Context, instanceName, id string) error {
+	inst, err := s.instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+	if id != webhook.DefaultSubscriptionID {
+		return s.subs.Delete(ctx, string(inst.ID), id)
+	}
+	if inst.Webhook.URL == "" {
+		return repositories.ErrSubscriptionNotFound
+	}
+	inst.Webhook = instance.InstanceWebhook{}
+	inst.WebhookURL = ""
+	inst.UpdatedAt = time.Now().UTC()
+	return s.instances.Update(ctx, inst)
+}
+
+func (s *webhookSubscriptionService) RotateSecret(ctx context.Context, instanceName, id string, in instance.RotateWebhookSecretInput) (*instance.RotateWebhookSecretResponse, error) {
+	inst, err := s.instances.GetByName(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if id == webhook.DefaultSubscriptionID {
+		secret, previous, expiresAt, err := rotateWebhookSecret(inst.Webhook.Secret, in, now)
+		if err != nil {
+			return nil, err
+		}
+		inst.Webhook.Secret = secret
+		inst.Webhook.PreviousSecret = previous
+		inst.Webhook.PreviousSecretExpiresAt = expiresAt
+		inst.UpdatedAt = now
+		if err := s.instances.Update(ctx, inst); err != nil {
+			return nil, err
+		}
+		return &instance.RotateWebhookSecretResponse{InstanceName: inst.Name, SubscriptionID: id, Secret: secret, PreviousSecretExpiresAt: expiresAt}, nil
+	}
+	sub, err := s.subs.Get(ctx, string(inst.ID), id)
+	if err != nil {
+		return nil, err
+	}
+	secret, previous, expiresAt, err := rotateWebhookSecret(sub.Secret, in, now)
+	if err != nil {
+		return nil, err
+	}
+	sub.Secret = secret
+	sub.PreviousSecret = previous
+	sub.PreviousSecretExpiresAt = expiresAt
+	sub.UpdatedAt = now
+	if err := s.subs.Update(ctx, sub); err != nil {
+		return nil, err
+	}
+	return &instance.RotateWebhookSecretResponse{InstanceName: inst.Name, SubscriptionID: id, Secret: secret, PreviousSecretExpiresAt: expiresAt}, nil
+}
+
+// applySubscriptionInput valida e aplica os campos informados; eventos seguem o formato do
+// /webhook/set (maiúsculas) e headers com nome vazio são descartados.
+func applySubscriptionInput(sub *webhook.Subscription, in webhook.SubscriptionInput) error {
+	if in.Name != nil {
+		sub.Name = strings.TrimSpace(*in.Name)
+	}
+	if in.URL != nil {
+		raw := strings.TrimSpace(*in.URL)
+		parsed, err := url.Parse(raw)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return ErrInvalidWebhookURL
+		}
+		sub.URL = raw
+	}
+	if in.Events != nil {
+		events := make([]string, 0, len(*in.Events))
+		for _, evt := range *in.Events {
+			if trimmed := strings.TrimSpace(evt); trimmed != "" {
+				events = append(events, strings.ToUpper(trimmed))
+			}
+		}
+		sub.Events = events
+	}
+	if in.Headers != nil {
+		var headers map[string]string
+		for k, v := range *in.Headers {
+			key := strings.TrimSpace(k)
+			if key == "" {
+				continue
+			}
+			if headers == nil {
+				headers = make(map[string]string)
+			}
+			headers[key] = strings.TrimSpace(v)
+		}
+		sub.Headers = headers
+	}
+	if in.Enabled != nil {
+		sub.Enabled = *in.Enabled
+	}
+	return nil
+}
+
+// presentSubscription preenche os campos derivados exibidos na API.
+func presentSubscription(sub *webhook.Subscription) *webhook.Subscription {
+	out := *sub
+	out.Signed = sub.Secret != ""
+	if len(sub.SigningSecrets(time.Now())) < 2 {
+		out.PreviousSecretExpiresAt = nil
+	}
+	return &out
+}
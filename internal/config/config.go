@@ -5,7 +5,9 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type AppConfig struct {
@@ -21,7 +23,93 @@ type AppConfig struct {
 	Storage                   StorageConfig
 	CommunityEventsWebhookURL string
 	CommunityEventsToken      string
+	CommunityEventsSecrets    []string // segredo HMAC atual e, durante a rotação, o anterior
 	EventLogDir               string
+	DeviceStore               string // sqlite (um arquivo por instância) ou postgres
+	Reconnect                 ReconnectConfig
+	Cluster                   ClusterConfig
+	ShutdownTimeout           time.Duration // prazo para drenar eventos/webhooks no encerramento
+	EventStreamRetention      time.Duration // eventos mantidos para a retomada dos streams SSE/WebSocket (0 = sem limpeza)
+	Quota                     QuotaConfig
+	Webhook                   WebhookConfig
+	Redis                     RedisConfig
+	RabbitMQ                  RabbitMQConfig
+}
+
+// RedisConfig é a conexão com o Redis (mesmas variáveis do docker-compose) e o destino de
+// eventos Redis Streams, habilitado por instância em /redis/set/{instance}.
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+	Events   RedisEventsConfig
+}
+
+// RabbitMQConfig é a conexão AMQP 0-9-1 usada como destino de eventos (habilitado por instância
+// em /rabbitmq/set/{instance}) e, com CommandQueue, como entrada de comandos de envio.
+type RabbitMQConfig struct {
+	URL            string
+	EventsEnabled  bool
+	Exchange       string
+	ConfirmTimeout time.Duration
+	CommandQueue   string
+	Prefetch       int
+}
+
+type RedisEventsConfig struct {
+	Enabled     bool
+	Stream      string // stream global ou prefixo dos streams por instância
+	PerInstance bool
+	MaxLen      int64
+}
+
+// WebhookConfig controla a fila de entregas de webhook: cada entrega é gravada no outbox e
+// repetida com backoff exponencial até MaxAttempts, depois vai para o dead-letter.
+type WebhookConfig struct {
+	Timeout      time.Duration
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+	Workers      int
+	Ordered      bool // preserva a ordem por instância/URL; uma entrega com falha segura as seguintes
+	// Base64MaxBytes limita as mídias embutidas com webhookBase64; acima disso vai só a URL do storage
+	Base64MaxBytes int
+	LogRetention   time.Duration // histórico de entregas consultável em /webhook/deliveries (0 = sem limpeza)
+	Global         GlobalWebhookConfig
+}
+
+// GlobalWebhookConfig é o webhook do servidor que recebe os eventos de todas as instâncias,
+// além dos webhooks de cada uma.
+type GlobalWebhookConfig struct {
+	URL     string
+	Token   string
+	Events  []string // vazio = todos os eventos
+	Secrets []string // segredo HMAC atual e, durante a rotação, o anterior
+}
+
+// QuotaConfig define a cota padrão de envios por instância (0 = sem limite) e o fuso usado
+// para a virada do dia e do mês.
+type QuotaConfig struct {
+	Daily    int
+	Monthly  int
+	Location *time.Location
+}
+
+// ClusterConfig habilita várias réplicas dividindo as instâncias por leases no Postgres.
+type ClusterConfig struct {
+	Enabled   bool
+	NodeID    string
+	Address   string // URL base deste nó, usada pelos outros para encaminhar requisições
+	LeaseTTL  time.Duration
+	Heartbeat time.Duration
+	Forward   string // proxy (padrão) ou redirect
+	Secret    string // assina o cabeçalho de encaminhamento entre os nós
+}
+
+type ReconnectConfig struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	MaxRetries     int
 }
 
 type PostgresConfig struct {
@@ -111,7 +199,7 @@ func Load() *AppConfig {
 		}
 	} else {
 		if dsn == "" {
-			dsn = "file:whatsapp.db?_foreign_keys=on"
+			dsn = "file:whatsapp.db?_pragma=foreign_keys(1)"
 		}
 	}
 
@@ -128,7 +216,71 @@ func Load() *AppConfig {
 		Storage:                   storage,
 		CommunityEventsWebhookURL: strings.TrimSpace(getEnv("COMMUNITY_EVENTS_WEBHOOK_URL", "")),
 		CommunityEventsToken:      strings.TrimSpace(getEnv("COMMUNITY_EVENTS_BEARER_TOKEN", "")),
+		CommunityEventsSecrets:    []string{getEnv("COMMUNITY_EVENTS_WEBHOOK_SECRET", ""), getEnv("COMMUNITY_EVENTS_WEBHOOK_PREVIOUS_SECRET", "")},
 		EventLogDir:               strings.TrimSpace(getEnv("EVENT_LOG_DIR", "")),
+		DeviceStore:               strings.ToLower(strings.TrimSpace(getEnv("WA_DEVICE_STORE", "sqlite"))),
+		Reconnect: ReconnectConfig{
+			InitialBackoff: getEnvDuration("WA_RECONNECT_INITIAL_BACKOFF", 2*time.Second),
+			MaxBackoff:     getEnvDuration("WA_RECONNECT_MAX_BACKOFF", 5*time.Minute),
+			MaxRetries:     getEnvInt("WA_RECONNECT_MAX_RETRIES", 10),
+		},
+		Cluster:              loadClusterConfig(),
+		ShutdownTimeout:      getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		EventStreamRetention: getEnvDuration("EVENT_STREAM_RETENTION", 24*time.Hour),
+		Quota:                loadQuotaConfig(),
+		Webhook: WebhookConfig{
+			Timeout:        getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxAttempts:    getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			RetryBackoff:   getEnvDuration("WEBHOOK_RETRY_BACKOFF", 5*time.Second),
+			MaxBackoff:     getEnvDuration("WEBHOOK_RETRY_MAX_BACKOFF", 10*time.Minute),
+			Workers:        getEnvInt("WEBHOOK_WORKERS", 4),
+			Ordered:        getEnv("WEBHOOK_ORDERED", "false") == "true",
+			Base64MaxBytes: getEnvInt("WEBHOOK_BASE64_MAX_BYTES", 5<<20),
+			LogRetention:   getEnvDuration("WEBHOOK_LOG_RETENTION", 7*24*time.Hour),
+			Global: GlobalWebhookConfig{
+				URL:     strings.TrimSpace(getEnv("GLOBAL_WEBHOOK_URL", "")),
+				Token:   strings.TrimSpace(getEnv("GLOBAL_WEBHOOK_BEARER_TOKEN", "")),
+				Events:  getEnvList("GLOBAL_WEBHOOK_EVENTS"),
+				Secrets: []string{getEnv("GLOBAL_WEBHOOK_SECRET", ""), getEnv("GLOBAL_WEBHOOK_PREVIOUS_SECRET", "")},
+			},
+		},
+		Redis: RedisConfig{
+			Addr:     fmt.Sprintf("%s:%s", getEnv("REDIS_HOST", "localhost"), getEnv("REDIS_PORT", "6379")),
+			Password: getEnv("REDIS_PASSWORD", ""),
+			DB:       getEnvInt("REDIS_DB", 0),
+			Events: RedisEventsConfig{
+				Enabled:     getEnv("EVENTS_REDIS_ENABLED", "false") == "true",
+				Stream:      strings.TrimSpace(getEnv("EVENTS_REDIS_STREAM", "whatsapp:events")),
+				PerInstance: !strings.EqualFold(strings.TrimSpace(getEnv("EVENTS_REDIS_MODE", "instance")), "global"),
+				MaxLen:      int64(getEnvInt("EVENTS_REDIS_MAXLEN", 10000)),
+			},
+		},
+		RabbitMQ: RabbitMQConfig{
+			URL:            strings.TrimSpace(getEnv("RABBITMQ_URL", "")),
+			EventsEnabled:  getEnv("EVENTS_RABBITMQ_ENABLED", "false") == "true",
+			Exchange:       strings.TrimSpace(getEnv("RABBITMQ_EXCHANGE", "whatsapp.events")),
+			ConfirmTimeout: getEnvDuration("RABBITMQ_CONFIRM_TIMEOUT", 5*time.Second),
+			CommandQueue:   strings.TrimSpace(getEnv("RABBITMQ_COMMAND_QUEUE", "")),
+			Prefetch:       getEnvInt("RABBITMQ_PREFETCH", 10),
+		},
+	}
+	if cfg.DeviceStore == "postgres" && driver != "postgres" {
+		log.Printf("WA_DEVICE_STORE=postgres requires DB_DRIVER=postgres; falling back to sqlite device store")
+		cfg.DeviceStore = "sqlite"
+	}
+	if cfg.Cluster.Enabled && (driver != "postgres" || cfg.DeviceStore != "postgres") {
+		log.Printf("CLUSTER_ENABLED requires DB_DRIVER=postgres and WA_DEVICE_STORE=postgres; cluster mode disabled")
+		cfg.Cluster.Enabled = false
+	}
+	if cfg.Cluster.Enabled && cfg.Cluster.Secret == "" {
+		// Todos os nós compartilham o master token; sem nenhum dos dois o encaminhamento não é autenticado.
+		cfg.Cluster.Secret = cfg.MasterToken
+		if cfg.Cluster.Secret == "" {
+			log.Printf("CLUSTER_SECRET is empty; forwarded requests between nodes cannot be authenticated")
+		}
+	}
+	if cfg.Cluster.Enabled && cfg.Cluster.Address == "" {
+		cfg.Cluster.Address = fmt.Sprintf("http://%s:%s", cfg.Cluster.NodeID, cfg.HTTPPort)
 	}
 	if strings.EqualFold(cfg.EventLogDir, "off") || strings.EqualFold(cfg.EventLogDir, "disabled") {
 		cfg.EventLogDir = ""
@@ -139,6 +291,50 @@ func Load() *AppConfig {
 	return cfg
 }
 
+func loadQuotaConfig() QuotaConfig {
+	quota := QuotaConfig{
+		Daily:    getEnvInt("SEND_QUOTA_DAILY", 0),
+		Monthly:  getEnvInt("SEND_QUOTA_MONTHLY", 0),
+		Location: time.UTC,
+	}
+	if name := strings.TrimSpace(getEnv("SEND_QUOTA_TIMEZONE", "")); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			log.Printf("invalid SEND_QUOTA_TIMEZONE %q: %v; using UTC", name, err)
+		} else {
+			quota.Location = loc
+		}
+	}
+	return quota
+}
+
+func loadClusterConfig() ClusterConfig {
+	nodeID := strings.TrimSpace(getEnv("CLUSTER_NODE_ID", ""))
+	if nodeID == "" {
+		nodeID, _ = os.Hostname()
+	}
+	cluster := ClusterConfig{
+		Enabled:   getEnv("CLUSTER_ENABLED", "false") == "true",
+		NodeID:    nodeID,
+		Address:   strings.TrimRight(strings.TrimSpace(getEnv("CLUSTER_NODE_ADDRESS", "")), "/"),
+		LeaseTTL:  getEnvDuration("CLUSTER_LEASE_TTL", 30*time.Second),
+		Heartbeat: getEnvDuration("CLUSTER_HEARTBEAT_INTERVAL", 10*time.Second),
+		Forward:   strings.ToLower(strings.TrimSpace(getEnv("CLUSTER_FORWARD_MODE", "proxy"))),
+		Secret:    strings.TrimSpace(getEnv("CLUSTER_SECRET", "")),
+	}
+	if cluster.LeaseTTL <= 0 {
+		cluster.LeaseTTL = 30 * time.Second
+	}
+	if cluster.Heartbeat <= 0 || cluster.Heartbeat >= cluster.LeaseTTL {
+		log.Printf("CLUSTER_HEARTBEAT_INTERVAL must be shorter than CLUSTER_LEASE_TTL; using %s", cluster.LeaseTTL/3)
+		cluster.Heartbeat = cluster.LeaseTTL / 3
+	}
+	if cluster.Forward != "redirect" {
+		cluster.Forward = "proxy"
+	}
+	return cluster
+}
+
 func buildPostgresDSN(pg PostgresConfig) string {
 	host := pg.Host
 	if host == "" {
@@ -186,6 +382,43 @@ func getEnv(key, def string) string {
 	return def
 }
 
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("invalid %s=%q, using %s", key, v, def)
+		return def
+	}
+	return d
+}
+
+// getEnvList lê uma lista separada por vírgulas, ignorando itens vazios.
+func getEnvList(key string) []string {
+	var out []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func getEnvInt(key string, def int) int {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("invalid %s=%q, using %d", key, v, def)
+		return def
+	}
+	return n
+}
+
 func MustLoad() *AppConfig {
 	cfg := Load()
 	if cfg.HTTPPort == "" {
@@ -0,0 +1,104 @@
+package apikey
+
+import (
+	"slices"
+	"time"
+)
+
+// Escopos aceitos pelas API keys.
+const (
+	ScopeMessagesSend   = "messages:send"
+	ScopeGroupsRead     = "groups:read"
+	ScopeGroupsWrite    = "groups:write"
+	ScopeAnalyticsRead  = "analytics:read"
+	ScopeInstancesRead  = "instances:read"
+	ScopeInstancesWrite = "instances:write"
+	ScopeInstancesAdmin = "instances:admin"
+)
+
+// Scopes lista os escopos válidos.
+var Scopes = []string{
+	ScopeMessagesSend,
+	ScopeGroupsRead,
+	ScopeGroupsWrite,
+	ScopeAnalyticsRead,
+	ScopeInstancesRead,
+	ScopeInstancesWrite,
+	ScopeInstancesAdmin,
+}
+
+// implied indica os escopos concedidos implicitamente por um escopo mais amplo.
+var implied = map[string][]string{
+	ScopeGroupsWrite:    {ScopeGroupsRead},
+	ScopeInstancesWrite: {ScopeInstancesRead},
+	ScopeInstancesAdmin: {ScopeInstancesWrite, ScopeInstancesRead},
+}
+
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// APIKey é uma chave nomeada com escopos, allowlist de instâncias opcional e expiração.
+// Apenas o hash da chave é armazenado.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // início da chave, para identificação
+	Scopes     []string   `json:"scopes"`
+	Instances  []string   `json:"instances,omitempty"` // vazio = todas as instâncias
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	KeyHash    string     `json:"-"`
+	KeyLookup  string     `json:"-"`
+}
+
+func (k *APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || slices.Contains(implied[s], scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsInstance indica se a chave pode acessar a instância. Chaves com allowlist não
+// acessam rotas sem instância (instance == ""), como a listagem.
+func (k *APIKey) AllowsInstance(instance string) bool {
+	if len(k.Instances) == 0 {
+		return true
+	}
+	return instance != "" && slices.Contains(k.Instances, instance)
+}
+
+func (k *APIKey) Allows(scope, instance string) bool {
+	return k.HasScope(scope) && k.AllowsInstance(instance)
+}
+
+type CreateAPIKeyInput struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	Instances []string   `json:"instances,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// UpdateAPIKeyInput altera apenas os campos informados.
+type UpdateAPIKeyInput struct {
+	Name      *string    `json:"name,omitempty"`
+	Scopes    *[]string  `json:"scopes,omitempty"`
+	Instances *[]string  `json:"instances,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// ClearExpiry remove a expiração
+	ClearExpiry bool `json:"clearExpiry,omitempty"`
+}
+
+// CreateAPIKeyResponse inclui a chave em texto puro, retornada apenas na criação.
+type CreateAPIKeyResponse struct {
+	*APIKey
+	Key string `json:"key"`
+}
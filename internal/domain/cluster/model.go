@@ -0,0 +1,25 @@
+package cluster
+
+import "time"
+
+// Node é uma réplica da API participando do cluster.
+type Node struct {
+	ID          string    `json:"id"`
+	Address     string    `json:"address"`
+	StartedAt   time.Time `json:"startedAt"`
+	HeartbeatAt time.Time `json:"heartbeatAt"`
+}
+
+// Lease indica qual nó é dono (e mantém conectada) uma instância até ExpiresAt.
+type Lease struct {
+	Instance    string    `json:"instance"`
+	NodeID      string    `json:"nodeId"`
+	NodeAddress string    `json:"nodeAddress,omitempty"`
+	AcquiredAt  time.Time `json:"acquiredAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// Active informa se o lease ainda vale no instante informado.
+func (l Lease) Active(now time.Time) bool {
+	return l.NodeID != "" && now.Before(l.ExpiresAt)
+}
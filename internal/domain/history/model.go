@@ -0,0 +1,46 @@
+package history
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Chat representa uma conversa recebida via history sync
+type Chat struct {
+	InstanceID    string     `json:"instanceId" db:"instance_id"`
+	JID           string     `json:"remoteJid" db:"jid"`
+	Name          string     `json:"name,omitempty" db:"name"`
+	UnreadCount   int        `json:"unreadCount" db:"unread_count"`
+	Archived      bool       `json:"archived" db:"archived"`
+	Pinned        bool       `json:"pinned" db:"pinned"`
+	LastMessageAt *time.Time `json:"lastMessageAt,omitempty" db:"last_message_at"`
+	UpdatedAt     time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// Contact representa um contato (push name) recebido via history sync
+type Contact struct {
+	InstanceID string    `json:"instanceId" db:"instance_id"`
+	JID        string    `json:"remoteJid" db:"jid"`
+	PushName   string    `json:"pushName,omitempty" db:"push_name"`
+	UpdatedAt  time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Message representa uma mensagem histórica no formato enviado aos webhooks
+type Message struct {
+	InstanceID  string          `json:"instanceId" db:"instance_id"`
+	MessageID   string          `json:"messageId" db:"message_id"`
+	RemoteJID   string          `json:"remoteJid" db:"remote_jid"`
+	FromMe      bool            `json:"fromMe" db:"from_me"`
+	Participant string          `json:"participant,omitempty" db:"participant"`
+	PushName    string          `json:"pushName,omitempty" db:"push_name"`
+	MessageType string          `json:"messageType" db:"message_type"`
+	Payload     json.RawMessage `json:"payload" db:"payload"`
+	Timestamp   time.Time       `json:"messageTimestamp" db:"message_timestamp"`
+}
+
+// SyncBatch agrupa os dados extraídos de um evento HistorySync
+type SyncBatch struct {
+	Chats    []Chat
+	Contacts []Contact
+	Messages []Message
+}
@@ -21,20 +21,91 @@ type InstanceWebhook struct {
 	Headers  map[string]string `json:"headers,omitempty"`
 	Events   []string          `json:"events,omitempty"`
 	Enabled  bool              `json:"enabled"`
+	// Segredo HMAC das entregas; persistido junto da config e só retornado na rotação.
+	Secret                  string     `json:"secret,omitempty"`
+	PreviousSecret          string     `json:"previousSecret,omitempty"`
+	PreviousSecretExpiresAt *time.Time `json:"previousSecretExpiresAt,omitempty"`
+}
+
+// SigningSecrets retorna os segredos que assinam as entregas: o atual e, durante o período
+// de sobreposição da rotação, o anterior.
+func (w InstanceWebhook) SigningSecrets(now time.Time) []string {
+	var secrets []string
+	if w.Secret != "" {
+		secrets = append(secrets, w.Secret)
+	}
+	if w.PreviousSecret != "" && w.PreviousSecretExpiresAt != nil && now.Before(*w.PreviousSecretExpiresAt) {
+		secrets = append(secrets, w.PreviousSecret)
+	}
+	return secrets
+}
+
+// Redacted retorna uma cópia sem os segredos, para respostas da API
+func (w InstanceWebhook) Redacted() InstanceWebhook {
+	w.Secret = ""
+	w.PreviousSecret = ""
+	return w
+}
+
+// InstanceProxy define o proxy de saída (HTTP ou SOCKS5) usado pela instância
+type InstanceProxy struct {
+	Enabled  bool   `json:"enabled"`
+	URL      string `json:"url,omitempty"` // http://host:port, https://host:port ou socks5://host:port
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// InstancePairing define como a instância se apresenta no login por código de pareamento
+type InstancePairing struct {
+	ClientType  string `json:"clientType,omitempty"`  // chrome (padrão), edge, firefox, ie, opera, safari, electron, uwp, other
+	DisplayName string `json:"displayName,omitempty"` // formato "Browser (OS)", ex.: "Chrome (Linux)"
+}
+
+// Redacted retorna uma cópia sem a senha, para respostas da API
+func (p InstanceProxy) Redacted() *InstanceProxy {
+	if !p.Enabled && p.URL == "" {
+		return nil
+	}
+	p.Password = ""
+	return &p
 }
 
 type Instance struct {
 	ID          ID               `json:"id"`
 	Name        string           `json:"instanceName"`
-	Token       string           `json:"token"`
+	Token       string           `json:"token,omitempty"` // texto puro, só retornado na criação/rotação
 	Number      string           `json:"number,omitempty"`
 	Integration string           `json:"integration,omitempty"`
 	WebhookURL  string           `json:"webhookUrl,omitempty"`
 	Settings    InstanceSettings `json:"settings"`
 	Webhook     InstanceWebhook  `json:"webhook"`
+	Proxy       InstanceProxy    `json:"proxy"`
+	Pairing     InstancePairing  `json:"pairing"`
+	Tags        []string         `json:"tags,omitempty"`
+	Metadata    map[string]any   `json:"metadata,omitempty"` // dados livres do cliente (ex.: customerId, time)
 	CreatedAt   time.Time        `json:"createdAt"`
 	UpdatedAt   time.Time        `json:"updatedAt"`
 	Status      string           `json:"status"` // open, closed, disconnected
+	// Última desconexão registrada pelos eventos do whatsmeow (limpa ao reconectar)
+	DisconnectionReasonCode *int       `json:"disconnectionReasonCode,omitempty"`
+	DisconnectionObject     *string    `json:"disconnectionObject,omitempty"`
+	DisconnectionAt         *time.Time `json:"disconnectionAt,omitempty"`
+	// Apenas o hash salgado do token é persistido; o lookup indexa a busca na autenticação.
+	TokenHash              string     `json:"-"`
+	TokenLookup            string     `json:"-"`
+	PreviousTokenHash      string     `json:"-"`
+	PreviousTokenLookup    string     `json:"-"`
+	PreviousTokenExpiresAt *time.Time `json:"-"`
+}
+
+// ConnectionStatus é o estado da conexão gravado pelos eventos do whatsmeow, sem tocar nas
+// demais colunas da instância (settings, webhook, proxy, tokens...).
+type ConnectionStatus struct {
+	Status                  string
+	DisconnectionReasonCode *int
+	DisconnectionObject     *string
+	DisconnectionAt         *time.Time
+	UpdatedAt               time.Time
 }
 
 // InstanceListResponse represents the full instance response following Evolution API format
@@ -47,7 +118,7 @@ type InstanceListResponse struct {
 	ProfilePicURL           string                  `json:"profilePicUrl,omitempty"`
 	Integration             string                  `json:"integration"`
 	Number                  string                  `json:"number"`
-	Token                   string                  `json:"token"`
+	Token                   string                  `json:"token,omitempty"`
 	ClientName              string                  `json:"clientName"`
 	DisconnectionReasonCode *int                    `json:"disconnectionReasonCode"`
 	DisconnectionObject     *string                 `json:"disconnectionObject"`
@@ -55,9 +126,33 @@ type InstanceListResponse struct {
 	CreatedAt               time.Time               `json:"createdAt"`
 	UpdatedAt               time.Time               `json:"updatedAt"`
 	Setting                 *InstanceSettingDetails `json:"Setting,omitempty"`
+	Presence                *InstancePresence       `json:"presence,omitempty"`
+	HistorySync             *InstanceHistorySync    `json:"historySync,omitempty"`
+	Health                  *InstanceHealth         `json:"health,omitempty"`
+	Proxy                   *InstanceProxy          `json:"proxy,omitempty"`
+	Owner                   *InstanceOwner          `json:"owner,omitempty"`
+	Tags                    []string                `json:"tags,omitempty"`
+	Metadata                map[string]any          `json:"metadata,omitempty"`
 	Count                   *InstanceCount          `json:"_count,omitempty"`
 }
 
+// InstanceOwner identifica o nó do cluster que mantém a instância conectada.
+type InstanceOwner struct {
+	NodeID         string    `json:"nodeId"`
+	Address        string    `json:"address,omitempty"`
+	Local          bool      `json:"local"`
+	LeaseExpiresAt time.Time `json:"leaseExpiresAt"`
+}
+
+// InstancePresence represents the state of the alwaysOnline presence keeper
+type InstancePresence struct {
+	Status     string     `json:"status"`
+	Reason     string     `json:"reason,omitempty"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	LastSentAt *time.Time `json:"lastSentAt,omitempty"`
+	LastError  string     `json:"lastError,omitempty"`
+}
+
 // InstanceSettingDetails represents the Setting object with additional metadata
 type InstanceSettingDetails struct {
 	ID              string    `json:"id"`
@@ -73,6 +168,42 @@ type InstanceSettingDetails struct {
 	InstanceID      string    `json:"instanceId"`
 }
 
+// InstanceHistorySync represents the progress of the history sync received after pairing
+type InstanceHistorySync struct {
+	SyncType   string    `json:"syncType"`
+	ChunkOrder uint32    `json:"chunkOrder"`
+	Progress   uint32    `json:"progress"`
+	Chats      int       `json:"chats"`
+	Contacts   int       `json:"contacts"`
+	Messages   int       `json:"messages"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// InstanceHealth represents the reconnect supervisor state machine of a session
+type InstanceHealth struct {
+	State           string     `json:"state"`
+	Reason          string     `json:"reason,omitempty"`
+	Code            int        `json:"code,omitempty"`
+	Attempts        int        `json:"attempts"`
+	Terminal        bool       `json:"terminal"`
+	LastError       string     `json:"lastError,omitempty"`
+	NextRetryAt     *time.Time `json:"nextRetryAt,omitempty"`
+	LastConnectedAt *time.Time `json:"lastConnectedAt,omitempty"`
+	LastDisconnect  *time.Time `json:"lastDisconnectAt,omitempty"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// ConnectionStateResponse follows the Evolution API /instance/connectionState format
+type ConnectionStateResponse struct {
+	Instance ConnectionStateInstance `json:"instance"`
+}
+
+type ConnectionStateInstance struct {
+	InstanceName string          `json:"instanceName"`
+	State        string          `json:"state"`
+	Health       *InstanceHealth `json:"health,omitempty"`
+}
+
 // InstanceCount represents message/contact/chat counts
 type InstanceCount struct {
 	Message int `json:"Message"`
@@ -88,6 +219,10 @@ type CreateInstanceInput struct {
 	Integration     string            `json:"integration"`
 	Settings        *InstanceSettings `json:"settings"`
 	Webhook         *InstanceWebhook  `json:"webhook"`
+	Proxy           *InstanceProxy    `json:"proxy"`
+	Pairing         *InstancePairing  `json:"pairing"`
+	Tags            []string          `json:"tags"`
+	Metadata        map[string]any    `json:"metadata"`
 	WebhookURL      string            `json:"webhookUrl"`
 	RejectCall      *bool             `json:"rejectCall"`
 	MsgCall         *string           `json:"msgCall"`
@@ -115,4 +250,94 @@ type SetSettingsInput struct {
 	ReadMessages    bool   `json:"readMessages"`
 	ReadStatus      bool   `json:"readStatus"`
 	SyncFullHistory bool   `json:"syncFullHistory"`
+	// Proxy substitui o proxy da instância quando informado
+	Proxy *InstanceProxy `json:"proxy,omitempty"`
+	// Pairing substitui o tipo de cliente e o nome exibidos no pareamento por código
+	Pairing *InstancePairing `json:"pairing,omitempty"`
+}
+
+// UpdateInstanceInput altera os dados da instância (PATCH); campos ausentes ficam como estão.
+type UpdateInstanceInput struct {
+	// InstanceName renomeia a instância, movendo também o device store
+	InstanceName *string   `json:"instanceName,omitempty"`
+	Number       *string   `json:"number,omitempty"`
+	Integration  *string   `json:"integration,omitempty"`
+	Tags         *[]string `json:"tags,omitempty"`
+	// Metadata é mesclado ao atual; chaves com valor null são removidas
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// ListFilter filtra o fetchInstances; filtros vazios são ignorados.
+type ListFilter struct {
+	Tags     []string // a instância precisa ter todas as tags
+	Status   string   // connectionStatus (open, close, connecting...)
+	OwnerJID string
+}
+
+// ProxyTestInput testa o proxy informado ou, se ausente, o proxy salvo na instância
+type ProxyTestInput struct {
+	Proxy     *InstanceProxy `json:"proxy,omitempty"`
+	TargetURL string         `json:"targetUrl,omitempty"`
+}
+
+type ProxyTestResult struct {
+	InstanceName string `json:"instanceName"`
+	Proxy        string `json:"proxy"` // URL sem credenciais
+	TargetURL    string `json:"targetUrl"`
+	OK           bool   `json:"ok"`
+	StatusCode   int    `json:"statusCode,omitempty"`
+	LatencyMs    int64  `json:"latencyMs"`
+	Error        string `json:"error,omitempty"`
+}
+
+// ExportInstanceInput solicita o arquivo de migração da instância
+type ExportInstanceInput struct {
+	Passphrase string `json:"passphrase"`
+	// Disconnect encerra o websocket local após a exportação, evitando duas conexões com o mesmo device
+	Disconnect bool `json:"disconnect"`
+}
+
+type ExportInstanceResponse struct {
+	InstanceName string    `json:"instanceName"`
+	Archive      string    `json:"archive"` // base64 do arquivo cifrado
+	ExportedAt   time.Time `json:"exportedAt"`
+}
+
+type ImportInstanceInput struct {
+	Archive      string `json:"archive"`
+	Passphrase   string `json:"passphrase"`
+	InstanceName string `json:"instanceName,omitempty"` // opcional: importa com outro nome
+}
+
+type ImportInstanceResponse struct {
+	InstanceName string `json:"instanceName"`
+	InstanceID   string `json:"instanceId"`
+	OwnerJID     string `json:"ownerJid"`
+	Status       string `json:"status"`
+}
+
+// RotateTokenInput gera um novo token; o anterior continua válido durante o período de carência
+type RotateTokenInput struct {
+	Token              string `json:"token,omitempty"` // opcional: token informado pelo cliente
+	GracePeriodSeconds int    `json:"gracePeriodSeconds,omitempty"`
+}
+
+// RotateWebhookSecretInput gera um novo segredo de assinatura; o anterior continua assinando
+// as entregas durante o período de sobreposição
+type RotateWebhookSecretInput struct {
+	Secret             string `json:"secret,omitempty"` // opcional: segredo informado pelo cliente
+	GracePeriodSeconds int    `json:"gracePeriodSeconds,omitempty"`
+}
+
+type RotateWebhookSecretResponse struct {
+	InstanceName            string     `json:"instanceName"`
+	SubscriptionID          string     `json:"subscriptionId,omitempty"`
+	Secret                  string     `json:"secret"`
+	PreviousSecretExpiresAt *time.Time `json:"previousSecretExpiresAt,omitempty"`
+}
+
+type RotateTokenResponse struct {
+	InstanceName           string     `json:"instanceName"`
+	Token                  string     `json:"token"`
+	PreviousTokenExpiresAt *time.Time `json:"previousTokenExpiresAt,omitempty"`
 }
@@ -0,0 +1,42 @@
+package usage
+
+import "time"
+
+// Períodos de cota.
+const (
+	PeriodDaily   = "daily"
+	PeriodMonthly = "monthly"
+)
+
+// Quota limita os envios da instância por dia e por mês; 0 significa sem limite.
+type Quota struct {
+	Daily   int `json:"daily"`
+	Monthly int `json:"monthly"`
+}
+
+// DailyUsage é o total de envios de um dia, separado por tipo de mensagem.
+type DailyUsage struct {
+	Day    string         `json:"day"` // YYYY-MM-DD no fuso das cotas
+	Total  int            `json:"total"`
+	ByType map[string]int `json:"byType"`
+}
+
+// Report resume o consumo da instância frente à cota vigente.
+type Report struct {
+	InstanceName   string       `json:"instanceName"`
+	Quota          Quota        `json:"quota"`
+	QuotaSource    string       `json:"quotaSource"` // instance (definida na instância) ou default
+	Today          int          `json:"today"`
+	Month          int          `json:"month"`
+	RemainingToday *int         `json:"remainingToday,omitempty"`
+	RemainingMonth *int         `json:"remainingMonth,omitempty"`
+	DailyResetAt   time.Time    `json:"dailyResetAt"`
+	MonthlyResetAt time.Time    `json:"monthlyResetAt"`
+	History        []DailyUsage `json:"history"`
+}
+
+// SetQuotaInput define a cota própria da instância (sobrepõe a padrão do servidor).
+type SetQuotaInput struct {
+	Daily   *int `json:"daily"`
+	Monthly *int `json:"monthly"`
+}
@@ -0,0 +1,25 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// JournalEvent é um evento publicado nos streams, guardado para a retomada por cursor. Seq é
+// crescente na ordem de gravação e Payload é o mesmo corpo enviado aos webhooks.
+type JournalEvent struct {
+	Seq          int64
+	InstanceID   string
+	InstanceName string
+	Event        string // nome canônico (ex.: messages.upsert)
+	Payload      json.RawMessage
+	CreatedAt    time.Time
+}
+
+// JournalFilter seleciona eventos do journal posteriores a AfterSeq; listas vazias não filtram.
+type JournalFilter struct {
+	AfterSeq  int64
+	Instances []string // nomes das instâncias
+	Events    []string // nomes canônicos
+	Limit     int
+}
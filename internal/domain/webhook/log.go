@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Situação de uma entrega no log.
+const (
+	StatusPending   = "pending"   // aguardando a primeira tentativa ou um retry
+	StatusDelivered = "delivered" // receptor respondeu 2xx
+	StatusFailed    = "failed"    // tentativas esgotadas (dead-letter)
+	StatusDiscarded = "discarded" // assinatura removida antes da entrega ou falha ao enfileirar
+)
+
+// DeliveryRecord é o histórico de uma entrega: gravado ao enfileirar e atualizado a cada
+// tentativa. O payload fica guardado para permitir o replay.
+type DeliveryRecord struct {
+	ID             string            `json:"id"`
+	InstanceID     string            `json:"instanceId"`
+	InstanceName   string            `json:"instanceName"` // nome na hora do envio
+	SubscriptionID string            `json:"subscriptionId"`
+	Event          string            `json:"event"`
+	URL            string            `json:"url"`
+	PayloadHash    string            `json:"payloadHash"` // sha256 do corpo enviado
+	Payload        json.RawMessage   `json:"payload,omitempty"`
+	Status         string            `json:"status"`
+	Attempts       int               `json:"attempts"`
+	LastStatus     int               `json:"lastStatus,omitempty"`
+	LastError      string            `json:"lastError,omitempty"`
+	ReplayOf       string            `json:"replayOf,omitempty"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	UpdatedAt      time.Time         `json:"updatedAt"`
+	AttemptLog     []DeliveryAttempt `json:"attemptLog,omitempty"`
+}
+
+// DeliveryAttempt registra uma tentativa de entrega.
+type DeliveryAttempt struct {
+	DeliveryID string    `json:"-"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	LatencyMs  int64     `json:"latencyMs"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// DeliveryFilter seleciona entregas do log; campos vazios não filtram.
+type DeliveryFilter struct {
+	InstanceID     string
+	SubscriptionID string
+	Event          string
+	Status         string
+	Since          time.Time
+	Until          time.Time
+	Limit          int
+	Ascending      bool // da mais antiga para a mais recente; por padrão as mais recentes primeiro
+}
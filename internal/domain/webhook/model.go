@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Delivery é uma entrega de webhook gravada no outbox antes do envio. O payload guarda o
+// corpo exato que será enviado, de modo que as tentativas repetem os mesmos bytes.
+type Delivery struct {
+	ID             string            `json:"id"`
+	InstanceID     string            `json:"instanceId"`
+	InstanceName   string            `json:"instanceName"`
+	SubscriptionID string            `json:"subscriptionId"`
+	Event          string            `json:"event"`
+	URL            string            `json:"url"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	Payload        json.RawMessage   `json:"payload"`
+	OrderKey       string            `json:"-"` // entregas com a mesma chave saem na ordem de criação
+	Attempts       int               `json:"attempts"`
+	LastError      string            `json:"lastError,omitempty"`
+	LastStatus     int               `json:"lastStatus,omitempty"`
+	NextAttemptAt  time.Time         `json:"nextAttemptAt"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	FailedAt       *time.Time        `json:"failedAt,omitempty"` // preenchido no dead-letter
+	ReplayOf       string            `json:"replayOf,omitempty"` // entrega original, quando é um replay
+}
@@ -0,0 +1,19 @@
+package webhook
+
+import "time"
+
+// SinkConfig habilita, para uma instância, um destino de eventos além dos webhooks
+// (ex.: Redis Streams). Sem config o destino fica desligado para a instância.
+type SinkConfig struct {
+	InstanceID string    `json:"-"`
+	Sink       string    `json:"sink"`
+	Enabled    bool      `json:"enabled"`
+	Events     []string  `json:"events,omitempty"` // vazio = todos os eventos
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// SinkConfigInput é o corpo de /{sink}/set/{instance}
+type SinkConfigInput struct {
+	Enabled bool     `json:"enabled"`
+	Events  []string `json:"events"`
+}
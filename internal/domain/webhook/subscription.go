@@ -0,0 +1,51 @@
+package webhook
+
+import "time"
+
+// DefaultSubscriptionID identifica a assinatura formada pela config legada da instância
+// (/webhook/set); as demais assinaturas ficam na coleção própria.
+const DefaultSubscriptionID = "default"
+
+// GlobalSubscriptionID identifica as entregas ao webhook global do servidor (GLOBAL_WEBHOOK_URL),
+// que recebe os eventos de todas as instâncias.
+const GlobalSubscriptionID = "global"
+
+// Subscription é um endpoint de webhook da instância, com filtro de eventos, headers e
+// segredo de assinatura próprios. Cada assinatura recebe as entregas de forma independente.
+type Subscription struct {
+	ID                      string            `json:"id"`
+	InstanceID              string            `json:"-"`
+	Name                    string            `json:"name,omitempty"`
+	URL                     string            `json:"url"`
+	Events                  []string          `json:"events,omitempty"` // vazio = todos os eventos
+	Headers                 map[string]string `json:"headers,omitempty"`
+	Enabled                 bool              `json:"enabled"`
+	Signed                  bool              `json:"signed"`
+	Secret                  string            `json:"-"`
+	PreviousSecret          string            `json:"-"`
+	PreviousSecretExpiresAt *time.Time        `json:"previousSecretExpiresAt,omitempty"`
+	CreatedAt               time.Time         `json:"createdAt"`
+	UpdatedAt               time.Time         `json:"updatedAt"`
+}
+
+// SigningSecrets retorna o segredo atual e, durante a sobreposição da rotação, o anterior.
+func (s Subscription) SigningSecrets(now time.Time) []string {
+	var secrets []string
+	if s.Secret != "" {
+		secrets = append(secrets, s.Secret)
+	}
+	if s.PreviousSecret != "" && s.PreviousSecretExpiresAt != nil && now.Before(*s.PreviousSecretExpiresAt) {
+		secrets = append(secrets, s.PreviousSecret)
+	}
+	return secrets
+}
+
+// SubscriptionInput cria ou altera uma assinatura; campos nulos mantêm o valor atual.
+type SubscriptionInput struct {
+	Name    *string            `json:"name"`
+	URL     *string            `json:"url"`
+	Events  *[]string          `json:"events"`
+	Headers *map[string]string `json:"headers"`
+	Enabled *bool              `json:"enabled"`
+	Secret  *string            `json:"secret"` // opcional na criação; depois use rotateSecret
+}
@@ -3,12 +3,17 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
 func Open(driver, dsn string) (*sql.DB, error) {
+	if driver == "sqlite" {
+		dsn = SQLiteDSN(dsn)
+	}
 	db, err := sql.Open(driver, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("open %s connection: %w", driver, err)
@@ -16,9 +21,44 @@ func Open(driver, dsn string) (*sql.DB, error) {
 	db.SetConnMaxLifetime(5 * time.Minute)
 	db.SetMaxIdleConns(5)
 	db.SetMaxOpenConns(10)
+	if driver == "sqlite" {
+		// SQLite aceita um único escritor; uma conexão evita SQLITE_BUSY entre transações.
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		db.SetConnMaxLifetime(0)
+	}
 	if err := db.Ping(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("ping %s connection: %w", driver, err)
 	}
 	return db, nil
 }
+
+// SQLiteDSN completa o DSN com os pragmas esperados pelos repositórios
+// (foreign keys, busy timeout, WAL e timestamps legíveis pelo driver modernc).
+func SQLiteDSN(dsn string) string {
+	dsn = strings.TrimSpace(dsn)
+	if dsn == "" {
+		dsn = "file:whatsapp.db"
+	}
+	// Compatibilidade com o DSN antigo no formato do mattn/go-sqlite3.
+	dsn = strings.Replace(dsn, "_foreign_keys=on", "_pragma=foreign_keys(1)", 1)
+
+	params := []struct{ key, value string }{
+		{"foreign_keys", "_pragma=foreign_keys(1)"},
+		{"busy_timeout", "_pragma=busy_timeout(10000)"},
+		{"journal_mode", "_pragma=journal_mode(WAL)"},
+		{"_time_format", "_time_format=sqlite"},
+	}
+	for _, p := range params {
+		if strings.Contains(dsn, p.key) {
+			continue
+		}
+		sep := "&"
+		if !strings.Contains(dsn, "?") {
+			sep = "?"
+		}
+		dsn += sep + p.value
+	}
+	return dsn
+}
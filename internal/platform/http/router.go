@@ -8,25 +8,42 @@ import (
 	"sync"
 
 	"github.com/faeln1/go-whatsapp-api/internal/app/controllers"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/apikey"
 	"github.com/faeln1/go-whatsapp-api/internal/platform/middleware"
 	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/apitoken"
 	waLog "go.mau.fi/whatsmeow/util/log"
 	yaml "gopkg.in/yaml.v3"
 )
 
 type RouterConfig struct {
-	InstanceCtrl  *controllers.InstanceController
-	MessageCtrl   *controllers.MessageController
-	CommunityCtrl *controllers.CommunityController
-	WebhookCtrl   *controllers.WebhookController
-	SettingsCtrl  *controllers.SettingsController
-	GroupCtrl     *controllers.GroupController
-	ProfileCtrl   *controllers.ProfileController
-	AnalyticsCtrl *controllers.AnalyticsController
-	Logger        waLog.Logger
-	WAManager     *whatsapp.Manager
-	SwaggerEnable bool
-	MasterToken   string
+	InstanceCtrl    *controllers.InstanceController
+	MessageCtrl     *controllers.MessageController
+	CommunityCtrl   *controllers.CommunityController
+	WebhookCtrl     *controllers.WebhookController
+	SettingsCtrl    *controllers.SettingsController
+	GroupCtrl       *controllers.GroupController
+	ProfileCtrl     *controllers.ProfileController
+	AnalyticsCtrl   *controllers.AnalyticsController
+	TransferCtrl    *controllers.TransferController
+	APIKeyCtrl      *controllers.APIKeyController
+	LoginStreamCtrl *controllers.LoginStreamController
+	UsageCtrl       *controllers.UsageController
+	WebhookSubsCtrl *controllers.WebhookSubscriptionController
+	WebhookLogCtrl  *controllers.WebhookDeliveryController
+	EventStreamCtrl *controllers.EventStreamController
+	EventSinkCtrl   *controllers.EventSinkController
+	APIKeys         services.APIKeyService
+	ClusterOwners   middleware.OwnerResolver
+	ClusterNodeID   string
+	ClusterForward  string
+	ClusterSecret   string
+	Lifecycle       *services.Lifecycle
+	Logger          waLog.Logger
+	WAManager       *whatsapp.Manager
+	SwaggerEnable   bool
+	MasterToken     string
 }
 
 func NewRouter(cfg RouterConfig) stdhttp.Handler {
@@ -95,6 +112,19 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 		})
 	})
 
+	// Comparação do master token em tempo constante
+	isMasterToken := func(token string) bool {
+		return cfg.MasterToken != "" && apitoken.Equal(token, cfg.MasterToken)
+	}
+
+	// apiKeyFor resolve API keys com escopo (prefixo gwa_)
+	apiKeyFor := func(token string) (*apikey.APIKey, bool) {
+		if cfg.APIKeys == nil {
+			return nil, false
+		}
+		return cfg.APIKeys.Authenticate(token)
+	}
+
 	// Verify credentials endpoint
 	mux.HandleFunc("/verify-creds", func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
 		if r.Method != stdhttp.MethodPost {
@@ -126,7 +156,7 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 		}
 
 		// Check master token first
-		if cfg.MasterToken != "" && token == cfg.MasterToken {
+		if isMasterToken(token) {
 			// Return master credentials info
 			w.WriteHeader(stdhttp.StatusOK)
 			json.NewEncoder(w).Encode(map[string]interface{}{
@@ -138,6 +168,21 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 			return
 		}
 
+		if key, ok := apiKeyFor(token); ok {
+			w.WriteHeader(stdhttp.StatusOK)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"valid":     true,
+				"tokenType": "apikey",
+				"keyId":     key.ID,
+				"name":      key.Name,
+				"scopes":    key.Scopes,
+				"instances": key.Instances,
+				"expiresAt": key.ExpiresAt,
+				"status":    "authenticated",
+			})
+			return
+		}
+
 		// Validate instance token
 		sess, ok := cfg.WAManager.ValidateToken(token)
 		if !ok || sess == nil {
@@ -185,7 +230,9 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 		return strings.TrimSpace(parts[1])
 	}
 
-	authorizeInstance := func(w stdhttp.ResponseWriter, r *stdhttp.Request, instance string) bool {
+	// authorizeInstance aceita o master token, o token da própria instância ou uma API key
+	// com o escopo exigido e a instância na allowlist.
+	authorizeInstance := func(w stdhttp.ResponseWriter, r *stdhttp.Request, instance, scope string) bool {
 		token := extractBearer(r.Header.Get("Authorization"))
 		if token == "" {
 			token = strings.TrimSpace(r.Header.Get("apikey"))
@@ -195,7 +242,14 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 			return false
 		}
 		// Check master token first (bypasses instance validation)
-		if cfg.MasterToken != "" && token == cfg.MasterToken {
+		if isMasterToken(token) {
+			return true
+		}
+		if key, ok := apiKeyFor(token); ok {
+			if !key.Allows(scope, instance) {
+				w.WriteHeader(stdhttp.StatusForbidden)
+				return false
+			}
 			return true
 		}
 		// Validate instance-specific token
@@ -207,6 +261,23 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 		return true
 	}
 
+	// authorizeOperator é como authorizeInstance, mas recusa o token da instância: usado em
+	// rotas que a própria instância não pode alterar (ex.: as suas cotas).
+	authorizeOperator := func(w stdhttp.ResponseWriter, r *stdhttp.Request, instance, scope string) bool {
+		token := extractBearer(r.Header.Get("Authorization"))
+		if token == "" {
+			token = strings.TrimSpace(r.Header.Get("apikey"))
+		}
+		if isMasterToken(token) {
+			return true
+		}
+		if key, ok := apiKeyFor(token); ok && key.Allows(scope, instance) {
+			return true
+		}
+		w.WriteHeader(stdhttp.StatusForbidden)
+		return false
+	}
+
 	splitSegments := func(path string) []string {
 		raw := strings.Split(path, "/")
 		out := make([]string, 0, len(raw))
@@ -219,6 +290,47 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 		return out
 	}
 
+	// isLoginStream identifica os streams de login, que aceitam o token via ?apikey=
+	isLoginStream := func(r *stdhttp.Request) bool {
+		segments := splitSegments(strings.TrimPrefix(r.URL.Path, "/instances"))
+		return r.Method == stdhttp.MethodGet && len(segments) == 3 && segments[1] == "qr" && (segments[2] == "stream" || segments[2] == "ws")
+	}
+
+	// isEventStream identifica os streams de eventos por instância, que também aceitam ?apikey=
+	isEventStream := func(r *stdhttp.Request) bool {
+		segments := splitSegments(strings.TrimPrefix(r.URL.Path, "/instances"))
+		return r.Method == stdhttp.MethodGet && len(segments) == 3 && segments[1] == "events" && (segments[2] == "stream" || segments[2] == "ws")
+	}
+
+	// instanceFromPath resolve a instância alvo das rotas /instances/{name}/... e /{grupo}/{op}/{name}
+	instanceFromPath := func(r *stdhttp.Request) string {
+		segments := splitSegments(r.URL.Path)
+		if len(segments) < 2 {
+			return ""
+		}
+		switch segments[0] {
+		case "instances":
+			switch segments[1] {
+			case "fetchInstances", "create", "import":
+				return ""
+			}
+			return segments[1]
+		case "message", "group", "webhook", "settings", "chat":
+			if len(segments) == 3 {
+				return segments[2]
+			}
+		default:
+			if cfg.EventSinkCtrl != nil && len(segments) == 3 {
+				for _, sink := range cfg.EventSinkCtrl.Sinks() {
+					if segments[0] == sink {
+						return segments[2]
+					}
+				}
+			}
+		}
+		return ""
+	}
+
 	// --- Documentation endpoints (if enabled) ---
 	if cfg.SwaggerEnable {
 		var (
@@ -302,6 +414,16 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 			return
 		}
 
+		// Handle /instances/import (migração entre servidores)
+		if r.URL.Path == "/instances/import" && cfg.TransferCtrl != nil {
+			if r.Method == stdhttp.MethodPost {
+				cfg.TransferCtrl.Import(w, r)
+				return
+			}
+			w.WriteHeader(stdhttp.StatusMethodNotAllowed)
+			return
+		}
+
 		// Handle /instances/{name}/*
 		if !strings.HasPrefix(r.URL.Path, "/instances/") {
 			w.WriteHeader(stdhttp.StatusNotFound)
@@ -321,7 +443,7 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 				w.WriteHeader(stdhttp.StatusUnauthorized)
 				return
 			}
-			if !authorizeInstance(w, r, instanceName) {
+			if !authorizeInstance(w, r, instanceName, readWriteScope(r, apikey.ScopeGroupsRead, apikey.ScopeGroupsWrite)) {
 				return
 			}
 			remainder := segments[2:]
@@ -410,6 +532,14 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 				return
 			}
 		}
+		if r.Method == stdhttp.MethodPatch && len(segments) == 1 {
+			// /instances/{name}
+			if !authorizeInstance(w, r, segments[0], apikey.ScopeInstancesWrite) {
+				return
+			}
+			cfg.InstanceCtrl.Update(w, r, segments[0])
+			return
+		}
 		if r.Method == stdhttp.MethodDelete {
 			// delete instance
 			r = r.Clone(r.Context())
@@ -434,6 +564,127 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 			cfg.InstanceCtrl.Disconnect(w, r)
 			return
 		}
+		if r.Method == stdhttp.MethodGet && len(segments) == 2 && segments[1] == "connectionState" {
+			// /instances/{name}/connectionState
+			if !authorizeInstance(w, r, segments[0], apikey.ScopeInstancesRead) {
+				return
+			}
+			cfg.InstanceCtrl.ConnectionState(w, r)
+			return
+		}
+		if r.Method == stdhttp.MethodPost && len(segments) == 2 && segments[1] == "rotateToken" {
+			// /instances/{name}/rotateToken
+			if !authorizeInstance(w, r, segments[0], apikey.ScopeInstancesAdmin) {
+				return
+			}
+			cfg.InstanceCtrl.RotateToken(w, r, segments[0])
+			return
+		}
+		if r.Method == stdhttp.MethodPost && len(segments) == 3 && segments[1] == "proxy" && segments[2] == "test" {
+			// /instances/{name}/proxy/test
+			if !authorizeInstance(w, r, segments[0], apikey.ScopeInstancesWrite) {
+				return
+			}
+			cfg.InstanceCtrl.TestProxy(w, r, segments[0])
+			return
+		}
+		if cfg.UsageCtrl != nil && len(segments) >= 2 && segments[1] == "usage" {
+			switch {
+			case len(segments) == 2 && r.Method == stdhttp.MethodGet:
+				// /instances/{name}/usage
+				if !authorizeInstance(w, r, segments[0], apikey.ScopeInstancesRead) {
+					return
+				}
+				cfg.UsageCtrl.Get(w, r, segments[0])
+			case len(segments) == 3 && segments[2] == "quota" && (r.Method == stdhttp.MethodPut || r.Method == stdhttp.MethodDelete):
+				// /instances/{name}/usage/quota
+				if !authorizeOperator(w, r, segments[0], apikey.ScopeInstancesAdmin) {
+					return
+				}
+				if r.Method == stdhttp.MethodPut {
+					cfg.UsageCtrl.SetQuota(w, r, segments[0])
+				} else {
+					cfg.UsageCtrl.ResetQuota(w, r, segments[0])
+				}
+			default:
+				w.WriteHeader(stdhttp.StatusMethodNotAllowed)
+			}
+			return
+		}
+		if cfg.WebhookSubsCtrl != nil && len(segments) >= 2 && segments[1] == "webhooks" {
+			instanceName := segments[0]
+			switch {
+			case len(segments) == 2:
+				// /instances/{name}/webhooks
+				if !authorizeInstance(w, r, instanceName, readWriteScope(r, apikey.ScopeInstancesRead, apikey.ScopeInstancesWrite)) {
+					return
+				}
+				switch r.Method {
+				case stdhttp.MethodGet:
+					cfg.WebhookSubsCtrl.List(w, r, instanceName)
+				case stdhttp.MethodPost:
+					cfg.WebhookSubsCtrl.Create(w, r, instanceName)
+				default:
+					w.WriteHeader(stdhttp.StatusMethodNotAllowed)
+				}
+			case len(segments) == 3:
+				// /instances/{name}/webhooks/{id}
+				if !authorizeInstance(w, r, instanceName, readWriteScope(r, apikey.ScopeInstancesRead, apikey.ScopeInstancesWrite)) {
+					return
+				}
+				switch r.Method {
+				case stdhttp.MethodGet:
+					cfg.WebhookSubsCtrl.Get(w, r, instanceName, segments[2])
+				case stdhttp.MethodPatch:
+					cfg.WebhookSubsCtrl.Update(w, r, instanceName, segments[2])
+				case stdhttp.MethodDelete:
+					cfg.WebhookSubsCtrl.Delete(w, r, instanceName, segments[2])
+				default:
+					w.WriteHeader(stdhttp.StatusMethodNotAllowed)
+				}
+			case len(segments) == 4 && segments[3] == "rotateSecret" && r.Method == stdhttp.MethodPost:
+				// /instances/{name}/webhooks/{id}/rotateSecret
+				if !authorizeInstance(w, r, instanceName, apikey.ScopeInstancesAdmin) {
+					return
+				}
+				cfg.WebhookSubsCtrl.RotateSecret(w, r, instanceName, segments[2])
+			default:
+				w.WriteHeader(stdhttp.StatusNotFound)
+			}
+			return
+		}
+		if cfg.TransferCtrl != nil && r.Method == stdhttp.MethodPost && len(segments) == 2 && segments[1] == "export" {
+			// /instances/{name}/export
+			if !authorizeInstance(w, r, segments[0], apikey.ScopeInstancesAdmin) {
+				return
+			}
+			cfg.TransferCtrl.Export(w, r, segments[0])
+			return
+		}
+		if cfg.LoginStreamCtrl != nil && isLoginStream(r) {
+			// /instances/{name}/qr/stream (SSE) e /instances/{name}/qr/ws (WebSocket)
+			if !authorizeInstance(w, r, segments[0], apikey.ScopeInstancesRead) {
+				return
+			}
+			if segments[2] == "ws" {
+				cfg.LoginStreamCtrl.WebSocket(w, r, segments[0])
+			} else {
+				cfg.LoginStreamCtrl.SSE(w, r, segments[0])
+			}
+			return
+		}
+		if cfg.EventStreamCtrl != nil && isEventStream(r) {
+			// /instances/{name}/events/stream (SSE) e /instances/{name}/events/ws (WebSocket)
+			if !authorizeInstance(w, r, segments[0], apikey.ScopeInstancesRead) {
+				return
+			}
+			if segments[2] == "ws" {
+				cfg.EventStreamCtrl.InstanceWebSocket(w, r, segments[0])
+			} else {
+				cfg.EventStreamCtrl.InstanceSSE(w, r, segments[0])
+			}
+			return
+		}
 		if r.Method == stdhttp.MethodGet && len(path) > 3 && path[len(path)-3:] == "qr" {
 			// /instances/{name}/qr
 			r = r.Clone(r.Context())
@@ -446,16 +697,21 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 	// Apply authentication middleware to instance routes
 	authenticatedInstances := middleware.BearerAuth(func(token string, r *stdhttp.Request) bool {
 		// Check master token first
-		if cfg.MasterToken != "" && token == cfg.MasterToken {
+		if isMasterToken(token) {
 			return true
 		}
+		if key, ok := apiKeyFor(token); ok {
+			return key.Allows(instanceRouteScope(r))
+		}
 		// Validate instance-specific token
 		_, ok := cfg.WAManager.ValidateToken(token)
 		return ok
 	})(instanceMux)
 
 	mux.Handle("/instances", authenticatedInstances)
-	mux.Handle("/instances/", authenticatedInstances)
+	mux.Handle("/instances/", middleware.TokenFromQuery("apikey", func(r *stdhttp.Request) bool {
+		return isLoginStream(r) || isEventStream(r)
+	})(authenticatedInstances))
 
 	messageMux := stdhttp.NewServeMux()
 	messageMux.HandleFunc("/message/sendText/", func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
@@ -538,15 +794,32 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 
 	authenticatedMessages := middleware.BearerAuth(func(token string, r *stdhttp.Request) bool {
 		// Check master token first
-		if cfg.MasterToken != "" && token == cfg.MasterToken {
+		if isMasterToken(token) {
 			return true
 		}
+		if key, ok := apiKeyFor(token); ok {
+			// /message/{tipo}/{instance}
+			segments := splitSegments(r.URL.Path)
+			return len(segments) == 3 && key.Allows(apikey.ScopeMessagesSend, segments[2])
+		}
 		// Validate instance-specific token
 		_, ok := cfg.WAManager.ValidateToken(token)
 		return ok
 	})(messageMux)
 
-	mux.Handle("/message/", authenticatedMessages)
+	// Durante o shutdown novos envios são recusados enquanto o trabalho pendente é drenado
+	mux.Handle("/message/", stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		if cfg.Lifecycle.Draining() {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(stdhttp.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "server is shutting down",
+			})
+			return
+		}
+		authenticatedMessages.ServeHTTP(w, r)
+	}))
 
 	if cfg.GroupCtrl != nil {
 		groupMux := stdhttp.NewServeMux()
@@ -561,7 +834,7 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 					w.WriteHeader(stdhttp.StatusBadRequest)
 					return
 				}
-				if !authorizeInstance(w, r, remainder) {
+				if !authorizeInstance(w, r, remainder, apikey.ScopeGroupsWrite) {
 					return
 				}
 				handler(w, r, remainder)
@@ -588,7 +861,7 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 				w.WriteHeader(stdhttp.StatusBadRequest)
 				return
 			}
-			if !authorizeInstance(w, r, instanceName) {
+			if !authorizeInstance(w, r, instanceName, apikey.ScopeInstancesWrite) {
 				return
 			}
 			cfg.WebhookCtrl.Set(w, r, instanceName)
@@ -603,11 +876,91 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 				w.WriteHeader(stdhttp.StatusBadRequest)
 				return
 			}
-			if !authorizeInstance(w, r, instanceName) {
+			if !authorizeInstance(w, r, instanceName, apikey.ScopeInstancesRead) {
 				return
 			}
 			cfg.WebhookCtrl.Find(w, r, instanceName)
 		})
+		webhookMux.HandleFunc("/webhook/rotateSecret/", func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			if r.Method != stdhttp.MethodPost {
+				w.WriteHeader(stdhttp.StatusMethodNotAllowed)
+				return
+			}
+			instanceName := strings.Trim(strings.TrimPrefix(r.URL.Path, "/webhook/rotateSecret/"), "/")
+			if instanceName == "" {
+				w.WriteHeader(stdhttp.StatusBadRequest)
+				return
+			}
+			if !authorizeInstance(w, r, instanceName, apikey.ScopeInstancesAdmin) {
+				return
+			}
+			cfg.WebhookCtrl.RotateSecret(w, r, instanceName)
+		})
+		webhookMux.HandleFunc("/webhook/secret/", func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			if r.Method != stdhttp.MethodDelete {
+				w.WriteHeader(stdhttp.StatusMethodNotAllowed)
+				return
+			}
+			instanceName := strings.Trim(strings.TrimPrefix(r.URL.Path, "/webhook/secret/"), "/")
+			if instanceName == "" {
+				w.WriteHeader(stdhttp.StatusBadRequest)
+				return
+			}
+			if !authorizeInstance(w, r, instanceName, apikey.ScopeInstancesAdmin) {
+				return
+			}
+			cfg.WebhookCtrl.DeleteSecret(w, r, instanceName)
+		})
+		if cfg.WebhookLogCtrl != nil {
+			// /webhook/deliveries/{instance}[/replay | /{id}[/replay]]
+			webhookMux.HandleFunc("/webhook/deliveries/", func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+				segments := splitSegments(strings.TrimPrefix(r.URL.Path, "/webhook/deliveries/"))
+				if len(segments) == 0 {
+					w.WriteHeader(stdhttp.StatusBadRequest)
+					return
+				}
+				instanceName := segments[0]
+				scope := apikey.ScopeInstancesRead
+				if r.Method != stdhttp.MethodGet {
+					scope = apikey.ScopeInstancesWrite
+				}
+				switch {
+				case len(segments) == 1 && r.Method == stdhttp.MethodGet:
+					if authorizeInstance(w, r, instanceName, scope) {
+						cfg.WebhookLogCtrl.List(w, r, instanceName)
+					}
+				case len(segments) == 2 && segments[1] == "replay" && r.Method == stdhttp.MethodPost:
+					if authorizeInstance(w, r, instanceName, scope) {
+						cfg.WebhookLogCtrl.ReplayRange(w, r, instanceName)
+					}
+				case len(segments) == 2 && r.Method == stdhttp.MethodGet:
+					if authorizeInstance(w, r, instanceName, scope) {
+						cfg.WebhookLogCtrl.Get(w, r, instanceName, segments[1])
+					}
+				case len(segments) == 3 && segments[2] == "replay" && r.Method == stdhttp.MethodPost:
+					if authorizeInstance(w, r, instanceName, scope) {
+						cfg.WebhookLogCtrl.Replay(w, r, instanceName, segments[1])
+					}
+				default:
+					w.WriteHeader(stdhttp.StatusMethodNotAllowed)
+				}
+			})
+			webhookMux.HandleFunc("/webhook/test/", func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+				if r.Method != stdhttp.MethodPost {
+					w.WriteHeader(stdhttp.StatusMethodNotAllowed)
+					return
+				}
+				instanceName := strings.Trim(strings.TrimPrefix(r.URL.Path, "/webhook/test/"), "/")
+				if instanceName == "" {
+					w.WriteHeader(stdhttp.StatusBadRequest)
+					return
+				}
+				if !authorizeInstance(w, r, instanceName, apikey.ScopeInstancesWrite) {
+					return
+				}
+				cfg.WebhookLogCtrl.SendTest(w, r, instanceName)
+			})
+		}
 		mux.Handle("/webhook/", webhookMux)
 	}
 
@@ -623,7 +976,7 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 				w.WriteHeader(stdhttp.StatusBadRequest)
 				return
 			}
-			if !authorizeInstance(w, r, instanceName) {
+			if !authorizeInstance(w, r, instanceName, apikey.ScopeInstancesWrite) {
 				return
 			}
 			cfg.SettingsCtrl.Set(w, r, instanceName)
@@ -638,7 +991,7 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 				w.WriteHeader(stdhttp.StatusBadRequest)
 				return
 			}
-			if !authorizeInstance(w, r, instanceName) {
+			if !authorizeInstance(w, r, instanceName, apikey.ScopeInstancesRead) {
 				return
 			}
 			cfg.SettingsCtrl.Find(w, r, instanceName)
@@ -646,6 +999,36 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 		mux.Handle("/settings/", settingsMux)
 	}
 
+	// Destinos de eventos habilitados no servidor: /{sink}/set/{instance} e /{sink}/find/{instance}
+	if cfg.EventSinkCtrl != nil {
+		for _, sink := range cfg.EventSinkCtrl.Sinks() {
+			sink := sink
+			mux.HandleFunc("/"+sink+"/", func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+				segments := splitSegments(strings.TrimPrefix(r.URL.Path, "/"+sink))
+				if len(segments) != 2 {
+					w.WriteHeader(stdhttp.StatusNotFound)
+					return
+				}
+				switch {
+				case segments[0] == "set" && r.Method == stdhttp.MethodPost:
+					if !authorizeInstance(w, r, segments[1], apikey.ScopeInstancesWrite) {
+						return
+					}
+					cfg.EventSinkCtrl.Set(w, r, sink, segments[1])
+				case segments[0] == "find" && r.Method == stdhttp.MethodGet:
+					if !authorizeInstance(w, r, segments[1], apikey.ScopeInstancesRead) {
+						return
+					}
+					cfg.EventSinkCtrl.Find(w, r, sink, segments[1])
+				case segments[0] == "set" || segments[0] == "find":
+					w.WriteHeader(stdhttp.StatusMethodNotAllowed)
+				default:
+					w.WriteHeader(stdhttp.StatusNotFound)
+				}
+			})
+		}
+	}
+
 	if cfg.ProfileCtrl != nil {
 		chatMux := stdhttp.NewServeMux()
 
@@ -660,7 +1043,7 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 				w.WriteHeader(stdhttp.StatusBadRequest)
 				return
 			}
-			if !authorizeInstance(w, r, instanceName) {
+			if !authorizeInstance(w, r, instanceName, apikey.ScopeInstancesWrite) {
 				return
 			}
 			cfg.ProfileCtrl.UpdateProfileStatus(w, r, instanceName)
@@ -677,7 +1060,7 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 				w.WriteHeader(stdhttp.StatusBadRequest)
 				return
 			}
-			if !authorizeInstance(w, r, instanceName) {
+			if !authorizeInstance(w, r, instanceName, apikey.ScopeInstancesWrite) {
 				return
 			}
 			cfg.ProfileCtrl.UpdateProfilePicture(w, r, instanceName)
@@ -694,7 +1077,7 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 				w.WriteHeader(stdhttp.StatusBadRequest)
 				return
 			}
-			if !authorizeInstance(w, r, instanceName) {
+			if !authorizeInstance(w, r, instanceName, apikey.ScopeInstancesWrite) {
 				return
 			}
 			cfg.ProfileCtrl.RemoveProfilePicture(w, r, instanceName)
@@ -711,7 +1094,7 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 				w.WriteHeader(stdhttp.StatusBadRequest)
 				return
 			}
-			if !authorizeInstance(w, r, instanceName) {
+			if !authorizeInstance(w, r, instanceName, apikey.ScopeInstancesRead) {
 				return
 			}
 			cfg.ProfileCtrl.FetchPrivacySettings(w, r, instanceName)
@@ -728,7 +1111,7 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 				w.WriteHeader(stdhttp.StatusBadRequest)
 				return
 			}
-			if !authorizeInstance(w, r, instanceName) {
+			if !authorizeInstance(w, r, instanceName, apikey.ScopeInstancesWrite) {
 				return
 			}
 			cfg.ProfileCtrl.UpdatePrivacySettings(w, r, instanceName)
@@ -779,12 +1162,93 @@ func NewRouter(cfg RouterConfig) stdhttp.Handler {
 			cfg.AnalyticsCtrl.GetInstanceMetrics(w, r, instanceID)
 		})
 
-		mux.Handle("/analytics/", stdhttp.StripPrefix("/analytics", analyticsMux))
+		authenticatedAnalytics := middleware.BearerAuth(func(token string, r *stdhttp.Request) bool {
+			if isMasterToken(token) {
+				return true
+			}
+			if key, ok := apiKeyFor(token); ok {
+				if !key.HasScope(apikey.ScopeAnalyticsRead) {
+					return false
+				}
+				if len(key.Instances) == 0 {
+					return true
+				}
+				// Com allowlist só as métricas por instância são liberadas (o path traz o ID da instância)
+				segments := splitSegments(r.URL.Path)
+				if len(segments) < 3 || segments[1] != "instances" || cfg.WAManager == nil {
+					return false
+				}
+				for _, sess := range cfg.WAManager.List(r.Context()) {
+					if sess.ID == segments[2] {
+						return key.AllowsInstance(sess.Name)
+					}
+				}
+				return false
+			}
+			_, ok := cfg.WAManager.ValidateToken(token)
+			return ok
+		})
+		mux.Handle("/analytics/", authenticatedAnalytics(stdhttp.StripPrefix("/analytics", analyticsMux)))
+	}
+
+	// Stream de eventos de todas as instâncias (apenas master token)
+	if cfg.EventStreamCtrl != nil {
+		eventsHandler := stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			segments := splitSegments(strings.TrimPrefix(r.URL.Path, "/events"))
+			switch {
+			case len(segments) != 1 || (segments[0] != "stream" && segments[0] != "ws"):
+				w.WriteHeader(stdhttp.StatusNotFound)
+			case r.Method != stdhttp.MethodGet:
+				w.WriteHeader(stdhttp.StatusMethodNotAllowed)
+			case segments[0] == "ws":
+				cfg.EventStreamCtrl.WebSocket(w, r)
+			default:
+				cfg.EventStreamCtrl.SSE(w, r)
+			}
+		})
+		authenticatedEvents := middleware.BearerAuth(func(token string, r *stdhttp.Request) bool {
+			return isMasterToken(token)
+		})(eventsHandler)
+		mux.Handle("/events/", middleware.TokenFromQuery("apikey", func(r *stdhttp.Request) bool {
+			return r.Method == stdhttp.MethodGet
+		})(authenticatedEvents))
+	}
+
+	// API keys com escopo (apenas master token)
+	if cfg.APIKeyCtrl != nil {
+		apiKeyHandler := stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			segments := splitSegments(strings.TrimPrefix(r.URL.Path, "/apikeys"))
+			switch {
+			case len(segments) == 0 && r.Method == stdhttp.MethodGet:
+				cfg.APIKeyCtrl.List(w, r)
+			case len(segments) == 0 && r.Method == stdhttp.MethodPost:
+				cfg.APIKeyCtrl.Create(w, r)
+			case len(segments) == 1 && r.Method == stdhttp.MethodGet:
+				cfg.APIKeyCtrl.Get(w, r, segments[0])
+			case len(segments) == 1 && r.Method == stdhttp.MethodPatch:
+				cfg.APIKeyCtrl.Update(w, r, segments[0])
+			case len(segments) == 1 && r.Method == stdhttp.MethodDelete:
+				cfg.APIKeyCtrl.Delete(w, r, segments[0])
+			case len(segments) > 1:
+				w.WriteHeader(stdhttp.StatusNotFound)
+			default:
+				w.WriteHeader(stdhttp.StatusMethodNotAllowed)
+			}
+		})
+		authenticatedAPIKeys := middleware.BearerAuth(func(token string, r *stdhttp.Request) bool {
+			return isMasterToken(token)
+		})(apiKeyHandler)
+		mux.Handle("/apikeys", authenticatedAPIKeys)
+		mux.Handle("/apikeys/", authenticatedAPIKeys)
 	}
 
 	// Middlewares wrap
 	var handler stdhttp.Handler = mux
 	handler = middleware.Logging(cfg.Logger)(handler)
 	handler = middleware.CORS(handler) // Apply CORS to all routes
+	if cfg.ClusterOwners != nil {
+		// Fora do CORS: o nó dono já devolve os próprios cabeçalhos.
+		handler = middleware.ClusterForward(cfg.ClusterOwners, cfg.ClusterForward, cfg.ClusterNodeID, cfg.ClusterSecret, instanceFromPath, cfg.Logger)(handler)
+	}
 	return handler
 }
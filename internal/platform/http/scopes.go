@@ -0,0 +1,50 @@
+package http
+
+import (
+	stdhttp "net/http"
+	"strings"
+
+	"github.com/faeln1/go-whatsapp-api/internal/domain/apikey"
+)
+
+// instanceRouteScope retorna o escopo exigido de uma API key nas rotas /instances e a
+// instância alvo ("" para rotas sem instância, como listagem e criação).
+func instanceRouteScope(r *stdhttp.Request) (scope, instance string) {
+	segments := strings.FieldsFunc(strings.TrimPrefix(r.URL.Path, "/instances"), func(c rune) bool { return c == '/' })
+	if len(segments) == 0 || segments[0] == "fetchInstances" {
+		if r.Method == stdhttp.MethodGet {
+			return apikey.ScopeInstancesRead, ""
+		}
+		return apikey.ScopeInstancesAdmin, ""
+	}
+	if segments[0] == "create" || segments[0] == "import" {
+		return apikey.ScopeInstancesAdmin, ""
+	}
+	instance = segments[0]
+	if len(segments) >= 2 && segments[1] == "communities" {
+		return readWriteScope(r, apikey.ScopeGroupsRead, apikey.ScopeGroupsWrite), instance
+	}
+	if len(segments) >= 2 && segments[1] == "webhooks" {
+		if len(segments) == 4 && segments[3] == "rotateSecret" {
+			return apikey.ScopeInstancesAdmin, instance
+		}
+		return readWriteScope(r, apikey.ScopeInstancesRead, apikey.ScopeInstancesWrite), instance
+	}
+	switch {
+	case r.Method == stdhttp.MethodDelete:
+		return apikey.ScopeInstancesAdmin, instance
+	case len(segments) == 2 && (segments[1] == "rotateToken" || segments[1] == "export"):
+		return apikey.ScopeInstancesAdmin, instance
+	case len(segments) == 3 && segments[1] == "usage" && segments[2] == "quota":
+		return apikey.ScopeInstancesAdmin, instance
+	default:
+		return readWriteScope(r, apikey.ScopeInstancesRead, apikey.ScopeInstancesWrite), instance
+	}
+}
+
+func readWriteScope(r *stdhttp.Request, read, write string) string {
+	if r.Method == stdhttp.MethodGet {
+		return read
+	}
+	return write
+}
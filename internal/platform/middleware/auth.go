@@ -31,3 +31,18 @@ func BearerAuth(validator TokenValidator) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// TokenFromQuery copia o token do parâmetro de query para o header apikey nas requisições
+// aceitas por match. EventSource e WebSocket no navegador não enviam headers customizados.
+func TokenFromQuery(param string, match func(*http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := strings.TrimSpace(r.URL.Query().Get(param))
+			if token != "" && r.Header.Get("Authorization") == "" && r.Header.Get("apikey") == "" && match(r) {
+				r = r.Clone(r.Context())
+				r.Header.Set("apikey", token)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// ForwardedHeader marca requisições já encaminhadas por outro nó, evitando loops. O valor é
+// "{nó};{unix};{hmac}", assinado com o segredo do cluster; sem assinatura válida o cabeçalho
+// é descartado e a requisição segue como externa.
+const ForwardedHeader = "X-Cluster-Forwarded"
+
+// forwardedMaxSkew limita a idade (e o desvio de relógio) aceitos na assinatura.
+const forwardedMaxSkew = time.Minute
+
+// Modos de encaminhamento para o nó dono da instância.
+const (
+	ForwardProxy    = "proxy"
+	ForwardRedirect = "redirect"
+)
+
+// OwnerResolver informa o endereço do nó dono quando a instância pertence a outro nó.
+type OwnerResolver interface {
+	RemoteOwner(ctx context.Context, instanceName string) (address string, ok bool)
+}
+
+// SignForwarded gera o valor de ForwardedHeader para o nó, assinado com secret.
+func SignForwarded(secret, nodeID string, now time.Time) string {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	return nodeID + ";" + ts + ";" + forwardedMAC(secret, nodeID, ts)
+}
+
+// VerifyForwarded valida um valor de ForwardedHeader e retorna o nó de origem.
+func VerifyForwarded(secret, value string, now time.Time) (string, bool) {
+	if secret == "" {
+		return "", false
+	}
+	parts := strings.Split(value, ";")
+	if len(parts) != 3 || parts[0] == "" {
+		return "", false
+	}
+	unix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", false
+	}
+	if skew := now.Sub(time.Unix(unix, 0)); skew > forwardedMaxSkew || skew < -forwardedMaxSkew {
+		return "", false
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(forwardedMAC(secret, parts[0], parts[1]))) {
+		return "", false
+	}
+	return parts[0], true
+}
+
+func forwardedMAC(secret, nodeID, ts string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(nodeID + "." + ts))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ClusterForward encaminha (proxy reverso) ou redireciona (307) as requisições de uma
+// instância para o nó que detém o seu lease. instanceOf extrai o nome da instância da rota;
+// rotas sem instância são atendidas localmente. secret autentica ForwardedHeader entre os nós.
+func ClusterForward(resolver OwnerResolver, mode, nodeID, secret string, instanceOf func(*http.Request) string, log waLog.Logger) func(http.Handler) http.Handler {
+	var (
+		mu      sync.Mutex
+		proxies = make(map[string]*httputil.ReverseProxy)
+	)
+	proxyFor := func(address string) (*httputil.ReverseProxy, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if p, ok := proxies[address]; ok {
+			return p, nil
+		}
+		target, err := url.Parse(address)
+		if err != nil {
+			return nil, err
+		}
+		p := httputil.NewSingleHostReverseProxy(target)
+		// Streams (SSE/QR) precisam ser repassados sem buffer.
+		p.FlushInterval = -1
+		p.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			if log != nil {
+				log.Warnf("cluster forward to %s failed: %v", address, err)
+			}
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		proxies[address] = p
+		return p, nil
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if value := r.Header.Get(ForwardedHeader); value != "" {
+				if _, ok := VerifyForwarded(secret, value, time.Now()); ok {
+					next.ServeHTTP(w, r)
+					return
+				}
+				// Cabeçalho forjado por um cliente externo: não pula o roteamento para o dono.
+				r = r.Clone(r.Context())
+				r.Header.Del(ForwardedHeader)
+			}
+			name := instanceOf(r)
+			if name == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			address, ok := resolver.RemoteOwner(r.Context(), name)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if mode == ForwardRedirect {
+				http.Redirect(w, r, address+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+				return
+			}
+			p, err := proxyFor(address)
+			if err != nil {
+				if log != nil {
+					log.Errorf("invalid cluster node address %q: %v", address, err)
+				}
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			r = r.Clone(r.Context())
+			r.Header.Set(ForwardedHeader, SignForwarded(secret, nodeID, time.Now()))
+			p.ServeHTTP(w, r)
+		})
+	}
+}
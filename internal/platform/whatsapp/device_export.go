@@ -0,0 +1,369 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+var (
+	ErrDeviceNotPaired = errors.New("device not paired")
+	ErrDeviceExists    = errors.New("device store already has a paired device for this instance")
+	ErrDeviceOwned     = errors.New("device jid already belongs to another instance")
+)
+
+// deviceOwnerColumns indica a coluna com o JID do device em cada tabela do whatsmeow.
+// whatsmeow_lid_map é global (sem dono) e só é exportada no modo SQLite, em que o arquivo
+// pertence a uma única instância.
+var deviceOwnerColumns = map[string]string{
+	"whatsmeow_device":                  "jid",
+	"whatsmeow_identity_keys":           "our_jid",
+	"whatsmeow_pre_keys":                "jid",
+	"whatsmeow_sessions":                "our_jid",
+	"whatsmeow_sender_keys":             "our_jid",
+	"whatsmeow_app_state_sync_keys":     "jid",
+	"whatsmeow_app_state_version":       "jid",
+	"whatsmeow_app_state_mutation_macs": "jid",
+	"whatsmeow_contacts":                "our_jid",
+	"whatsmeow_chat_settings":           "our_jid",
+	"whatsmeow_message_secrets":         "our_jid",
+	"whatsmeow_privacy_tokens":          "our_jid",
+	"whatsmeow_lid_map":                 "",
+	"whatsmeow_event_buffer":            "our_jid",
+}
+
+// DeviceSnapshot contém as linhas do device store de uma instância, independente do banco de origem.
+type DeviceSnapshot struct {
+	JID    string          `json:"jid"`
+	Tables []TableSnapshot `json:"tables"`
+}
+
+type TableSnapshot struct {
+	Name    string            `json:"name"`
+	Columns []string          `json:"columns"`
+	Rows    [][]SnapshotValue `json:"rows"`
+}
+
+// SnapshotValue preserva o tipo do valor no JSON: bytes viram {"b": base64}, inteiros
+// ficam como número e texto como string.
+type SnapshotValue struct {
+	V any
+}
+
+type snapshotBytes struct {
+	B []byte `json:"b"`
+}
+
+func (v SnapshotValue) MarshalJSON() ([]byte, error) {
+	switch val := v.V.(type) {
+	case []byte:
+		return json.Marshal(snapshotBytes{B: val})
+	case time.Time:
+		return json.Marshal(val.UTC().Format(time.RFC3339Nano))
+	default:
+		return json.Marshal(val)
+	}
+}
+
+func (v *SnapshotValue) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var b snapshotBytes
+		if err := json.Unmarshal(data, &b); err != nil {
+			return err
+		}
+		if b.B == nil {
+			b.B = []byte{}
+		}
+		v.V = b.B
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	if n, ok := raw.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			v.V = i
+			return nil
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return err
+		}
+		v.V = f
+		return nil
+	}
+	v.V = raw
+	return nil
+}
+
+// ExportDevice lê as linhas do device pareado da instância.
+func (f *StoreFactory) ExportDevice(ctx context.Context, instanceName string) (*DeviceSnapshot, error) {
+	if f.shared != nil {
+		jid, err := f.boundJID(ctx, instanceName)
+		if err != nil {
+			return nil, err
+		}
+		if jid.IsEmpty() {
+			return nil, ErrDeviceNotPaired
+		}
+		return exportDeviceRows(ctx, f.db, jid.String(), false)
+	}
+
+	path := f.sqlitePath(instanceName)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrDeviceNotPaired
+		}
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	var jid string
+	err = db.QueryRowContext(ctx, `SELECT jid FROM whatsmeow_device LIMIT 1`).Scan(&jid)
+	if errors.Is(err, sql.ErrNoRows) || (err != nil && strings.Contains(err.Error(), "no such table")) {
+		return nil, ErrDeviceNotPaired
+	}
+	if err != nil {
+		return nil, err
+	}
+	return exportDeviceRows(ctx, db, jid, true)
+}
+
+func exportDeviceRows(ctx context.Context, db *sql.DB, jid string, includeGlobal bool) (*DeviceSnapshot, error) {
+	snapshot := &DeviceSnapshot{JID: jid}
+	for _, table := range append([]string{"whatsmeow_device"}, whatsmeowTables...) {
+		owner := deviceOwnerColumns[table]
+		if owner == "" && !includeGlobal {
+			continue
+		}
+		query := "SELECT * FROM " + table
+		args := []any{}
+		if owner != "" {
+			query += " WHERE " + owner + " = $1"
+			args = append(args, jid)
+		}
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			if strings.Contains(err.Error(), "no such table") || strings.Contains(err.Error(), "does not exist") {
+				continue
+			}
+			return nil, fmt.Errorf("%s: %w", table, err)
+		}
+		ts, err := scanTableSnapshot(table, rows)
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table, err)
+		}
+		snapshot.Tables = append(snapshot.Tables, ts)
+	}
+	if len(snapshot.Tables) == 0 || len(snapshot.Tables[0].Rows) == 0 {
+		return nil, ErrDeviceNotPaired
+	}
+	return snapshot, nil
+}
+
+func scanTableSnapshot(table string, rows *sql.Rows) (TableSnapshot, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return TableSnapshot{}, err
+	}
+	ts := TableSnapshot{Name: table, Columns: columns}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return TableSnapshot{}, err
+		}
+		row := make([]SnapshotValue, len(columns))
+		for i, v := range values {
+			row[i] = SnapshotValue{V: v}
+		}
+		ts.Rows = append(ts.Rows, row)
+	}
+	return ts, rows.Err()
+}
+
+// ImportDevice grava um snapshot como device store da instância. No modo SQLite o arquivo
+// {DATA_DIR}/{instância}.db é criado; no modo Postgres o device é associado à instância.
+func (f *StoreFactory) ImportDevice(ctx context.Context, instanceName string, snapshot *DeviceSnapshot) error {
+	if snapshot == nil || snapshot.JID == "" || len(snapshot.Tables) == 0 {
+		return ErrDeviceNotPaired
+	}
+	jid, err := types.ParseJID(snapshot.JID)
+	if err != nil {
+		return err
+	}
+
+	if f.shared != nil {
+		// Serializa imports concorrentes do mesmo JID entre a checagem de dono e o bind.
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		bound, err := f.boundJID(ctx, instanceName)
+		if err != nil {
+			return err
+		}
+		if !bound.IsEmpty() {
+			if device, err := f.shared.GetDevice(ctx, bound); err != nil {
+				return err
+			} else if device != nil {
+				return ErrDeviceExists
+			}
+		}
+		// No store compartilhado o ON CONFLICT DO NOTHING manteria as chaves do device de
+		// outra instância e o bind faria as duas usarem a mesma sessão.
+		owner, err := f.deviceOwner(ctx, jid)
+		if err != nil {
+			return err
+		}
+		if owner != "" && owner != instanceName {
+			return fmt.Errorf("%w: %s", ErrDeviceOwned, owner)
+		}
+		if device, err := f.shared.GetDevice(ctx, jid); err != nil {
+			return err
+		} else if device != nil {
+			return ErrDeviceOwned
+		}
+		if err := importDeviceRows(ctx, f.db, snapshot, true); err != nil {
+			return err
+		}
+		return f.BindDevice(ctx, instanceName, jid)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := f.sqlitePath(instanceName)
+	// sqlstore.New cria/atualiza o schema do whatsmeow antes da cópia.
+	container, err := f.openSQLite(ctx, path)
+	if err != nil {
+		return err
+	}
+	existing, err := container.GetFirstDevice(ctx)
+	_ = container.Close()
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != nil {
+		return ErrDeviceExists
+	}
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return importDeviceRows(ctx, db, snapshot, false)
+}
+
+func importDeviceRows(ctx context.Context, db *sql.DB, snapshot *DeviceSnapshot, postgres bool) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, ts := range snapshot.Tables {
+		if _, ok := deviceOwnerColumns[ts.Name]; !ok {
+			return fmt.Errorf("unexpected table %q in snapshot", ts.Name)
+		}
+		if len(ts.Rows) == 0 {
+			continue
+		}
+		var boolColumns map[string]bool
+		if postgres {
+			if boolColumns, err = postgresBoolColumns(ctx, tx, ts.Name); err != nil {
+				return err
+			}
+		}
+		placeholders := make([]string, len(ts.Columns))
+		for i := range ts.Columns {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+		}
+		insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING",
+			ts.Name, strings.Join(ts.Columns, ", "), strings.Join(placeholders, ", "))
+		for _, row := range ts.Rows {
+			if len(row) != len(ts.Columns) {
+				return fmt.Errorf("%s: row has %d values, expected %d", ts.Name, len(row), len(ts.Columns))
+			}
+			values := make([]any, len(row))
+			for i, v := range row {
+				values[i] = v.V
+				// SQLite guarda BOOLEAN como inteiro
+				if n, ok := v.V.(int64); ok && boolColumns[ts.Columns[i]] {
+					values[i] = n != 0
+				}
+			}
+			if _, err := tx.ExecContext(ctx, insert, values...); err != nil {
+				return fmt.Errorf("%s: %w", ts.Name, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteDevice remove o device store da instância (usado para desfazer um import incompleto).
+func (f *StoreFactory) DeleteDevice(ctx context.Context, instanceName string) error {
+	if f.shared != nil {
+		jid, err := f.boundJID(ctx, instanceName)
+		if err != nil || jid.IsEmpty() {
+			return err
+		}
+		if device, err := f.shared.GetDevice(ctx, jid); err == nil && device != nil {
+			if err := device.Delete(ctx); err != nil {
+				return err
+			}
+		}
+		_, err = f.db.ExecContext(ctx, `DELETE FROM whatsapp_instance_devices WHERE instance_name = $1`, instanceName)
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_ = f.closeLocked(instanceName)
+	path := f.sqlitePath(instanceName)
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameDevice move o device store para o novo nome da instância. A sessão deve estar
+// desconectada; no SQLite os arquivos (incluindo -wal/-shm) são renomeados.
+func (f *StoreFactory) RenameDevice(ctx context.Context, oldName, newName string) error {
+	if f.shared != nil {
+		_, err := f.db.ExecContext(ctx, `UPDATE whatsapp_instance_devices SET instance_name = $1, updated_at = NOW() WHERE instance_name = $2`, newName, oldName)
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	oldPath, newPath := f.sqlitePath(oldName), f.sqlitePath(newName)
+	if _, err := os.Stat(newPath); err == nil {
+		return ErrDeviceExists
+	}
+	if err := f.closeLocked(oldName); err != nil {
+		return err
+	}
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := os.Rename(oldPath+suffix, newPath+suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package whatsapp
+
+import (
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waCompanionReg"
+	"go.mau.fi/whatsmeow/proto/waWa6"
+	"go.mau.fi/whatsmeow/store"
+	"google.golang.org/protobuf/proto"
+)
+
+// fullHistoryDaysLimit limita o histórico completo solicitado ao celular no pareamento.
+const fullHistoryDaysLimit = 3650
+
+// HistorySyncDeviceProps retorna uma cópia das DeviceProps padrão do whatsmeow configurada
+// para solicitar, ou não, o histórico completo. O store.DeviceProps global não é alterado.
+func HistorySyncDeviceProps(fullHistory bool) *waCompanionReg.DeviceProps {
+	props := proto.Clone(store.DeviceProps).(*waCompanionReg.DeviceProps)
+	props.RequireFullSync = proto.Bool(fullHistory)
+	if fullHistory {
+		if props.HistorySyncConfig == nil {
+			props.HistorySyncConfig = &waCompanionReg.DeviceProps_HistorySyncConfig{}
+		}
+		props.HistorySyncConfig.FullSyncDaysLimit = proto.Uint32(fullHistoryDaysLimit)
+	}
+	return props
+}
+
+// ApplyHistorySyncProps faz o client enviar, no handshake de registro (pareamento), as
+// DeviceProps com o histórico solicitado. A configuração fica no próprio client, de modo que
+// pareamentos simultâneos de outras instâncias não se afetam. Deve ser chamado antes do Connect.
+func ApplyHistorySyncProps(client *whatsmeow.Client, fullHistory bool) {
+	if client == nil {
+		return
+	}
+	encoded, err := proto.Marshal(HistorySyncDeviceProps(fullHistory))
+	if err != nil {
+		return
+	}
+	client.GetClientPayload = func() *waWa6.ClientPayload {
+		payload := client.Store.GetClientPayload()
+		if pairing := payload.GetDevicePairingData(); pairing != nil {
+			pairing.DeviceProps = encoded
+		}
+		return payload
+	}
+}
@@ -0,0 +1,93 @@
+package whatsapp
+
+import "time"
+
+// Estados da máquina de saúde de uma sessão.
+const (
+	HealthConnecting   = "connecting"
+	HealthConnected    = "connected"
+	HealthDegraded     = "degraded"     // keepalive falhando, socket ainda aberto
+	HealthReconnecting = "reconnecting" // aguardando backoff ou reconectando
+	HealthStopped      = "stopped"      // desconexão manual, supervisor não reage
+	HealthFailed       = "failed"       // limite de tentativas atingido
+	HealthLoggedOut    = "logged_out"   // terminal
+	HealthBanned       = "banned"       // terminal
+	HealthReplaced     = "replaced"     // terminal: outra conexão assumiu a sessão
+)
+
+// SessionHealth descreve o estado de conexão supervisionado de uma sessão.
+type SessionHealth struct {
+	State           string
+	Reason          string
+	Code            int
+	Attempts        int
+	Terminal        bool
+	LastError       string
+	NextRetryAt     time.Time
+	LastConnectedAt time.Time
+	LastDisconnect  time.Time
+	UpdatedAt       time.Time
+}
+
+// IsTerminalHealth indica estados dos quais o supervisor não tenta sair sozinho;
+// apenas um novo connect (InitNewSession) reinicia a supervisão.
+func IsTerminalHealth(state string) bool {
+	switch state {
+	case HealthLoggedOut, HealthBanned, HealthFailed, HealthReplaced:
+		return true
+	}
+	return false
+}
+
+func (m *Manager) UpdateHealth(name string, fn func(*SessionHealth)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[name]; !ok {
+		return ErrNotFound
+	}
+	state := m.health[name]
+	fn(&state)
+	state.UpdatedAt = time.Now().UTC()
+	m.health[name] = state
+	return nil
+}
+
+func (m *Manager) GetHealth(name string) (SessionHealth, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.health[name]
+	return v, ok
+}
+
+// MarkStopped registra uma desconexão intencional (disconnect/logout via API)
+// para que o supervisor não tente reconectar a sessão.
+func (m *Manager) MarkStopped(name, reason string) {
+	_ = m.UpdateHealth(name, func(h *SessionHealth) {
+		h.State = HealthStopped
+		h.Reason = reason
+		h.Terminal = false
+		h.NextRetryAt = time.Time{}
+	})
+}
+
+// DisconnectAll desconecta todas as sessões (shutdown) sem que o supervisor reconecte.
+// Retorna as sessões que estavam conectadas.
+func (m *Manager) DisconnectAll(reason string) []string {
+	m.mu.RLock()
+	names := make([]string, 0, len(m.sessions))
+	for name := range m.sessions {
+		names = append(names, name)
+	}
+	m.mu.RUnlock()
+	var connected []string
+	for _, name := range names {
+		m.MarkStopped(name, reason)
+		if sess, ok := m.Get(name); ok && sess.Client != nil {
+			if sess.Client.IsConnected() {
+				connected = append(connected, name)
+			}
+			sess.Client.Disconnect()
+		}
+	}
+	return connected
+}
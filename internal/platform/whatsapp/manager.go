@@ -2,9 +2,11 @@ package whatsapp
 
 import (
 	"context"
+	"net/http"
 	"sync"
 	"time"
 
+	"github.com/faeln1/go-whatsapp-api/pkg/apitoken"
 	"github.com/google/uuid"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/store"
@@ -19,7 +21,26 @@ type Session struct {
 	Client    *whatsmeow.Client
 	Device    *store.Device
 	QRChan    <-chan whatsmeow.QRChannelItem
-	Token     string
+}
+
+// PresenceState descreve o estado do keeper de presença (alwaysOnline) de uma sessão.
+type PresenceState struct {
+	Status     string // running, waiting, stopped
+	Reason     string
+	StartedAt  time.Time
+	LastSentAt time.Time
+	LastError  string
+}
+
+// HistorySyncState acompanha o progresso da sincronização de histórico de uma sessão.
+type HistorySyncState struct {
+	SyncType   string
+	ChunkOrder uint32
+	Progress   uint32
+	Chats      int
+	Contacts   int
+	Messages   int
+	UpdatedAt  time.Time
 }
 
 type Manager struct {
@@ -27,20 +48,44 @@ type Manager struct {
 	sessions map[string]*Session // key: name
 	log      waLog.Logger
 	lastQR   map[string]string // name -> data:image/png;base64,... or code string
+	presence map[string]PresenceState
+	history  map[string]HistorySyncState
+	health   map[string]SessionHealth
+	// tokens indexa as credenciais pelo lookup do token (hash), evitando varrer as sessões.
+	tokens map[string][]tokenEntry
+	// proxy de saída por instância (endereço e transport HTTP reaproveitado)
+	proxies    map[string]string
+	transports map[string]*http.Transport
+}
+
+type tokenEntry struct {
+	name       string
+	credential apitoken.Credential
 }
 
 func NewManager(log waLog.Logger) *Manager {
-	return &Manager{sessions: make(map[string]*Session), log: log, lastQR: make(map[string]string)}
+	return &Manager{
+		sessions:   make(map[string]*Session),
+		log:        log,
+		lastQR:     make(map[string]string),
+		presence:   make(map[string]PresenceState),
+		history:    make(map[string]HistorySyncState),
+		health:     make(map[string]SessionHealth),
+		tokens:     make(map[string][]tokenEntry),
+		proxies:    make(map[string]string),
+		transports: make(map[string]*http.Transport),
+	}
 }
 
-func (m *Manager) Create(ctx context.Context, name, token string) (*Session, error) {
+func (m *Manager) Create(ctx context.Context, name string, credentials ...apitoken.Credential) (*Session, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if _, exists := m.sessions[name]; exists {
 		return nil, ErrAlreadyExists
 	}
-	sess := &Session{ID: uuid.NewString(), Name: name, CreatedAt: time.Now(), Token: token}
+	sess := &Session{ID: uuid.NewString(), Name: name, CreatedAt: time.Now()}
 	m.sessions[name] = sess
+	m.setTokensLocked(name, credentials)
 	return sess, nil
 }
 
@@ -73,6 +118,14 @@ func (m *Manager) StartEventLoop(sess *Session) {
 			m.log.Infof("Instância %s pareada com sucesso! JID: %s, Platform: %s", sess.Name, e.ID.String(), e.Platform)
 		case *events.LoggedOut:
 			m.log.Warnf("Instância %s deslogada. Motivo: %s", sess.Name, e.Reason.String())
+		case *events.StreamReplaced:
+			m.log.Warnf("Instância %s desconectada: sessão substituída por outra conexão", sess.Name)
+		case *events.TemporaryBan:
+			m.log.Errorf("Instância %s banida temporariamente: %s", sess.Name, e.String())
+		case *events.ConnectFailure:
+			m.log.Errorf("Instância %s falhou ao conectar: %s (%s)", sess.Name, e.Reason.String(), e.Message)
+		case *events.KeepAliveTimeout:
+			m.log.Warnf("Instância %s sem resposta ao keepalive (%d falhas)", sess.Name, e.ErrorCount)
 		}
 	})
 }
@@ -98,37 +151,125 @@ func (m *Manager) Delete(name string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	delete(m.sessions, name)
+	m.setTokensLocked(name, nil)
+	if transport, ok := m.transports[name]; ok {
+		transport.CloseIdleConnections()
+	}
+	delete(m.proxies, name)
+	delete(m.transports, name)
 	delete(m.lastQR, name)
+	delete(m.presence, name)
+	delete(m.health, name)
+	delete(m.history, name)
 }
 
-// GeneratePairingCode solicita ao cliente whatsmeow que gere um código de pareamento baseado em número de telefone.
-// É esperado que a sessão já esteja conectada (via InitNewSession) antes da chamada.
-func (m *Manager) GeneratePairingCode(ctx context.Context, name string, phone string) (string, error) {
-	m.mu.RLock()
-	sess, ok := m.sessions[name]
-	m.mu.RUnlock()
+// Rename move a sessão e o estado associado (tokens, saúde, proxy...) para o novo nome.
+// O client deve estar desconectado: os handlers de eventos guardam o nome antigo.
+func (m *Manager) Rename(oldName, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sess, ok := m.sessions[oldName]
 	if !ok {
-		return "", ErrNotFound
+		return ErrNotFound
 	}
-	if sess.Client == nil {
-		return "", ErrClientUnavailable
+	if _, exists := m.sessions[newName]; exists {
+		return ErrAlreadyExists
+	}
+	delete(m.sessions, oldName)
+	sess.Name = newName
+	m.sessions[newName] = sess
+	for _, entries := range m.tokens {
+		for i := range entries {
+			if entries[i].name == oldName {
+				entries[i].name = newName
+			}
+		}
+	}
+	if v, ok := m.lastQR[oldName]; ok {
+		m.lastQR[newName] = v
+		delete(m.lastQR, oldName)
+	}
+	if v, ok := m.presence[oldName]; ok {
+		m.presence[newName] = v
+		delete(m.presence, oldName)
 	}
-	// Defaults match common desktop login scenario
-	return sess.Client.PairPhone(ctx, phone, false, whatsmeow.PairClientChrome, "Chrome (Windows)")
+	if v, ok := m.history[oldName]; ok {
+		m.history[newName] = v
+		delete(m.history, oldName)
+	}
+	if v, ok := m.health[oldName]; ok {
+		m.health[newName] = v
+		delete(m.health, oldName)
+	}
+	if v, ok := m.proxies[oldName]; ok {
+		m.proxies[newName] = v
+		delete(m.proxies, oldName)
+	}
+	if v, ok := m.transports[oldName]; ok {
+		m.transports[newName] = v
+		delete(m.transports, oldName)
+	}
+	return nil
 }
 
-// ValidateToken retorna a sessão associada ao token (se existir)
+// ValidateToken retorna a sessão associada ao token (se existir). A busca usa o índice
+// por lookup e o hash é conferido em tempo constante.
 func (m *Manager) ValidateToken(token string) (*Session, bool) {
+	if token == "" {
+		return nil, false
+	}
+	now := time.Now()
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	for _, s := range m.sessions {
-		if s.Token == token {
-			return s, true
+	for _, entry := range m.tokens[apitoken.Lookup(token)] {
+		if entry.credential.Verify(token, now) {
+			s, ok := m.sessions[entry.name]
+			return s, ok
 		}
 	}
 	return nil, false
 }
 
+// SetTokens substitui as credenciais aceitas para a sessão (ex.: após rotação do token).
+func (m *Manager) SetTokens(name string, credentials ...apitoken.Credential) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[name]; !ok {
+		return ErrNotFound
+	}
+	m.setTokensLocked(name, credentials)
+	return nil
+}
+
+// TokenLookupInUse indica se alguma sessão já usa um token com o mesmo lookup.
+func (m *Manager) TokenLookupInUse(lookup string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.tokens[lookup]) > 0
+}
+
+func (m *Manager) setTokensLocked(name string, credentials []apitoken.Credential) {
+	for lookup, entries := range m.tokens {
+		kept := entries[:0]
+		for _, entry := range entries {
+			if entry.name != name {
+				kept = append(kept, entry)
+			}
+		}
+		if len(kept) == 0 {
+			delete(m.tokens, lookup)
+		} else {
+			m.tokens[lookup] = kept
+		}
+	}
+	for _, credential := range credentials {
+		if credential.Lookup == "" || credential.Hash == "" {
+			continue
+		}
+		m.tokens[credential.Lookup] = append(m.tokens[credential.Lookup], tokenEntry{name: name, credential: credential})
+	}
+}
+
 // QR cache helpers (store raw code; UI can render image elsewhere or upstream can convert)
 func (m *Manager) SetLastQR(name, code string) error {
 	m.mu.Lock()
@@ -146,3 +287,41 @@ func (m *Manager) GetLastQR(name string) (string, bool) {
 	v, ok := m.lastQR[name]
 	return v, ok
 }
+
+// SetPresenceState registra o estado atual do keeper de presença da sessão.
+func (m *Manager) SetPresenceState(name string, state PresenceState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[name]; !ok {
+		return ErrNotFound
+	}
+	m.presence[name] = state
+	return nil
+}
+
+func (m *Manager) GetPresenceState(name string) (PresenceState, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.presence[name]
+	return v, ok
+}
+
+// UpdateHistorySyncState aplica fn sobre o estado de history sync da sessão de forma atômica.
+func (m *Manager) UpdateHistorySyncState(name string, fn func(*HistorySyncState)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[name]; !ok {
+		return ErrNotFound
+	}
+	state := m.history[name]
+	fn(&state)
+	m.history[name] = state
+	return nil
+}
+
+func (m *Manager) GetHistorySyncState(name string) (HistorySyncState, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.history[name]
+	return v, ok
+}
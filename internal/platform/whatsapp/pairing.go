@@ -0,0 +1,66 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+)
+
+var ErrInvalidPairClient = errors.New("invalid pairing client type")
+
+// Padrões usados quando a instância não define tipo de cliente/nome para o pareamento.
+const (
+	DefaultPairClientType  = "chrome"
+	DefaultPairDisplayName = "Chrome (Windows)"
+)
+
+var pairClientTypes = map[string]whatsmeow.PairClientType{
+	"chrome":   whatsmeow.PairClientChrome,
+	"edge":     whatsmeow.PairClientEdge,
+	"firefox":  whatsmeow.PairClientFirefox,
+	"ie":       whatsmeow.PairClientIE,
+	"opera":    whatsmeow.PairClientOpera,
+	"safari":   whatsmeow.PairClientSafari,
+	"electron": whatsmeow.PairClientElectron,
+	"uwp":      whatsmeow.PairClientUWP,
+	"other":    whatsmeow.PairClientOtherWebClient,
+}
+
+// PairClientType converte o nome do tipo de cliente ("" = chrome) para a constante do whatsmeow.
+func PairClientType(name string) (whatsmeow.PairClientType, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" {
+		key = DefaultPairClientType
+	}
+	clientType, ok := pairClientTypes[key]
+	if !ok {
+		return whatsmeow.PairClientUnknown, fmt.Errorf("%w: %q", ErrInvalidPairClient, name)
+	}
+	return clientType, nil
+}
+
+// GeneratePairingCode solicita ao cliente whatsmeow que gere um código de pareamento baseado em número de telefone.
+// É esperado que a sessão já esteja conectada (via InitNewSession) antes da chamada. clientType e displayName
+// vazios usam os padrões de login desktop.
+func (m *Manager) GeneratePairingCode(ctx context.Context, name, phone, clientType, displayName string) (string, error) {
+	pairType, err := PairClientType(clientType)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(displayName) == "" {
+		displayName = DefaultPairDisplayName
+	}
+	m.mu.RLock()
+	sess, ok := m.sessions[name]
+	m.mu.RUnlock()
+	if !ok {
+		return "", ErrNotFound
+	}
+	if sess.Client == nil {
+		return "", ErrClientUnavailable
+	}
+	return sess.Client.PairPhone(ctx, phone, false, pairType, displayName)
+}
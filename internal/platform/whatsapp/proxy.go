@@ -0,0 +1,108 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+var ErrInvalidProxy = errors.New("invalid proxy url")
+
+// ProxyAddress monta a URL do proxy (http, https ou socks5) com as credenciais informadas.
+func ProxyAddress(rawURL, username, password string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidProxy, rawURL)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return "", fmt.Errorf("%w: unsupported scheme %q", ErrInvalidProxy, parsed.Scheme)
+	}
+	if username != "" {
+		parsed.User = url.UserPassword(username, password)
+	}
+	return parsed.String(), nil
+}
+
+// NewProxyTransport cria um transport HTTP que sai pelo proxy informado ("" = conexão direta).
+func NewProxyTransport(addr string) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if addr == "" {
+		return transport, nil
+	}
+	parsed, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProxy, err)
+	}
+	switch parsed.Scheme {
+	case "http", "https":
+		transport.Proxy = http.ProxyURL(parsed)
+	case "socks5":
+		dialer, err := proxy.FromURL(parsed, proxy.Direct)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidProxy, err)
+		}
+		transport.Proxy = nil
+		if contextDialer, ok := dialer.(proxy.ContextDialer); ok {
+			transport.DialContext = contextDialer.DialContext
+		} else {
+			transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+				return dialer.Dial(network, address)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported scheme %q", ErrInvalidProxy, parsed.Scheme)
+	}
+	return transport, nil
+}
+
+// SetProxy define o proxy da instância. O endereço é aplicado ao client já anexado
+// (websocket na próxima conexão e mídia imediatamente) e usado em HTTPClient.
+func (m *Manager) SetProxy(name, addr string) error {
+	transport, err := NewProxyTransport(addr)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, ok := m.transports[name]; ok {
+		old.CloseIdleConnections()
+	}
+	if addr == "" {
+		delete(m.proxies, name)
+		delete(m.transports, name)
+	} else {
+		m.proxies[name] = addr
+		m.transports[name] = transport
+	}
+	if sess, ok := m.sessions[name]; ok && sess.Client != nil {
+		return sess.Client.SetProxyAddress(addr)
+	}
+	return nil
+}
+
+// Proxy retorna o endereço do proxy configurado para a instância ("" = direto).
+func (m *Manager) Proxy(name string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.proxies[name]
+}
+
+// HTTPClient retorna um client HTTP que respeita o proxy da instância; usado nos downloads
+// de mídia e link preview feitos em nome dela.
+func (m *Manager) HTTPClient(name string, timeout time.Duration) *http.Client {
+	m.mu.RLock()
+	transport, ok := m.transports[name]
+	m.mu.RUnlock()
+	if !ok {
+		return &http.Client{Timeout: timeout}
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
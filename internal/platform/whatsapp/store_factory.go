@@ -2,45 +2,363 @@ package whatsapp
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
+	"go.mau.fi/whatsmeow/store"
 	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types"
 	waLog "go.mau.fi/whatsmeow/util/log"
 	_ "modernc.org/sqlite"
 )
 
-// StoreFactory cria containers sqlstore por instância.
+// StoreFactory cria os device stores das instâncias: um arquivo SQLite por instância
+// ou, no modo Postgres, um sqlstore.Container compartilhado com um device por instância.
 type StoreFactory struct {
 	baseDir string
 	log     waLog.Logger
 	mu      sync.Mutex // Protege criação de stores para evitar race conditions
+	// containers SQLite abertos por instância, fechados no shutdown
+	open map[string][]*sqlstore.Container
+
+	db     *sql.DB             // apenas modo Postgres
+	shared *sqlstore.Container // apenas modo Postgres
 }
 
 func NewStoreFactory(baseDir string, log waLog.Logger) *StoreFactory {
 	return &StoreFactory{baseDir: baseDir, log: log}
 }
 
+// NewPostgresStoreFactory usa o banco Postgres da aplicação para os devices do whatsmeow.
+// O mapeamento instância -> JID do device fica em whatsapp_instance_devices.
+func NewPostgresStoreFactory(ctx context.Context, db *sql.DB, baseDir string, log waLog.Logger) (*StoreFactory, error) {
+	container := sqlstore.NewWithDB(db, "postgres", log.Sub("DB"))
+	if err := container.Upgrade(ctx); err != nil {
+		return nil, fmt.Errorf("upgrade whatsmeow schema: %w", err)
+	}
+	const createTable = `
+        CREATE TABLE IF NOT EXISTS whatsapp_instance_devices (
+            instance_name TEXT PRIMARY KEY,
+            jid TEXT NOT NULL,
+            updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        )`
+	if _, err := db.ExecContext(ctx, createTable); err != nil {
+		return nil, err
+	}
+	return &StoreFactory{baseDir: baseDir, log: log, db: db, shared: container}, nil
+}
+
+// Shared indica se os devices ficam no container Postgres compartilhado.
+func (f *StoreFactory) Shared() bool {
+	return f.shared != nil
+}
+
 func (f *StoreFactory) EnsureDir() error {
 	return os.MkdirAll(f.baseDir, 0o755)
 }
 
 func (f *StoreFactory) NewDeviceStore(ctx context.Context, instanceName string) (*sqlstore.Container, error) {
+	if f.shared != nil {
+		return f.shared, nil
+	}
 	// Lock para evitar múltiplas inicializações simultâneas do mesmo banco
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	container, err := f.openSQLite(ctx, f.sqlitePath(instanceName))
+	if err != nil {
+		return nil, err
+	}
+	if f.open == nil {
+		f.open = make(map[string][]*sqlstore.Container)
+	}
+	f.open[instanceName] = append(f.open[instanceName], container)
+	return container, nil
+}
+
+// Close fecha os device stores SQLite abertos. No modo Postgres o container compartilha o
+// banco da aplicação, que é fechado por quem o abriu.
+func (f *StoreFactory) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var errs []error
+	for name := range f.open {
+		errs = append(errs, f.closeLocked(name))
+	}
+	return errors.Join(errs...)
+}
+
+func (f *StoreFactory) closeLocked(instanceName string) error {
+	var errs []error
+	for _, container := range f.open[instanceName] {
+		if err := container.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	delete(f.open, instanceName)
+	return errors.Join(errs...)
+}
 
+// Device retorna o device da instância (novo, se ainda não pareado).
+func (f *StoreFactory) Device(ctx context.Context, instanceName string) (*store.Device, error) {
+	if f.shared == nil {
+		container, err := f.NewDeviceStore(ctx, instanceName)
+		if err != nil {
+			return nil, err
+		}
+		return container.GetFirstDevice(ctx)
+	}
+
+	jid, err := f.boundJID(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	if !jid.IsEmpty() {
+		device, err := f.shared.GetDevice(ctx, jid)
+		if err != nil {
+			return nil, err
+		}
+		if device != nil {
+			return device, nil
+		}
+		// Device removido (ex.: logout): um novo pareamento será necessário.
+	}
+	return f.shared.NewDevice(), nil
+}
+
+// BindDevice persiste o JID do device pareado para a instância (modo Postgres).
+func (f *StoreFactory) BindDevice(ctx context.Context, instanceName string, jid types.JID) error {
+	if f.shared == nil || jid.IsEmpty() {
+		return nil
+	}
+	const query = `
+        INSERT INTO whatsapp_instance_devices (instance_name, jid, updated_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (instance_name) DO UPDATE SET jid = EXCLUDED.jid, updated_at = EXCLUDED.updated_at`
+	_, err := f.db.ExecContext(ctx, query, instanceName, jid.String(), time.Now().UTC())
+	return err
+}
+
+func (f *StoreFactory) boundJID(ctx context.Context, instanceName string) (types.JID, error) {
+	var raw string
+	err := f.db.QueryRowContext(ctx, `SELECT jid FROM whatsapp_instance_devices WHERE instance_name = $1`, instanceName).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.EmptyJID, nil
+	}
+	if err != nil {
+		return types.EmptyJID, err
+	}
+	return types.ParseJID(raw)
+}
+
+// deviceOwner retorna a instância associada ao JID no store compartilhado, ou "" se nenhuma.
+func (f *StoreFactory) deviceOwner(ctx context.Context, jid types.JID) (string, error) {
+	var name string
+	err := f.db.QueryRowContext(ctx, `SELECT instance_name FROM whatsapp_instance_devices WHERE jid = $1 LIMIT 1`, jid.String()).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return name, err
+}
+
+// whatsmeowTables lista as tabelas copiadas na migração, em ordem compatível com as FKs.
+// whatsmeow_device é gravada via PutDevice.
+var whatsmeowTables = []string{
+	"whatsmeow_identity_keys",
+	"whatsmeow_pre_keys",
+	"whatsmeow_sessions",
+	"whatsmeow_sender_keys",
+	"whatsmeow_app_state_sync_keys",
+	"whatsmeow_app_state_version",
+	"whatsmeow_app_state_mutation_macs",
+	"whatsmeow_contacts",
+	"whatsmeow_chat_settings",
+	"whatsmeow_message_secrets",
+	"whatsmeow_privacy_tokens",
+	"whatsmeow_lid_map",
+	"whatsmeow_event_buffer",
+}
+
+// MigrateSQLiteDevices copia para o Postgres os devices dos arquivos {DATA_DIR}/{instância}.db
+// das instâncias informadas ainda não mapeadas. Outros .db do diretório (app.db, backups)
+// nunca são tocados. Arquivos migrados são renomeados para .db.migrated.
+func (f *StoreFactory) MigrateSQLiteDevices(ctx context.Context, instanceNames []string) (int, error) {
+	if f.shared == nil {
+		return 0, nil
+	}
+	migrated := 0
+	for _, name := range instanceNames {
+		if name == "" || filepath.Base(name) != name {
+			continue
+		}
+		path := f.sqlitePath(name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		jid, err := f.boundJID(ctx, name)
+		if err != nil {
+			return migrated, err
+		}
+		if !jid.IsEmpty() {
+			continue
+		}
+		ok, err := f.migrateSQLiteFile(ctx, name, path)
+		if err != nil {
+			return migrated, fmt.Errorf("migrate %s: %w", path, err)
+		}
+		if ok {
+			migrated++
+		}
+	}
+	return migrated, nil
+}
+
+func (f *StoreFactory) migrateSQLiteFile(ctx context.Context, instanceName, path string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	src, err := f.openSQLite(ctx, path)
+	if err != nil {
+		return false, err
+	}
+	device, err := src.GetFirstDevice(ctx)
+	if err != nil || device == nil || device.ID == nil {
+		_ = src.Close()
+		if err != nil {
+			return false, err
+		}
+		f.log.Infof("device store %s não pareado; ignorando migração", path)
+		return false, nil
+	}
+	jid := *device.ID
+
+	if err := f.shared.PutDevice(ctx, device); err != nil {
+		_ = src.Close()
+		return false, err
+	}
+	srcDB, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		_ = src.Close()
+		return false, err
+	}
+	copyErr := f.copyTables(ctx, srcDB)
+	_ = srcDB.Close()
+	_ = src.Close()
+	if copyErr != nil {
+		return false, copyErr
+	}
+	if err := f.BindDevice(ctx, instanceName, jid); err != nil {
+		return false, err
+	}
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if _, err := os.Stat(path + suffix); err == nil {
+			if err := os.Rename(path+suffix, path+".migrated"+suffix); err != nil {
+				f.log.Warnf("falha ao renomear %s após migração: %v", path+suffix, err)
+			}
+		}
+	}
+	f.log.Infof("device %s da instância %s migrado de %s para o Postgres", jid, instanceName, path)
+	return true, nil
+}
+
+func (f *StoreFactory) copyTables(ctx context.Context, src *sql.DB) error {
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	for _, table := range whatsmeowTables {
+		if err := copyTable(ctx, src, tx, table); err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func copyTable(ctx context.Context, src *sql.DB, dst *sql.Tx, table string) error {
+	boolColumns, err := postgresBoolColumns(ctx, dst, table)
+	if err != nil {
+		return err
+	}
+	rows, err := src.QueryContext(ctx, "SELECT * FROM "+table)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil
+		}
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING",
+		table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		for i, col := range columns {
+			// SQLite guarda BOOLEAN como inteiro
+			if n, ok := values[i].(int64); ok && boolColumns[col] {
+				values[i] = n != 0
+			}
+		}
+		if _, err := dst.ExecContext(ctx, insert, values...); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func postgresBoolColumns(ctx context.Context, tx *sql.Tx, table string) (map[string]bool, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT column_name FROM information_schema.columns WHERE table_name = $1 AND data_type = 'boolean'`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		out[name] = true
+	}
+	return out, rows.Err()
+}
+
+func (f *StoreFactory) sqlitePath(instanceName string) string {
+	return filepath.Join(f.baseDir, fmt.Sprintf("%s.db", instanceName))
+}
+
+func (f *StoreFactory) openSQLite(ctx context.Context, path string) (*sqlstore.Container, error) {
 	if err := f.EnsureDir(); err != nil {
 		return nil, err
 	}
-	dbPath := filepath.Join(f.baseDir, fmt.Sprintf("%s.db", instanceName))
 	// modernc.org/sqlite driver name is "sqlite" with optimized settings for concurrency
-	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(ON)&_pragma=busy_timeout(30000)&_pragma=journal_mode(WAL)&_txlock=immediate", dbPath)
-	container, err := sqlstore.New(ctx, "sqlite", dsn, f.log.Sub("DB"))
+	container, err := sqlstore.New(ctx, "sqlite", sqliteDSN(path), f.log.Sub("DB"))
 	if err != nil {
 		return nil, err
 	}
 	return container, nil
 }
+
+func sqliteDSN(path string) string {
+	return fmt.Sprintf("file:%s?_pragma=foreign_keys(ON)&_pragma=busy_timeout(30000)&_pragma=journal_mode(WAL)&_txlock=immediate", path)
+}
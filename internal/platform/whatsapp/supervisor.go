@@ -0,0 +1,335 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// ReconnectPolicy define o backoff exponencial usado pelo supervisor.
+type ReconnectPolicy struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	MaxRetries     int // 0 = sem limite
+}
+
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		InitialBackoff: 2 * time.Second,
+		MaxBackoff:     5 * time.Minute,
+		MaxRetries:     10,
+	}
+}
+
+// Backoff retorna o atraso da tentativa (1-based): InitialBackoff * 2^(attempt-1), limitado a MaxBackoff.
+func (p ReconnectPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := p.InitialBackoff
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return delay
+}
+
+// Disconnect é a classificação de um evento de queda de conexão.
+type Disconnect struct {
+	State  string // HealthReconnecting ou um estado terminal
+	Reason string
+	Code   int
+	Retry  bool
+}
+
+// ClassifyDisconnect classifica eventos de queda do whatsmeow em recuperáveis ou terminais.
+func ClassifyDisconnect(evt any) (Disconnect, bool) {
+	switch e := evt.(type) {
+	case *events.Disconnected:
+		return Disconnect{State: HealthReconnecting, Reason: "connection_lost", Retry: true}, true
+	case *events.StreamReplaced:
+		// Reconectar tomaria a sessão de volta do outro client, que reconectaria em seguida.
+		return Disconnect{State: HealthReplaced, Reason: "stream_replaced"}, true
+	case *events.KeepAliveTimeout:
+		return Disconnect{State: HealthReconnecting, Reason: "keepalive_timeout", Retry: true}, true
+	case *events.LoggedOut:
+		return Disconnect{State: HealthLoggedOut, Reason: "logged_out", Code: int(e.Reason)}, true
+	case *events.TemporaryBan:
+		return Disconnect{State: HealthBanned, Reason: e.String(), Code: int(e.Code)}, true
+	case *events.ClientOutdated:
+		return Disconnect{State: HealthFailed, Reason: "client_outdated", Code: int(events.ConnectFailureClientOutdated)}, true
+	case *events.ConnectFailure:
+		switch {
+		case e.Reason.IsLoggedOut():
+			return Disconnect{State: HealthLoggedOut, Reason: e.Reason.String(), Code: int(e.Reason)}, true
+		case e.Reason == events.ConnectFailureTempBanned:
+			return Disconnect{State: HealthBanned, Reason: e.Reason.String(), Code: int(e.Reason)}, true
+		case e.Reason == events.ConnectFailureClientOutdated, e.Reason == events.ConnectFailureBadUserAgent:
+			return Disconnect{State: HealthFailed, Reason: e.Reason.String(), Code: int(e.Reason)}, true
+		}
+		return Disconnect{State: HealthReconnecting, Reason: e.Reason.String(), Code: int(e.Reason), Retry: true}, true
+	}
+	return Disconnect{}, false
+}
+
+// Supervisor assume a reconexão das sessões (no lugar do auto-reconnect do whatsmeow),
+// aplicando backoff exponencial, limite de tentativas e estados terminais.
+type Supervisor struct {
+	mgr    *Manager
+	policy ReconnectPolicy
+	log    waLog.Logger
+
+	mu    sync.Mutex
+	loops map[string]*reconnectLoop
+}
+
+type reconnectLoop struct {
+	cancel context.CancelFunc
+	client *whatsmeow.Client
+}
+
+func NewSupervisor(mgr *Manager, policy ReconnectPolicy, log waLog.Logger) *Supervisor {
+	return &Supervisor{mgr: mgr, policy: policy, log: log, loops: make(map[string]*reconnectLoop)}
+}
+
+// Watch passa a supervisionar o client da sessão, reiniciando a máquina de estados.
+// Deve ser chamado antes de client.Connect.
+func (s *Supervisor) Watch(name string, client *whatsmeow.Client) {
+	if s == nil || client == nil {
+		return
+	}
+	client.EnableAutoReconnect = false
+	s.cancel(name)
+	_ = s.mgr.UpdateHealth(name, func(h *SessionHealth) {
+		*h = SessionHealth{State: HealthConnecting, LastConnectedAt: h.LastConnectedAt, LastDisconnect: h.LastDisconnect}
+	})
+	client.AddEventHandler(func(evt any) {
+		s.handle(name, client, evt)
+	})
+}
+
+// Refresh sincroniza o estado depois que o client foi associado à sessão no Manager;
+// eventos anteriores à associação são ignorados por handle.
+func (s *Supervisor) Refresh(name string, client *whatsmeow.Client) {
+	if s == nil || client == nil || !s.current(name, client) || !client.IsLoggedIn() {
+		return
+	}
+	_ = s.mgr.UpdateHealth(name, func(h *SessionHealth) {
+		if h.State == HealthConnecting {
+			h.State = HealthConnected
+			h.LastConnectedAt = time.Now().UTC()
+		}
+	})
+}
+
+// Stop interrompe uma reconexão em andamento.
+func (s *Supervisor) Stop(name string) {
+	if s == nil {
+		return
+	}
+	s.cancel(name)
+}
+
+func (s *Supervisor) handle(name string, client *whatsmeow.Client, evt any) {
+	if !s.current(name, client) {
+		return
+	}
+	now := time.Now().UTC()
+	switch e := evt.(type) {
+	case *events.Connected:
+		s.cancel(name)
+		_ = s.mgr.UpdateHealth(name, func(h *SessionHealth) {
+			h.Attempts = 0
+			h.State = HealthConnected
+			h.Reason = ""
+			h.Code = 0
+			h.Terminal = false
+			h.LastError = ""
+			h.NextRetryAt = time.Time{}
+			h.LastConnectedAt = now
+		})
+		return
+	case *events.KeepAliveRestored:
+		_ = s.mgr.UpdateHealth(name, func(h *SessionHealth) {
+			if h.State == HealthDegraded {
+				h.State = HealthConnected
+				h.Reason = ""
+			}
+		})
+		return
+	case *events.PairSuccess:
+		_ = s.mgr.UpdateHealth(name, func(h *SessionHealth) {
+			h.State = HealthConnecting
+			h.Reason = "pair_success"
+		})
+		return
+	case *events.KeepAliveTimeout:
+		if time.Since(e.LastSuccess) <= whatsmeow.KeepAliveMaxFailTime {
+			_ = s.mgr.UpdateHealth(name, func(h *SessionHealth) {
+				h.State = HealthDegraded
+				h.Reason = "keepalive_timeout"
+			})
+			return
+		}
+		// Sem auto-reconnect do whatsmeow, o socket precisa ser derrubado aqui.
+		client.Disconnect()
+	}
+
+	d, ok := ClassifyDisconnect(evt)
+	if !ok {
+		return
+	}
+	if !d.Retry {
+		s.cancel(name)
+		_ = s.mgr.UpdateHealth(name, func(h *SessionHealth) {
+			h.State = d.State
+			h.Reason = d.Reason
+			h.Code = d.Code
+			h.Terminal = true
+			h.NextRetryAt = time.Time{}
+			h.LastDisconnect = now
+		})
+		if s.log != nil {
+			s.log.Warnf("Instância %s em estado terminal %s (%s)", name, d.State, d.Reason)
+		}
+		return
+	}
+	if client.Store == nil || client.Store.ID == nil {
+		// Ainda não pareado: um novo QR exige novo connect pela API.
+		return
+	}
+	s.schedule(name, client, d)
+}
+
+func (s *Supervisor) schedule(name string, client *whatsmeow.Client, d Disconnect) {
+	stopped := false
+	_ = s.mgr.UpdateHealth(name, func(h *SessionHealth) {
+		if h.State == HealthStopped || IsTerminalHealth(h.State) {
+			stopped = true
+			return
+		}
+		h.State = HealthReconnecting
+		h.Reason = d.Reason
+		h.Code = d.Code
+		h.LastDisconnect = time.Now().UTC()
+	})
+	if stopped {
+		return
+	}
+
+	s.mu.Lock()
+	if loop, ok := s.loops[name]; ok {
+		if loop.client == client {
+			s.mu.Unlock()
+			return
+		}
+		loop.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	loop := &reconnectLoop{cancel: cancel, client: client}
+	s.loops[name] = loop
+	s.mu.Unlock()
+
+	go s.run(ctx, name, client, loop)
+}
+
+func (s *Supervisor) run(ctx context.Context, name string, client *whatsmeow.Client, loop *reconnectLoop) {
+	defer s.finish(name, loop)
+	for {
+		h, ok := s.mgr.GetHealth(name)
+		if !ok || h.State == HealthStopped || IsTerminalHealth(h.State) {
+			return
+		}
+		attempt := h.Attempts + 1
+		if s.policy.MaxRetries > 0 && attempt > s.policy.MaxRetries {
+			_ = s.mgr.UpdateHealth(name, func(h *SessionHealth) {
+				h.State = HealthFailed
+				h.Terminal = true
+				h.NextRetryAt = time.Time{}
+			})
+			if s.log != nil {
+				s.log.Errorf("Instância %s: limite de %d tentativas de reconexão atingido", name, s.policy.MaxRetries)
+			}
+			return
+		}
+
+		delay := s.policy.Backoff(attempt)
+		_ = s.mgr.UpdateHealth(name, func(h *SessionHealth) {
+			h.State = HealthReconnecting
+			h.Attempts = attempt
+			h.NextRetryAt = time.Now().UTC().Add(delay)
+		})
+		if s.log != nil {
+			s.log.Infof("Instância %s: reconectando em %s (tentativa %d)", name, delay, attempt)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !s.current(name, client) {
+			return
+		}
+		if h, ok := s.mgr.GetHealth(name); !ok || h.State == HealthStopped || IsTerminalHealth(h.State) {
+			return
+		}
+		if client.IsConnected() {
+			return
+		}
+		err := client.Connect()
+		if err == nil || errors.Is(err, whatsmeow.ErrAlreadyConnected) {
+			// O evento Connected (ou uma nova falha) conduz o próximo estado.
+			_ = s.mgr.UpdateHealth(name, func(h *SessionHealth) {
+				h.NextRetryAt = time.Time{}
+			})
+			return
+		}
+		_ = s.mgr.UpdateHealth(name, func(h *SessionHealth) {
+			h.LastError = err.Error()
+		})
+		if s.log != nil {
+			s.log.Warnf("Instância %s: falha ao reconectar: %v", name, err)
+		}
+	}
+}
+
+func (s *Supervisor) finish(name string, loop *reconnectLoop) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.loops[name]; ok && current == loop {
+		delete(s.loops, name)
+	}
+	loop.cancel()
+}
+
+func (s *Supervisor) cancel(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if loop, ok := s.loops[name]; ok {
+		loop.cancel()
+		delete(s.loops, name)
+	}
+}
+
+// current verifica se o client ainda é o client ativo da sessão.
+func (s *Supervisor) current(name string, client *whatsmeow.Client) bool {
+	sess, ok := s.mgr.Get(name)
+	return ok && sess != nil && sess.Client == client
+}
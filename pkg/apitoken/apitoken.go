@@ -0,0 +1,93 @@
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Formato do hash persistido: sha256$<salt base64>$<sha256(salt||token) base64>.
+const hashPrefix = "sha256$"
+
+const saltSize = 16
+
+// lookupSize é o tamanho (em bytes) da chave de índice derivada do token.
+const lookupSize = 8
+
+var ErrEmptyToken = errors.New("token is empty")
+
+// Credential representa um token de API armazenado apenas como hash com salt.
+// Lookup é uma chave curta derivada do token, usada só para indexar; a validação
+// sempre confere o hash em tempo constante.
+type Credential struct {
+	Lookup    string
+	Hash      string
+	ExpiresAt time.Time // zero = sem expiração
+}
+
+// New gera a credencial (lookup + hash com salt aleatório) de um token em texto puro.
+func New(token string) (Credential, error) {
+	if token == "" {
+		return Credential{}, ErrEmptyToken
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return Credential{}, err
+	}
+	return Credential{
+		Lookup: Lookup(token),
+		Hash:   hashPrefix + base64.RawStdEncoding.EncodeToString(salt) + "$" + base64.RawStdEncoding.EncodeToString(digest(salt, token)),
+	}, nil
+}
+
+// Lookup retorna a chave de índice do token.
+func Lookup(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:lookupSize])
+}
+
+// IsHash indica se o valor já está no formato de hash (e não é um token legado em texto puro).
+func IsHash(value string) bool {
+	return strings.HasPrefix(value, hashPrefix)
+}
+
+// Verify confere o token contra a credencial em tempo constante.
+func (c Credential) Verify(token string, now time.Time) bool {
+	if c.Expired(now) || !IsHash(c.Hash) {
+		return false
+	}
+	parts := strings.Split(strings.TrimPrefix(c.Hash, hashPrefix), "$")
+	if len(parts) != 2 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(expected, digest(salt, token)) == 1
+}
+
+func (c Credential) Expired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && !now.Before(c.ExpiresAt)
+}
+
+// Equal compara dois segredos (ex.: master token) em tempo constante.
+func Equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func digest(salt []byte, token string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(token))
+	return h.Sum(nil)
+}
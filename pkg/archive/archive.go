@@ -0,0 +1,112 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Formato: magic | salt | nonce | AES-256-GCM(gzip(payload)).
+// A chave é derivada da passphrase com scrypt; o magic entra como dado autenticado.
+var magic = []byte("GWAARCH1")
+
+const (
+	saltSize  = 16
+	nonceSize = 12
+	keySize   = 32
+
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	// MinPassphraseLength é o tamanho mínimo aceito para a passphrase.
+	MinPassphraseLength = 8
+)
+
+var (
+	ErrPassphraseTooShort = fmt.Errorf("passphrase must have at least %d characters", MinPassphraseLength)
+	ErrInvalidArchive     = errors.New("invalid archive")
+	// ErrDecrypt cobre passphrase incorreta e arquivo adulterado, que o GCM não distingue.
+	ErrDecrypt = errors.New("wrong passphrase or corrupted archive")
+)
+
+// Seal comprime e cifra payload com a passphrase informada.
+func Seal(passphrase string, payload []byte) ([]byte, error) {
+	if len(passphrase) < MinPassphraseLength {
+		return nil, ErrPassphraseTooShort
+	}
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(magic)+saltSize+nonceSize+compressed.Len()+aead.Overhead())
+	out = append(out, magic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, compressed.Bytes(), magic), nil
+}
+
+// Open decifra um arquivo gerado por Seal e retorna o payload original.
+func Open(passphrase string, data []byte) ([]byte, error) {
+	header := len(magic) + saltSize + nonceSize
+	if len(data) <= header || !bytes.Equal(data[:len(magic)], magic) {
+		return nil, ErrInvalidArchive
+	}
+	salt := data[len(magic) : len(magic)+saltSize]
+	nonce := data[len(magic)+saltSize : header]
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := aead.Open(nil, nonce, data[header:], magic)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+	defer zr.Close()
+	payload, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+	return payload, nil
+}
+
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,101 @@
+// Package webhooksig assina e verifica os corpos de webhook com HMAC-SHA256.
+//
+// O conteúdo assinado é "<delivery id>.<timestamp unix>.<corpo>" e a assinatura vai no header
+// X-Webhook-Signature como "v1=<hex>". Durante a rotação do segredo a entrega é assinada com
+// o segredo novo e o anterior ("v1=<novo>,v1=<anterior>"); o receptor aceita qualquer uma.
+package webhooksig
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+
+	version = "v1"
+	// DefaultTolerance é a diferença máxima de relógio aceita por Verify contra replays.
+	DefaultTolerance = 5 * time.Minute
+)
+
+var (
+	ErrMissingHeaders   = errors.New("webhook signature headers missing")
+	ErrInvalidTimestamp = errors.New("webhook timestamp invalid or outside tolerance")
+	ErrInvalidSignature = errors.New("webhook signature mismatch")
+)
+
+// NewSecret gera um segredo aleatório (32 bytes em hex) prefixado com "whsec_".
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// Sign retorna o HMAC-SHA256 em hex do conteúdo assinado.
+func Sign(secret, id string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id))
+	mac.Write([]byte("."))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Apply grava os headers de id e timestamp e, havendo segredos, a assinatura com cada um deles.
+func Apply(h http.Header, id string, now time.Time, body []byte, secrets ...string) {
+	timestamp := now.Unix()
+	h.Set(HeaderID, id)
+	h.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	var signatures []string
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		signatures = append(signatures, version+"="+Sign(secret, id, timestamp, body))
+	}
+	if len(signatures) > 0 {
+		h.Set(HeaderSignature, strings.Join(signatures, ","))
+	}
+}
+
+// Verify confere os headers de uma requisição recebida: o timestamp deve estar dentro de
+// tolerance e alguma das assinaturas deve corresponder a algum dos segredos.
+func Verify(h http.Header, body []byte, now time.Time, tolerance time.Duration, secrets ...string) error {
+	id, rawTimestamp, header := h.Get(HeaderID), h.Get(HeaderTimestamp), h.Get(HeaderSignature)
+	if id == "" || rawTimestamp == "" || header == "" {
+		return ErrMissingHeaders
+	}
+	timestamp, err := strconv.ParseInt(rawTimestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	if tolerance > 0 {
+		if skew := now.Sub(time.Unix(timestamp, 0)); skew > tolerance || skew < -tolerance {
+			return ErrInvalidTimestamp
+		}
+	}
+	for _, part := range strings.Split(header, ",") {
+		v, sig, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || v != version {
+			continue
+		}
+		for _, secret := range secrets {
+			if secret != "" && hmac.Equal([]byte(sig), []byte(Sign(secret, id, timestamp, body))) {
+				return nil
+			}
+		}
+	}
+	return ErrInvalidSignature
+}
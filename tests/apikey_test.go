@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/controllers"
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/apikey"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/database"
+	httpPlatform "github.com/faeln1/go-whatsapp-api/internal/platform/http"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/logger"
+)
+
+func TestAPIKeyServiceScopes(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	repo, err := repositories.NewSQLiteAPIKeyRepo(db)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	svc := services.NewAPIKeyService(repo, nil)
+
+	if _, err := svc.Create(ctx, apikey.CreateAPIKeyInput{Name: "bad", Scopes: []string{"messages:delete"}}); !errors.Is(err, services.ErrAPIKeyScopes) {
+		t.Fatalf("expected ErrAPIKeyScopes, got %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if _, err := svc.Create(ctx, apikey.CreateAPIKeyInput{Name: "old", Scopes: []string{apikey.ScopeMessagesSend}, ExpiresAt: &past}); !errors.Is(err, services.ErrAPIKeyExpiry) {
+		t.Fatalf("expected ErrAPIKeyExpiry, got %v", err)
+	}
+
+	created, err := svc.Create(ctx, apikey.CreateAPIKeyInput{
+		Name:      "crm",
+		Scopes:    []string{apikey.ScopeMessagesSend, apikey.ScopeGroupsWrite},
+		Instances: []string{"sales"},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	key, ok := svc.Authenticate(created.Key)
+	if !ok {
+		t.Fatalf("expected key to authenticate")
+	}
+	if !key.Allows(apikey.ScopeMessagesSend, "sales") || !key.Allows(apikey.ScopeGroupsRead, "sales") {
+		t.Fatalf("expected scopes granted on allowlisted instance")
+	}
+	if key.Allows(apikey.ScopeMessagesSend, "support") || key.Allows(apikey.ScopeInstancesRead, "sales") {
+		t.Fatalf("expected other instance and missing scope to be denied")
+	}
+	if key.LastUsedAt == nil {
+		t.Fatalf("expected lastUsedAt tracked")
+	}
+	if _, ok := svc.Authenticate(created.Key + "x"); ok {
+		t.Fatalf("expected wrong key to be rejected")
+	}
+
+	scopes := []string{apikey.ScopeInstancesAdmin}
+	if _, err := svc.Update(ctx, created.ID, apikey.UpdateAPIKeyInput{Scopes: &scopes, Instances: &[]string{}}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	// Uma nova instância do serviço recarrega as chaves do banco.
+	reloaded := services.NewAPIKeyService(repo, nil)
+	if err := reloaded.Load(ctx); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	key, ok = reloaded.Authenticate(created.Key)
+	if !ok || !key.Allows(apikey.ScopeInstancesRead, "any") || key.HasScope(apikey.ScopeMessagesSend) {
+		t.Fatalf("expected updated scopes after reload, got %+v", key)
+	}
+	if err := reloaded.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := reloaded.Authenticate(created.Key); ok {
+		t.Fatalf("expected deleted key to be rejected")
+	}
+}
+
+func TestRouterEnforcesAPIKeyScopes(t *testing.T) {
+	svc := services.NewAPIKeyService(repositories.NewInMemoryAPIKeyRepo(), nil)
+	router := httpPlatform.NewRouter(httpPlatform.RouterConfig{
+		APIKeyCtrl:  controllers.NewAPIKeyController(svc),
+		APIKeys:     svc,
+		WAManager:   whatsapp.NewManager(logger.InitForTests().App),
+		Logger:      logger.InitForTests().HTTP,
+		MasterToken: "master",
+	})
+	do := func(method, path, token string, body any) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			_ = json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/apikeys", "master", apikey.CreateAPIKeyInput{Name: "reader", Scopes: []string{apikey.ScopeAnalyticsRead}})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created apikey.CreateAPIKeyResponse
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil || created.Key == "" {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if rec := do(http.MethodGet, "/apikeys", created.Key, nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected api key management to require master token, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/message/sendText/sales", created.Key, map[string]string{"number": "1"}); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected messages:send to be required, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/instances/sales/rotateToken", created.Key, nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected instances:admin to be required, got %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/apikeys/"+created.ID, "master", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/cluster"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	httpPlatform "github.com/faeln1/go-whatsapp-api/internal/platform/http"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/middleware"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/logger"
+)
+
+const testLeaseTTL = 200 * time.Millisecond
+
+type startRecorder struct {
+	mu      sync.Mutex
+	started []string
+}
+
+func (s *startRecorder) start(_ context.Context, inst *instance.Instance) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = append(s.started, inst.Name)
+}
+
+func (s *startRecorder) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.started)
+}
+
+func newTestCoordinator(t *testing.T, leases repositories.LeaseRepository, repo repositories.InstanceRepository, id string) (*services.ClusterCoordinator, *whatsapp.Manager, *startRecorder) {
+	t.Helper()
+	waMgr := whatsapp.NewManager(logger.InitForTests().App)
+	node := cluster.Node{ID: id, Address: "http://" + id + ":8080"}
+	c := services.NewClusterCoordinator(leases, repo, waMgr, node, testLeaseTTL, 50*time.Millisecond, nil)
+	rec := &startRecorder{}
+	c.Start = rec.start
+	return c, waMgr, rec
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met before deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClusterCoordinatorFailover(t *testing.T) {
+	ctx := context.Background()
+	leases := repositories.NewInMemoryLeaseRepo()
+	repo := repositories.NewInMemoryInstanceRepo()
+	for _, name := range []string{"alpha", "beta"} {
+		if err := repo.Create(ctx, &instance.Instance{ID: instance.ID(name + "-id"), Name: name}); err != nil {
+			t.Fatalf("create instance: %v", err)
+		}
+	}
+	nodeA, mgrA, startedA := newTestCoordinator(t, leases, repo, "node-a")
+	nodeB, mgrB, startedB := newTestCoordinator(t, leases, repo, "node-b")
+
+	nodeA.Sync(ctx)
+	waitFor(t, func() bool { return startedA.count() == 2 })
+	if _, ok := mgrA.Get("alpha"); !ok {
+		t.Fatalf("expected instances registered in node-a manager")
+	}
+
+	nodeB.Sync(ctx)
+	if _, ok := mgrB.Get("beta"); !ok {
+		t.Fatalf("expected instances registered in node-b manager for authentication")
+	}
+	if startedB.count() != 0 {
+		t.Fatalf("node-b must not start instances leased by node-a")
+	}
+	if err := nodeB.Claim(ctx, "alpha"); !errors.Is(err, services.ErrInstanceOwnedElsewhere) {
+		t.Fatalf("expected ErrInstanceOwnedElsewhere, got %v", err)
+	}
+	if addr, ok := nodeB.RemoteOwner(ctx, "alpha"); !ok || addr != "http://node-a:8080" {
+		t.Fatalf("expected node-a as remote owner, got %q %v", addr, ok)
+	}
+	if _, ok := nodeA.RemoteOwner(ctx, "alpha"); ok {
+		t.Fatalf("owner must serve its own instances locally")
+	}
+
+	// node-a para de renovar: após o TTL node-b assume as duas instâncias.
+	time.Sleep(testLeaseTTL + 50*time.Millisecond)
+	nodeB.Sync(ctx)
+	waitFor(t, func() bool { return startedB.count() == 2 })
+	owners, err := nodeB.Owners(ctx)
+	if err != nil {
+		t.Fatalf("owners: %v", err)
+	}
+	if owners["alpha"].NodeID != "node-b" || owners["beta"].NodeID != "node-b" {
+		t.Fatalf("expected node-b to own both instances, got %+v", owners)
+	}
+
+	// node-a volta, percebe que perdeu os leases e para as sessões locais.
+	nodeA.Sync(ctx)
+	if health, _ := mgrA.GetHealth("alpha"); health.State != whatsapp.HealthStopped || health.Reason != "lease_lost" {
+		t.Fatalf("expected node-a session stopped after losing lease, got %+v", health)
+	}
+	if startedA.count() != 2 {
+		t.Fatalf("node-a must not reclaim instances owned by node-b")
+	}
+
+	nodeB.Shutdown(ctx)
+	if _, active, _ := nodeA.Owner(ctx, "alpha"); active {
+		t.Fatalf("expected leases released on shutdown")
+	}
+}
+
+type staticOwners map[string]string
+
+func (o staticOwners) RemoteOwner(_ context.Context, name string) (string, bool) {
+	addr, ok := o[name]
+	return addr, ok
+}
+
+func TestClusterForwarding(t *testing.T) {
+	owner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		node, _ := middleware.VerifyForwarded("cluster-secret", r.Header.Get(middleware.ForwardedHeader), time.Now())
+		w.Header().Set("X-Owner-Saw", node+" "+r.URL.Path)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer owner.Close()
+
+	newServer := func(mode string) *httptest.Server {
+		loggers := logger.InitForTests()
+		router := httpPlatform.NewRouter(httpPlatform.RouterConfig{
+			WAManager:      whatsapp.NewManager(loggers.App),
+			Logger:         loggers.HTTP,
+			MasterToken:    "master",
+			ClusterOwners:  staticOwners{"remote": owner.URL},
+			ClusterNodeID:  "node-a",
+			ClusterForward: mode,
+			ClusterSecret:  "cluster-secret",
+		})
+		srv := httptest.NewServer(router)
+		t.Cleanup(srv.Close)
+		return srv
+	}
+
+	srv := newServer(middleware.ForwardProxy)
+	resp, err := http.Get(srv.URL + "/instances/remote/connectionState")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted || resp.Header.Get("X-Owner-Saw") != "node-a /instances/remote/connectionState" {
+		t.Fatalf("expected request proxied to owner, got %d %q", resp.StatusCode, resp.Header.Get("X-Owner-Saw"))
+	}
+	resp, err = http.Post(srv.URL+"/message/sendText/remote", "application/json", nil)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected message route proxied to owner, got %d", resp.StatusCode)
+	}
+
+	// Instâncias locais (ou sem dono) seguem para o roteador local.
+	resp, err = http.Get(srv.URL + "/instances/local/connectionState")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected local handling, got %d", resp.StatusCode)
+	}
+
+	// Um cabeçalho forjado por cliente externo não evita o encaminhamento; um assinado por outro nó, sim.
+	for _, tc := range []struct {
+		value  string
+		status int
+	}{
+		{"node-x", http.StatusAccepted},
+		{middleware.SignForwarded("wrong-secret", "node-b", time.Now()), http.StatusAccepted},
+		{middleware.SignForwarded("cluster-secret", "node-b", time.Now().Add(-time.Hour)), http.StatusAccepted},
+		{middleware.SignForwarded("cluster-secret", "node-b", time.Now()), http.StatusUnauthorized},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/instances/remote/connectionState", nil)
+		req.Header.Set(middleware.ForwardedHeader, tc.value)
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Fatalf("%q: expected %d, got %d", tc.value, tc.status, resp.StatusCode)
+		}
+	}
+
+	srv = newServer(middleware.ForwardRedirect)
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err = client.Get(srv.URL + "/instances/remote/connectionState?x=1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTemporaryRedirect || resp.Header.Get("Location") != owner.URL+"/instances/remote/connectionState?x=1" {
+		t.Fatalf("expected 307 to owner, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+}
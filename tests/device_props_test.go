@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waCompanionReg"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestHistorySyncPropsPerClient(t *testing.T) {
+	ctx := context.Background()
+	stores := whatsapp.NewStoreFactory(t.TempDir(), waLog.Noop)
+	defer stores.Close()
+	globalBefore := proto.Clone(store.DeviceProps)
+
+	registrationProps := func(client *whatsmeow.Client) *waCompanionReg.DeviceProps {
+		t.Helper()
+		pairing := client.GetClientPayload().GetDevicePairingData()
+		if pairing == nil {
+			t.Fatalf("expected a registration payload for an unpaired device")
+		}
+		props := &waCompanionReg.DeviceProps{}
+		if err := proto.Unmarshal(pairing.GetDeviceProps(), props); err != nil {
+			t.Fatalf("unmarshal device props: %v", err)
+		}
+		return props
+	}
+
+	clients := map[string]*whatsmeow.Client{}
+	for _, name := range []string{"full", "recent"} {
+		device, err := stores.Device(ctx, name)
+		if err != nil {
+			t.Fatalf("device %s: %v", name, err)
+		}
+		clients[name] = whatsmeow.NewClient(device, waLog.Noop)
+	}
+
+	// Clients configurados em paralelo mantêm cada um as próprias props (go test -race).
+	var wg sync.WaitGroup
+	for name, client := range clients {
+		wg.Add(1)
+		go func(name string, client *whatsmeow.Client) {
+			defer wg.Done()
+			whatsapp.ApplyHistorySyncProps(client, name == "full")
+		}(name, client)
+	}
+	wg.Wait()
+
+	full := registrationProps(clients["full"])
+	if !full.GetRequireFullSync() || full.GetHistorySyncConfig().GetFullSyncDaysLimit() == 0 {
+		t.Fatalf("expected full history requested, got %+v", full)
+	}
+	if recent := registrationProps(clients["recent"]); recent.GetRequireFullSync() {
+		t.Fatalf("expected recent-only history, got %+v", recent)
+	}
+	if full.GetOs() != store.DeviceProps.GetOs() {
+		t.Fatalf("expected default device props preserved, got os %q", full.GetOs())
+	}
+	if !proto.Equal(globalBefore, store.DeviceProps) {
+		t.Fatalf("global store.DeviceProps must not be modified")
+	}
+
+	// Devices já pareados fazem login sem DeviceProps.
+	paired := clients["recent"]
+	jid := types.NewJID("5511999990000", types.DefaultUserServer)
+	paired.Store.ID = &jid
+	if paired.GetClientPayload().GetDevicePairingData() != nil {
+		t.Fatalf("login payload must not carry pairing data")
+	}
+}
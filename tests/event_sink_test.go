@@ -0,0 +1,218 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/database"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// REDIS_TEST_ADDR roda o teste contra um Redis local (ex.: o do docker-compose); sem ela é
+// usado um Redis em memória.
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		addr = miniredis.RunT(t).Addr()
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr, Password: os.Getenv("REDIS_TEST_PASSWORD")})
+	t.Cleanup(func() { client.Close() })
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("redis ping: %v", err)
+	}
+	return client
+}
+
+func TestRedisStreamSinkPublishesEnabledInstances(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	sinkRepo, err := repositories.NewSQLiteEventSinkRepo(db)
+	if err != nil {
+		t.Fatalf("new sink repo: %v", err)
+	}
+	repo := repositories.NewInMemoryInstanceRepo()
+	instanceSvc := services.NewInstanceService(repo, whatsapp.NewManager(logger.InitForTests().App), nil)
+	instanceSvc.SetEventSinks(sinkRepo)
+	for _, name := range []string{"shop", "other"} {
+		if _, err := instanceSvc.Create(ctx, instance.CreateInstanceInput{InstanceName: name}); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+
+	client := newTestRedis(t)
+	prefix := fmt.Sprintf("test:events:%d", time.Now().UnixNano())
+	sink := services.NewRedisStreamSink(client, services.RedisStreamOptions{Stream: prefix, PerInstance: true, MaxLen: 2})
+	sinks := services.NewEventSinks(nil, sinkRepo, nil)
+	sinks.Register(sink)
+	svc := services.NewEventSinkService(repo, sinkRepo, sinks)
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go sinks.Run(runCtx)
+
+	shop, _ := repo.GetByName(ctx, "shop")
+	other, _ := repo.GetByName(ctx, "other")
+	if sinks.HasAudience(ctx, shop) {
+		t.Fatalf("expected no audience before enabling the sink")
+	}
+	if _, err := svc.Set(ctx, "shop", "rabbitmq", webhook.SinkConfigInput{Enabled: true}); !errors.Is(err, services.ErrUnknownEventSink) {
+		t.Fatalf("expected ErrUnknownEventSink, got %v", err)
+	}
+	cfg, err := svc.Set(ctx, "shop", services.RedisStreamSinkName, webhook.SinkConfigInput{Enabled: true, Events: []string{"MESSAGES_UPSERT"}})
+	if err != nil || !cfg.Enabled {
+		t.Fatalf("set: %+v err=%v", cfg, err)
+	}
+	if !sinks.HasAudience(ctx, shop) || sinks.HasAudience(ctx, other) {
+		t.Fatalf("expected audience only for the enabled instance")
+	}
+
+	stream := sink.StreamFor("shop")
+	if err := client.XGroupCreateMkStream(ctx, stream, "workers", "0").Err(); err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	for i, evt := range []string{"messages.upsert", "presence.update", "messages.upsert", "messages.upsert"} {
+		if _, err := sinks.Dispatch(ctx, shop, evt, map[string]any{"n": i}); err != nil {
+			t.Fatalf("dispatch: %v", err)
+		}
+	}
+	if _, err := sinks.Dispatch(ctx, other, "messages.upsert", map[string]any{"n": 9}); err != nil {
+		t.Fatalf("dispatch other: %v", err)
+	}
+	// A publicação é assíncrona: aguarda o último evento chegar ao stream.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		entries, _ := client.XRevRangeN(ctx, stream, "+", "-", 1).Result()
+		if len(entries) == 1 {
+			if payload, _ := entries[0].Values["payload"].(string); jsonField(payload, "n") == float64(3) {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the sink to publish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n, err := client.Exists(ctx, sink.StreamFor("other")).Result(); err != nil || n != 0 {
+		t.Fatalf("expected no stream for disabled instance, exists=%d err=%v", n, err)
+	}
+
+	res, err := client.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "workers", Consumer: "w1", Streams: []string{stream, ">"}, Count: 10}).Result()
+	if err != nil || len(res) != 1 {
+		t.Fatalf("read group: %+v err=%v", res, err)
+	}
+	// presence.update ficou de fora pelo filtro; dos três messages.upsert o MAXLEN manteve ao menos dois.
+	msgs := res[0].Messages
+	if len(msgs) < 2 || len(msgs) > 3 {
+		t.Fatalf("expected 2-3 entries after MAXLEN, got %d", len(msgs))
+	}
+	last := msgs[len(msgs)-1]
+	if last.Values["event"] != "messages.upsert" || last.Values["instance"] != "shop" {
+		t.Fatalf("unexpected entry fields: %+v", last.Values)
+	}
+	var body struct {
+		Event    string         `json:"event"`
+		Instance string         `json:"instance"`
+		Data     map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(last.Values["payload"].(string)), &body); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if body.Event != "messages.upsert" || body.Instance != "shop" || body.Data["n"] != float64(3) {
+		t.Fatalf("unexpected payload: %+v", body)
+	}
+
+	if err := instanceSvc.Delete(ctx, "shop"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := sinkRepo.Get(ctx, string(shop.ID), services.RedisStreamSinkName); !errors.Is(err, repositories.ErrSinkConfigNotFound) {
+		t.Fatalf("expected sink config removed with the instance, got %v", err)
+	}
+}
+
+func jsonField(raw, field string) any {
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	_ = json.Unmarshal([]byte(raw), &body)
+	return body.Data[field]
+}
+
+// flakySink falha as primeiras tentativas e registra as publicações bem-sucedidas.
+type flakySink struct {
+	mu        sync.Mutex
+	failures  int
+	attempts  int
+	published []string
+}
+
+func (f *flakySink) Name() string { return "flaky" }
+
+func (f *flakySink) Publish(ctx context.Context, inst *instance.Instance, event string, body []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if f.attempts <= f.failures {
+		return errors.New("unavailable")
+	}
+	f.published = append(f.published, event)
+	return nil
+}
+
+func TestEventSinksRetryInBackground(t *testing.T) {
+	ctx := context.Background()
+	sinkRepo := repositories.NewInMemoryEventSinkRepo()
+	inst := &instance.Instance{ID: "inst-1", Name: "shop"}
+	if err := sinkRepo.Save(ctx, &webhook.SinkConfig{InstanceID: "inst-1", Sink: "flaky", Enabled: true}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	sink := &flakySink{failures: 2}
+	sinks := services.NewEventSinks(nil, sinkRepo, nil)
+	sinks.Register(sink)
+
+	// Sem o publicador rodando o Dispatch não bloqueia: o evento fica no buffer.
+	if delivered, err := sinks.Dispatch(ctx, inst, "messages.upsert", map[string]any{}); err != nil || !delivered {
+		t.Fatalf("dispatch: delivered=%v err=%v", delivered, err)
+	}
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sinks.Run(runCtx)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		sink.mu.Lock()
+		published, attempts := len(sink.published), sink.attempts
+		sink.mu.Unlock()
+		if published == 1 {
+			if attempts != 3 {
+				t.Fatalf("expected two retries before success, got %d attempts", attempts)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for retries, attempts=%d", attempts)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	stop()
+	<-done
+}
@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/database"
+)
+
+func TestEventStreamsFilterAndResume(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	outbox, err := repositories.NewSQLiteWebhookOutboxRepo(db)
+	if err != nil {
+		t.Fatalf("new outbox repo: %v", err)
+	}
+	journal, err := repositories.NewSQLiteEventJournalRepo(db)
+	if err != nil {
+		t.Fatalf("new event journal: %v", err)
+	}
+	receiver := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer receiver.Close()
+
+	queue := services.NewWebhookQueue(outbox, nil, services.WebhookQueueOptions{}, nil)
+	streams := services.NewEventStreams(queue, nil)
+	streams.SetJournal(journal, time.Hour)
+
+	shop := &instance.Instance{Name: "shop"}
+	other := &instance.Instance{Name: "other"}
+	if streams.HasAudience(ctx, shop) {
+		t.Fatalf("expected no audience without webhook or stream")
+	}
+	frames, cancel := streams.Subscribe(services.EventStreamFilter{Instances: []string{"shop"}, Events: []string{"MESSAGES_UPSERT"}})
+	defer cancel()
+	if !streams.HasAudience(ctx, shop) || streams.HasAudience(ctx, other) {
+		t.Fatalf("expected audience only for the streamed instance")
+	}
+
+	// Todo evento despachado entra no journal, tenha ou não webhook.
+	if _, err := streams.Dispatch(ctx, other, "messages.upsert", map[string]any{"id": "X"}); err != nil {
+		t.Fatalf("dispatch other: %v", err)
+	}
+	if _, err := streams.Dispatch(ctx, shop, "presence.update", map[string]any{"id": "P"}); err != nil {
+		t.Fatalf("dispatch presence: %v", err)
+	}
+	if _, err := streams.Dispatch(ctx, shop, "messages.upsert", map[string]any{"id": "A"}); err != nil {
+		t.Fatalf("dispatch A: %v", err)
+	}
+	first := nextStreamEvent(t, frames)
+	if first.Instance != "shop" || first.Event != "messages.upsert" || first.Data.(map[string]any)["id"] != "A" {
+		t.Fatalf("unexpected event: %+v", first)
+	}
+
+	shop.Webhook = instance.InstanceWebhook{Enabled: true, URL: receiver.URL}
+	for _, id := range []string{"B", "C"} {
+		if _, err := streams.Dispatch(ctx, shop, "messages.upsert", map[string]any{"id": id}); err != nil {
+			t.Fatalf("dispatch %s: %v", id, err)
+		}
+	}
+	second := nextStreamEvent(t, frames)
+	nextStreamEvent(t, frames)
+
+	filter := services.EventStreamFilter{Instances: []string{"shop"}}
+	backlog, err := streams.Backlog(ctx, filter, first.ID)
+	if err != nil {
+		t.Fatalf("backlog: %v", err)
+	}
+	if len(backlog) != 2 || backlog[0].Event != "messages.upsert" || !jsonEquals(backlog[0].Data, `{"id":"B"}`) {
+		t.Fatalf("unexpected backlog from first cursor: %+v", backlog)
+	}
+	backlog, err = streams.Backlog(ctx, filter, second.ID)
+	if err != nil || len(backlog) != 1 {
+		t.Fatalf("unexpected backlog from second cursor: %+v err=%v", backlog, err)
+	}
+	backlog, err = streams.Backlog(ctx, services.EventStreamFilter{Instances: []string{"shop"}, Events: []string{"MESSAGES_UPSERT"}}, "0")
+	if err != nil || len(backlog) != 3 || backlog[0].ID != first.ID || !jsonEquals(backlog[0].Data, `{"id":"A"}`) {
+		t.Fatalf("expected the event sent without webhook in the backlog, got %+v err=%v", backlog, err)
+	}
+	if _, err := streams.Backlog(ctx, filter, "yesterday"); !errors.Is(err, services.ErrInvalidStreamCursor) {
+		t.Fatalf("expected ErrInvalidStreamCursor, got %v", err)
+	}
+	if _, err := services.NewEventStreams(nil, nil).Backlog(ctx, filter, first.ID); !errors.Is(err, services.ErrStreamResumeUnavailable) {
+		t.Fatalf("expected ErrStreamResumeUnavailable, got %v", err)
+	}
+
+	streams.CloseAll()
+	if _, ok := <-frames; ok {
+		t.Fatalf("expected stream to be closed")
+	}
+}
+
+func TestEventStreamsBacklogGap(t *testing.T) {
+	ctx := context.Background()
+	streams := services.NewEventStreams(nil, nil)
+	streams.SetJournal(repositories.NewInMemoryEventJournalRepo(), 0)
+	shop := &instance.Instance{Name: "shop"}
+	other := &instance.Instance{Name: "other"}
+	// Eventos de outra instância não consomem o limite do backlog.
+	for i := 0; i < 505; i++ {
+		_, _ = streams.Dispatch(ctx, other, "messages.upsert", map[string]any{"n": i})
+		_, _ = streams.Dispatch(ctx, shop, "messages.upsert", map[string]any{"n": i})
+	}
+	filter := services.EventStreamFilter{Instances: []string{"shop"}}
+	backlog, err := streams.Backlog(ctx, filter, "0")
+	if err != nil || len(backlog) != 501 {
+		t.Fatalf("expected 500 events and a gap frame, got %d err=%v", len(backlog), err)
+	}
+	if !jsonEquals(backlog[0].Data, `{"n":0}`) {
+		t.Fatalf("expected the backlog to start at the oldest event, got %+v", backlog[0])
+	}
+	gap := backlog[500]
+	if gap.Event != services.StreamGapEvent || gap.ID != backlog[499].ID {
+		t.Fatalf("unexpected gap frame: %+v", gap)
+	}
+	rest, err := streams.Backlog(ctx, filter, gap.ID)
+	if err != nil || len(rest) != 5 || !jsonEquals(rest[0].Data, `{"n":500}`) {
+		t.Fatalf("expected the remaining events after the gap, got %+v err=%v", rest, err)
+	}
+}
+
+func TestEventStreamsTailSharedJournal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Dois nós do cluster gravando no mesmo journal.
+	journal := repositories.NewInMemoryEventJournalRepo()
+	nodeA := services.NewEventStreams(nil, nil)
+	nodeB := services.NewEventStreams(nil, nil)
+	for _, node := range []*services.EventStreams{nodeA, nodeB} {
+		node.SetJournal(journal, 0)
+		node.TailJournal(10 * time.Millisecond)
+	}
+	local := &instance.Instance{Name: "local"}
+	remote := &instance.Instance{Name: "remote"}
+	if _, err := nodeB.Dispatch(ctx, remote, "messages.upsert", map[string]any{"n": 0}); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if !nodeB.HasAudience(ctx, remote) {
+		t.Fatalf("expected every instance to have audience when tailing the journal")
+	}
+	frames, stop := nodeA.Subscribe(services.EventStreamFilter{})
+	defer stop()
+	go nodeA.Run(ctx)
+	go nodeB.Run(ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	// O evento do outro nó chega ao stream; o anterior ao início do tail não é reenviado.
+	if _, err := nodeB.Dispatch(ctx, remote, "messages.upsert", map[string]any{"n": 1}); err != nil {
+		t.Fatalf("dispatch remote: %v", err)
+	}
+	evt := nextStreamEvent(t, frames)
+	if evt.Instance != "remote" || evt.ID != "2" || !jsonEquals(evt.Data, `{"n":1}`) {
+		t.Fatalf("unexpected remote frame: %+v", evt)
+	}
+	// O evento local chega uma única vez, pelo journal.
+	if _, err := nodeA.Dispatch(ctx, local, "messages.upsert", map[string]any{"n": 2}); err != nil {
+		t.Fatalf("dispatch local: %v", err)
+	}
+	if evt := nextStreamEvent(t, frames); evt.Instance != "local" || evt.ID != "3" {
+		t.Fatalf("unexpected local frame: %+v", evt)
+	}
+	select {
+	case evt := <-frames:
+		t.Fatalf("unexpected duplicate frame: %+v", evt)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func jsonEquals(v any, want string) bool {
+	raw, err := json.Marshal(v)
+	return err == nil && string(raw) == want
+}
+
+func nextStreamEvent(t *testing.T, frames <-chan services.StreamEvent) services.StreamEvent {
+	t.Helper()
+	select {
+	case evt, ok := <-frames:
+		if !ok {
+			t.Fatalf("stream closed")
+		}
+		return evt
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for stream event")
+	}
+	return services.StreamEvent{}
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/logger"
+	"github.com/faeln1/go-whatsapp-api/pkg/webhooksig"
+)
+
+func TestGlobalWebhookReceivesEveryInstance(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewInMemoryInstanceRepo()
+	instanceSvc := services.NewInstanceService(repo, whatsapp.NewManager(logger.InitForTests().App), nil)
+	for _, name := range []string{"shop", "support"} {
+		if _, err := instanceSvc.Create(ctx, instance.CreateInstanceInput{InstanceName: name}); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+
+	type hit struct {
+		instance string
+		event    string
+		header   http.Header
+	}
+	var (
+		mu   sync.Mutex
+		hits = map[string][]hit{}
+	)
+	receiver := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var envelope struct {
+				Event    string `json:"event"`
+				Instance string `json:"instance"`
+			}
+			_ = json.Unmarshal(body, &envelope)
+			mu.Lock()
+			hits[name] = append(hits[name], hit{instance: envelope.Instance, event: envelope.Event, header: r.Header.Clone()})
+			mu.Unlock()
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+	global := receiver("global")
+	defer global.Close()
+	shop := receiver("shop")
+	defer shop.Close()
+
+	// Só "shop" tem webhook próprio; o global recebe as duas instâncias.
+	if _, err := instanceSvc.SetWebhook(ctx, "shop", instance.SetWebhookInput{Enabled: true, URL: shop.URL, Events: []string{"ALL"}}); err != nil {
+		t.Fatalf("set webhook: %v", err)
+	}
+	queue := services.NewWebhookQueue(repositories.NewInMemoryWebhookOutboxRepo(), nil, services.WebhookQueueOptions{
+		Retry:        services.WebhookRetryPolicy{MaxAttempts: 2, InitialBackoff: 10 * time.Millisecond},
+		Workers:      2,
+		PollInterval: 10 * time.Millisecond,
+	}, nil)
+	queue.SetInstances(repo)
+	deliveryLog := repositories.NewInMemoryWebhookDeliveryLogRepo()
+	queue.SetDeliveryLog(deliveryLog)
+	queue.SetGlobal(services.GlobalWebhook{
+		URL:     global.URL,
+		Token:   "global-token",
+		Events:  []string{"MESSAGES_UPSERT", "connection.update"},
+		Secrets: []string{"global-secret-0123456789", " "},
+	})
+
+	support, _ := repo.GetByName(ctx, "support")
+	if !queue.HasAudience(ctx, support) {
+		t.Fatalf("expected global webhook to count as audience for every instance")
+	}
+	shopInst, _ := repo.GetByName(ctx, "shop")
+	for _, inst := range []*instance.Instance{shopInst, support} {
+		for _, event := range []string{"messages.upsert", "presence.update"} {
+			if _, err := queue.Dispatch(ctx, inst, event, map[string]any{}); err != nil {
+				t.Fatalf("dispatch %s/%s: %v", inst.Name, event, err)
+			}
+		}
+	}
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go queue.Run(runCtx)
+
+	// O filtro do global deixa passar só messages.upsert; o webhook da instância recebe tudo.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		done := len(hits["global"]) == 2 && len(hits["shop"]) == 2
+		mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			mu.Lock()
+			t.Fatalf("unexpected deliveries: global=%d shop=%d", len(hits["global"]), len(hits["shop"]))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(hits["global"]) != 2 {
+		t.Fatalf("expected filtered global deliveries, got %d", len(hits["global"]))
+	}
+	instances := map[string]bool{}
+	for _, h := range hits["global"] {
+		instances[h.instance] = true
+		if h.event != "messages.upsert" {
+			t.Fatalf("global webhook received filtered event %q", h.event)
+		}
+		if got := h.header.Get("Authorization"); got != "Bearer global-token" {
+			t.Fatalf("expected bearer token on global delivery, got %q", got)
+		}
+		if h.header.Get(webhooksig.HeaderSignature) == "" {
+			t.Fatalf("expected global delivery to be signed")
+		}
+	}
+	if !instances["shop"] || !instances["support"] {
+		t.Fatalf("expected global deliveries from both instances, got %v", instances)
+	}
+	for _, h := range hits["shop"] {
+		if h.header.Get("Authorization") != "" {
+			t.Fatalf("global token leaked to instance webhook")
+		}
+	}
+
+	// O webhook global não aparece nem é acionável pelas rotas da instância.
+	deliveries := services.NewWebhookDeliveryService(repo, deliveryLog, queue)
+	for _, name := range []string{"shop", "support"} {
+		records, err := deliveries.List(ctx, name, webhook.DeliveryFilter{})
+		if err != nil {
+			t.Fatalf("list %s: %v", name, err)
+		}
+		for _, rec := range records {
+			if rec.SubscriptionID == webhook.GlobalSubscriptionID || rec.URL == global.URL {
+				t.Fatalf("global delivery exposed in %s log: %+v", name, rec)
+			}
+		}
+		if _, err := deliveries.SendTest(ctx, name, webhook.GlobalSubscriptionID); !errors.Is(err, services.ErrWebhookTargetUnavailable) {
+			t.Fatalf("expected global test to be refused for %s, got %v", name, err)
+		}
+	}
+
+	// Entregas globais gravadas no log antes da mudança também ficam ocultas.
+	legacy := &webhook.Delivery{ID: "legacy-global", InstanceID: string(shopInst.ID), InstanceName: "shop", SubscriptionID: webhook.GlobalSubscriptionID, Event: "messages.upsert", URL: global.URL, Payload: []byte(`{}`), CreatedAt: time.Now().UTC()}
+	if err := deliveryLog.Record(ctx, legacy); err != nil {
+		t.Fatalf("record legacy: %v", err)
+	}
+	if records, _ := deliveries.List(ctx, "shop", webhook.DeliveryFilter{SubscriptionID: webhook.GlobalSubscriptionID}); len(records) != 0 {
+		t.Fatalf("expected no global records, got %d", len(records))
+	}
+	if _, err := deliveries.Get(ctx, "shop", legacy.ID); !errors.Is(err, repositories.ErrDeliveryRecordNotFound) {
+		t.Fatalf("expected legacy global delivery hidden, got %v", err)
+	}
+	if _, err := deliveries.Replay(ctx, "shop", legacy.ID); !errors.Is(err, repositories.ErrDeliveryRecordNotFound) {
+		t.Fatalf("expected legacy global replay refused, got %v", err)
+	}
+}
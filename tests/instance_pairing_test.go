@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/database"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/logger"
+	"go.mau.fi/whatsmeow"
+)
+
+func TestPairClientType(t *testing.T) {
+	cases := map[string]whatsmeow.PairClientType{
+		"":        whatsmeow.PairClientChrome,
+		"Firefox": whatsmeow.PairClientFirefox,
+		" edge ":  whatsmeow.PairClientEdge,
+		"other":   whatsmeow.PairClientOtherWebClient,
+	}
+	for name, want := range cases {
+		got, err := whatsapp.PairClientType(name)
+		if err != nil || got != want {
+			t.Fatalf("PairClientType(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := whatsapp.PairClientType("netscape"); !errors.Is(err, whatsapp.ErrInvalidPairClient) {
+		t.Fatalf("expected ErrInvalidPairClient, got %v", err)
+	}
+}
+
+func TestInstancePairingSettingsPersisted(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	repo, err := repositories.NewSQLiteInstanceRepo(db)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	svc := services.NewInstanceService(repo, whatsapp.NewManager(logger.InitForTests().App), nil)
+
+	bad := &instance.InstancePairing{ClientType: "netscape"}
+	if _, err := svc.Create(ctx, instance.CreateInstanceInput{InstanceName: "bad", Pairing: bad}); !errors.Is(err, whatsapp.ErrInvalidPairClient) {
+		t.Fatalf("expected invalid client type on create, got %v", err)
+	}
+
+	pairing := &instance.InstancePairing{ClientType: "Safari", DisplayName: "Safari (Mac OS)"}
+	if _, err := svc.Create(ctx, instance.CreateInstanceInput{InstanceName: "pair", Number: "5511999999999", Pairing: pairing}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	stored, err := repo.GetByName(ctx, "pair")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if stored.Pairing.ClientType != "safari" || stored.Pairing.DisplayName != "Safari (Mac OS)" {
+		t.Fatalf("unexpected pairing %+v", stored.Pairing)
+	}
+
+	update := &instance.InstancePairing{ClientType: "edge", DisplayName: "Edge (Windows)"}
+	if _, err := svc.SetSettings(ctx, "pair", instance.SetSettingsInput{MsgCall: "busy", Pairing: update}); err != nil {
+		t.Fatalf("set settings: %v", err)
+	}
+	stored, _ = repo.GetByName(ctx, "pair")
+	if stored.Pairing != *update {
+		t.Fatalf("expected pairing updated, got %+v", stored.Pairing)
+	}
+
+	// Sem client conectado o pareamento falha antes de chamar o WhatsApp.
+	if _, err := svc.GeneratePairingCode(ctx, "pair", ""); !errors.Is(err, whatsapp.ErrClientUnavailable) && !errors.Is(err, whatsapp.ErrNotFound) {
+		t.Fatalf("expected client unavailable, got %v", err)
+	}
+}
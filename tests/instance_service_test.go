@@ -46,7 +46,7 @@ func TestCreateInstance(t *testing.T) {
 	if inst.WebhookURL != in.Webhook.URL {
 		t.Fatalf("expected legacy webhook url propagated")
 	}
-	list, _ := svc.List(ctx)
+	list, _ := svc.List(ctx, instance.ListFilter{})
 	if len(list) != 1 {
 		t.Fatalf("expected 1 instance, got %d", len(list))
 	}
@@ -79,3 +79,52 @@ func TestCreateInstanceFlatSettings(t *testing.T) {
 }
 
 func boolPtr(v bool) *bool { return &v }
+
+// fullUpdateCounter conta os Update completos feitos na linha da instância.
+type fullUpdateCounter struct {
+	repositories.InstanceRepository
+	updates int
+}
+
+func (r *fullUpdateCounter) Update(ctx context.Context, inst *instance.Instance) error {
+	r.updates++
+	return r.InstanceRepository.Update(ctx, inst)
+}
+
+func TestListPersistsOnlyConnectionStatus(t *testing.T) {
+	ctx := context.Background()
+	repo := &fullUpdateCounter{InstanceRepository: repositories.NewInMemoryInstanceRepo()}
+	waMgr := whatsapp.NewManager(logger.InitForTests().App)
+	svc := services.NewInstanceService(repo, waMgr, nil)
+	created, err := svc.Create(ctx, instance.CreateInstanceInput{InstanceName: "shop"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// Status gravado diverge do estado real da sessão, que não existe mais.
+	waMgr.Delete("shop")
+	if err := repo.UpdateStatus(ctx, "shop", instance.ConnectionStatus{Status: "open"}); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	repo.updates = 0
+
+	list, err := svc.List(ctx, instance.ListFilter{})
+	if err != nil || len(list) != 1 {
+		t.Fatalf("list: %v (%d items)", err, len(list))
+	}
+	stored, _ := repo.GetByName(ctx, "shop")
+	if stored.Status != list[0].ConnectionStatus {
+		t.Fatalf("expected status %q persisted, got %q", list[0].ConnectionStatus, stored.Status)
+	}
+	if err := repo.UpdateStatus(ctx, "shop", instance.ConnectionStatus{Status: "open"}); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	if _, err := svc.GetByID(ctx, string(created.ID)); err != nil {
+		t.Fatalf("get by id: %v", err)
+	}
+	if stored, _ = repo.GetByName(ctx, "shop"); stored.Status == "open" {
+		t.Fatalf("expected GetByID to persist the current status")
+	}
+	if repo.updates != 0 {
+		t.Fatalf("expected status-only writes, got %d full updates", repo.updates)
+	}
+}
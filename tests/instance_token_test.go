@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/apitoken"
+	"github.com/faeln1/go-whatsapp-api/pkg/logger"
+)
+
+func TestAPITokenCredential(t *testing.T) {
+	cred, err := apitoken.New("secret-token")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if strings.Contains(cred.Hash, "secret-token") || !apitoken.IsHash(cred.Hash) {
+		t.Fatalf("unexpected hash format %q", cred.Hash)
+	}
+	other, _ := apitoken.New("secret-token")
+	if other.Hash == cred.Hash {
+		t.Fatalf("expected distinct salts")
+	}
+	if other.Lookup != cred.Lookup {
+		t.Fatalf("expected stable lookup")
+	}
+	now := time.Now()
+	if !cred.Verify("secret-token", now) || cred.Verify("wrong", now) {
+		t.Fatalf("verify mismatch")
+	}
+	cred.ExpiresAt = now.Add(-time.Second)
+	if cred.Verify("secret-token", now) {
+		t.Fatalf("expected expired credential to be rejected")
+	}
+}
+
+func TestRotateTokenWithGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewInMemoryInstanceRepo()
+	waMgr := whatsapp.NewManager(logger.New("DEBUG").App)
+	svc := services.NewInstanceService(repo, waMgr, nil)
+
+	inst, err := svc.Create(ctx, instance.CreateInstanceInput{InstanceName: "rot", Token: "old-token"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if inst.Token != "old-token" {
+		t.Fatalf("expected plaintext token in create response")
+	}
+	stored, _ := repo.GetByName(ctx, "rot")
+	if stored.Token != "" || !apitoken.IsHash(stored.TokenHash) {
+		t.Fatalf("expected only hash persisted")
+	}
+	if _, err := svc.Create(ctx, instance.CreateInstanceInput{InstanceName: "dup", Token: "old-token"}); !errors.Is(err, repositories.ErrTokenAlreadyExists) {
+		t.Fatalf("expected ErrTokenAlreadyExists, got %v", err)
+	}
+
+	if _, err := svc.RotateToken(ctx, "rot", instance.RotateTokenInput{GracePeriodSeconds: -1}); !errors.Is(err, services.ErrInvalidGracePeriod) {
+		t.Fatalf("expected ErrInvalidGracePeriod, got %v", err)
+	}
+	resp, err := svc.RotateToken(ctx, "rot", instance.RotateTokenInput{GracePeriodSeconds: 60})
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if resp.Token == "" || resp.Token == "old-token" || resp.PreviousTokenExpiresAt == nil {
+		t.Fatalf("unexpected rotate response %+v", resp)
+	}
+	for _, token := range []string{resp.Token, "old-token"} {
+		if sess, ok := waMgr.ValidateToken(token); !ok || sess.Name != "rot" {
+			t.Fatalf("expected %q to be accepted", token)
+		}
+	}
+
+	// Sem carência o token anterior deixa de valer imediatamente.
+	next, err := svc.RotateToken(ctx, "rot", instance.RotateTokenInput{Token: "new-token"})
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if next.Token != "new-token" {
+		t.Fatalf("expected custom token")
+	}
+	for _, token := range []string{resp.Token, "old-token"} {
+		if _, ok := waMgr.ValidateToken(token); ok {
+			t.Fatalf("expected %q to be rejected", token)
+		}
+	}
+	if _, ok := waMgr.ValidateToken("new-token"); !ok {
+		t.Fatalf("expected new token to be accepted")
+	}
+}
+
+func TestHashLegacyTokens(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewInMemoryInstanceRepo()
+	if err := repo.Create(ctx, &instance.Instance{ID: "id-1", Name: "legacy", TokenHash: "plain-token"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	converted, err := services.HashLegacyTokens(ctx, repo)
+	if err != nil || converted != 1 {
+		t.Fatalf("expected 1 converted, got %d (%v)", converted, err)
+	}
+	inst, _ := repo.GetByName(ctx, "legacy")
+	creds := services.InstanceCredentials(inst)
+	if len(creds) != 1 || !creds[0].Verify("plain-token", time.Now()) {
+		t.Fatalf("expected legacy token to verify after hashing")
+	}
+	if converted, _ := services.HashLegacyTokens(ctx, repo); converted != 0 {
+		t.Fatalf("expected idempotent conversion")
+	}
+}
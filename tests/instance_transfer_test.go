@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/usage"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/archive"
+	"github.com/faeln1/go-whatsapp-api/pkg/logger"
+	"go.mau.fi/whatsmeow/proto/waAdv"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+func TestArchiveSealOpen(t *testing.T) {
+	sealed, err := archive.Seal("correct horse", []byte(`{"hello":"world"}`))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	payload, err := archive.Open("correct horse", sealed)
+	if err != nil || string(payload) != `{"hello":"world"}` {
+		t.Fatalf("open: %v %q", err, payload)
+	}
+	if _, err := archive.Open("wrong passphrase", sealed); !errors.Is(err, archive.ErrDecrypt) {
+		t.Fatalf("expected ErrDecrypt, got %v", err)
+	}
+	if _, err := archive.Seal("short", nil); !errors.Is(err, archive.ErrPassphraseTooShort) {
+		t.Fatalf("expected ErrPassphraseTooShort, got %v", err)
+	}
+}
+
+func TestStoreFactoryDeviceExportImport(t *testing.T) {
+	ctx := context.Background()
+	log := waLog.Noop
+
+	source := whatsapp.NewStoreFactory(t.TempDir(), log)
+	if _, err := source.ExportDevice(ctx, "alpha"); !errors.Is(err, whatsapp.ErrDeviceNotPaired) {
+		t.Fatalf("expected ErrDeviceNotPaired, got %v", err)
+	}
+
+	container, err := source.NewDeviceStore(ctx, "alpha")
+	if err != nil {
+		t.Fatalf("device store: %v", err)
+	}
+	device := container.NewDevice()
+	jid := types.NewADJID("5511999999999", 0, 7)
+	device.ID = &jid
+	device.PushName = "Alpha"
+	device.AdvSecretKey = make([]byte, 32)
+	device.Account = &waAdv.ADVSignedDeviceIdentity{
+		Details:             []byte{1},
+		AccountSignature:    make([]byte, 64),
+		AccountSignatureKey: make([]byte, 32),
+		DeviceSignature:     make([]byte, 64),
+	}
+	if err := container.PutDevice(ctx, device); err != nil {
+		t.Fatalf("put device: %v", err)
+	}
+	if _, _, err := device.Contacts.PutPushName(ctx, types.NewJID("5511888888888", types.DefaultUserServer), "Bob"); err != nil {
+		t.Fatalf("put contact: %v", err)
+	}
+	_ = container.Close()
+
+	snapshot, err := source.ExportDevice(ctx, "alpha")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if snapshot.JID != jid.String() {
+		t.Fatalf("unexpected jid %s", snapshot.JID)
+	}
+
+	target := whatsapp.NewStoreFactory(t.TempDir(), log)
+	if err := target.ImportDevice(ctx, "beta", snapshot); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if err := target.ImportDevice(ctx, "beta", snapshot); !errors.Is(err, whatsapp.ErrDeviceExists) {
+		t.Fatalf("expected ErrDeviceExists, got %v", err)
+	}
+	restored, err := target.Device(ctx, "beta")
+	if err != nil {
+		t.Fatalf("device: %v", err)
+	}
+	if restored.ID == nil || restored.ID.String() != jid.String() || restored.PushName != "Alpha" {
+		t.Fatalf("unexpected restored device: %+v", restored.ID)
+	}
+	contact, err := restored.Contacts.GetContact(ctx, types.NewJID("5511888888888", types.DefaultUserServer))
+	if err != nil || contact.PushName != "Bob" {
+		t.Fatalf("contact not restored: %v %+v", err, contact)
+	}
+}
+
+// refuseGuard impede a conexão da instância importada, que não tem rede nos testes.
+type refuseGuard struct{}
+
+func (refuseGuard) Claim(ctx context.Context, instanceName string) error {
+	return services.ErrInstanceOwnedElsewhere
+}
+
+type transferEnv struct {
+	repo     repositories.InstanceRepository
+	subs     repositories.WebhookSubscriptionRepository
+	sinks    repositories.EventSinkRepository
+	usage    repositories.UsageRepository
+	stores   *whatsapp.StoreFactory
+	svc      services.InstanceService
+	transfer services.InstanceTransferService
+}
+
+func newTransferEnv(t *testing.T) *transferEnv {
+	t.Helper()
+	env := &transferEnv{
+		repo:   repositories.NewInMemoryInstanceRepo(),
+		subs:   repositories.NewInMemoryWebhookSubscriptionRepo(),
+		sinks:  repositories.NewInMemoryEventSinkRepo(),
+		usage:  repositories.NewInMemoryUsageRepo(),
+		stores: whatsapp.NewStoreFactory(t.TempDir(), waLog.Noop),
+	}
+	t.Cleanup(func() { env.stores.Close() })
+	waMgr := whatsapp.NewManager(logger.InitForTests().App)
+	env.svc = services.NewInstanceService(env.repo, waMgr, nil)
+	bootstrap := services.NewSessionBootstrap(env.stores, waMgr, waLog.Noop, nil, nil)
+	bootstrap.Guard = refuseGuard{}
+	env.transfer = services.NewInstanceTransferService(env.repo, env.svc, waMgr, bootstrap, nil)
+	env.transfer.SetWebhookSubscriptions(env.subs)
+	env.transfer.SetEventSinks(env.sinks)
+	env.transfer.SetUsage(env.usage)
+	return env
+}
+
+func TestInstanceTransferCarriesSubscriptionsSinksAndQuota(t *testing.T) {
+	ctx := context.Background()
+	source := newTransferEnv(t)
+	inst, err := source.svc.Create(ctx, instance.CreateInstanceInput{InstanceName: "shop"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	container, err := source.stores.NewDeviceStore(ctx, "shop")
+	if err != nil {
+		t.Fatalf("device store: %v", err)
+	}
+	device := container.NewDevice()
+	jid := types.NewADJID("5511999999999", 0, 7)
+	device.ID = &jid
+	device.AdvSecretKey = make([]byte, 32)
+	device.Account = &waAdv.ADVSignedDeviceIdentity{Details: []byte{1}, AccountSignature: make([]byte, 64), AccountSignatureKey: make([]byte, 32), DeviceSignature: make([]byte, 64)}
+	if err := container.PutDevice(ctx, device); err != nil {
+		t.Fatalf("put device: %v", err)
+	}
+
+	sourceID := string(inst.ID)
+	sub := &webhook.Subscription{ID: "sub-1", InstanceID: sourceID, Name: "crm", URL: "https://crm.example.com/hook", Events: []string{"messages.upsert"}, Headers: map[string]string{"X-Key": "k"}, Enabled: true, Signed: true, Secret: "crm-secret-0123456789"}
+	if err := source.subs.Create(ctx, sub); err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	if err := source.sinks.Save(ctx, &webhook.SinkConfig{InstanceID: sourceID, Sink: "redis", Enabled: true, Events: []string{"messages.upsert"}}); err != nil {
+		t.Fatalf("save sink: %v", err)
+	}
+	if err := source.usage.SetQuota(ctx, sourceID, usage.Quota{Daily: 50, Monthly: 900}); err != nil {
+		t.Fatalf("set quota: %v", err)
+	}
+	exported, err := source.transfer.Export(ctx, "shop", instance.ExportInstanceInput{Passphrase: "correct horse battery"})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	for _, name := range []string{"", "shop-copy"} {
+		target := newTransferEnv(t)
+		imported, err := target.transfer.Import(ctx, instance.ImportInstanceInput{Archive: exported.Archive, Passphrase: "correct horse battery", InstanceName: name})
+		if err != nil {
+			t.Fatalf("import %q: %v", name, err)
+		}
+		renamed := name != ""
+		if (imported.InstanceID != sourceID) != renamed {
+			t.Fatalf("import %q: unexpected instance id %s", name, imported.InstanceID)
+		}
+		subs, _ := target.subs.List(ctx, imported.InstanceID)
+		if len(subs) != 1 {
+			t.Fatalf("import %q: expected the subscription recreated, got %d", name, len(subs))
+		}
+		got := subs[0]
+		if got.URL != sub.URL || got.Secret != sub.Secret || !got.Signed || got.Headers["X-Key"] != "k" || len(got.Events) != 1 {
+			t.Fatalf("import %q: unexpected subscription %+v", name, got)
+		}
+		// Renomeada, a instância ganha novo ID e as assinaturas também.
+		if (got.ID != sub.ID) != renamed {
+			t.Fatalf("import %q: unexpected subscription id %s", name, got.ID)
+		}
+		sink, err := target.sinks.Get(ctx, imported.InstanceID, "redis")
+		if err != nil || !sink.Enabled || len(sink.Events) != 1 {
+			t.Fatalf("import %q: sink config not restored: %v %+v", name, err, sink)
+		}
+		if quota, err := target.usage.GetQuota(ctx, imported.InstanceID); err != nil || quota.Daily != 50 || quota.Monthly != 900 {
+			t.Fatalf("import %q: quota not restored: %v %+v", name, err, quota)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	httpPlatform "github.com/faeln1/go-whatsapp-api/internal/platform/http"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/logger"
+)
+
+type deviceRenames [][2]string
+
+func (d *deviceRenames) RenameDevice(_ context.Context, oldName, newName string) error {
+	*d = append(*d, [2]string{oldName, newName})
+	return nil
+}
+
+func TestUpdateInstanceFieldsAndFilters(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewInMemoryInstanceRepo()
+	svc := services.NewInstanceService(repo, whatsapp.NewManager(logger.InitForTests().App), nil)
+
+	if _, err := svc.Create(ctx, instance.CreateInstanceInput{
+		InstanceName: "sales",
+		Tags:         []string{"crm", " crm ", "br"},
+		Metadata:     map[string]any{"customerId": "c-1", "team": "a"},
+	}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := svc.Create(ctx, instance.CreateInstanceInput{InstanceName: "support", Tags: []string{"crm"}}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	number, tags := "5511999999999", []string{"br", "vip"}
+	updated, err := svc.Update(ctx, "sales", instance.UpdateInstanceInput{
+		Number:   &number,
+		Tags:     &tags,
+		Metadata: map[string]any{"team": nil, "tier": "gold"},
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Number != number || len(updated.Tags) != 2 {
+		t.Fatalf("unexpected update result %+v", updated)
+	}
+	if _, ok := updated.Metadata["team"]; ok || updated.Metadata["customerId"] != "c-1" || updated.Metadata["tier"] != "gold" {
+		t.Fatalf("expected metadata merged, got %+v", updated.Metadata)
+	}
+	if _, err := svc.Update(ctx, "missing", instance.UpdateInstanceInput{}); !errors.Is(err, repositories.ErrInstanceNotFound) {
+		t.Fatalf("expected ErrInstanceNotFound, got %v", err)
+	}
+
+	list, _ := svc.List(ctx, instance.ListFilter{Tags: []string{"vip", "br"}})
+	if len(list) != 1 || list[0].Name != "sales" || list[0].Metadata["tier"] != "gold" {
+		t.Fatalf("expected only sales tagged vip+br, got %d", len(list))
+	}
+	if list, _ := svc.List(ctx, instance.ListFilter{Tags: []string{"crm"}}); len(list) != 1 || list[0].Name != "support" {
+		t.Fatalf("expected only support tagged crm")
+	}
+	if list, _ := svc.List(ctx, instance.ListFilter{Status: "open"}); len(list) != 0 {
+		t.Fatalf("expected no open instances, got %d", len(list))
+	}
+	if list, _ := svc.List(ctx, instance.ListFilter{OwnerJID: "5511999999999"}); len(list) != 0 {
+		t.Fatalf("expected no paired instances, got %d", len(list))
+	}
+}
+
+func TestRenameInstance(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewInMemoryInstanceRepo()
+	waMgr := whatsapp.NewManager(logger.InitForTests().App)
+	svc := services.NewInstanceService(repo, waMgr, nil)
+	devices := &deviceRenames{}
+	svc.SetDeviceStore(devices)
+
+	for _, name := range []string{"old", "taken"} {
+		if _, err := svc.Create(ctx, instance.CreateInstanceInput{InstanceName: name, Token: name + "-token"}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	taken := "taken"
+	if _, err := svc.Update(ctx, "old", instance.UpdateInstanceInput{InstanceName: &taken}); !errors.Is(err, repositories.ErrInstanceAlreadyExists) {
+		t.Fatalf("expected ErrInstanceAlreadyExists, got %v", err)
+	}
+	// O nome vira caminho do device store ({DATA_DIR}/{nome}.db): nada de escapar do diretório.
+	for _, bad := range []string{"../x", "a/b", `a\b`, ".hidden", "tab\tname"} {
+		bad := bad
+		if _, err := svc.Update(ctx, "old", instance.UpdateInstanceInput{InstanceName: &bad}); !errors.Is(err, services.ErrInvalidInstanceName) {
+			t.Fatalf("%q: expected ErrInvalidInstanceName, got %v", bad, err)
+		}
+		if _, err := svc.Create(ctx, instance.CreateInstanceInput{InstanceName: bad}); !errors.Is(err, services.ErrInvalidInstanceName) {
+			t.Fatalf("%q: expected ErrInvalidInstanceName on create, got %v", bad, err)
+		}
+	}
+	if len(*devices) != 0 {
+		t.Fatalf("expected no device store moved for invalid names, got %+v", *devices)
+	}
+
+	newName := "new"
+	inst, err := svc.Update(ctx, "old", instance.UpdateInstanceInput{InstanceName: &newName})
+	if err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if inst.Name != "new" || len(*devices) != 1 || (*devices)[0] != [2]string{"old", "new"} {
+		t.Fatalf("expected device store moved, got %+v", *devices)
+	}
+	if _, err := repo.GetByName(ctx, "old"); !errors.Is(err, repositories.ErrInstanceNotFound) {
+		t.Fatalf("expected old name removed from repository")
+	}
+	if stored, err := repo.GetByName(ctx, "new"); err != nil || stored.ID != inst.ID {
+		t.Fatalf("expected instance under new name, got %v", err)
+	}
+	if _, ok := waMgr.Get("old"); ok {
+		t.Fatalf("expected session moved out of old name")
+	}
+	if sess, ok := waMgr.ValidateToken("old-token"); !ok || sess.Name != "new" {
+		t.Fatalf("expected token to keep working under the new name")
+	}
+}
+
+func TestLifecycleDrain(t *testing.T) {
+	lifecycle := services.NewLifecycle()
+	release := make(chan struct{})
+	lifecycle.Go(func() { <-release })
+	if lifecycle.Pending() != 1 {
+		t.Fatalf("expected one pending task")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := lifecycle.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected wait to time out, got %v", err)
+	}
+	close(release)
+	if err := lifecycle.Wait(context.Background()); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+
+	loggers := logger.InitForTests()
+	srv := httptest.NewServer(httpPlatform.NewRouter(httpPlatform.RouterConfig{
+		WAManager:   whatsapp.NewManager(loggers.App),
+		Logger:      loggers.HTTP,
+		MasterToken: "master",
+		Lifecycle:   lifecycle,
+	}))
+	defer srv.Close()
+
+	lifecycle.BeginDrain()
+	resp, err := http.Post(srv.URL+"/message/sendText/any", "application/json", nil)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("expected 503 while draining, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/controllers"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	httpPlatform "github.com/faeln1/go-whatsapp-api/internal/platform/http"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/logger"
+	"github.com/gorilla/websocket"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+type recordingQRListener struct{ codes chan string }
+
+func (l *recordingQRListener) HandleQRCode(_ context.Context, _ string, code, _ string) {
+	l.codes <- code
+}
+
+func newLoginStreamServer(t *testing.T) (*services.LoginStreams, *httptest.Server) {
+	t.Helper()
+	loggers := logger.InitForTests()
+	waMgr := whatsapp.NewManager(loggers.App)
+	if _, err := waMgr.Create(context.Background(), "live"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	streams := services.NewLoginStreams(waMgr, nil, nil, loggers.App)
+	router := httpPlatform.NewRouter(httpPlatform.RouterConfig{
+		LoginStreamCtrl: controllers.NewLoginStreamController(streams),
+		WAManager:       waMgr,
+		Logger:          loggers.HTTP,
+		MasterToken:     "master",
+	})
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return streams, srv
+}
+
+// publishAfterSubscribe publica depois que o handler teve tempo de assinar o stream.
+func publishAfterSubscribe(publish func()) {
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		publish()
+	}()
+}
+
+func TestLoginStreamsForwardAndPublish(t *testing.T) {
+	waMgr := whatsapp.NewManager(logger.InitForTests().App)
+	if _, err := waMgr.Create(context.Background(), "live"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	inner := &recordingQRListener{codes: make(chan string, 1)}
+	streams := services.NewLoginStreams(waMgr, inner, nil, nil)
+	frames, cancel := streams.Subscribe("live")
+	defer cancel()
+
+	streams.HandleQRCode(context.Background(), "live", "2@abc", "ABCD1234")
+	if got := <-inner.codes; got != "2@abc" {
+		t.Fatalf("expected QR forwarded to inner listener, got %q", got)
+	}
+	frame := <-frames
+	if frame.Event != services.LoginEventQRCode || frame.PairingCode != "ABCD1234" || !strings.HasPrefix(frame.Base64, "data:image/png;base64,") {
+		t.Fatalf("unexpected qr frame %+v", frame)
+	}
+
+	streams.HandleConnectionEvent(context.Background(), "live", &events.Connected{})
+	if frame := <-frames; frame.Event != services.LoginEventConnection || frame.State != "open" {
+		t.Fatalf("unexpected connection frame %+v", frame)
+	}
+	if frame := <-frames; !frame.Final() {
+		t.Fatalf("expected final success frame, got %+v", frame)
+	}
+
+	snapshot, ok := streams.Snapshot("live")
+	if !ok || len(snapshot) != 1 || snapshot[0].State != "close" {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+	if _, ok := streams.Snapshot("missing"); ok {
+		t.Fatalf("expected unknown instance to have no snapshot")
+	}
+}
+
+func TestLoginStreamSSE(t *testing.T) {
+	streams, srv := newLoginStreamServer(t)
+
+	resp, err := http.Get(srv.URL + "/instances/missing/qr/stream?apikey=master")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown instance, got %d", resp.StatusCode)
+	}
+	resp, err = http.Get(srv.URL + "/instances/live/qr/stream")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", resp.StatusCode)
+	}
+
+	publishAfterSubscribe(func() {
+		streams.HandleQRCode(context.Background(), "live", "2@abc", "")
+		streams.HandleConnectionEvent(context.Background(), "live", &events.Connected{})
+	})
+	resp, err = http.Get(srv.URL + "/instances/live/qr/stream?apikey=master")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	var names []string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		if name, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+			names = append(names, name)
+		}
+	}
+	// O servidor encerra o stream após o success.
+	want := []string{"connection", "qrcode", "connection", "success"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected events %v, got %v", want, names)
+	}
+}
+
+func TestLoginStreamWebSocket(t *testing.T) {
+	streams, srv := newLoginStreamServer(t)
+
+	publishAfterSubscribe(func() {
+		streams.HandleConnectionEvent(context.Background(), "live", &events.Connected{})
+	})
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/instances/live/qr/ws?apikey=master"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	var got []string
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				t.Fatalf("expected normal closure, got %v", err)
+			}
+			break
+		}
+		var frame services.LoginEvent
+		if err := json.Unmarshal(data, &frame); err != nil {
+			t.Fatalf("decode frame: %v", err)
+		}
+		got = append(got, frame.Event)
+	}
+	if strings.Join(got, ",") != "connection,connection,success" {
+		t.Fatalf("unexpected frames %v", got)
+	}
+}
@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/logger"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// pairedOfflineSession registra uma sessão com device "pareado" mas sem conexão, de modo que
+// o keeper fique em waiting sem enviar presença.
+func pairedOfflineSession(t *testing.T, waMgr *whatsapp.Manager, stores *whatsapp.StoreFactory, name string) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := waMgr.Create(ctx, name); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	device, err := stores.Device(ctx, name)
+	if err != nil {
+		t.Fatalf("device: %v", err)
+	}
+	jid := types.NewJID("5511999990000", types.DefaultUserServer)
+	device.ID = &jid
+	if err := waMgr.AttachClient(name, device, whatsmeow.NewClient(device, waLog.Noop), nil); err != nil {
+		t.Fatalf("attach client: %v", err)
+	}
+}
+
+func waitPresence(t *testing.T, waMgr *whatsapp.Manager, name, status, reason string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		state, _ := waMgr.GetPresenceState(name)
+		if state.Status == status && state.Reason == reason {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: expected presence %s/%q, got %s/%q", name, status, reason, state.Status, state.Reason)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPresenceKeeperStartStop(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewInMemoryInstanceRepo()
+	waMgr := whatsapp.NewManager(logger.InitForTests().App)
+	stores := whatsapp.NewStoreFactory(t.TempDir(), waLog.Noop)
+	defer stores.Close()
+	svc := services.NewInstanceService(repo, waMgr, nil)
+	for _, name := range []string{"online", "offline"} {
+		if _, err := svc.Create(ctx, instance.CreateInstanceInput{InstanceName: name, Settings: &instance.InstanceSettings{AlwaysOnline: name == "online"}}); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		waMgr.Delete(name)
+		pairedOfflineSession(t, waMgr, stores, name)
+	}
+	keeper := services.NewPresenceKeeper(repo, waMgr, 10*time.Millisecond, nil)
+
+	// Sem alwaysOnline nada é iniciado; com ele o loop fica aguardando a conexão.
+	keeper.Apply(ctx, "offline")
+	if keeper.Stop("offline", "test") {
+		t.Fatalf("expected no keeper for an instance without alwaysOnline")
+	}
+	keeper.Apply(ctx, "online")
+	waitPresence(t, waMgr, "online", "waiting", "")
+
+	// Desligar o alwaysOnline encerra o loop no Apply.
+	inst, _ := repo.GetByName(ctx, "online")
+	inst.Settings.AlwaysOnline = false
+	if err := repo.Update(ctx, inst); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	keeper.Apply(ctx, "online")
+	waitPresence(t, waMgr, "online", "stopped", "disabled")
+	if keeper.Stop("online", "test") {
+		t.Fatalf("expected keeper already stopped")
+	}
+
+	inst.Settings.AlwaysOnline = true
+	_ = repo.Update(ctx, inst)
+	keeper.Apply(ctx, "online")
+	waitPresence(t, waMgr, "online", "waiting", "")
+	if !keeper.Stop("online", "logged_out") {
+		t.Fatalf("expected Stop to end a running keeper")
+	}
+	waitPresence(t, waMgr, "online", "stopped", "logged_out")
+}
+
+func TestPresenceKeeperStopsOnDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewInMemoryInstanceRepo()
+	waMgr := whatsapp.NewManager(logger.InitForTests().App)
+	stores := whatsapp.NewStoreFactory(t.TempDir(), waLog.Noop)
+	defer stores.Close()
+	svc := services.NewInstanceService(repo, waMgr, nil)
+	if _, err := svc.Create(ctx, instance.CreateInstanceInput{InstanceName: "shop", Settings: &instance.InstanceSettings{AlwaysOnline: true}}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	waMgr.Delete("shop")
+	pairedOfflineSession(t, waMgr, stores, "shop")
+	keeper := services.NewPresenceKeeper(repo, waMgr, 10*time.Millisecond, nil)
+	keeper.Apply(ctx, "shop")
+	waitPresence(t, waMgr, "shop", "waiting", "")
+
+	// Instância removida do repositório: o próximo tick encerra o loop.
+	if err := repo.Delete(ctx, "shop"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	waitPresence(t, waMgr, "shop", "stopped", "instance_removed")
+	if keeper.Stop("shop", "test") {
+		t.Fatalf("expected keeper gone after the instance was removed")
+	}
+}
+
+func TestPresenceKeeperFollowsRename(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewInMemoryInstanceRepo()
+	waMgr := whatsapp.NewManager(logger.InitForTests().App)
+	stores := whatsapp.NewStoreFactory(t.TempDir(), waLog.Noop)
+	defer stores.Close()
+	svc := services.NewInstanceService(repo, waMgr, nil)
+	keeper := services.NewPresenceKeeper(repo, waMgr, 10*time.Millisecond, nil)
+	svc.AddRenameListener(keeper)
+	if _, err := svc.Create(ctx, instance.CreateInstanceInput{InstanceName: "old", Settings: &instance.InstanceSettings{AlwaysOnline: true}}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	waMgr.Delete("old")
+	pairedOfflineSession(t, waMgr, stores, "old")
+	keeper.Apply(ctx, "old")
+	waitPresence(t, waMgr, "old", "waiting", "")
+
+	newName := "new"
+	if _, err := svc.Update(ctx, "old", instance.UpdateInstanceInput{InstanceName: &newName}); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	// O loop passa para o novo nome; nenhum loop fica preso ao nome antigo.
+	waitPresence(t, waMgr, "new", "waiting", "")
+	if keeper.Stop("old", "test") {
+		t.Fatalf("expected no keeper left under the old name")
+	}
+	if !keeper.Stop("new", "test") {
+		t.Fatalf("expected keeper running under the new name")
+	}
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/analytics"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/community"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/database"
+)
+
+func TestSQLiteInstanceRepo(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	repo, err := repositories.NewSQLiteInstanceRepo(db)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	inst := &instance.Instance{
+		ID:        "id-1",
+		Name:      "alpha",
+		TokenHash: "tok-1",
+		Status:    "active",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	inst.Settings.RejectCall = true
+	if err := repo.Create(ctx, inst); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	dup := *inst
+	dup.ID = "id-2"
+	dup.TokenHash = "tok-2"
+	if err := repo.Create(ctx, &dup); !errors.Is(err, repositories.ErrInstanceAlreadyExists) {
+		t.Fatalf("expected ErrInstanceAlreadyExists, got %v", err)
+	}
+	dup.Name = "beta"
+	dup.TokenHash = "tok-1"
+	if err := repo.Create(ctx, &dup); !errors.Is(err, repositories.ErrTokenAlreadyExists) {
+		t.Fatalf("expected ErrTokenAlreadyExists, got %v", err)
+	}
+
+	inst.Status = "open"
+	inst.Settings.GroupsIgnore = true
+	if err := repo.Update(ctx, inst); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	// O evento de conexão grava só o status, sem desfazer o que a API alterou.
+	code := 401
+	if err := repo.UpdateStatus(ctx, "alpha", instance.ConnectionStatus{Status: "logged_out", DisconnectionReasonCode: &code, DisconnectionAt: &now, UpdatedAt: now}); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	if err := repo.UpdateStatus(ctx, "missing", instance.ConnectionStatus{Status: "open", UpdatedAt: now}); !errors.Is(err, repositories.ErrInstanceNotFound) {
+		t.Fatalf("expected ErrInstanceNotFound, got %v", err)
+	}
+
+	// Reabre o repositório no mesmo banco, como no restore do boot.
+	repo, err = repositories.NewSQLiteInstanceRepo(db)
+	if err != nil {
+		t.Fatalf("reopen repo: %v", err)
+	}
+	list, err := repo.List(ctx)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("list: %v (%d items)", err, len(list))
+	}
+	got := list[0]
+	if got.Status != "logged_out" || !got.Settings.RejectCall || !got.Settings.GroupsIgnore || !got.CreatedAt.Equal(now) {
+		t.Fatalf("unexpected instance: %+v", got)
+	}
+	if got.DisconnectionReasonCode == nil || *got.DisconnectionReasonCode != 401 || got.DisconnectionAt == nil || !got.DisconnectionAt.Equal(now) {
+		t.Fatalf("disconnection not persisted: %+v", got)
+	}
+
+	if err := repo.Delete(ctx, "alpha"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := repo.GetByName(ctx, "alpha"); !errors.Is(err, repositories.ErrInstanceNotFound) {
+		t.Fatalf("expected ErrInstanceNotFound, got %v", err)
+	}
+}
+
+func TestSQLiteMembershipAndAnalytics(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	members, err := repositories.NewSQLiteCommunityMembershipRepo(db)
+	if err != nil {
+		t.Fatalf("new membership repo: %v", err)
+	}
+	if _, err := members.ReconcileMembers(ctx, "i1", "c@g.us", []community.Member{{JID: "1@s.whatsapp.net"}, {JID: "2@s.whatsapp.net"}}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	snapshot, err := members.ReconcileMembers(ctx, "i1", "c@g.us", []community.Member{{JID: "1@s.whatsapp.net", IsAdmin: true}})
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(snapshot.Active) != 1 || !snapshot.Active[0].IsAdmin || len(snapshot.Former) != 1 || snapshot.Former[0].JID != "2@s.whatsapp.net" {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+
+	repo, err := repositories.NewSQLiteAnalyticsRepository(db)
+	if err != nil {
+		t.Fatalf("new analytics repo: %v", err)
+	}
+	tracking, err := repo.CreateMessageTracking(ctx, analytics.CreateMessageTrackingInput{
+		InstanceID: "i1", MessageID: "m1", RemoteJID: "c@g.us", MessageType: "text", SentAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("create tracking: %v", err)
+	}
+	for _, viewer := range []string{"5511999999999:3@s.whatsapp.net", "5511999999999@s.whatsapp.net", "5511888888888@s.whatsapp.net"} {
+		if _, err := repo.CreateMessageView(ctx, analytics.CreateMessageViewInput{MessageTrackID: tracking.ID, ViewerJID: viewer, ViewedAt: time.Now()}); err != nil {
+			t.Fatalf("create view: %v", err)
+		}
+	}
+	summaries, err := repo.GetInstanceMessageMetrics(ctx, "i1", 10, 0)
+	if err != nil {
+		t.Fatalf("instance metrics: %v", err)
+	}
+	if len(summaries) != 1 || summaries[0].ViewCount != 2 {
+		t.Fatalf("unexpected summaries: %+v", summaries)
+	}
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestReconnectPolicyBackoff(t *testing.T) {
+	policy := whatsapp.ReconnectPolicy{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second, MaxRetries: 5}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, want := range expected {
+		if got := policy.Backoff(i + 1); got != want {
+			t.Fatalf("attempt %d: expected %s, got %s", i+1, want, got)
+		}
+	}
+}
+
+func TestClassifyDisconnect(t *testing.T) {
+	cases := []struct {
+		name  string
+		evt   any
+		state string
+		retry bool
+	}{
+		{"connection lost", &events.Disconnected{}, whatsapp.HealthReconnecting, true},
+		{"stream replaced", &events.StreamReplaced{}, whatsapp.HealthReplaced, false},
+		{"keepalive timeout", &events.KeepAliveTimeout{}, whatsapp.HealthReconnecting, true},
+		{"service unavailable", &events.ConnectFailure{Reason: events.ConnectFailureServiceUnavailable}, whatsapp.HealthReconnecting, true},
+		{"logged out", &events.LoggedOut{Reason: events.ConnectFailureLoggedOut}, whatsapp.HealthLoggedOut, false},
+		{"temporary ban", &events.TemporaryBan{}, whatsapp.HealthBanned, false},
+		{"banned on connect", &events.ConnectFailure{Reason: events.ConnectFailureUnknownLogout}, whatsapp.HealthLoggedOut, false},
+		{"client outdated", &events.ClientOutdated{}, whatsapp.HealthFailed, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, ok := whatsapp.ClassifyDisconnect(tc.evt)
+			if !ok {
+				t.Fatalf("expected event to be classified")
+			}
+			if d.State != tc.state || d.Retry != tc.retry {
+				t.Fatalf("unexpected classification: %+v", d)
+			}
+			if !tc.retry && !whatsapp.IsTerminalHealth(d.State) {
+				t.Fatalf("state %s should be terminal", d.State)
+			}
+		})
+	}
+	if _, ok := whatsapp.ClassifyDisconnect(&events.Connected{}); ok {
+		t.Fatalf("connected must not be classified as a disconnect")
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/usage"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/database"
+)
+
+func TestSendQuotaAndUsage(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	usageRepo, err := repositories.NewSQLiteUsageRepo(db)
+	if err != nil {
+		t.Fatalf("new usage repo: %v", err)
+	}
+	instances := repositories.NewInMemoryInstanceRepo()
+	if err := instances.Create(ctx, &instance.Instance{ID: "id-1", Name: "reseller"}); err != nil {
+		t.Fatalf("create instance: %v", err)
+	}
+	svc := services.NewUsageService(usageRepo, instances, usage.Quota{Daily: 2}, nil, nil)
+
+	for _, kind := range []string{"text", "image"} {
+		done, err := svc.Reserve(ctx, "id-1", "reseller", kind)
+		if err != nil {
+			t.Fatalf("reserve %s: %v", kind, err)
+		}
+		done(true)
+	}
+	_, err = svc.Reserve(ctx, "id-1", "reseller", "text")
+	var quotaErr *services.QuotaExceededError
+	if !errors.As(err, &quotaErr) || !errors.Is(err, services.ErrQuotaExceeded) {
+		t.Fatalf("expected QuotaExceededError, got %v", err)
+	}
+	if quotaErr.Period != usage.PeriodDaily || quotaErr.Limit != 2 || quotaErr.Used != 2 || quotaErr.ResetAt.IsZero() {
+		t.Fatalf("unexpected quota error %+v", quotaErr)
+	}
+
+	// A cota própria sobrepõe a padrão; envios que falham devolvem a reserva.
+	daily := 3
+	if _, err := svc.SetQuota(ctx, "reseller", usage.SetQuotaInput{Daily: &daily}); err != nil {
+		t.Fatalf("set quota: %v", err)
+	}
+	done, err := svc.Reserve(ctx, "id-1", "reseller", "text")
+	if err != nil {
+		t.Fatalf("reserve after raising quota: %v", err)
+	}
+	done(false)
+	done, err = svc.Reserve(ctx, "id-1", "reseller", "text")
+	if err != nil {
+		t.Fatalf("expected failed send to release its reservation: %v", err)
+	}
+	done(true)
+
+	report, err := svc.Usage(ctx, "reseller", 7)
+	if err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	if report.Today != 3 || report.Month != 3 || report.QuotaSource != "instance" || report.RemainingToday == nil || *report.RemainingToday != 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if len(report.History) != 1 || report.History[0].ByType["text"] != 2 || report.History[0].ByType["image"] != 1 {
+		t.Fatalf("unexpected history %+v", report.History)
+	}
+
+	// Consumo e cota seguem o ID: renomear a instância não zera o uso nem perde a cota própria.
+	if err := instances.Rename(ctx, "reseller", "renamed"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if _, err := svc.Reserve(ctx, "", "renamed", "text"); !errors.Is(err, services.ErrQuotaExceeded) {
+		t.Fatalf("expected quota kept after rename, got %v", err)
+	}
+	fresh := services.NewUsageService(usageRepo, instances, usage.Quota{Daily: 2}, nil, nil)
+	if report, err := fresh.Usage(ctx, "renamed", 7); err != nil || report.Today != 3 || report.QuotaSource != "instance" {
+		t.Fatalf("expected usage kept after rename, got %+v (%v)", report, err)
+	}
+	if err := instances.Rename(ctx, "renamed", "reseller"); err != nil {
+		t.Fatalf("rename back: %v", err)
+	}
+
+	if err := svc.ResetQuota(ctx, "reseller"); err != nil {
+		t.Fatalf("reset quota: %v", err)
+	}
+	if report, _ := svc.Usage(ctx, "reseller", 0); report.QuotaSource != "default" || report.Quota.Daily != 2 {
+		t.Fatalf("expected default quota after reset, got %+v", report.Quota)
+	}
+	if _, err := svc.Usage(ctx, "missing", 0); !errors.Is(err, repositories.ErrInstanceNotFound) {
+		t.Fatalf("expected ErrInstanceNotFound, got %v", err)
+	}
+}
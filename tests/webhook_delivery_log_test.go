@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/database"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/logger"
+)
+
+func TestWebhookDeliveryLogAndReplay(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	outbox, err := repositories.NewSQLiteWebhookOutboxRepo(db)
+	if err != nil {
+		t.Fatalf("new outbox repo: %v", err)
+	}
+	deliveryLog, err := repositories.NewSQLiteWebhookDeliveryLogRepo(db)
+	if err != nil {
+		t.Fatalf("new delivery log: %v", err)
+	}
+	repo := repositories.NewInMemoryInstanceRepo()
+	instanceSvc := services.NewInstanceService(repo, whatsapp.NewManager(logger.InitForTests().App), nil)
+	if _, err := instanceSvc.Create(ctx, instance.CreateInstanceInput{InstanceName: "shop"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// O receptor falha enquanto down estiver ativo.
+	var (
+		mu   sync.Mutex
+		down = true
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if down {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer receiver.Close()
+	if _, err := instanceSvc.SetWebhook(ctx, "shop", instance.SetWebhookInput{Enabled: true, URL: receiver.URL, Events: []string{"ALL"}}); err != nil {
+		t.Fatalf("set webhook: %v", err)
+	}
+
+	queue := services.NewWebhookQueue(outbox, nil, services.WebhookQueueOptions{
+		Retry:        services.WebhookRetryPolicy{MaxAttempts: 2, InitialBackoff: 10 * time.Millisecond},
+		Workers:      2,
+		PollInterval: 10 * time.Millisecond,
+	}, nil)
+	queue.SetInstances(repo)
+	queue.SetDeliveryLog(deliveryLog)
+	svc := services.NewWebhookDeliveryService(repo, deliveryLog, queue)
+
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go queue.Run(runCtx)
+
+	inst, _ := repo.GetByName(ctx, "shop")
+	if _, err := queue.Dispatch(ctx, inst, "messages.upsert", map[string]any{"id": "ABC"}); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	failed := waitForDeliveries(t, svc, webhook.DeliveryFilter{Status: webhook.StatusFailed}, 1)[0]
+	rec, err := svc.Get(ctx, "shop", failed.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if rec.Event != "messages.upsert" || rec.PayloadHash != repositories.PayloadHash(rec.Payload) || len(rec.AttemptLog) != 2 {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if a := rec.AttemptLog[1]; a.Attempt != 2 || a.StatusCode != http.StatusBadGateway || a.Error == "" {
+		t.Fatalf("unexpected attempt: %+v", a)
+	}
+	if _, err := svc.Get(ctx, "other", failed.ID); !errors.Is(err, repositories.ErrInstanceNotFound) {
+		t.Fatalf("expected ErrInstanceNotFound for another instance, got %v", err)
+	}
+
+	// Com o receptor de volta, o replay reenvia o mesmo corpo como uma entrega nova.
+	mu.Lock()
+	down = false
+	mu.Unlock()
+	replayed, err := svc.Replay(ctx, "shop", failed.ID)
+	if err != nil || replayed.ID == failed.ID || string(replayed.Payload) != string(rec.Payload) {
+		t.Fatalf("replay: %+v err=%v", replayed, err)
+	}
+	if _, err := svc.SendTest(ctx, "shop", ""); err != nil {
+		t.Fatalf("send test: %v", err)
+	}
+	if _, err := svc.SendTest(ctx, "shop", "missing"); !errors.Is(err, services.ErrWebhookTargetUnavailable) {
+		t.Fatalf("expected ErrWebhookTargetUnavailable, got %v", err)
+	}
+	delivered := waitForDeliveries(t, svc, webhook.DeliveryFilter{Status: webhook.StatusDelivered}, 2)
+	var sawReplay, sawTest bool
+	for _, d := range delivered {
+		sawReplay = sawReplay || d.ReplayOf == failed.ID
+		sawTest = sawTest || d.Event == services.WebhookTestEvent
+	}
+	if !sawReplay || !sawTest {
+		t.Fatalf("expected replay and test deliveries, got %+v", delivered)
+	}
+
+	if _, err := svc.ReplayRange(ctx, "shop", webhook.DeliveryFilter{}); !errors.Is(err, services.ErrReplayRangeRequired) {
+		t.Fatalf("expected ErrReplayRangeRequired, got %v", err)
+	}
+	batch, err := svc.ReplayRange(ctx, "shop", webhook.DeliveryFilter{Since: time.Now().Add(-time.Hour), Event: "messages.upsert", Status: webhook.StatusFailed})
+	if err != nil || len(batch) != 1 || batch[0].ReplayOf != failed.ID {
+		t.Fatalf("replay range: %+v err=%v", batch, err)
+	}
+	waitForDeliveries(t, svc, webhook.DeliveryFilter{Status: webhook.StatusDelivered}, 3)
+
+	// O lote parte da entrega mais antiga do período, não das mais recentes.
+	oldest, err := svc.ReplayRange(ctx, "shop", webhook.DeliveryFilter{Since: time.Now().Add(-time.Hour), Event: "messages.upsert", Limit: 1})
+	if err != nil || len(oldest) != 1 || oldest[0].ReplayOf != failed.ID {
+		t.Fatalf("expected replay range to start at the oldest delivery, got %+v err=%v", oldest, err)
+	}
+
+	// O histórico é da instância, não do nome: acompanha a renomeação.
+	renamed := "store"
+	if _, err := instanceSvc.Update(ctx, "shop", instance.UpdateInstanceInput{InstanceName: &renamed}); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if _, err := svc.Get(ctx, "store", failed.ID); err != nil {
+		t.Fatalf("expected history to follow the rename: %v", err)
+	}
+	if _, err := instanceSvc.Create(ctx, instance.CreateInstanceInput{InstanceName: "shop"}); err != nil {
+		t.Fatalf("recreate: %v", err)
+	}
+	if records, err := svc.List(ctx, "shop", webhook.DeliveryFilter{}); err != nil || len(records) != 0 {
+		t.Fatalf("expected a new instance reusing the name to start with no history, got %d err=%v", len(records), err)
+	}
+}
+
+func waitForDeliveries(t *testing.T, svc services.WebhookDeliveryService, filter webhook.DeliveryFilter, n int) []*webhook.DeliveryRecord {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		records, err := svc.List(context.Background(), "shop", filter)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		if len(records) >= n {
+			return records
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d %s deliveries, got %d", n, filter.Status, len(records))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
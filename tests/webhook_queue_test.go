@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/database"
+)
+
+func TestWebhookQueueRetriesInOrderAndDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	outbox, err := repositories.NewSQLiteWebhookOutboxRepo(db)
+	if err != nil {
+		t.Fatalf("new outbox repo: %v", err)
+	}
+
+	// O receptor recusa as duas primeiras requisições e depois aceita.
+	var (
+		mu       sync.Mutex
+		calls    int
+		received []float64
+	)
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Data map[string]any `json:"data"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received = append(received, body.Data["n"].(float64))
+	}))
+	defer ok.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer down.Close()
+
+	queue := services.NewWebhookQueue(outbox, nil, services.WebhookQueueOptions{
+		Retry:        services.WebhookRetryPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 40 * time.Millisecond},
+		Workers:      4,
+		Ordered:      true,
+		PollInterval: 10 * time.Millisecond,
+	}, nil)
+
+	// Entregas gravadas antes dos workers subirem ficam no outbox até Run.
+	healthy := &instance.Instance{Name: "shop", Webhook: instance.InstanceWebhook{URL: ok.URL}}
+	for n := 1; n <= 3; n++ {
+		queued, err := queue.Dispatch(ctx, healthy, "messages.upsert", map[string]any{"n": n})
+		if err != nil || !queued {
+			t.Fatalf("dispatch %d: queued=%v err=%v", n, queued, err)
+		}
+	}
+	broken := &instance.Instance{Name: "legacy", Webhook: instance.InstanceWebhook{URL: down.URL}}
+	if _, err := queue.Dispatch(ctx, broken, "connection.update", map[string]any{"state": "open"}); err != nil {
+		t.Fatalf("dispatch to broken receiver: %v", err)
+	}
+	if queued, _ := queue.Dispatch(ctx, &instance.Instance{Name: "silent"}, "messages.upsert", nil); queued {
+		t.Fatalf("instances without webhook URL must not enqueue")
+	}
+
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		queue.Run(runCtx)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var pending, dead int
+		_ = db.QueryRow(`SELECT COUNT(*) FROM webhook_outbox`).Scan(&pending)
+		_ = db.QueryRow(`SELECT COUNT(*) FROM webhook_dead_letters`).Scan(&dead)
+		if pending == 0 && dead == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("queue did not settle: pending=%d dead=%d", pending, dead)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	stop()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 3 || received[0] != 1 || received[1] != 2 || received[2] != 3 {
+		t.Fatalf("expected deliveries in order after retries, got %v", received)
+	}
+	var (
+		instanceName, lastError string
+		attempts, lastStatus    int
+	)
+	if err := db.QueryRow(`SELECT instance_name, attempts, last_status, last_error FROM webhook_dead_letters`).
+		Scan(&instanceName, &attempts, &lastStatus, &lastError); err != nil {
+		t.Fatalf("read dead letter: %v", err)
+	}
+	if instanceName != "legacy" || attempts != 3 || lastStatus != http.StatusInternalServerError || lastError == "" {
+		t.Fatalf("unexpected dead letter instance=%s attempts=%d status=%d err=%q", instanceName, attempts, lastStatus, lastError)
+	}
+}
+
+func TestWebhookRetryBackoff(t *testing.T) {
+	policy := services.WebhookRetryPolicy{InitialBackoff: time.Second, MaxBackoff: 8 * time.Second}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 3: 4 * time.Second, 10: 8 * time.Second} {
+		got := policy.Backoff(attempt)
+		if got < want/2 || got > want {
+			t.Fatalf("attempt %d: backoff %s outside [%s, %s]", attempt, got, want/2, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/logger"
+	"github.com/faeln1/go-whatsapp-api/pkg/webhooksig"
+)
+
+func TestWebhookSignatureVerify(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"event":"messages.upsert"}`)
+	h := http.Header{}
+	webhooksig.Apply(h, "delivery-1", now, body, "new-secret-0123456789", "old-secret-0123456789")
+
+	if err := webhooksig.Verify(h, body, now, webhooksig.DefaultTolerance, "old-secret-0123456789"); err != nil {
+		t.Fatalf("expected dual-signed request to verify with the previous secret: %v", err)
+	}
+	if err := webhooksig.Verify(h, []byte(`{"event":"tampered"}`), now, webhooksig.DefaultTolerance, "new-secret-0123456789"); !errors.Is(err, webhooksig.ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for tampered body, got %v", err)
+	}
+	if err := webhooksig.Verify(h, body, now.Add(10*time.Minute), webhooksig.DefaultTolerance, "new-secret-0123456789"); !errors.Is(err, webhooksig.ErrInvalidTimestamp) {
+		t.Fatalf("expected replayed request to be rejected, got %v", err)
+	}
+}
+
+func TestWebhookSecretRotationSignsDeliveries(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewInMemoryInstanceRepo()
+	svc := services.NewInstanceService(repo, whatsapp.NewManager(logger.InitForTests().App), nil)
+	// Segredo curto é recusado também na criação, não só na rotação.
+	weak := &instance.InstanceWebhook{URL: "https://example.com/hook", Secret: "short"}
+	if _, err := svc.Create(ctx, instance.CreateInstanceInput{InstanceName: "weak", Webhook: weak}); !errors.Is(err, services.ErrWebhookSecretTooShort) {
+		t.Fatalf("expected ErrWebhookSecretTooShort on create, got %v", err)
+	}
+	if _, err := svc.Create(ctx, instance.CreateInstanceInput{InstanceName: "shop"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	var (
+		mu      sync.Mutex
+		headers []http.Header
+		bodies  [][]byte
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		headers = append(headers, r.Header.Clone())
+		bodies = append(bodies, body)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+	if _, err := svc.SetWebhook(ctx, "shop", instance.SetWebhookInput{Enabled: true, URL: receiver.URL, Events: []string{"ALL"}}); err != nil {
+		t.Fatalf("set webhook: %v", err)
+	}
+
+	if _, err := svc.RotateWebhookSecret(ctx, "shop", instance.RotateWebhookSecretInput{Secret: "short"}); !errors.Is(err, services.ErrWebhookSecretTooShort) {
+		t.Fatalf("expected ErrWebhookSecretTooShort, got %v", err)
+	}
+	first, err := svc.RotateWebhookSecret(ctx, "shop", instance.RotateWebhookSecretInput{})
+	if err != nil || first.Secret == "" || first.PreviousSecretExpiresAt != nil {
+		t.Fatalf("first rotation: %+v err=%v", first, err)
+	}
+	second, err := svc.RotateWebhookSecret(ctx, "shop", instance.RotateWebhookSecretInput{GracePeriodSeconds: 3600})
+	if err != nil || second.Secret == first.Secret || second.PreviousSecretExpiresAt == nil {
+		t.Fatalf("second rotation: %+v err=%v", second, err)
+	}
+
+	queue := services.NewWebhookQueue(repositories.NewInMemoryWebhookOutboxRepo(), nil, services.WebhookQueueOptions{PollInterval: 10 * time.Millisecond}, nil)
+	queue.SetInstances(repo)
+	inst, _ := repo.GetByName(ctx, "shop")
+	if _, err := queue.Dispatch(ctx, inst, "connection.update", map[string]any{"state": "open"}); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go queue.Run(runCtx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(headers)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("webhook not delivered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if headers[0].Get(webhooksig.HeaderID) == "" {
+		t.Fatalf("expected delivery id header")
+	}
+	// Durante a sobreposição a entrega é válida tanto para o segredo novo quanto para o anterior.
+	for _, secret := range []string{second.Secret, first.Secret} {
+		if err := webhooksig.Verify(headers[0], bodies[0], time.Now(), webhooksig.DefaultTolerance, secret); err != nil {
+			t.Fatalf("verify with %q: %v", secret, err)
+		}
+	}
+
+	if err := svc.DeleteWebhookSecret(ctx, "shop"); err != nil {
+		t.Fatalf("delete secret: %v", err)
+	}
+	if cfg, _ := svc.GetWebhook(ctx, "shop"); len(cfg.SigningSecrets(time.Now())) != 0 {
+		t.Fatalf("expected signing disabled after delete")
+	}
+}
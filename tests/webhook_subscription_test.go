@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faeln1/go-whatsapp-api/internal/app/repositories"
+	"github.com/faeln1/go-whatsapp-api/internal/app/services"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/instance"
+	"github.com/faeln1/go-whatsapp-api/internal/domain/webhook"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/database"
+	"github.com/faeln1/go-whatsapp-api/internal/platform/whatsapp"
+	"github.com/faeln1/go-whatsapp-api/pkg/logger"
+	"github.com/faeln1/go-whatsapp-api/pkg/webhooksig"
+)
+
+func TestWebhookSubscriptionsFanOutIndependently(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	subsRepo, err := repositories.NewSQLiteWebhookSubscriptionRepo(db)
+	if err != nil {
+		t.Fatalf("new subscription repo: %v", err)
+	}
+	outbox, err := repositories.NewSQLiteWebhookOutboxRepo(db)
+	if err != nil {
+		t.Fatalf("new outbox repo: %v", err)
+	}
+	repo := repositories.NewInMemoryInstanceRepo()
+	instanceSvc := services.NewInstanceService(repo, whatsapp.NewManager(logger.InitForTests().App), nil)
+	instanceSvc.SetWebhookSubscriptions(subsRepo)
+	if _, err := instanceSvc.Create(ctx, instance.CreateInstanceInput{InstanceName: "shop"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	svc := services.NewWebhookSubscriptionService(repo, subsRepo)
+
+	type hit struct {
+		event  string
+		header http.Header
+	}
+	var (
+		mu   sync.Mutex
+		hits = map[string][]hit{}
+	)
+	receiver := func(name string, status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits[name] = append(hits[name], hit{event: r.Header.Get("X-Event"), header: r.Header.Clone()})
+			mu.Unlock()
+			w.WriteHeader(status)
+		}))
+	}
+	legacy := receiver("legacy", http.StatusOK)
+	defer legacy.Close()
+	crm := receiver("crm", http.StatusOK)
+	defer crm.Close()
+	broken := receiver("broken", http.StatusInternalServerError)
+	defer broken.Close()
+
+	// O /webhook/set continua valendo e aparece como a assinatura "default".
+	if _, err := instanceSvc.SetWebhook(ctx, "shop", instance.SetWebhookInput{Enabled: true, URL: legacy.URL, Events: []string{"ALL"}}); err != nil {
+		t.Fatalf("set webhook: %v", err)
+	}
+	str := func(v string) *string { return &v }
+	if _, err := svc.Create(ctx, "shop", webhook.SubscriptionInput{URL: str("ftp://example.com")}); !errors.Is(err, services.ErrInvalidWebhookURL) {
+		t.Fatalf("expected ErrInvalidWebhookURL, got %v", err)
+	}
+	crmSub, err := svc.Create(ctx, "shop", webhook.SubscriptionInput{
+		Name:    str("crm"),
+		URL:     str(crm.URL),
+		Events:  &[]string{"messages.upsert"},
+		Headers: &map[string]string{"X-Event": "crm"},
+		Secret:  str("crm-secret-0123456789"),
+	})
+	if err != nil || !crmSub.Signed || !crmSub.Enabled {
+		t.Fatalf("create crm subscription: %+v err=%v", crmSub, err)
+	}
+	brokenSub, err := svc.Create(ctx, "shop", webhook.SubscriptionInput{URL: str(broken.URL)})
+	if err != nil {
+		t.Fatalf("create broken subscription: %v", err)
+	}
+	subs, err := svc.List(ctx, "shop")
+	if err != nil || len(subs) != 3 || subs[0].ID != webhook.DefaultSubscriptionID || subs[0].URL != legacy.URL {
+		t.Fatalf("list: %+v err=%v", subs, err)
+	}
+
+	queue := services.NewWebhookQueue(outbox, nil, services.WebhookQueueOptions{
+		Retry:        services.WebhookRetryPolicy{MaxAttempts: 2, InitialBackoff: 10 * time.Millisecond},
+		Workers:      4,
+		Ordered:      true,
+		PollInterval: 10 * time.Millisecond,
+	}, nil)
+	queue.SetInstances(repo)
+	queue.SetSubscriptions(subsRepo)
+	inst, _ := repo.GetByName(ctx, "shop")
+	for _, event := range []string{"messages.upsert", "connection.update"} {
+		if queued, err := queue.Dispatch(ctx, inst, event, map[string]any{}); err != nil || !queued {
+			t.Fatalf("dispatch %s: queued=%v err=%v", event, queued, err)
+		}
+	}
+	// Os headers da assinatura são resolvidos na tentativa e não ficam gravados no outbox.
+	var stored int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhook_outbox WHERE headers <> '{}'`).Scan(&stored); err != nil || stored != 0 {
+		t.Fatalf("expected no headers persisted in the outbox, got %d err=%v", stored, err)
+	}
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go queue.Run(runCtx)
+
+	// Uma assinatura com falha não bloqueia as demais: legacy recebe 2 eventos, crm só o
+	// filtrado e broken é tentado até o dead-letter.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		done := len(hits["legacy"]) == 2 && len(hits["crm"]) == 1 && len(hits["broken"]) == 4
+		mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			mu.Lock()
+			t.Fatalf("unexpected deliveries: legacy=%d crm=%d broken=%d", len(hits["legacy"]), len(hits["crm"]), len(hits["broken"]))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	crmHit := hits["crm"][0]
+	mu.Unlock()
+	if crmHit.event != "crm" {
+		t.Fatalf("expected subscription headers on delivery, got %q", crmHit.event)
+	}
+	if crmHit.header.Get(webhooksig.HeaderSignature) == "" {
+		t.Fatalf("expected crm delivery to be signed")
+	}
+
+	disabled := false
+	if updated, err := svc.Update(ctx, "shop", crmSub.ID, webhook.SubscriptionInput{Enabled: &disabled}); err != nil || updated.Enabled {
+		t.Fatalf("disable: %+v err=%v", updated, err)
+	}
+	if err := svc.Delete(ctx, "shop", brokenSub.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := svc.Get(ctx, "shop", brokenSub.ID); !errors.Is(err, repositories.ErrSubscriptionNotFound) {
+		t.Fatalf("expected ErrSubscriptionNotFound after delete, got %v", err)
+	}
+	if err := svc.Delete(ctx, "shop", webhook.DefaultSubscriptionID); err != nil {
+		t.Fatalf("delete default: %v", err)
+	}
+	if cfg, _ := instanceSvc.GetWebhook(ctx, "shop"); cfg.URL != "" {
+		t.Fatalf("expected legacy webhook cleared, got %q", cfg.URL)
+	}
+
+	if err := instanceSvc.Delete(ctx, "shop"); err != nil {
+		t.Fatalf("delete instance: %v", err)
+	}
+	if left, _ := subsRepo.List(ctx, string(inst.ID)); len(left) != 0 {
+		t.Fatalf("expected subscriptions removed with the instance, got %d", len(left))
+	}
+}